@@ -1,99 +1,155 @@
 package cli
 
 import (
-	"bufio"
-	"encoding/json"
 	"fmt"
-	"os"
+	"io"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/vburojevic/xcw/internal/domain"
+	"github.com/vburojevic/xcw/internal/filter"
 	"github.com/vburojevic/xcw/internal/output"
 )
 
 // AnalyzeCmd analyzes a recorded NDJSON log file
 type AnalyzeCmd struct {
-	File            string `arg:"" required:"" help:"NDJSON log file to analyze"`
-	PersistPatterns bool   `help:"Save detected patterns for future reference (marks new vs known)"`
-	PatternFile     string `help:"Custom pattern file path (default: ~/.xcw/patterns.json)"`
+	File            string   `arg:"" required:"" help:"NDJSON log file to analyze (.gz/.zst are decompressed)"`
+	PersistPatterns bool     `help:"Save detected patterns for future reference (marks new vs known)"`
+	PatternFile     string   `help:"Custom pattern file path (default: ~/.xcw/patterns.json)"`
+	Extract         []string `help:"Extract key/value fields from messages and report them: logfmt, json, regex (can be repeated)"`
+	ExtractRegex    []string `help:"Regex with named groups for field extraction, e.g. 'took (?P<ms>[0-9]+)ms' (can be repeated; implies --extract regex)"`
+	Where           []string `short:"w" help:"Field filter expression applied before analysis (supports AND/OR/NOT, parentheses; fields.<key> for extracted fields)"`
+	Group           bool     `help:"Coalesce multi-line messages (stack traces, exception dumps) from the same pid/tid before detecting patterns"`
+	GroupWindow     string   `help:"Maximum gap between continuation lines for --group (default: 10ms)"`
+
+	App              string  `help:"App bundle ID the run is recorded under with --persist-patterns; runs are compared per app (default: from session_start in the file)"`
+	AppVersion       string  `help:"App version recorded with --persist-patterns (default: from session_start in the file)"`
+	AppBuild         string  `help:"App build recorded with --persist-patterns (default: from session_start in the file)"`
+	RegressionRuns   int     `default:"3" help:"Flag a known pattern as returned after this many previous runs without it"`
+	RateIncrease     float64 `default:"50" help:"Flag a pattern whose rate per 1000 entries rose more than this percent over its recent baseline"`
+	FailOnRegression bool    `help:"Exit non-zero when a pattern is new in this build, returned, or increased in rate (requires --persist-patterns)"`
+
+	ReportFlags
+	HistogramFlags
 }
 
 // Run executes the analyze command
 func (c *AnalyzeCmd) Run(globals *Globals) error {
-	// Open input file
-	file, err := os.Open(c.File)
+	extractor, err := filter.NewFieldExtractor(c.Extract, c.ExtractRegex)
 	if err != nil {
-		return c.outputError(globals, "FILE_NOT_FOUND", fmt.Sprintf("cannot open file: %s", err))
+		return c.outputError(globals, "INVALID_FILTER", err.Error(), hintForExtract(err))
+	}
+	whereFilter, err := filter.NewWhereFilter(c.Where)
+	if err != nil {
+		return c.outputError(globals, "INVALID_FILTER", err.Error(), hintForFilter(err))
+	}
+	grouper, err := buildCoalescer(c.Group, c.GroupWindow)
+	if err != nil {
+		return c.outputError(globals, "INVALID_GROUP_WINDOW", err.Error(), "use a positive duration such as '10ms' or '50ms'")
+	}
+	if c.FailOnRegression && !c.PersistPatterns {
+		return c.outputError(globals, "INVALID_FLAGS", "--fail-on-regression requires --persist-patterns", "add --persist-patterns (and optionally --pattern-file) so runs can be compared")
+	}
+	if err := c.validateReport(globals); err != nil {
+		return err
 	}
-	defer func() {
-		if err := file.Close(); err != nil {
-			globals.Debug("Failed to close file: %v", err)
-		}
-	}()
-
-	// Read and parse log entries
-	var entries []domain.LogEntry
-	scanner := bufio.NewScanner(file)
-	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
-
-	lineNum := 0
-	for scanner.Scan() {
-		lineNum++
-		line := scanner.Bytes()
-		if len(line) == 0 {
-			continue
-		}
-
-		var entry domain.LogEntry
-		if err := json.Unmarshal(line, &entry); err != nil {
-			// Try to detect if it's a different type (summary, heartbeat, etc.)
-			var typeCheck struct {
-				Type string `json:"type"`
-			}
-			if json.Unmarshal(line, &typeCheck) == nil && typeCheck.Type != "" {
-				// Skip non-log entries (summaries, heartbeats, etc.)
-				continue
-			}
-			globals.Debug("Skipping unparseable line %d: %v", lineNum, err)
-			continue
-		}
-
-		// Skip if no timestamp (invalid entry)
-		if entry.Timestamp.IsZero() {
-			continue
-		}
 
-		entries = append(entries, entry)
+	// Read and parse log entries; session_start supplies the app version/build
+	rec, cerr := readRecordingFile(globals, c.File)
+	if cerr != nil {
+		return c.outputError(globals, cerr.Code, cerr.Message)
+	}
+	entries := rec.Entries
+	meta := output.RunMeta{App: c.App, Version: c.AppVersion, Build: c.AppBuild}
+	if meta.App == "" {
+		meta.App = rec.App
+	}
+	if meta.Version == "" {
+		meta.Version = rec.Version
+	}
+	if meta.Build == "" {
+		meta.Build = rec.Build
 	}
 
-	if err := scanner.Err(); err != nil {
-		return c.outputError(globals, "READ_ERROR", fmt.Sprintf("error reading file: %s", err))
+	// Coalesce multi-line messages so one stack trace counts as one pattern.
+	if grouper != nil {
+		entries = filter.CoalesceEntries(entries, grouper.Window())
 	}
+	kept := entries[:0]
+	for i := range entries {
+		extractor.Extract(&entries[i])
+		if whereFilter.Match(&entries[i]) {
+			kept = append(kept, entries[i])
+		}
+	}
+	entries = kept
 
 	if len(entries) == 0 {
 		return c.outputError(globals, "NO_ENTRIES", "no valid log entries found in file")
 	}
 
 	// Analyze entries
-	analyzer := output.NewAnalyzer()
+	analyzer, err := newAnalyzer(globals)
+	if err != nil {
+		return c.outputError(globals, "INVALID_PATTERN", err.Error(), "check the patterns section of your config")
+	}
 	summary := analyzer.Summarize(entries)
 	patterns := analyzer.DetectPatterns(entries)
+	fields := analyzer.SummarizeFields(entries, 0)
+	if err := c.addHistogram(globals, analyzer, summary, entries); err != nil {
+		return err
+	}
+
+	// Record this run in the pattern history
+	var enhanced []output.EnhancedPatternMatch
+	if c.PersistPatterns {
+		store := output.NewPatternStore(c.PatternFile)
+		enhanced = store.RecordRun(meta, len(entries), patterns, output.TrendOptions{
+			CleanRuns:       c.RegressionRuns,
+			RateIncreasePct: c.RateIncrease,
+		})
+		if err := store.Save(); err != nil {
+			globals.Debug("Failed to save patterns: %v", err)
+		}
+	}
+
+	// CI report (JUnit/SARIF) built from the same patterns
+	if c.Report != "" {
+		report := output.NewEnhancedSummaryOutput(summary, reportPatterns(patterns, enhanced))
+		report.App = meta.App
+		report.AppVersion = meta.Version
+		report.AppBuild = meta.Build
+		if err := c.writeReport(globals, report, "xcw analyze "+c.File); err != nil {
+			return err
+		}
+		if c.reportReplacesOutput() {
+			// Keep stdout a valid report; the exit status still reflects regressions.
+			if regressed := c.regressions(enhanced); len(regressed) > 0 {
+				return fmt.Errorf("%d pattern regression(s)", len(regressed))
+			}
+			return nil
+		}
+	}
 
 	// Output results
 	if globals.Format == "ndjson" {
 		writer := output.NewNDJSONWriter(globals.Stdout)
 
 		if c.PersistPatterns {
-			store := output.NewPatternStore(c.PatternFile)
-			enhanced := store.RecordPatterns(patterns)
-			if err := store.Save(); err != nil {
-				globals.Debug("Failed to save patterns: %v", err)
-			}
 			analysisOutput := output.NewEnhancedSummaryOutput(summary, enhanced)
-			return writer.WriteRaw(analysisOutput)
+			analysisOutput.Fields = fields
+			analysisOutput.App = meta.App
+			analysisOutput.AppVersion = meta.Version
+			analysisOutput.AppBuild = meta.Build
+			if err := writer.WriteRaw(analysisOutput); err != nil {
+				return err
+			}
+			return c.checkRegressions(globals, enhanced)
 		}
 
 		analysisOutput := output.NewSummaryOutput(summary, patterns)
+		analysisOutput.Fields = fields
 		return writer.WriteRaw(analysisOutput)
 	}
 
@@ -154,22 +210,31 @@ func (c *AnalyzeCmd) Run(globals *Globals) error {
 		}
 	}
 
+	if len(summary.Histogram) > 0 {
+		if err := printTimeline(globals.Stdout, summary); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintln(globals.Stdout); err != nil {
+			return err
+		}
+	}
+
+	if err := printFieldStats(globals.Stdout, fields); err != nil {
+		return err
+	}
+
 	// Patterns
 	if len(patterns) > 0 {
 		if _, err := fmt.Fprintln(globals.Stdout, "Error Patterns:"); err != nil {
 			return err
 		}
 		if c.PersistPatterns {
-			store := output.NewPatternStore(c.PatternFile)
-			enhanced := store.RecordPatterns(patterns)
-			if err := store.Save(); err != nil {
-				globals.Debug("Failed to save patterns: %v", err)
-			}
 			for _, p := range enhanced {
 				status := "[NEW]"
 				if !p.IsNew {
 					status = "[KNOWN]"
 				}
+				status += trendMarkers(p)
 				if _, err := fmt.Fprintf(globals.Stdout, "  %s (%dx) %s\n", status, p.Count, p.Pattern); err != nil {
 					return err
 				}
@@ -183,9 +248,91 @@ func (c *AnalyzeCmd) Run(globals *Globals) error {
 		}
 	}
 
-	return nil
+	return c.checkRegressions(globals, enhanced)
+}
+
+// checkRegressions fails the run for --fail-on-regression when any pattern regressed.
+func (c *AnalyzeCmd) checkRegressions(globals *Globals, patterns []output.EnhancedPatternMatch) error {
+	regressed := c.regressions(patterns)
+	if len(regressed) == 0 {
+		return nil
+	}
+	return c.outputError(globals, "PATTERN_REGRESSION",
+		fmt.Sprintf("%d pattern regression(s): %s", len(regressed), strings.Join(regressed, "; ")),
+		"inspect patterns with new_in_build, returned or rate_increased set")
+}
+
+// regressions lists the patterns that fail --fail-on-regression (none when the flag is off).
+func (c *AnalyzeCmd) regressions(patterns []output.EnhancedPatternMatch) []string {
+	if !c.FailOnRegression {
+		return nil
+	}
+	var regressed []string
+	for _, p := range patterns {
+		if p.IsRegression() {
+			regressed = append(regressed, p.Pattern)
+		}
+	}
+	return regressed
 }
 
-func (c *AnalyzeCmd) outputError(globals *Globals, code, message string) error {
-	return outputErrorCommon(globals, code, message)
+// trendMarkers renders the RecordRun trend flags for text output.
+func trendMarkers(p output.EnhancedPatternMatch) string {
+	var b strings.Builder
+	if p.NewInBuild {
+		b.WriteString("[NEW IN BUILD]")
+	}
+	if p.Returned {
+		fmt.Fprintf(&b, "[RETURNED after %d clean runs]", p.CleanRuns)
+	}
+	if p.RateIncreased {
+		fmt.Fprintf(&b, "[RATE +%.0f%%]", p.RateChangePct)
+	}
+	return b.String()
+}
+
+func (c *AnalyzeCmd) outputError(globals *Globals, code, message string, hint ...string) error {
+	return outputErrorCommon(globals, code, message, hint...)
+}
+
+// printFieldStats writes the extracted-field table shared by analyze and discover text output.
+func printFieldStats(w io.Writer, fields []domain.FieldInfo) error {
+	if len(fields) == 0 {
+		return nil
+	}
+	if _, err := fmt.Fprintln(w, "Fields:"); err != nil {
+		return err
+	}
+	for _, f := range fields {
+		line := fmt.Sprintf("  %-30s %5d", f.Name, f.Count)
+		if f.Min != nil && f.Max != nil {
+			line += fmt.Sprintf("  min=%s max=%s",
+				strconv.FormatFloat(*f.Min, 'f', -1, 64),
+				strconv.FormatFloat(*f.Max, 'f', -1, 64))
+		} else if len(f.Samples) > 0 {
+			line += "  e.g. " + strings.Join(f.Samples, ", ")
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
+
+// newAnalyzer creates an analyzer with the masks and similarity from the
+// config's patterns section.
+func newAnalyzer(globals *Globals) (*output.Analyzer, error) {
+	opts := output.TemplateOptions{}
+	if cfg := globals.Config; cfg != nil {
+		opts.Similarity = cfg.Patterns.Similarity
+		for _, m := range cfg.Patterns.Masks {
+			opts.Masks = append(opts.Masks, output.MaskRule{Name: m.Name, Regex: m.Regex, Replace: m.Replace})
+		}
+	}
+	analyzer, err := output.NewAnalyzerWithOptions(opts)
+	if err != nil {
+		return nil, fmt.Errorf("invalid patterns config: %w", err)
+	}
+	return analyzer, nil
 }
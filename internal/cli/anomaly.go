@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/vburojevic/xcw/internal/domain"
+	"github.com/vburojevic/xcw/internal/output"
+)
+
+// AnomalyFlags groups rate-based anomaly detection flags shared by tail and watch.
+type AnomalyFlags struct {
+	Anomalies          bool   `help:"Emit 'anomaly' events when the error rate spikes, a usually busy subsystem goes silent, or a new message pattern appears"`
+	AnomalySensitivity string `default:"medium" enum:"low,medium,high" help:"Anomaly sensitivity: low, medium or high (high also reports new Default-level patterns)"`
+	AnomalyWindow      string `help:"Rate window the baselines are learned over (default: 10s)"`
+	AnomalyWarmup      string `help:"Learning period before anomalies are reported (default: 1m)"`
+}
+
+// newAnomalyDetector creates a detector, or returns nil when --anomalies is off.
+func (f *AnomalyFlags) newAnomalyDetector() (*output.AnomalyDetector, error) {
+	if !f.Anomalies {
+		return nil, nil
+	}
+	cfg := output.AnomalyConfig{Sensitivity: f.AnomalySensitivity}
+	if f.AnomalyWindow != "" {
+		d, err := time.ParseDuration(f.AnomalyWindow)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid --anomaly-window %q", f.AnomalyWindow)
+		}
+		cfg.Window = d
+	}
+	if f.AnomalyWarmup != "" {
+		d, err := time.ParseDuration(f.AnomalyWarmup)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid --anomaly-warmup %q", f.AnomalyWarmup)
+		}
+		cfg.Warmup = d
+	}
+	return output.NewAnomalyDetector(cfg)
+}
+
+// anomalyClock returns the time entries are observed at. Entries replayed
+// from --source-file use their own timestamps, since wall-clock time would put
+// a whole recording into one window; the ticker is then not needed.
+func anomalyClock(globals *Globals) (observedAt func(entry *domain.LogEntry, now time.Time) time.Time, tick bool) {
+	if globals.SourceFile == "" {
+		return func(_ *domain.LogEntry, now time.Time) time.Time { return now }, true
+	}
+	return func(entry *domain.LogEntry, now time.Time) time.Time {
+		if entry.Timestamp.IsZero() {
+			return now
+		}
+		return entry.Timestamp
+	}, false
+}
+
+// anomalyText renders an anomaly for text output
+func anomalyText(a *domain.Anomaly) string {
+	return fmt.Sprintf("⚠ ANOMALY (%s): %s", a.Kind, a.Message)
+}
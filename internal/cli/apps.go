@@ -53,6 +53,10 @@ func (c *AppsCmd) Run(globals *Globals) error {
 		return c.outputError(globals, "INVALID_FLAGS", "--simulator and --booted are mutually exclusive")
 	}
 
+	if err := rejectSourceFile(globals, "apps"); err != nil {
+		return err
+	}
+
 	// Find the simulator
 	mgr := simulator.NewManager()
 	device, err := resolveSimulatorDevice(ctx, mgr, c.Simulator, c.Booted)
@@ -2,8 +2,12 @@ package cli
 
 import (
 	"bytes"
+	"compress/gzip"
 	"encoding/json"
+	"fmt"
+	"io"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -13,6 +17,8 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/vburojevic/xcw/internal/config"
 	"github.com/vburojevic/xcw/internal/domain"
+	"github.com/vburojevic/xcw/internal/filter"
+	"github.com/vburojevic/xcw/internal/output"
 )
 
 // testGlobals creates a Globals struct with captured stdout/stderr
@@ -341,6 +347,29 @@ func TestListSessions(t *testing.T) {
 		require.NoError(t, err)
 		assert.Len(t, sessions, 1)
 	})
+	t.Run("lists compressed files and parts", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		files := []string{
+			"20251209-100000-com_example_app.ndjson.gz",
+			"20251209-100000-com_example_app.part2.ndjson.gz",
+			"20251209-090000-com_example_app.ndjson.zst",
+		}
+		for _, f := range files {
+			require.NoError(t, os.WriteFile(filepath.Join(tmpDir, f), []byte("test"), 0644))
+		}
+
+		sessions, err := ListSessions(tmpDir)
+		require.NoError(t, err)
+		require.Len(t, sessions, 3)
+
+		assert.Equal(t, "20251209-100000-com_example_app.part2.ndjson.gz", sessions[0].Name)
+		assert.Equal(t, 2, sessions[0].Part)
+		assert.Equal(t, "gzip", sessions[0].Compression)
+		assert.Equal(t, "com_example_app", sessions[0].Prefix)
+		assert.Equal(t, 0, sessions[1].Part)
+		assert.Equal(t, "zstd", sessions[2].Compression)
+		assert.Equal(t, "com_example_app", sessions[2].Prefix)
+	})
 }
 
 func TestLatestSession(t *testing.T) {
@@ -399,6 +428,56 @@ func TestCleanOldSessions(t *testing.T) {
 		assert.Equal(t, "20251209-130000-app.ndjson", remaining[1].Name)
 	})
 
+	t.Run("keeps every part of a rotated session", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		files := []string{
+			"20251209-100000-app.ndjson",
+			"20251209-110000-app.ndjson",
+			"20251209-120000-app.ndjson.gz",
+			"20251209-120000-app.part2.ndjson.gz",
+			"20251209-120000-app.part3.ndjson",
+		}
+		for _, f := range files {
+			require.NoError(t, os.WriteFile(filepath.Join(tmpDir, f), []byte("test"), 0644))
+		}
+
+		deleted, err := CleanSessions(tmpDir, 2, 0)
+		require.NoError(t, err)
+		require.Len(t, deleted, 1)
+		assert.Equal(t, "20251209-100000-app.ndjson", filepath.Base(deleted[0]))
+
+		remaining, err := ListSessions(tmpDir)
+		require.NoError(t, err)
+		assert.Len(t, remaining, 4)
+		assert.Equal(t, 2, countSessions(remaining))
+	})
+
+	t.Run("enforces a total size budget", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		files := []string{
+			"20251209-100000-app.ndjson",
+			"20251209-110000-app.ndjson.gz",
+			"20251209-120000-app.ndjson",
+		}
+		for _, f := range files {
+			require.NoError(t, os.WriteFile(filepath.Join(tmpDir, f), bytes.Repeat([]byte("x"), 100), 0644))
+		}
+
+		deleted, err := CleanSessions(tmpDir, 10, 250)
+		require.NoError(t, err)
+		require.Len(t, deleted, 1)
+		assert.Equal(t, "20251209-100000-app.ndjson", filepath.Base(deleted[0]))
+
+		// The newest file survives even when it alone exceeds the budget
+		deleted, err = CleanSessions(tmpDir, 10, 50)
+		require.NoError(t, err)
+		require.Len(t, deleted, 1)
+		remaining, err := ListSessions(tmpDir)
+		require.NoError(t, err)
+		require.Len(t, remaining, 1)
+		assert.Equal(t, "20251209-120000-app.ndjson", remaining[0].Name)
+	})
+
 	t.Run("does nothing if fewer sessions than keep count", func(t *testing.T) {
 		tmpDir := t.TempDir()
 		require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "20251209-100000-app.ndjson"), []byte("test"), 0644))
@@ -409,6 +488,162 @@ func TestCleanOldSessions(t *testing.T) {
 	})
 }
 
+func TestRotation(t *testing.T) {
+	readAll := func(t *testing.T, path string) string {
+		rc, err := openRecording(path)
+		require.NoError(t, err)
+		defer func() { require.NoError(t, rc.Close()) }()
+		data, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	for _, codec := range []string{compressGzip, compressZstd} {
+		t.Run("compresses with "+codec, func(t *testing.T) {
+			base := filepath.Join(t.TempDir(), "out.ndjson")
+			r := newRotation(func(int) (string, error) { return base, nil })
+			require.NoError(t, (&TailOutputFlags{Output: base, Compress: codec}).configureRotation(r))
+
+			w, path, err := r.Open(1)
+			require.NoError(t, err)
+			assert.Equal(t, base+compressionExt(codec), path)
+			_, err = io.WriteString(w, "{\"type\":\"log\"}\n")
+			require.NoError(t, err)
+			require.NoError(t, r.Close())
+
+			assert.Equal(t, "{\"type\":\"log\"}\n", readAll(t, path))
+		})
+	}
+
+	t.Run("splits a session into parts by size", func(t *testing.T) {
+		base := filepath.Join(t.TempDir(), "out.ndjson")
+		r := newRotation(func(int) (string, error) { return base, nil })
+		r.maxSize = 20
+		var parts []string
+		r.onPart = func(path string, part int) error {
+			parts = append(parts, fmt.Sprintf("%d:%s", part, filepath.Base(path)))
+			return nil
+		}
+
+		w, _, err := r.Open(1)
+		require.NoError(t, err)
+		for i := 0; i < 3; i++ {
+			_, err = fmt.Fprintf(w, "line-%d-0123456789abc\n", i)
+			require.NoError(t, err)
+		}
+		require.NoError(t, r.Close())
+
+		assert.Equal(t, []string{"2:out.part2.ndjson", "3:out.part3.ndjson"}, parts)
+		assert.Equal(t, "line-0-0123456789abc\n", readAll(t, base))
+		assert.Equal(t, "line-2-0123456789abc\n", readAll(t, filepath.Join(filepath.Dir(base), "out.part3.ndjson")))
+	})
+
+	t.Run("splits a session into parts by age", func(t *testing.T) {
+		base := filepath.Join(t.TempDir(), "out.ndjson")
+		now := time.Date(2025, 12, 9, 10, 0, 0, 0, time.UTC)
+		r := newRotation(func(int) (string, error) { return base, nil })
+		r.now = func() time.Time { return now }
+		r.maxAge = time.Minute
+
+		w, _, err := r.Open(1)
+		require.NoError(t, err)
+		_, err = io.WriteString(w, "a\n")
+		require.NoError(t, err)
+		now = now.Add(2 * time.Minute)
+		_, err = io.WriteString(w, "b\n")
+		require.NoError(t, err)
+		_, err = io.WriteString(w, "c\n")
+		require.NoError(t, err)
+		require.NoError(t, r.Close())
+
+		assert.Equal(t, "a\n", readAll(t, base))
+		assert.Equal(t, "b\nc\n", readAll(t, filepath.Join(filepath.Dir(base), "out.part2.ndjson")))
+	})
+
+	for _, codec := range []string{compressGzip, compressZstd} {
+		t.Run("reads a truncated "+codec+" recording up to the cut", func(t *testing.T) {
+			base := filepath.Join(t.TempDir(), "out.ndjson")
+			r := newRotation(func(int) (string, error) { return base, nil })
+			r.compress = codec
+			w, path, err := r.Open(1)
+			require.NoError(t, err)
+			for i := 0; i < 5000; i++ {
+				_, err = fmt.Fprintf(w, "{\"type\":\"log\",\"timestamp\":\"2025-12-09T10:00:00Z\",\"message\":\"entry %d %x\"}\n", i, i*7919)
+				require.NoError(t, err)
+			}
+			require.NoError(t, r.Close())
+
+			// Cut the file as if it were still being written
+			data, err := os.ReadFile(path)
+			require.NoError(t, err)
+			require.NoError(t, os.WriteFile(path, data[:len(data)*2/3], 0o644))
+
+			globals, _, stderr := testGlobals("ndjson")
+			rec, cerr := readRecordingFile(globals, path)
+			require.Nil(t, cerr)
+			assert.True(t, rec.Truncated)
+			assert.NotEmpty(t, rec.Entries)
+			assert.Less(t, len(rec.Entries), 5000)
+			assert.Contains(t, stderr.String(), "ends mid-stream")
+		})
+	}
+
+	t.Run("rejects limits without a file", func(t *testing.T) {
+		err := (&TailOutputFlags{Compress: "gzip"}).configureRotation(newRotation(nil))
+		require.Error(t, err)
+		err = (&TailOutputFlags{Output: "x.ndjson", Compress: "lz4"}).configureRotation(newRotation(nil))
+		require.Error(t, err)
+		err = (&TailOutputFlags{Output: "x.ndjson", MaxFileSize: "lots"}).configureRotation(newRotation(nil))
+		require.Error(t, err)
+	})
+}
+
+func TestSessionsShowCmd_Run(t *testing.T) {
+	tmpDir := t.TempDir()
+	r := newRotation(func(int) (string, error) {
+		return filepath.Join(tmpDir, "20251209-100000-app.ndjson"), nil
+	})
+	r.compress = compressGzip
+	w, _, err := r.Open(1)
+	require.NoError(t, err)
+	_, err = io.WriteString(w, "{\"type\":\"log\",\"message\":\"hello\"}\n")
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+
+	t.Run("reports compression", func(t *testing.T) {
+		globals, stdout, _ := testGlobals("ndjson")
+		require.NoError(t, (&SessionsShowCmd{Dir: tmpDir, Latest: true}).Run(globals))
+
+		var so SessionOutput
+		require.NoError(t, json.Unmarshal(stdout.Bytes(), &so))
+		assert.Equal(t, "20251209-100000-app.ndjson.gz", so.Name)
+		assert.Equal(t, "gzip", so.Compression)
+		assert.Equal(t, "app", so.Prefix)
+	})
+
+	t.Run("cat decompresses", func(t *testing.T) {
+		globals, stdout, _ := testGlobals("text")
+		require.NoError(t, (&SessionsShowCmd{Dir: tmpDir, Latest: true, Cat: true}).Run(globals))
+		assert.Equal(t, "{\"type\":\"log\",\"message\":\"hello\"}\n", stdout.String())
+	})
+}
+
+func TestParseByteSize(t *testing.T) {
+	for in, want := range map[string]int64{
+		"1024":  1024,
+		"64k":   64 << 10,
+		"500MB": 500 << 20,
+		"1.5GB": 3 << 29,
+		"2GiB":  2 << 30,
+	} {
+		got, err := parseByteSize(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+	_, err := parseByteSize("-1MB")
+	assert.Error(t, err)
+}
+
 func TestSessionSchema(t *testing.T) {
 	schema := sessionSchema()
 
@@ -481,6 +716,25 @@ func TestAnalyzeCmd_Run(t *testing.T) {
 		// patterns may be omitted if empty (omitempty)
 	})
 
+	t.Run("reads gzip-compressed recordings", func(t *testing.T) {
+		data, err := os.ReadFile(logFile)
+		require.NoError(t, err)
+		gzFile := filepath.Join(tmpDir, "test.ndjson.gz")
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		_, err = zw.Write(data)
+		require.NoError(t, err)
+		require.NoError(t, zw.Close())
+		require.NoError(t, os.WriteFile(gzFile, buf.Bytes(), 0644))
+
+		globals, stdout, _ := testGlobals("ndjson")
+		require.NoError(t, (&AnalyzeCmd{File: gzFile}).Run(globals))
+
+		var result output.EnhancedSummaryOutput
+		require.NoError(t, json.Unmarshal(stdout.Bytes(), &result))
+		assert.Equal(t, len(entries), result.Summary.TotalCount)
+	})
+
 	t.Run("returns error for non-existent file", func(t *testing.T) {
 		globals, _, _ := testGlobals("text")
 		cmd := &AnalyzeCmd{File: "/nonexistent/file.ndjson"}
@@ -501,6 +755,102 @@ func TestAnalyzeCmd_Run(t *testing.T) {
 		assert.Contains(t, err.Error(), "no valid log entries")
 	})
 
+	t.Run("writes junit report instead of ndjson", func(t *testing.T) {
+		globals, stdout, _ := testGlobals("ndjson")
+		cmd := &AnalyzeCmd{File: logFile, ReportFlags: ReportFlags{Report: "junit"}}
+		require.NoError(t, cmd.Run(globals))
+
+		out := stdout.String()
+		assert.True(t, strings.HasPrefix(out, "<?xml"))
+		assert.Contains(t, out, `<testsuite name="xcw analyze `+logFile+`"`)
+	})
+
+	t.Run("writes sarif report file alongside output", func(t *testing.T) {
+		reportFile := filepath.Join(tmpDir, "report.sarif")
+		globals, stdout, _ := testGlobals("ndjson")
+		cmd := &AnalyzeCmd{File: logFile, ReportFlags: ReportFlags{Report: "sarif", ReportFile: reportFile}}
+		require.NoError(t, cmd.Run(globals))
+
+		var result map[string]interface{}
+		require.NoError(t, json.Unmarshal(stdout.Bytes(), &result))
+		assert.Equal(t, "analysis", result["type"])
+
+		data, err := os.ReadFile(reportFile)
+		require.NoError(t, err)
+		var sarif map[string]interface{}
+		require.NoError(t, json.Unmarshal(data, &sarif))
+		assert.Equal(t, "2.1.0", sarif["version"])
+	})
+
+	t.Run("rejects unknown report format", func(t *testing.T) {
+		globals, stdout, _ := testGlobals("ndjson")
+		cmd := &AnalyzeCmd{File: logFile, ReportFlags: ReportFlags{Report: "html"}}
+		require.Error(t, cmd.Run(globals))
+		assert.Contains(t, stdout.String(), "INVALID_FLAGS")
+	})
+
+	t.Run("extracts fields and filters on them", func(t *testing.T) {
+		fieldFile := filepath.Join(tmpDir, "fields.ndjson")
+		f, err := os.Create(fieldFile)
+		require.NoError(t, err)
+		encoder := json.NewEncoder(f)
+		for i, msg := range []string{"GET /a status=200 latency_ms=12", "GET /b status=503 latency_ms=900", "GET /c status=500 latency_ms=40"} {
+			require.NoError(t, encoder.Encode(domain.LogEntry{Timestamp: time.Now().Add(time.Duration(i) * time.Second), Level: domain.LogLevelInfo, Process: "TestApp", Message: msg}))
+		}
+		require.NoError(t, f.Close())
+
+		globals, stdout, _ := testGlobals("ndjson")
+		cmd := &AnalyzeCmd{File: fieldFile, Extract: []string{"logfmt"}, Where: []string{"fields.status>=500"}}
+		require.NoError(t, cmd.Run(globals))
+
+		var result struct {
+			Summary struct {
+				TotalCount int `json:"totalCount"`
+			} `json:"summary"`
+			Fields []domain.FieldInfo `json:"fields"`
+		}
+		require.NoError(t, json.Unmarshal(stdout.Bytes(), &result))
+		assert.Equal(t, 2, result.Summary.TotalCount)
+		require.Len(t, result.Fields, 2)
+		assert.Equal(t, "latency_ms", result.Fields[0].Name)
+		require.NotNil(t, result.Fields[0].Max)
+		assert.Equal(t, 900.0, *result.Fields[0].Max)
+
+		globals, _, _ = testGlobals("ndjson")
+		cmd = &AnalyzeCmd{File: fieldFile, Extract: []string{"yaml"}}
+		assert.Error(t, cmd.Run(globals))
+	})
+
+	t.Run("groups multi-line messages before counting", func(t *testing.T) {
+		groupFile := filepath.Join(tmpDir, "group.ndjson")
+		f, err := os.Create(groupFile)
+		require.NoError(t, err)
+		encoder := json.NewEncoder(f)
+		start := time.Now().Add(-time.Minute)
+		for i, msg := range []string{"*** Terminating app", "0 CoreFoundation 0x1", "1 libobjc.A.dylib 0x2", "2 MyApp 0x3"} {
+			require.NoError(t, encoder.Encode(domain.LogEntry{Timestamp: start.Add(time.Duration(i) * time.Millisecond), Level: domain.LogLevelError, Process: "TestApp", PID: 9, TID: 3, Message: msg}))
+		}
+		require.NoError(t, f.Close())
+
+		globals, stdout, _ := testGlobals("ndjson")
+		cmd := &AnalyzeCmd{File: groupFile, Group: true}
+		require.NoError(t, cmd.Run(globals))
+
+		var result struct {
+			Summary struct {
+				TotalCount int `json:"totalCount"`
+				ErrorCount int `json:"errorCount"`
+			} `json:"summary"`
+		}
+		require.NoError(t, json.Unmarshal(stdout.Bytes(), &result))
+		assert.Equal(t, 1, result.Summary.TotalCount)
+		assert.Equal(t, 1, result.Summary.ErrorCount)
+
+		globals, _, _ = testGlobals("ndjson")
+		cmd = &AnalyzeCmd{File: groupFile, Group: true, GroupWindow: "-1s"}
+		assert.Error(t, cmd.Run(globals))
+	})
+
 	t.Run("with pattern persistence", func(t *testing.T) {
 		patternFile := filepath.Join(tmpDir, "patterns.json")
 		globals, stdout, _ := testGlobals("ndjson")
@@ -524,10 +874,430 @@ func TestAnalyzeCmd_Run(t *testing.T) {
 		assert.Contains(t, result, "new_pattern_count")
 		assert.Contains(t, result, "known_pattern_count")
 	})
+
+	t.Run("fails on patterns new in build", func(t *testing.T) {
+		patternFile := filepath.Join(tmpDir, "history.json")
+		writeRun := func(name, build string, messages ...string) string {
+			path := filepath.Join(tmpDir, name)
+			f, err := os.Create(path)
+			require.NoError(t, err)
+			encoder := json.NewEncoder(f)
+			require.NoError(t, encoder.Encode(domain.NewSessionStartWithMeta(1, 123, 0, "com.example.app", "Sim", "UDID", "", "1.0", build, "", "")))
+			for i, msg := range messages {
+				require.NoError(t, encoder.Encode(domain.LogEntry{Timestamp: time.Now().Add(time.Duration(i) * time.Second), Level: domain.LogLevelError, Process: "TestApp", PID: 123, Message: msg}))
+			}
+			require.NoError(t, f.Close())
+			return path
+		}
+		base := writeRun("build1.ndjson", "1", "db timeout", "db timeout")
+		next := writeRun("build2.ndjson", "2", "db timeout", "db timeout", "cache miss exploded", "cache miss exploded")
+
+		globals, _, _ := testGlobals("ndjson")
+		cmd := &AnalyzeCmd{File: base, PersistPatterns: true, PatternFile: patternFile, FailOnRegression: true}
+		require.NoError(t, cmd.Run(globals))
+
+		globals, stdout, _ := testGlobals("ndjson")
+		cmd = &AnalyzeCmd{File: next, PersistPatterns: true, PatternFile: patternFile, FailOnRegression: true}
+		err := cmd.Run(globals)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "cache miss exploded")
+
+		dec := json.NewDecoder(stdout)
+		var analysis map[string]interface{}
+		require.NoError(t, dec.Decode(&analysis))
+		assert.Equal(t, "2", analysis["app_build"])
+		assert.EqualValues(t, 1, analysis["new_in_build_count"])
+		var failure map[string]interface{}
+		require.NoError(t, dec.Decode(&failure))
+		assert.Equal(t, "PATTERN_REGRESSION", failure["code"])
+
+		globals, _, _ = testGlobals("ndjson")
+		cmd = &AnalyzeCmd{File: next, FailOnRegression: true}
+		assert.Error(t, cmd.Run(globals))
+	})
+
+	t.Run("adds a histogram and draws a timeline", func(t *testing.T) {
+		globals, stdout, _ := testGlobals("ndjson")
+		cmd := &AnalyzeCmd{File: logFile, HistogramFlags: HistogramFlags{Bucket: "1m"}}
+		require.NoError(t, cmd.Run(globals))
+
+		var result struct {
+			Summary domain.LogSummary `json:"summary"`
+		}
+		require.NoError(t, json.Unmarshal(stdout.Bytes(), &result))
+		assert.Equal(t, "1m0s", result.Summary.BucketSize)
+		total := 0
+		for _, b := range result.Summary.Histogram {
+			total += b.TotalCount
+		}
+		assert.Equal(t, len(entries), total)
+
+		globals, stdout, _ = testGlobals("text")
+		cmd = &AnalyzeCmd{File: logFile, HistogramFlags: HistogramFlags{Histogram: true}}
+		require.NoError(t, cmd.Run(globals))
+		assert.Contains(t, stdout.String(), "Timeline (")
+		assert.Contains(t, stdout.String(), "Errors peaked at")
+
+		globals, _, _ = testGlobals("ndjson")
+		cmd = &AnalyzeCmd{File: logFile, HistogramFlags: HistogramFlags{Bucket: "1ns"}}
+		assert.Error(t, cmd.Run(globals))
+	})
 }
 
 // --- Replay Command Tests ---
 
+func TestDiffCmd_Run(t *testing.T) {
+	tmpDir := t.TempDir()
+	base := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	writeEntries := func(name string, entries []domain.LogEntry) string {
+		path := filepath.Join(tmpDir, name)
+		f, err := os.Create(path)
+		require.NoError(t, err)
+		encoder := json.NewEncoder(f)
+		for _, entry := range entries {
+			require.NoError(t, encoder.Encode(entry))
+		}
+		require.NoError(t, f.Close())
+		return path
+	}
+
+	fileA := writeEntries("a.ndjson", []domain.LogEntry{
+		{Timestamp: base, Level: domain.LogLevelInfo, Process: "TestApp", PID: 1, Session: 1, Message: "Loaded 3 items"},
+		{Timestamp: base.Add(time.Second), Level: domain.LogLevelInfo, Process: "TestApp", PID: 2, Session: 2, Message: "Loaded 5 items"},
+		{Timestamp: base.Add(2 * time.Second), Level: domain.LogLevelError, Process: "TestApp", PID: 2, Session: 2, Message: "Sync failed: code 42"},
+	})
+	fileB := writeEntries("b.ndjson", []domain.LogEntry{
+		{Timestamp: base, Level: domain.LogLevelInfo, Process: "TestApp", PID: 3, Message: "Loaded 9 items"},
+	})
+
+	t.Run("compares two files in NDJSON format", func(t *testing.T) {
+		globals, stdout, _ := testGlobals("ndjson")
+		cmd := &DiffCmd{FileA: fileA, FileB: fileB, Limit: 20}
+		require.NoError(t, cmd.Run(globals))
+
+		var result output.DiffResult
+		require.NoError(t, json.Unmarshal(stdout.Bytes(), &result))
+		assert.Equal(t, "diff_result", result.Type)
+		assert.Equal(t, 3, result.A.Total)
+		assert.Equal(t, 1, result.B.Total)
+		require.Len(t, result.OnlyInA, 1)
+		assert.Equal(t, "Sync failed: code <n>", result.OnlyInA[0].Pattern)
+		assert.Empty(t, result.OnlyInB)
+	})
+
+	t.Run("compares sessions within one file in text format", func(t *testing.T) {
+		globals, stdout, _ := testGlobals("text")
+		cmd := &DiffCmd{FileA: fileA, SessionA: 1, SessionB: 2, Limit: 20}
+		require.NoError(t, cmd.Run(globals))
+
+		out := stdout.String()
+		assert.Contains(t, out, "(session 1) - 1 entries")
+		assert.Contains(t, out, "Only in B (1):")
+		assert.Contains(t, out, "Sync failed: code <n>")
+	})
+
+	t.Run("requires sessions for a single file", func(t *testing.T) {
+		globals, _, _ := testGlobals("ndjson")
+		cmd := &DiffCmd{FileA: fileA}
+		assert.Error(t, cmd.Run(globals))
+	})
+
+	t.Run("returns error for missing session", func(t *testing.T) {
+		globals, stdout, _ := testGlobals("ndjson")
+		cmd := &DiffCmd{FileA: fileA, SessionA: 1, SessionB: 7}
+		assert.Error(t, cmd.Run(globals))
+		assert.Contains(t, stdout.String(), "SESSION_NOT_FOUND")
+	})
+}
+
+func TestExpectCmd_Run(t *testing.T) {
+	tmpDir := t.TempDir()
+	base := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	logFile := filepath.Join(tmpDir, "run.ndjson")
+	f, err := os.Create(logFile)
+	require.NoError(t, err)
+	encoder := json.NewEncoder(f)
+	for i, msg := range []string{"App launched", "Request 1 failed", "Login succeeded"} {
+		level := domain.LogLevelInfo
+		if strings.Contains(msg, "failed") {
+			level = domain.LogLevelError
+		}
+		require.NoError(t, encoder.Encode(domain.LogEntry{Timestamp: base.Add(time.Duration(i) * 5 * time.Second), Level: level, Process: "MyApp", PID: 1, Message: msg}))
+	}
+	require.NoError(t, f.Close())
+
+	writeRules := func(t *testing.T, rules string) string {
+		path := filepath.Join(t.TempDir(), "expect.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(rules), 0o644))
+		return path
+	}
+
+	t.Run("passes when all expectations hold", func(t *testing.T) {
+		rules := writeRules(t, `expectations:
+  - name: login
+    see: message~"Login succeeded"
+    within: 20s
+  - not_see: level=Fault
+  - count: level=Error
+    max: 1
+  - order: ['message~"launched"', 'message~"Login"']
+`)
+		globals, stdout, _ := testGlobals("ndjson")
+		cmd := &ExpectCmd{Rules: rules, File: logFile}
+		require.NoError(t, cmd.Run(globals))
+
+		var result domain.ExpectResult
+		require.NoError(t, json.Unmarshal(stdout.Bytes(), &result))
+		assert.Equal(t, "expect_result", result.Type)
+		assert.True(t, result.Passed)
+		assert.Equal(t, 4, result.PassedCount)
+		assert.Equal(t, 3, result.LogsChecked)
+		assert.Equal(t, "end_of_file", result.StopReason)
+	})
+
+	t.Run("fails with expectation_failed events", func(t *testing.T) {
+		rules := writeRules(t, `expectations:
+  - name: fast login
+    see: message~"Login succeeded"
+    within: 5s
+  - name: no errors
+    not_see: level=Error
+`)
+		globals, stdout, _ := testGlobals("ndjson")
+		cmd := &ExpectCmd{Rules: rules, File: logFile}
+		require.Error(t, cmd.Run(globals))
+
+		lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+		require.Len(t, lines, 3)
+		var failed domain.ExpectationFailed
+		require.NoError(t, json.Unmarshal([]byte(lines[0]), &failed))
+		assert.Equal(t, "expectation_failed", failed.Type)
+		assert.Equal(t, "no errors", failed.Name)
+		require.NotNil(t, failed.Entry)
+		assert.Equal(t, "Request 1 failed", failed.Entry.Message)
+		require.NoError(t, json.Unmarshal([]byte(lines[1]), &failed))
+		assert.Equal(t, "fast login", failed.Name)
+		assert.Equal(t, "not seen within 5s", failed.Reason)
+
+		var result domain.ExpectResult
+		require.NoError(t, json.Unmarshal([]byte(lines[2]), &result))
+		assert.False(t, result.Passed)
+		assert.Equal(t, 2, result.FailedCount)
+	})
+
+	t.Run("stops at first failure with fail-fast", func(t *testing.T) {
+		rules := writeRules(t, "expectations:\n  - not_see: level=Error\n  - see: message~never\n")
+		globals, stdout, _ := testGlobals("text")
+		cmd := &ExpectCmd{Rules: rules, File: logFile, FailFast: true}
+		require.Error(t, cmd.Run(globals))
+
+		out := stdout.String()
+		assert.Contains(t, out, "FAIL not_see #1: matched a log entry that must not appear")
+		assert.Contains(t, out, "FAIL see #2: never seen")
+		assert.Contains(t, out, "FAILED: 0/2 expectations passed (2 logs checked, fail_fast)")
+	})
+
+	t.Run("checks the whole recording without an explicit limit", func(t *testing.T) {
+		longFile := filepath.Join(t.TempDir(), "long.ndjson")
+		f, err := os.Create(longFile)
+		require.NoError(t, err)
+		encoder := json.NewEncoder(f)
+		require.NoError(t, encoder.Encode(domain.LogEntry{Timestamp: base, Level: domain.LogLevelInfo, Process: "MyApp", PID: 1, Message: "App launched"}))
+		require.NoError(t, encoder.Encode(domain.LogEntry{Timestamp: base.Add(3 * time.Minute), Level: domain.LogLevelError, Process: "MyApp", PID: 1, Message: "Request 2 failed"}))
+		require.NoError(t, f.Close())
+
+		rules := writeRules(t, "expectations:\n  - name: no errors\n    not_see: level=Error\n")
+		globals, stdout, _ := testGlobals("ndjson")
+		require.Error(t, (&ExpectCmd{Rules: rules, File: longFile}).Run(globals))
+		lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+		var result domain.ExpectResult
+		require.NoError(t, json.Unmarshal([]byte(lines[len(lines)-1]), &result))
+		assert.False(t, result.Passed)
+		assert.Equal(t, 2, result.LogsChecked)
+		assert.Equal(t, "all_decided", result.StopReason)
+
+		globals, stdout, _ = testGlobals("ndjson")
+		require.NoError(t, (&ExpectCmd{Rules: rules, File: longFile, MaxDuration: "1m"}).Run(globals))
+		require.NoError(t, json.Unmarshal(stdout.Bytes(), &result))
+		assert.True(t, result.Passed)
+		assert.Equal(t, "max_duration", result.StopReason)
+	})
+
+	t.Run("reads stdin and reports missing files like other commands", func(t *testing.T) {
+		data, err := os.ReadFile(logFile)
+		require.NoError(t, err)
+		r, w, err := os.Pipe()
+		require.NoError(t, err)
+		stdin := os.Stdin
+		os.Stdin = r
+		defer func() { os.Stdin = stdin }()
+		go func() {
+			_, _ = w.Write(data)
+			_ = w.Close()
+		}()
+
+		rules := writeRules(t, "expectations:\n  - see: message~\"Login succeeded\"\n")
+		globals, stdout, _ := testGlobals("ndjson")
+		require.NoError(t, (&ExpectCmd{Rules: rules, File: "-"}).Run(globals))
+		var result domain.ExpectResult
+		require.NoError(t, json.Unmarshal(stdout.Bytes(), &result))
+		assert.True(t, result.Passed)
+		assert.Equal(t, 3, result.LogsChecked)
+
+		globals, stdout, _ = testGlobals("ndjson")
+		require.Error(t, (&ExpectCmd{Rules: rules, File: filepath.Join(t.TempDir(), "missing.ndjson")}).Run(globals))
+		assert.Contains(t, stdout.String(), "FILE_NOT_FOUND")
+	})
+
+	t.Run("rejects invalid rules", func(t *testing.T) {
+		rules := writeRules(t, "expectations:\n  - count: level=Error\n")
+		globals, stdout, _ := testGlobals("ndjson")
+		cmd := &ExpectCmd{Rules: rules, File: logFile}
+		require.Error(t, cmd.Run(globals))
+		assert.Contains(t, stdout.String(), "INVALID_EXPECTATIONS")
+	})
+}
+
+func TestSearchCmd_Run(t *testing.T) {
+	tmpDir := t.TempDir()
+	now := time.Now()
+	write := func(name string, entries []domain.LogEntry) {
+		f, err := os.Create(filepath.Join(tmpDir, name))
+		require.NoError(t, err)
+		enc := json.NewEncoder(f)
+		for _, e := range entries {
+			require.NoError(t, enc.Encode(e))
+		}
+		require.NoError(t, f.Close())
+	}
+	write("20251209-100000-com_example_a.ndjson", []domain.LogEntry{
+		{Timestamp: now.Add(-30 * 24 * time.Hour), Level: domain.LogLevelFault, Process: "A", Message: "keychain locked (old)"},
+	})
+	write("20251210-100000-com_example_b.ndjson", []domain.LogEntry{
+		{Timestamp: now.Add(-2 * time.Hour), Level: domain.LogLevelInfo, Process: "B", Message: "keychain read"},
+		{Timestamp: now.Add(-time.Hour), Level: domain.LogLevelFault, Process: "B", Message: "Keychain item missing"},
+	})
+
+	t.Run("sessions index builds the index", func(t *testing.T) {
+		globals, stdout, _ := testGlobals("ndjson")
+		require.NoError(t, (&SessionsIndexCmd{Dir: tmpDir}).Run(globals))
+
+		var out SessionIndexOutput
+		require.NoError(t, json.Unmarshal(stdout.Bytes(), &out))
+		assert.Equal(t, "session_index", out.Type)
+		assert.Equal(t, 2, out.Files)
+		assert.Equal(t, 2, out.Indexed)
+		assert.Equal(t, 3, out.Entries)
+	})
+
+	t.Run("finds faults in the last 7 days", func(t *testing.T) {
+		globals, stdout, _ := testGlobals("ndjson")
+		require.NoError(t, (&SearchCmd{Query: []string{"keychain"}, Dir: tmpDir, Since: "7d", MinLevel: "fault", Limit: 100}).Run(globals))
+
+		lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+		require.Len(t, lines, 2)
+		assert.Contains(t, lines[0], "Keychain item missing")
+
+		var result SearchResult
+		require.NoError(t, json.Unmarshal([]byte(lines[1]), &result))
+		assert.Equal(t, "search_result", result.Type)
+		assert.Equal(t, 1, result.Total)
+		assert.Equal(t, 1, result.FilesSkipped)
+		assert.Equal(t, []string{"20251210-100000-com_example_b.ndjson"}, result.Files)
+	})
+
+	t.Run("text output and where filter", func(t *testing.T) {
+		globals, stdout, _ := testGlobals("text")
+		require.NoError(t, (&SearchCmd{Query: []string{"keychain"}, Dir: tmpDir, Where: []string{"process=A"}, Limit: 100}).Run(globals))
+		assert.Contains(t, stdout.String(), "keychain locked (old)")
+		assert.Contains(t, stdout.String(), "1 match(es)")
+	})
+
+	t.Run("rejects invalid since", func(t *testing.T) {
+		globals, _, _ := testGlobals("ndjson")
+		assert.Error(t, (&SearchCmd{Dir: tmpDir, Since: "last week"}).Run(globals))
+	})
+
+	t.Run("reports unreadable recordings instead of failing", func(t *testing.T) {
+		bad := "20251211-100000-com_example_c.ndjson.gz"
+		// Only the start of the gzip header has been written so far
+		require.NoError(t, os.WriteFile(filepath.Join(tmpDir, bad), []byte{0x1f, 0x8b, 0x08}, 0o644))
+
+		globals, stdout, _ := testGlobals("ndjson")
+		require.NoError(t, (&SearchCmd{Query: []string{"keychain"}, Dir: tmpDir, Limit: 100}).Run(globals))
+		lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+		var result SearchResult
+		require.NoError(t, json.Unmarshal([]byte(lines[len(lines)-1]), &result))
+		assert.Equal(t, 3, result.Total)
+		assert.Equal(t, []string{bad}, result.FilesFailed)
+
+		globals, stdout, _ = testGlobals("text")
+		require.NoError(t, (&SessionsIndexCmd{Dir: tmpDir}).Run(globals))
+		assert.Contains(t, stdout.String(), "Could not read 1 file(s), will retry: "+bad)
+	})
+}
+
+func TestSQLCmd_Run(t *testing.T) {
+	if _, err := exec.LookPath("sqlite3"); err != nil {
+		t.Skip("sqlite3 not installed")
+	}
+	path := filepath.Join(t.TempDir(), "session.ndjson")
+	content := `{"type":"session_start","schemaVersion":1,"session":1,"pid":100,"app":"com.example.app","simulator":"iPhone 17","udid":"U1","timestamp":"2025-12-09T10:00:00Z","version":"1.2"}
+{"timestamp":"2025-12-09T10:00:01Z","level":"Error","process":"MyApp","pid":100,"subsystem":"com.example.net","message":"request 12 timed out after 30s","session":1,"fields":{"code":"504"}}
+{"timestamp":"2025-12-09T10:00:02Z","level":"Error","process":"MyApp","pid":100,"subsystem":"com.example.net","message":"request 13 timed out after 45s","session":1}
+{"timestamp":"2025-12-09T10:00:03Z","level":"Info","process":"MyApp","pid":100,"subsystem":"com.example.ui","message":"ready","session":1}
+{"type":"session_end","schemaVersion":1,"session":1,"pid":100,"summary":{"total_logs":3,"errors":2,"faults":0,"duration_seconds":3}}
+`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+	t.Run("groups logs and keeps column order", func(t *testing.T) {
+		globals, stdout, _ := testGlobals("ndjson")
+		cmd := &SQLCmd{File: []string{path}, Query: "select subsystem, count(*) as n from logs group by subsystem order by n desc"}
+		require.NoError(t, cmd.Run(globals))
+
+		lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+		require.Len(t, lines, 3)
+		assert.Equal(t, `{"type":"sql_row","schemaVersion":1,"row":{"subsystem":"com.example.net","n":2}}`, lines[0])
+
+		var result SQLResult
+		require.NoError(t, json.Unmarshal([]byte(lines[2]), &result))
+		assert.Equal(t, "sql_result", result.Type)
+		assert.Equal(t, []string{"subsystem", "n"}, result.Columns)
+		assert.Equal(t, 2, result.Rows)
+		assert.Equal(t, []SQLLoadedFile{{File: "session.ndjson", Entries: 3, Sessions: 1}}, result.Loaded)
+	})
+
+	t.Run("loads sessions, fields and patterns into a kept database", func(t *testing.T) {
+		db := filepath.Join(t.TempDir(), "logs.db")
+		globals, _, _ := testGlobals("ndjson")
+		require.NoError(t, (&SQLCmd{File: []string{path}, DB: db}).Run(globals))
+		// Loading the same file again replaces its rows
+		globals, _, _ = testGlobals("ndjson")
+		require.NoError(t, (&SQLCmd{File: []string{path}, DB: db}).Run(globals))
+
+		globals, stdout, _ := testGlobals("text")
+		query := `select s.version, s.total_logs, p.pattern, p.count, f.value
+			from sessions s join patterns p using (file) join logs l using (file, template_id) join fields f on f.log_id = l.id`
+		require.NoError(t, (&SQLCmd{DB: db, Query: query}).Run(globals))
+		out := stdout.String()
+		assert.Contains(t, out, "request <n> timed out after <n>s")
+		assert.Regexp(t, `1\.2\s+3\s+.*\s2\s+504`, out)
+		assert.Contains(t, out, "(1 row(s))")
+	})
+
+	t.Run("reports SQL errors", func(t *testing.T) {
+		globals, stdout, _ := testGlobals("ndjson")
+		require.Error(t, (&SQLCmd{File: []string{path}, Query: "select nope from logs"}).Run(globals))
+		assert.Contains(t, stdout.String(), `"code":"SQL_FAILED"`)
+	})
+
+	t.Run("rejects dot-commands", func(t *testing.T) {
+		globals, stdout, _ := testGlobals("ndjson")
+		require.Error(t, (&SQLCmd{File: []string{path}, Query: ".shell ls"}).Run(globals))
+		assert.Contains(t, stdout.String(), "dot-commands")
+	})
+}
+
 func TestReplayCmd_Run(t *testing.T) {
 	// Create a temporary NDJSON log file
 	tmpDir := t.TempDir()
@@ -562,6 +1332,27 @@ func TestReplayCmd_Run(t *testing.T) {
 		assert.Contains(t, output, "Message 3")
 	})
 
+	t.Run("replays zstd-compressed recordings", func(t *testing.T) {
+		data, err := os.ReadFile(logFile)
+		require.NoError(t, err)
+		zstFile := filepath.Join(tmpDir, "test.ndjson.zst")
+		var buf bytes.Buffer
+		zw, err := newCompressor(&buf, compressZstd)
+		require.NoError(t, err)
+		_, err = zw.Write(data)
+		require.NoError(t, err)
+		require.NoError(t, zw.Close())
+		require.NoError(t, os.WriteFile(zstFile, buf.Bytes(), 0644))
+
+		globals, stdout, _ := testGlobals("ndjson")
+		globals.Quiet = true
+		require.NoError(t, (&ReplayCmd{File: zstFile}).Run(globals))
+		assert.Len(t, strings.Split(strings.TrimSpace(stdout.String()), "\n"), 3)
+
+		globals, _, _ = testGlobals("ndjson")
+		assert.Error(t, (&ReplayCmd{File: zstFile, Follow: true}).Run(globals))
+	})
+
 	t.Run("replays log file in NDJSON format", func(t *testing.T) {
 		globals, stdout, _ := testGlobals("ndjson")
 		globals.Quiet = true
@@ -685,6 +1476,39 @@ func TestDoctorCmd_checkWritePermission(t *testing.T) {
 	})
 }
 
+func TestDoctorCmd_checkRules(t *testing.T) {
+	cmd := &DoctorCmd{}
+	dir := t.TempDir()
+
+	good := filepath.Join(dir, "rules.yaml")
+	require.NoError(t, os.WriteFile(good, []byte(`
+rules:
+  - name: net errors
+    where: subsystem=com.example.net AND level>=error
+    threshold: 5 in 60s
+    actions:
+      - marker: network degraded
+  - name: crash
+    where: 'message~"fatal: "'
+    actions:
+      - webhook: https://hooks.slack.com/services/T/B/X
+        format: slack
+`), 0o644))
+	result := cmd.checkRules(good)
+	assert.Equal(t, "ok", result.Status)
+	assert.Equal(t, "2 rule(s) valid", result.Message)
+	assert.Equal(t, "net errors, crash", result.Details)
+
+	bad := filepath.Join(dir, "bad.yaml")
+	require.NoError(t, os.WriteFile(bad, []byte("rules:\n  - name: x\n    where: level=Error\n    actions:\n      - webhook: ftp://example.com\n"), 0o644))
+	result = cmd.checkRules(bad)
+	assert.Equal(t, "error", result.Status)
+	assert.Contains(t, result.Details, "rule x: action 1: invalid webhook URL")
+
+	result = cmd.checkRules(filepath.Join(dir, "missing.yaml"))
+	assert.Equal(t, "error", result.Status)
+}
+
 // --- Apps Command Tests ---
 
 func TestAppsCmd_appInfo(t *testing.T) {
@@ -777,3 +1601,237 @@ func TestVersionCmd_Run(t *testing.T) {
 		assert.Contains(t, result, "commit")
 	})
 }
+
+func TestTraceCmd_Run(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.ndjson")
+	content := `{"timestamp":"2025-12-09T10:00:02Z","level":"Error","process":"MyApp","pid":100,"message":"GET /users failed traceparent=00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}
+{"timestamp":"2025-12-09T10:00:00Z","level":"Info","process":"MyApp","pid":100,"message":"GET /users traceparent=00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}
+{"timestamp":"2025-12-09T10:00:01Z","level":"Info","process":"NetDaemon","pid":200,"message":"request_id=req-9 traceparent=00-4bf92f3577b34da6a3ce929d0e0e4736-b7ad6b7169203331-01"}
+{"timestamp":"2025-12-09T10:00:01Z","level":"Info","process":"MyApp","pid":100,"message":"child","activity_id":7,"parent_activity_id":5}
+{"timestamp":"2025-12-09T10:00:03Z","level":"Info","process":"MyApp","pid":100,"message":"activity root","activity_id":5}
+{"timestamp":"2025-12-09T10:00:04Z","level":"Info","process":"MyApp","pid":100,"message":"nested","activity_id":9,"parent_activity_id":7}
+`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+	t.Run("traceparent in time order with spans", func(t *testing.T) {
+		globals, stdout, _ := testGlobals("ndjson")
+		cmd := &TraceCmd{ID: "4BF92F3577B34DA6A3CE929D0E0E4736", File: []string{path}, ExtractTrace: []string{"traceparent"}}
+		require.NoError(t, cmd.Run(globals))
+
+		lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+		require.Len(t, lines, 4)
+		assert.Contains(t, lines[0], `"trace_id":"4bf92f3577b34da6a3ce929d0e0e4736","span_id":"00f067aa0ba902b7"`)
+		assert.Contains(t, lines[1], "NetDaemon")
+
+		var result TraceResult
+		require.NoError(t, json.Unmarshal([]byte(lines[3]), &result))
+		assert.Equal(t, "trace", result.Kind)
+		assert.Equal(t, 3, result.Entries)
+		assert.Equal(t, 1, result.Errors)
+		assert.Equal(t, int64(2000), result.DurationMs)
+		require.Len(t, result.Spans, 2)
+		assert.Equal(t, "00f067aa0ba902b7", result.Spans[0].ID)
+		assert.Equal(t, int64(2000), result.Spans[0].DurationMs)
+		assert.Equal(t, 2, result.Spans[0].Entries)
+		assert.Equal(t, []string{"NetDaemon"}, result.Spans[1].Processes)
+	})
+
+	t.Run("custom request id", func(t *testing.T) {
+		globals, stdout, _ := testGlobals("text")
+		cmd := &TraceCmd{ID: "req-9", File: []string{path}, ExtractTraceRegex: []string{`request_id=(?P<trace>[\w-]+)`}}
+		require.NoError(t, cmd.Run(globals))
+		assert.Contains(t, stdout.String(), "trace req-9: 1 entries")
+	})
+
+	t.Run("activity with nested activities", func(t *testing.T) {
+		globals, stdout, _ := testGlobals("text")
+		require.NoError(t, (&TraceCmd{ID: "0x5", File: []string{path}}).Run(globals))
+		out := stdout.String()
+		assert.Contains(t, out, "activity 0x5: 3 entries")
+		assert.Contains(t, out, "    7  +0ms  0ms  1 entries  MyApp\n  5  +2.000s")
+		assert.Contains(t, out, "\n      9  +3.000s")
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		globals, stdout, _ := testGlobals("ndjson")
+		err := (&TraceCmd{ID: "nope", File: []string{path}}).Run(globals)
+		require.Error(t, err)
+		assert.Contains(t, stdout.String(), "TRACE_NOT_FOUND")
+	})
+}
+
+func TestSparkline(t *testing.T) {
+	assert.Equal(t, "▁ █▄", sparkline([]int{1, 0, 8, 4}))
+	assert.Equal(t, "  ", sparkline([]int{0, 0}))
+}
+
+func TestDiscoverCmd_aggregate(t *testing.T) {
+	base := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	var entries []domain.LogEntry
+	add := func(n int, sub, cat string, level domain.LogLevel, msg string) {
+		for i := 0; i < n; i++ {
+			entries = append(entries, domain.LogEntry{
+				Timestamp: base.Add(time.Duration(len(entries)) * time.Second),
+				Level:     level,
+				Subsystem: sub,
+				Category:  cat,
+				Process:   "MyApp",
+				Message:   fmt.Sprintf(msg, i),
+			})
+		}
+	}
+	add(10, "com.example.myapp", "network", domain.LogLevelInfo, "GET /feed took %dms")
+	add(2, "com.example.myapp", "network", domain.LogLevelError, "request %d failed")
+	add(30, "com.apple.network", "connection", domain.LogLevelDebug, "nw_connection %d ready")
+	add(20, "com.apple.UIKit", "", domain.LogLevelDebug, "layout pass %d")
+	add(1, "com.apple.UIKit", "", domain.LogLevelFault, "constraint %d broken")
+	add(1, "com.apple.rare", "", domain.LogLevelDebug, "rare %d")
+
+	c := &DiscoverCmd{TopN: 10}
+	d := c.aggregate(output.NewAnalyzer(), entries, "com.example.myapp")
+
+	var app *domain.SubsystemInfo
+	for i := range d.Subsystems {
+		if d.Subsystems[i].Name == "com.example.myapp" {
+			app = &d.Subsystems[i]
+		}
+	}
+	require.NotNil(t, app)
+	assert.Equal(t, 12, app.Count)
+	assert.Equal(t, map[string]int{"Info": 10, "Error": 2}, app.Levels)
+	assert.Equal(t, "2024-01-15T10:00:00Z", app.FirstSeen)
+	assert.Equal(t, "2024-01-15T10:00:11Z", app.LastSeen)
+	assert.Equal(t, []string{"GET /feed took 0ms", "GET /feed took 1ms", "GET /feed took 2ms"}, app.Samples)
+	require.NotEmpty(t, app.Templates)
+	assert.Equal(t, domain.TemplateCount{ID: app.Templates[0].ID, Template: "GET /feed took <n>ms", Count: 10}, app.Templates[0])
+
+	require.NotEmpty(t, d.Categories)
+	assert.Equal(t, "connection", d.Categories[0].Name)
+	assert.Equal(t, map[string]int{"Debug": 30}, d.Categories[0].Levels)
+	assert.Len(t, d.Categories[0].Samples, 3)
+
+	s := d.Suggested
+	require.NotNil(t, s)
+	assert.Equal(t, []string{"com.apple.network"}, s.ExcludeSubsystems)
+	assert.Equal(t, []string{"subsystem not in (com.apple.UIKit) OR level>=error"}, s.Where)
+	assert.Equal(t, 50, s.Removes)
+	assert.Equal(t, "xcw tail -a com.example.myapp --exclude-subsystem com.apple.network --where 'subsystem not in (com.apple.UIKit) OR level>=error'", s.Command)
+	_, err := filter.NewWhereFilter(s.Where)
+	assert.NoError(t, err)
+
+	t.Run("no suggestions without noisy Apple subsystems", func(t *testing.T) {
+		d := c.aggregate(output.NewAnalyzer(), entries[:12], "")
+		assert.Nil(t, d.Suggested)
+	})
+}
+
+func TestDiscoverCmd_RunFiles(t *testing.T) {
+	dir := t.TempDir()
+	first := `{"type":"session_start","session":1,"pid":100,"app":"com.example.myapp","simulator":"iPhone 17 Pro","timestamp":"2025-12-09T10:00:00Z"}
+{"timestamp":"2025-12-09T10:00:00Z","level":"Info","subsystem":"com.example.myapp","category":"network","process":"MyApp","pid":100,"message":"GET /feed 200"}
+{"timestamp":"2025-12-09T10:00:01Z","level":"Error","subsystem":"com.example.myapp","category":"network","process":"MyApp","pid":100,"message":"GET /feed 500"}
+`
+	second := `{"timestamp":"2025-12-09T10:05:00Z","level":"Debug","subsystem":"com.apple.network","process":"MyApp","pid":100,"message":"nw_connection 1 ready"}
+{"timestamp":"2025-12-09T10:05:01Z","level":"Debug","subsystem":"com.apple.network","process":"MyApp","pid":100,"message":"nw_connection 2 ready"}
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.ndjson"), []byte(first), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.ndjson"), []byte(second), 0o644))
+
+	t.Run("glob input", func(t *testing.T) {
+		globals, stdout, _ := testGlobals("ndjson")
+		globals.Quiet = true
+		cmd := &DiscoverCmd{TopN: 20, Limit: 5000, Since: "5m", File: []string{filepath.Join(dir, "*.ndjson")}}
+		require.NoError(t, cmd.Run(globals))
+
+		var d domain.Discovery
+		require.NoError(t, json.Unmarshal(stdout.Bytes(), &d))
+		assert.Equal(t, "discovery", d.Type)
+		assert.Equal(t, []string{"a.ndjson", "b.ndjson"}, d.Files)
+		assert.Equal(t, "com.example.myapp", d.App)
+		assert.Equal(t, 4, d.TotalCount)
+		assert.Equal(t, "2025-12-09T10:00:00Z", d.TimeRange.Start)
+		assert.Equal(t, "2025-12-09T10:05:01Z", d.TimeRange.End)
+		require.Len(t, d.Subsystems, 2)
+		require.NotNil(t, d.Suggested)
+		assert.Equal(t, []string{"com.apple.network"}, d.Suggested.ExcludeSubsystems)
+	})
+
+	t.Run("since and limit count back from the last entry", func(t *testing.T) {
+		globals, stdout, _ := testGlobals("ndjson")
+		globals.Quiet = true
+		globals.FlagsSet = map[string]bool{"since": true, "limit": true}
+		cmd := &DiscoverCmd{TopN: 20, Limit: 3, Since: "10m", File: []string{filepath.Join(dir, "b.ndjson"), filepath.Join(dir, "a.ndjson")}}
+		require.NoError(t, cmd.Run(globals))
+		var d domain.Discovery
+		require.NoError(t, json.Unmarshal(stdout.Bytes(), &d))
+		assert.Equal(t, 3, d.TotalCount)
+		assert.Equal(t, "2025-12-09T10:00:01Z", d.TimeRange.Start)
+
+		globals, stdout, _ = testGlobals("ndjson")
+		globals.Quiet = true
+		globals.FlagsSet = map[string]bool{"since": true}
+		cmd = &DiscoverCmd{TopN: 20, Since: "1m", File: []string{filepath.Join(dir, "a.ndjson"), filepath.Join(dir, "b.ndjson")}}
+		require.NoError(t, cmd.Run(globals))
+		require.NoError(t, json.Unmarshal(stdout.Bytes(), &d))
+		assert.Equal(t, 2, d.TotalCount)
+	})
+
+	t.Run("info reports the window actually applied", func(t *testing.T) {
+		info := func(flagsSet map[string]bool) map[string]any {
+			globals, stdout, _ := testGlobals("ndjson")
+			globals.FlagsSet = flagsSet
+			cmd := &DiscoverCmd{TopN: 20, Since: "5m", File: []string{filepath.Join(dir, "a.ndjson")}}
+			require.NoError(t, cmd.Run(globals))
+			var v map[string]any
+			line, _, _ := strings.Cut(stdout.String(), "\n")
+			require.NoError(t, json.Unmarshal([]byte(line), &v))
+			require.Equal(t, "info", v["type"])
+			return v
+		}
+		assert.NotContains(t, info(nil), "since", "the default --since does not apply to recordings")
+		assert.Equal(t, "5m", info(map[string]bool{"since": true})["since"])
+	})
+
+	t.Run("reads stdin", func(t *testing.T) {
+		r, w, err := os.Pipe()
+		require.NoError(t, err)
+		stdin := os.Stdin
+		os.Stdin = r
+		defer func() { os.Stdin = stdin }()
+		go func() {
+			_, _ = w.WriteString(first)
+			_ = w.Close()
+		}()
+
+		globals, stdout, _ := testGlobals("ndjson")
+		globals.Quiet = true
+		require.NoError(t, (&DiscoverCmd{TopN: 20, File: []string{"-"}}).Run(globals))
+		var d domain.Discovery
+		require.NoError(t, json.Unmarshal(stdout.Bytes(), &d))
+		assert.Equal(t, []string{"(stdin)"}, d.Files)
+		assert.Equal(t, "com.example.myapp", d.App)
+		assert.Equal(t, 2, d.TotalCount)
+	})
+
+	t.Run("text output", func(t *testing.T) {
+		globals, stdout, stderr := testGlobals("text")
+		cmd := &DiscoverCmd{TopN: 20, File: []string{filepath.Join(dir, "a.ndjson")}}
+		require.NoError(t, cmd.Run(globals))
+		assert.Contains(t, stderr.String(), "Discovering logs from 1 recording(s)")
+		assert.Contains(t, stdout.String(), "Total logs: 2")
+		assert.Contains(t, stdout.String(), "(1 errors)")
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		globals, stdout, _ := testGlobals("ndjson")
+		err := (&DiscoverCmd{TopN: 20, File: []string{filepath.Join(dir, "*.log")}}).Run(globals)
+		require.Error(t, err)
+		assert.Contains(t, stdout.String(), "FILE_NOT_FOUND")
+
+		globals, stdout, _ = testGlobals("ndjson")
+		globals.FlagsSet = map[string]bool{"booted": true}
+		err = (&DiscoverCmd{TopN: 20, Booted: true, File: []string{filepath.Join(dir, "a.ndjson")}}).Run(globals)
+		require.Error(t, err)
+		assert.Contains(t, stdout.String(), "INVALID_FLAGS")
+	})
+}
@@ -0,0 +1,147 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression codecs for --compress
+const (
+	compressGzip = "gzip"
+	compressZstd = "zstd"
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// compressionExt returns the filename suffix appended for a codec
+func compressionExt(codec string) string {
+	switch codec {
+	case compressGzip:
+		return ".gz"
+	case compressZstd:
+		return ".zst"
+	default:
+		return ""
+	}
+}
+
+// validateCompression checks a --compress value ("" means uncompressed)
+func validateCompression(codec string) error {
+	switch codec {
+	case "", compressGzip, compressZstd:
+		return nil
+	default:
+		return fmt.Errorf("invalid compression %q (use gzip or zstd)", codec)
+	}
+}
+
+// newCompressor wraps w with the codec's streaming encoder
+func newCompressor(w io.Writer, codec string) (io.WriteCloser, error) {
+	switch codec {
+	case compressGzip:
+		return gzip.NewWriter(w), nil
+	case compressZstd:
+		return zstd.NewWriter(w)
+	default:
+		return nil, fmt.Errorf("invalid compression %q", codec)
+	}
+}
+
+// openRecording opens an NDJSON recording, transparently decompressing gzip
+// and zstd files. The codec is detected from the file's magic bytes, so the
+// extension does not matter.
+func openRecording(path string) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	rc, err := decompress(file)
+	if err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return rc, nil
+}
+
+// decompress returns a reader over file's decompressed content. Closing it
+// closes file.
+func decompress(file *os.File) (io.ReadCloser, error) {
+	br := bufio.NewReader(file)
+	head, _ := br.Peek(len(zstdMagic))
+	switch {
+	case bytes.HasPrefix(head, gzipMagic):
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip data: %w", err)
+		}
+		return &decompressReader{Reader: zr, close: zr.Close, file: file}, nil
+	case bytes.HasPrefix(head, zstdMagic):
+		zr, err := zstd.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("invalid zstd data: %w", err)
+		}
+		return &decompressReader{Reader: zr, close: func() error { zr.Close(); return nil }, file: file}, nil
+	default:
+		return &decompressReader{Reader: br, file: file}, nil
+	}
+}
+
+type decompressReader struct {
+	io.Reader
+	close func() error
+	file  *os.File
+}
+
+func (d *decompressReader) Close() error {
+	if d.close != nil {
+		if err := d.close(); err != nil {
+			_ = d.file.Close()
+			return err
+		}
+	}
+	return d.file.Close()
+}
+
+// isCompressedPath reports whether path has a gzip or zstd suffix
+func isCompressedPath(path string) bool {
+	return strings.HasSuffix(path, ".gz") || strings.HasSuffix(path, ".zst")
+}
+
+// parseByteSize parses sizes like "500MB", "2GB", "64k" or "1048576" (binary units)
+func parseByteSize(s string) (int64, error) {
+	str := strings.ToUpper(strings.TrimSpace(s))
+	str = strings.TrimSuffix(str, "IB")
+	str = strings.TrimSuffix(str, "B")
+	mult := int64(1)
+	if n := len(str); n > 0 {
+		switch str[n-1] {
+		case 'K':
+			mult = 1 << 10
+		case 'M':
+			mult = 1 << 20
+		case 'G':
+			mult = 1 << 30
+		case 'T':
+			mult = 1 << 40
+		}
+		if mult > 1 {
+			str = strings.TrimSpace(str[:n-1])
+		}
+	}
+	v, err := strconv.ParseFloat(str, 64)
+	if err != nil || v <= 0 {
+		return 0, fmt.Errorf("invalid size %q (e.g., '500MB', '2GB')", s)
+	}
+	return int64(v * float64(mult)), nil
+}
@@ -3,6 +3,7 @@ package cli
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/vburojevic/xcw/internal/config"
 	"github.com/vburojevic/xcw/internal/output"
@@ -59,6 +60,8 @@ func (c *ConfigShowCmd) Run(globals *Globals) error {
 			"tail":          cfg.Tail,
 			"query":         cfg.Query,
 			"watch":         cfg.Watch,
+			"presets":       presetInfos(cfg, configFile),
+			"patterns":      cfg.Patterns,
 			"sources":       sources,
 		}
 		encoder := json.NewEncoder(globals.Stdout)
@@ -153,6 +156,43 @@ func (c *ConfigShowCmd) Run(globals *Globals) error {
 		}
 	}
 
+	if presets := presetInfos(cfg, configFile); len(presets) > 0 {
+		if _, err := fmt.Fprintf(globals.Stdout, "\nFilter presets (--preset):\n"); err != nil {
+			return err
+		}
+		for _, p := range presets {
+			if _, err := fmt.Fprintf(globals.Stdout, "  %s (%s)\n", p.Name, p.Source); err != nil {
+				return err
+			}
+			if p.Description != "" {
+				if _, err := fmt.Fprintf(globals.Stdout, "    %s\n", p.Description); err != nil {
+					return err
+				}
+			}
+			for _, line := range describePreset(p.FilterPreset) {
+				if _, err := fmt.Fprintf(globals.Stdout, "    %s\n", line); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if len(cfg.Patterns.Masks) > 0 || cfg.Patterns.Similarity > 0 {
+		if _, err := fmt.Fprintf(globals.Stdout, "\nPattern templates:\n"); err != nil {
+			return err
+		}
+		if cfg.Patterns.Similarity > 0 {
+			if _, err := fmt.Fprintf(globals.Stdout, "  similarity: %g\n", cfg.Patterns.Similarity); err != nil {
+				return err
+			}
+		}
+		for _, m := range cfg.Patterns.Masks {
+			if _, err := fmt.Fprintf(globals.Stdout, "  mask %s: %s\n", m.Name, m.Regex); err != nil {
+				return err
+			}
+		}
+	}
+
 	if configFile != "" {
 		if _, err := fmt.Fprintln(globals.Stdout); err != nil {
 			return err
@@ -165,6 +205,48 @@ func (c *ConfigShowCmd) Run(globals *Globals) error {
 	return nil
 }
 
+// presetInfo describes a filter preset and where it was defined.
+type presetInfo struct {
+	Name   string `json:"name"`
+	Source string `json:"source"`
+	config.FilterPreset
+}
+
+// presetInfos lists configured presets by name. Presets can only come from the
+// loaded config file, so that path is the source.
+func presetInfos(cfg *config.Config, configFile string) []presetInfo {
+	source := configFile
+	if source == "" {
+		source = string(config.SourceConfig)
+	}
+	infos := make([]presetInfo, 0, len(cfg.Filters))
+	for _, name := range cfg.PresetNames() {
+		infos = append(infos, presetInfo{Name: name, Source: source, FilterPreset: cfg.Filters[name]})
+	}
+	return infos
+}
+
+// describePreset renders the non-empty preset filters as "key: value" lines.
+func describePreset(p config.FilterPreset) []string {
+	var lines []string
+	add := func(key string, vals ...string) {
+		if len(vals) == 0 || (len(vals) == 1 && vals[0] == "") {
+			return
+		}
+		lines = append(lines, fmt.Sprintf("%s: %s", key, strings.Join(vals, ", ")))
+	}
+	add("pattern", p.Pattern)
+	add("exclude", p.Exclude...)
+	add("exclude_subsystems", p.ExcludeSubsystems...)
+	add("subsystems", p.Subsystems...)
+	add("categories", p.Categories...)
+	add("processes", p.Processes...)
+	add("where", p.Where...)
+	add("min_level", p.MinLevel)
+	add("max_level", p.MaxLevel)
+	return lines
+}
+
 // ConfigPathCmd shows config file path
 type ConfigPathCmd struct{}
 
@@ -289,6 +371,32 @@ query:
 watch:
   # simulator: booted
   # cooldown: 5s
+
+# Named filter presets, applied with --preset on tail, query, watch and ui.
+# Presets merge with each other and with flags like repeated flags: values of
+# one list (subsystems, categories, processes) are ORed, different filters and
+# where clauses are ANDed; flag levels win.
+# filters:
+#   networking:
+#     description: HTTP traffic from the app
+#     subsystems: [com.example.myapp.network]
+#     where: ["message~/(GET|POST) /"]
+#   no-noise:
+#     exclude: ["heartbeat|keepalive"]
+#     exclude_subsystems: ["com.apple.*"]
+#     min_level: info
+
+# Message templating for analyze, summary, discover and pattern history.
+# Masks run before the built-in ones (URLs, e-mails, UUIDs, paths, numbers);
+# similarity (0-1, default 0.7) is how alike messages must be to share a template.
+# patterns:
+#   similarity: 0.7
+#   masks:
+#     - name: order
+#       regex: 'ORD-[0-9]+'
+#     - name: token
+#       regex: 'tok_[A-Za-z0-9]+'
+#       replace: '<secret>'
 `
 
 	if _, err := fmt.Fprint(globals.Stdout, sampleConfig); err != nil {
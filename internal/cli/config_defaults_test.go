@@ -67,7 +67,7 @@ func TestApplyTailDefaultsUsesConfig(t *testing.T) {
 
 	applyTailDefaults(cfg, cmd)
 
-	assert.Equal(t, "cfg-sim", cmd.Simulator)
+	assert.Equal(t, []string{"cfg-sim"}, cmd.Simulator)
 	assert.Equal(t, "com.cfg", cmd.App)
 	assert.Equal(t, "15s", cmd.SummaryInterval)
 	assert.Equal(t, "5s", cmd.Heartbeat)
@@ -91,7 +91,7 @@ func TestApplyTailDefaultsDoesNotOverrideExplicitValues(t *testing.T) {
 	}
 
 	cmd := &TailCmd{
-		Simulator: "cli-sim",
+		Simulator: []string{"cli-sim"},
 		App:       "com.cli",
 		TailFilterFlags: TailFilterFlags{
 			Exclude: []string{"keep"},
@@ -109,7 +109,7 @@ func TestApplyTailDefaultsDoesNotOverrideExplicitValues(t *testing.T) {
 
 	applyTailDefaults(cfg, cmd)
 
-	assert.Equal(t, "cli-sim", cmd.Simulator)
+	assert.Equal(t, []string{"cli-sim"}, cmd.Simulator)
 	assert.Equal(t, "com.cli", cmd.App)
 	assert.Equal(t, "25s", cmd.SummaryInterval)
 	assert.Equal(t, "3s", cmd.Heartbeat)
@@ -0,0 +1,125 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/vburojevic/xcw/internal/domain"
+	"github.com/vburojevic/xcw/internal/output"
+)
+
+// DiffCmd compares two NDJSON recordings, or two sessions within one recording
+type DiffCmd struct {
+	FileA    string `arg:"" required:"" help:"NDJSON recording (side A)"`
+	FileB    string `arg:"" optional:"" help:"NDJSON recording (side B); omit to compare --session-a and --session-b within FILE-A"`
+	SessionA int    `help:"Only use entries from this session on side A"`
+	SessionB int    `help:"Only use entries from this session on side B"`
+	Limit    int    `default:"20" help:"Maximum patterns and deltas listed per section"`
+}
+
+// Run executes the diff command
+func (c *DiffCmd) Run(globals *Globals) error {
+	fileB := c.FileB
+	if fileB == "" {
+		if c.SessionA <= 0 || c.SessionB <= 0 {
+			return c.outputError(globals, "INVALID_FLAGS", "a single recording requires --session-a and --session-b",
+				"pass two files, or e.g. --session-a 1 --session-b 2 to compare sessions within one file")
+		}
+		fileB = c.FileA
+	}
+
+	entriesA, err := c.readSide(globals, c.FileA, c.SessionA)
+	if err != nil {
+		return err
+	}
+	entriesB, err := c.readSide(globals, fileB, c.SessionB)
+	if err != nil {
+		return err
+	}
+
+	sideA := output.DiffSide{Label: c.FileA, Session: c.SessionA}
+	sideB := output.DiffSide{Label: fileB, Session: c.SessionB}
+	analyzer, err := newAnalyzer(globals)
+	if err != nil {
+		return c.outputError(globals, "INVALID_PATTERN", err.Error(), "check the patterns section of your config")
+	}
+	result := analyzer.Diff(sideA, entriesA, sideB, entriesB, c.Limit)
+
+	if globals.Format == "ndjson" {
+		return output.NewNDJSONWriter(globals.Stdout).WriteRaw(result)
+	}
+	_, err = fmt.Fprint(globals.Stdout, formatDiffText(result))
+	return err
+}
+
+// readSide loads one side of the diff, optionally restricted to a session
+func (c *DiffCmd) readSide(globals *Globals, path string, session int) ([]domain.LogEntry, error) {
+	rec, err := readRecordingFile(globals, path)
+	if err != nil {
+		return nil, c.outputError(globals, err.Code, err.Message)
+	}
+	if session <= 0 {
+		return rec.Entries, nil
+	}
+
+	entries := make([]domain.LogEntry, 0, len(rec.Entries))
+	for _, e := range rec.Entries {
+		if e.Session == session {
+			entries = append(entries, e)
+		}
+	}
+	if len(entries) == 0 {
+		return nil, c.outputError(globals, "SESSION_NOT_FOUND", fmt.Sprintf("no log entries for session %d in %s", session, path),
+			"sessions are numbered in recordings made with --app; check session_start events in the file")
+	}
+	return entries, nil
+}
+
+func (c *DiffCmd) outputError(globals *Globals, code, message string, hint ...string) error {
+	return outputErrorCommon(globals, code, message, hint...)
+}
+
+// formatDiffText renders a diff result for terminal output
+func formatDiffText(r *output.DiffResult) string {
+	var b strings.Builder
+	side := func(name string, s output.DiffSide) {
+		label := s.Label
+		if s.Session > 0 {
+			label = fmt.Sprintf("%s (session %d)", label, s.Session)
+		}
+		fmt.Fprintf(&b, "%s: %s - %d entries, %d patterns, %.0fs\n", name, label, s.Total, s.Patterns, s.DurationSeconds)
+	}
+	side("A", r.A)
+	side("B", r.B)
+	b.WriteString("\n")
+
+	patterns := func(title string, list []output.DiffPattern, total int) {
+		fmt.Fprintf(&b, "%s (%d):\n", title, total)
+		if len(list) == 0 {
+			b.WriteString("  (none)\n")
+		}
+		for _, p := range list {
+			fmt.Fprintf(&b, "  (%dx) [%s] %s\n", p.Count, p.Level, p.Pattern)
+		}
+		b.WriteString("\n")
+	}
+	patterns("Only in A", r.OnlyInA, r.OnlyInACount)
+	patterns("Only in B", r.OnlyInB, r.OnlyInBCount)
+
+	deltas := func(title string, list []output.DiffDelta) {
+		fmt.Fprintf(&b, "%s:\n", title)
+		for _, d := range list {
+			line := fmt.Sprintf("  %-30s %6d -> %-6d (%+d)  %.2f/min -> %.2f/min", d.Key, d.CountA, d.CountB, d.Delta, d.RateA, d.RateB)
+			if d.RateDeltaPct != 0 {
+				line += fmt.Sprintf(" (%+.0f%%)", d.RateDeltaPct)
+			}
+			b.WriteString(line + "\n")
+		}
+		b.WriteString("\n")
+	}
+	deltas("Levels", r.Levels)
+	deltas("Subsystems", r.Subsystems)
+	deltas("Categories", r.Categories)
+
+	return b.String()
+}
@@ -1,29 +1,38 @@
 package cli
 
 import (
-	"context"
 	"fmt"
+	"path/filepath"
+	"slices"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/vburojevic/xcw/internal/domain"
+	"github.com/vburojevic/xcw/internal/filter"
 	"github.com/vburojevic/xcw/internal/output"
 	"github.com/vburojevic/xcw/internal/simulator"
 )
 
 // DiscoverCmd discovers what subsystems, categories, and processes exist in logs
 type DiscoverCmd struct {
-	Simulator string `short:"s" help:"Simulator name or UDID"`
-	Booted    bool   `short:"b" help:"Use booted simulator (error if multiple)"`
-	App       string `short:"a" help:"App bundle identifier to filter logs (optional)"`
-	Since     string `default:"5m" help:"How far back to query (e.g., '5m', '1h', '30s')"`
-	Limit     int    `default:"5000" help:"Maximum number of logs to analyze"`
-	TopN      int    `default:"20" help:"Number of top items to show per category"`
+	Simulator    string   `short:"s" help:"Simulator name or UDID"`
+	Booted       bool     `short:"b" help:"Use booted simulator (error if multiple)"`
+	App          string   `short:"a" help:"App bundle identifier to filter logs (optional)"`
+	Since        string   `default:"5m" help:"How far back to query (e.g., '5m', '1h', '30s')"`
+	Limit        int      `default:"5000" help:"Maximum number of logs to analyze"`
+	TopN         int      `default:"20" help:"Number of top items to show per category"`
+	Extract      []string `help:"Extract key/value fields from messages and report them: logfmt, json, regex (can be repeated)"`
+	ExtractRegex []string `help:"Regex with named groups for field extraction, e.g. 'took (?P<ms>[0-9]+)ms' (can be repeated; implies --extract regex)"`
+	File         []string `help:"Discover from recordings instead of a simulator (.ndjson, .ndjson.gz, .ndjson.zst; globs allowed, '-' reads stdin); can be repeated"`
 }
 
 // Run executes the discover command
 func (c *DiscoverCmd) Run(globals *Globals) error {
-	ctx := context.Background()
+	if len(c.File) > 0 {
+		return c.runFiles(globals)
+	}
+	ctx := globals.baseContext()
 
 	// Validate mutual exclusivity of flags
 	if globals.FlagProvided("simulator") && globals.FlagProvided("booted") {
@@ -31,7 +40,7 @@ func (c *DiscoverCmd) Run(globals *Globals) error {
 	}
 
 	// Find the simulator
-	mgr := simulator.NewManager()
+	mgr := newSimulatorManager(globals)
 	device, err := resolveSimulatorDevice(ctx, mgr, c.Simulator, c.Booted)
 	if err != nil {
 		return c.outputError(globals, "DEVICE_NOT_FOUND", err.Error(), hintForStreamOrQuery(err))
@@ -73,7 +82,7 @@ func (c *DiscoverCmd) Run(globals *Globals) error {
 	}
 
 	// Create query reader with minimal filtering
-	reader := simulator.NewQueryReader()
+	reader := simulator.NewQueryReaderWithSource(mgr.Source())
 	var diagEmitter *output.Emitter
 	if globals.Format == "ndjson" {
 		diagEmitter = output.NewEmitter(globals.Stdout)
@@ -99,8 +108,134 @@ func (c *DiscoverCmd) Run(globals *Globals) error {
 	}
 	globals.Debug("Query returned %d entries", len(entries))
 
+	return c.report(globals, entries, c.App, nil)
+}
+
+// runFiles discovers from recorded NDJSON files, without a simulator. --since
+// and --limit, when given, count back from the last recorded entry.
+func (c *DiscoverCmd) runFiles(globals *Globals) error {
+	if globals.FlagProvided("simulator") || globals.FlagProvided("booted") {
+		return c.outputError(globals, "INVALID_FLAGS", "--file cannot be combined with --simulator or --booted")
+	}
+	// Recordings are only windowed when --since is given explicitly
+	var since time.Duration
+	windowSince := ""
+	if globals.FlagProvided("since") {
+		var err error
+		if since, err = time.ParseDuration(c.Since); err != nil {
+			return c.outputError(globals, "INVALID_DURATION", fmt.Sprintf("invalid since duration: %s", err))
+		}
+		windowSince = c.Since
+	}
+	files, err := expandRecordingPaths(c.File)
+	if err != nil {
+		return c.outputError(globals, "FILE_NOT_FOUND", err.Error())
+	}
+
+	if !globals.Quiet {
+		msg := fmt.Sprintf("Discovering logs from %d recording(s)", len(files))
+		if globals.Format == "ndjson" {
+			if err := output.NewNDJSONWriter(globals.Stdout).WriteInfo(msg, "", "", windowSince, "discovery"); err != nil {
+				return err
+			}
+		} else if _, err := fmt.Fprintf(globals.Stderr, "%s\n\n", msg); err != nil {
+			globals.Debug("failed to write discovery info: %v", err)
+		}
+	}
+
+	app := c.App
+	var entries []domain.LogEntry
+	for _, path := range files {
+		rec, err := c.readFile(globals, path)
+		if err != nil {
+			return err
+		}
+		if app == "" {
+			for _, s := range rec.Starts {
+				if s.App != "" {
+					app = s.App
+					break
+				}
+			}
+		}
+		entries = append(entries, rec.Entries...)
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Timestamp.Before(entries[j].Timestamp) })
+
+	if since > 0 && len(entries) > 0 {
+		cutoff := entries[len(entries)-1].Timestamp.Add(-since)
+		first := sort.Search(len(entries), func(i int) bool { return !entries[i].Timestamp.Before(cutoff) })
+		entries = entries[first:]
+	}
+	if globals.FlagProvided("limit") && c.Limit > 0 && len(entries) > c.Limit {
+		entries = entries[len(entries)-c.Limit:]
+	}
+	globals.Debug("Read %d entries from %d file(s)", len(entries), len(files))
+
+	names := make([]string, len(files))
+	for i, path := range files {
+		names[i] = filepath.Base(path)
+		if path == "-" {
+			names[i] = "(stdin)"
+		}
+	}
+	return c.report(globals, entries, app, names)
+}
+
+// readFile reads one recording; "-" is stdin
+func (c *DiscoverCmd) readFile(globals *Globals, path string) (*recording, error) {
+	rec, err := readRecordingFile(globals, path)
+	if err != nil {
+		return nil, c.outputError(globals, err.Code, err.Message)
+	}
+	return rec, nil
+}
+
+// expandRecordingPaths expands glob patterns, keeping the order given and
+// dropping duplicates. A pattern that matches nothing is an error.
+func expandRecordingPaths(patterns []string) ([]string, error) {
+	var files []string
+	seen := make(map[string]bool)
+	for _, p := range patterns {
+		matches := []string{p}
+		if p != "-" && strings.ContainsAny(p, "*?[") {
+			var err error
+			if matches, err = filepath.Glob(p); err != nil {
+				return nil, fmt.Errorf("invalid glob %q: %w", p, err)
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("no recordings match %q", p)
+			}
+		}
+		for _, m := range matches {
+			if !seen[m] {
+				seen[m] = true
+				files = append(files, m)
+			}
+		}
+	}
+	return files, nil
+}
+
+// report aggregates entries and writes the discovery
+func (c *DiscoverCmd) report(globals *Globals, entries []domain.LogEntry, app string, files []string) error {
+	extractor, err := filter.NewFieldExtractor(c.Extract, c.ExtractRegex)
+	if err != nil {
+		return c.outputError(globals, "INVALID_FILTER", err.Error(), hintForExtract(err))
+	}
+	if extractor != nil {
+		for i := range entries {
+			extractor.Extract(&entries[i])
+		}
+	}
+
 	// Aggregate results
-	discovery := c.aggregate(entries, c.App)
+	analyzer, err := newAnalyzer(globals)
+	if err != nil {
+		return c.outputError(globals, "INVALID_PATTERN", err.Error(), "check the patterns section of your config")
+	}
+	discovery := c.aggregate(analyzer, entries, app)
+	discovery.Files = files
 
 	// Output results
 	if globals.Format == "ndjson" {
@@ -117,17 +252,80 @@ func (c *DiscoverCmd) Run(globals *Globals) error {
 	return nil
 }
 
+// Discovery detail limits and suggested filter thresholds
+const (
+	discoverySamples       = 3    // Sample messages and templates per subsystem/category
+	discoverySampleRunes   = 200  // Sample messages are truncated to this length
+	noisySubsystemShare    = 0.05 // Share of all entries that makes an Apple subsystem noisy
+	maxSuggestedSubsystems = 5
+)
+
+// sourceStats accumulates one subsystem or category
+type sourceStats struct {
+	count       int
+	levels      map[string]int
+	first, last time.Time
+	samples     []string
+	indexes     []int // Entries belonging to the source
+}
+
+func (s *sourceStats) add(i int, e *domain.LogEntry) {
+	s.count++
+	s.levels[string(e.Level)]++
+	if s.first.IsZero() || e.Timestamp.Before(s.first) {
+		s.first = e.Timestamp
+	}
+	if e.Timestamp.After(s.last) {
+		s.last = e.Timestamp
+	}
+	if len(s.samples) < discoverySamples {
+		sample := truncateRunes(e.Message, discoverySampleRunes)
+		if !slices.Contains(s.samples, sample) {
+			s.samples = append(s.samples, sample)
+		}
+	}
+	s.indexes = append(s.indexes, i)
+}
+
+// templates mines the source's most frequent message templates
+func (s *sourceStats) templates(analyzer *output.Analyzer, entries []domain.LogEntry) []domain.TemplateCount {
+	group := make([]domain.LogEntry, len(s.indexes))
+	for i, idx := range s.indexes {
+		group[i] = entries[idx]
+	}
+	var out []domain.TemplateCount
+	for _, t := range analyzer.Templates(group, discoverySamples) {
+		out = append(out, domain.TemplateCount{ID: t.ID, Template: t.Template, Count: t.Count})
+	}
+	return out
+}
+
+func (s *sourceStats) errorCount() int {
+	return s.levels[string(domain.LogLevelError)] + s.levels[string(domain.LogLevelFault)]
+}
+
+func statsFor(m map[string]*sourceStats, name string) *sourceStats {
+	st, ok := m[name]
+	if !ok {
+		st = &sourceStats{levels: make(map[string]int)}
+		m[name] = st
+	}
+	return st
+}
+
 // aggregate builds discovery statistics from log entries
-func (c *DiscoverCmd) aggregate(entries []domain.LogEntry, app string) *domain.Discovery {
+func (c *DiscoverCmd) aggregate(analyzer *output.Analyzer, entries []domain.LogEntry, app string) *domain.Discovery {
 	// Track aggregates
-	subsystems := make(map[string]map[string]int) // subsystem -> level -> count
-	categories := make(map[string]int)
+	subsystems := make(map[string]*sourceStats)
+	categories := make(map[string]*sourceStats)
 	processes := make(map[string]int)
 	levels := make(map[string]int)
 
 	var earliest, latest time.Time
 
-	for _, entry := range entries {
+	for i := range entries {
+		entry := &entries[i]
+
 		// Track time range
 		if earliest.IsZero() || entry.Timestamp.Before(earliest) {
 			earliest = entry.Timestamp
@@ -141,17 +339,14 @@ func (c *DiscoverCmd) aggregate(entries []domain.LogEntry, app string) *domain.D
 		if sub == "" {
 			sub = "(none)"
 		}
-		if subsystems[sub] == nil {
-			subsystems[sub] = make(map[string]int)
-		}
-		subsystems[sub][string(entry.Level)]++
+		statsFor(subsystems, sub).add(i, entry)
 
 		// Count by category
 		cat := entry.Category
 		if cat == "" {
 			cat = "(none)"
 		}
-		categories[cat]++
+		statsFor(categories, cat).add(i, entry)
 
 		// Count by process
 		proc := entry.Process
@@ -164,17 +359,13 @@ func (c *DiscoverCmd) aggregate(entries []domain.LogEntry, app string) *domain.D
 		levels[string(entry.Level)]++
 	}
 
-	// Convert to sorted slices
+	// Convert to sorted slices; details are only computed for the top items
 	subsystemList := make([]domain.SubsystemInfo, 0, len(subsystems))
-	for name, levelCounts := range subsystems {
-		total := 0
-		for _, count := range levelCounts {
-			total += count
-		}
+	for name, st := range subsystems {
 		subsystemList = append(subsystemList, domain.SubsystemInfo{
 			Name:   name,
-			Count:  total,
-			Levels: levelCounts,
+			Count:  st.count,
+			Levels: st.levels,
 		})
 	}
 	sort.Slice(subsystemList, func(i, j int) bool {
@@ -183,10 +374,17 @@ func (c *DiscoverCmd) aggregate(entries []domain.LogEntry, app string) *domain.D
 	if len(subsystemList) > c.TopN {
 		subsystemList = subsystemList[:c.TopN]
 	}
+	for i := range subsystemList {
+		st := subsystems[subsystemList[i].Name]
+		subsystemList[i].FirstSeen = st.first.Format(time.RFC3339)
+		subsystemList[i].LastSeen = st.last.Format(time.RFC3339)
+		subsystemList[i].Samples = st.samples
+		subsystemList[i].Templates = st.templates(analyzer, entries)
+	}
 
 	categoryList := make([]domain.CategoryInfo, 0, len(categories))
-	for name, count := range categories {
-		categoryList = append(categoryList, domain.CategoryInfo{Name: name, Count: count})
+	for name, st := range categories {
+		categoryList = append(categoryList, domain.CategoryInfo{Name: name, Count: st.count})
 	}
 	sort.Slice(categoryList, func(i, j int) bool {
 		return categoryList[i].Count > categoryList[j].Count
@@ -194,6 +392,14 @@ func (c *DiscoverCmd) aggregate(entries []domain.LogEntry, app string) *domain.D
 	if len(categoryList) > c.TopN {
 		categoryList = categoryList[:c.TopN]
 	}
+	for i := range categoryList {
+		st := categories[categoryList[i].Name]
+		categoryList[i].Levels = st.levels
+		categoryList[i].FirstSeen = st.first.Format(time.RFC3339)
+		categoryList[i].LastSeen = st.last.Format(time.RFC3339)
+		categoryList[i].Samples = st.samples
+		categoryList[i].Templates = st.templates(analyzer, entries)
+	}
 
 	processList := make([]domain.ProcessInfo, 0, len(processes))
 	for name, count := range processes {
@@ -219,9 +425,84 @@ func (c *DiscoverCmd) aggregate(entries []domain.LogEntry, app string) *domain.D
 		Categories: categoryList,
 		Processes:  processList,
 		Levels:     levels,
+		Fields:     analyzer.SummarizeFields(entries, c.TopN),
+		Templates:  analyzer.Templates(entries, c.TopN),
+		Suggested:  suggestFilters(subsystems, len(entries), app),
 	}
 }
 
+// suggestFilters picks the noisiest Apple subsystems (each at least 5% of
+// entries) and builds filters that drop them. Subsystems that logged errors
+// or faults are kept for those levels with a --where clause rather than
+// excluded outright.
+func suggestFilters(subsystems map[string]*sourceStats, total int, app string) *domain.SuggestedFilters {
+	var noisy []string
+	for name, st := range subsystems {
+		if strings.HasPrefix(name, "com.apple.") && float64(st.count) >= noisySubsystemShare*float64(total) {
+			noisy = append(noisy, name)
+		}
+	}
+	if len(noisy) == 0 {
+		return nil
+	}
+	sort.Slice(noisy, func(i, j int) bool {
+		ci, cj := subsystems[noisy[i]].count, subsystems[noisy[j]].count
+		if ci != cj {
+			return ci > cj
+		}
+		return noisy[i] < noisy[j]
+	})
+	if len(noisy) > maxSuggestedSubsystems {
+		noisy = noisy[:maxSuggestedSubsystems]
+	}
+
+	s := &domain.SuggestedFilters{}
+	var keepErrors []string
+	for _, name := range noisy {
+		st := subsystems[name]
+		if st.errorCount() > 0 {
+			keepErrors = append(keepErrors, name)
+			s.Removes += st.count - st.errorCount()
+			continue
+		}
+		s.ExcludeSubsystems = append(s.ExcludeSubsystems, name)
+		s.Removes += st.count
+	}
+	if len(keepErrors) > 0 {
+		s.Where = []string{fmt.Sprintf("subsystem not in (%s) OR level>=error", strings.Join(keepErrors, ", "))}
+	}
+
+	args := []string{"xcw", "tail"}
+	if app != "" {
+		args = append(args, "-a", app)
+	}
+	for _, name := range s.ExcludeSubsystems {
+		args = append(args, "--exclude-subsystem", name)
+	}
+	for _, w := range s.Where {
+		args = append(args, "--where", w)
+	}
+	s.Command = shellJoin(args)
+	return s
+}
+
+// shellJoin renders args as a shell command line, single-quoting arguments
+// that contain anything beyond letters, digits and ._/:=-
+func shellJoin(args []string) string {
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		safe := a != "" && !strings.ContainsFunc(a, func(r rune) bool {
+			return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("._/:=-", r))
+		})
+		if safe {
+			quoted[i] = a
+		} else {
+			quoted[i] = "'" + strings.ReplaceAll(a, "'", `'\''`) + "'"
+		}
+	}
+	return strings.Join(quoted, " ")
+}
+
 // printTextOutput outputs discovery results in human-readable format
 func (c *DiscoverCmd) printTextOutput(globals *Globals, d *domain.Discovery) error {
 	if _, err := fmt.Fprintf(globals.Stdout, "=== Log Discovery ===\n\n"); err != nil {
@@ -284,6 +565,11 @@ func (c *DiscoverCmd) printTextOutput(globals *Globals, d *domain.Discovery) err
 		if _, err := fmt.Fprintln(globals.Stdout); err != nil {
 			return err
 		}
+		if len(s.Templates) > 0 {
+			if _, err := fmt.Fprintf(globals.Stdout, "      e.g. %s\n", truncateRunes(s.Templates[0].Template, 100)); err != nil {
+				return err
+			}
+		}
 	}
 	if _, err := fmt.Fprintln(globals.Stdout); err != nil {
 		return err
@@ -311,6 +597,33 @@ func (c *DiscoverCmd) printTextOutput(globals *Globals, d *domain.Discovery) err
 			return err
 		}
 	}
+
+	// Message templates
+	if len(d.Templates) > 0 {
+		if _, err := fmt.Fprintf(globals.Stdout, "\nTop Templates:\n"); err != nil {
+			return err
+		}
+		for _, t := range d.Templates {
+			if _, err := fmt.Fprintf(globals.Stdout, "  %s %5d  %-7s %s\n", t.ID, t.Count, t.Level, truncateRunes(t.Template, 100)); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Suggested noise filters
+	if d.Suggested != nil {
+		if _, err := fmt.Fprintf(globals.Stdout, "\nSuggested filters (drop %d noisy Apple entries):\n  %s\n", d.Suggested.Removes, d.Suggested.Command); err != nil {
+			return err
+		}
+	}
+
+	// Extracted fields (--extract)
+	if len(d.Fields) > 0 {
+		if _, err := fmt.Fprintln(globals.Stdout); err != nil {
+			return err
+		}
+		return printFieldStats(globals.Stdout, d.Fields)
+	}
 	return nil
 }
 
@@ -12,11 +12,12 @@ import (
 
 	"github.com/vburojevic/xcw/internal/config"
 	"github.com/vburojevic/xcw/internal/output"
-	"github.com/vburojevic/xcw/internal/simulator"
 )
 
 // DoctorCmd checks system requirements and configuration
-type DoctorCmd struct{}
+type DoctorCmd struct {
+	Rules []string `help:"Also validate watch rules files (can be repeated)"`
+}
 
 // checkResult represents a single diagnostic check
 type checkResult struct {
@@ -56,6 +57,9 @@ func (c *DoctorCmd) Run(globals *Globals) error {
 	// Check tmux
 	checks = append(checks, c.checkTmux())
 
+	// Check sqlite3 (optional, used by xcw sql)
+	checks = append(checks, c.checkSQLite())
+
 	// Check physical device tooling (optional)
 	checks = append(checks, c.checkPhysicalDeviceSupport())
 
@@ -63,7 +67,12 @@ func (c *DoctorCmd) Run(globals *Globals) error {
 	checks = append(checks, c.checkConfig())
 
 	// Check simulators
-	checks = append(checks, c.checkSimulators(ctx))
+	checks = append(checks, c.checkSimulators(ctx, globals))
+
+	// Check watch rules files
+	for _, path := range c.Rules {
+		checks = append(checks, c.checkRules(path))
+	}
 
 	// Count errors and warnings
 	errorCount := 0
@@ -243,6 +252,33 @@ func (c *DoctorCmd) checkTmux() checkResult {
 	}
 }
 
+func (c *DoctorCmd) checkSQLite() checkResult {
+	path, err := exec.LookPath("sqlite3")
+	if err != nil {
+		return checkResult{
+			Name:    "sqlite3",
+			Status:  "warning",
+			Message: "sqlite3 not found (optional, needed for xcw sql)",
+			Details: "Install with: brew install sqlite",
+		}
+	}
+
+	cmd := exec.Command("sqlite3", "-version")
+	output, _ := cmd.Output()
+	version := strings.Fields(string(output))
+
+	message := "sqlite3 available"
+	if len(version) > 0 {
+		message = "sqlite3 " + version[0]
+	}
+	return checkResult{
+		Name:    "sqlite3",
+		Status:  "ok",
+		Message: message,
+		Details: path,
+	}
+}
+
 func (c *DoctorCmd) checkPhysicalDeviceSupport() checkResult {
 	path, err := exec.LookPath("idevicesyslog")
 	if err != nil {
@@ -292,8 +328,32 @@ func (c *DoctorCmd) checkConfig() checkResult {
 	}
 }
 
-func (c *DoctorCmd) checkSimulators(ctx context.Context) checkResult {
-	mgr := simulator.NewManager()
+func (c *DoctorCmd) checkRules(path string) checkResult {
+	name := "Rules " + filepath.Base(path)
+	rules, err := loadWatchRules(path, 0, webhookFormatJSON, "")
+	if err != nil {
+		return checkResult{
+			Name:    name,
+			Status:  "error",
+			Message: "Rules file has errors",
+			Details: err.Error(),
+		}
+	}
+
+	var names []string
+	for _, r := range rules.summaries() {
+		names = append(names, r.Name)
+	}
+	return checkResult{
+		Name:    name,
+		Status:  "ok",
+		Message: fmt.Sprintf("%d rule(s) valid", len(names)),
+		Details: strings.Join(names, ", "),
+	}
+}
+
+func (c *DoctorCmd) checkSimulators(ctx context.Context, globals *Globals) checkResult {
+	mgr := newSimulatorManager(globals)
 	devices, err := mgr.ListDevices(ctx)
 	if err != nil {
 		return checkResult{
@@ -6,6 +6,7 @@ import (
 	"os/exec"
 	"strings"
 
+	"github.com/vburojevic/xcw/internal/filter"
 	"github.com/vburojevic/xcw/internal/simulator"
 )
 
@@ -71,9 +72,34 @@ func hintForFilter(err error) string {
 	if err == nil {
 		return ""
 	}
+	var syn *filter.WhereSyntaxError
+	if errors.As(err, &syn) {
+		return "Near:\n" + syn.Pointer() + "\nQuote the whole expression. Example: --where 'level in (error, fault) AND timestamp > now-5m'"
+	}
 	return "If the filter contains spaces/parentheses, quote it. Example: --where '(level=Error OR level=Fault) AND message~timeout' (regex literal: message~/timeout|crash/i)"
 }
 
+func hintForExtract(err error) string {
+	if err == nil {
+		return ""
+	}
+	return "Use --extract logfmt|json|regex; --extract-regex needs named groups. Example: --extract-regex 'took (?P<ms>[0-9]+)ms' --where 'fields.ms>=500'"
+}
+
+func hintForExtractTrace(err error) string {
+	if err == nil {
+		return ""
+	}
+	return "Use --extract-trace traceparent|regex; --extract-trace-regex needs a (?P<trace>...) group. Example: --extract-trace-regex 'request_id=(?P<trace>[0-9a-f-]+)'"
+}
+
+func hintForRules(err error) string {
+	if err == nil {
+		return ""
+	}
+	return "Use a top-level 'rules:' list; each rule needs a name, a where expression and actions (command, webhook or marker). Check it with: xcw doctor --rules FILE"
+}
+
 func isCommandNotFound(err error, name string) bool {
 	if err == nil {
 		return false
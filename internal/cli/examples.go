@@ -43,6 +43,9 @@ type WorkflowExample struct {
 	Steps       []string `json:"steps"`
 }
 
+// exampleCommandOrder is the display order for examples of all commands.
+var exampleCommandOrder = []string{"tail", "query", "watch", "summary", "discover", "list", "apps", "pick", "launch", "ui", "clear", "doctor", "config", "schema", "log-schema", "handoff", "completion", "examples", "update", "version", "analyze", "diff", "expect", "replay", "search", "sql", "trace", "sessions", "serve"}
+
 var commandExamples = map[string]CommandExamples{
 	"tail": {
 		Name:        "tail",
@@ -65,6 +68,11 @@ var commandExamples = map[string]CommandExamples{
 				Description: "Stream to file for later analysis",
 				When:        "Need to replay or share logs across analysis passes",
 			},
+			{
+				Command:     `xcw tail -s "iPhone 17 Pro" -a com.example.myapp --session-dir ~/.xcw/sessions --compress zstd --max-file-size 200MB --max-file-age 1h`,
+				Description: "Record compressed session files, split into parts by size/age",
+				When:        "Day-long recordings of chatty apps",
+			},
 			{
 				Command:     `xcw tail -s "iPhone 17 Pro" -a com.example.myapp --max-duration 5m`,
 				Description: "Stream for 5 minutes and stop (emits cutoff_reached)",
@@ -90,6 +98,26 @@ var commandExamples = map[string]CommandExamples{
 				Description: "Print resolved stream options as JSON and exit",
 				When:        "Debugging predicates/filters before starting a stream",
 			},
+			{
+				Command:     `xcw tail --all-booted -a com.example.myapp`,
+				Description: "Tail all booted simulators; events carry udid and simulator",
+				When:        "UI tests running on several simulators in parallel",
+			},
+			{
+				Command:     `xcw tail --serve 127.0.0.1:7070 -s "iPhone 17 Pro" -a com.example.myapp`,
+				Description: "Serve events over HTTP/SSE plus /buffer, /stats and /sessions",
+				When:        "Several people or tools need to watch the same simulator",
+			},
+			{
+				Command:     `xcw tail -s "iPhone 17 Pro" -a com.example.myapp --otlp-endpoint http://localhost:4318 --otlp-header "authorization=Bearer $TOKEN"`,
+				Description: "Forward logs to an OpenTelemetry collector as OTLP logs",
+				When:        "Correlating simulator logs with backend telemetry in Grafana, Honeycomb or Datadog",
+			},
+			{
+				Command:     `xcw tail -s "iPhone 17 Pro" -a com.example.myapp --anomalies`,
+				Description: "Emit anomaly events for error spikes, subsystems going silent and new message patterns",
+				When:        "Long sessions where nobody reads every line",
+			},
 		},
 	},
 	"query": {
@@ -117,6 +145,16 @@ var commandExamples = map[string]CommandExamples{
 				Output:      `{"type":"analysis","summary":{...},"patterns":[...]}`,
 				When:        "Get grouped error patterns and counts",
 			},
+			{
+				Command:     `xcw query -a com.example.myapp --since 10m --analyze --report junit --report-file xcw-junit.xml`,
+				Description: "Write error patterns as JUnit XML next to the normal output",
+				When:        "CI ingests JUnit test results",
+			},
+			{
+				Command:     `xcw query -s "iPhone 17 Pro" -a com.example.myapp --since 10m --preset networking --preset no-noise`,
+				Description: "Apply named filter presets from the config 'filters:' section",
+				When:        "Reuse the same filter combination across tail, query, watch and ui",
+			},
 			{
 				Command:     `xcw query -s "iPhone 17 Pro" -a com.example.myapp --since 5m --dry-run-json`,
 				Description: "Print resolved query options as JSON and exit",
@@ -138,6 +176,21 @@ var commandExamples = map[string]CommandExamples{
 				Description: "Run a command when a regex matches the message",
 				When:        "Trigger on crash signatures or keywords",
 			},
+			{
+				Command:     `xcw watch -s "iPhone 17 Pro" -a com.example.myapp --on-error-webhook https://hooks.slack.com/services/T000/B000/XXXX --webhook-format slack`,
+				Description: "Post error-level logs with their preceding lines to Slack",
+				When:        "Notifying a team channel without a wrapper script",
+			},
+			{
+				Command:     `xcw watch -s "iPhone 17 Pro" -a com.example.myapp --on-pattern-webhook 'crash|fatal:https://ci.example.com/hooks/xcw' --webhook-context 50`,
+				Description: "POST a JSON payload (entry, trigger_id, last 50 lines) when a regex matches",
+				When:        "Feeding CI or incident tooling directly",
+			},
+			{
+				Command:     `xcw watch -s "iPhone 17 Pro" -a com.example.myapp --rules rules.yaml`,
+				Description: "Run named rules (where, threshold like '5 in 60s', cooldown, actions) from a YAML file",
+				When:        "Alerting on bursts rather than single lines, or regexes containing ':'",
+			},
 			{
 				Command:     `xcw watch -s "iPhone 17 Pro" -a com.example.myapp --where level>=error --on-error "./notify.sh" --max-duration 5m`,
 				Description: "Watch for 5 minutes and stop (agent-safe cutoff)",
@@ -164,6 +217,26 @@ var commandExamples = map[string]CommandExamples{
 				Description: "Discover log sources for a specific app",
 				When:        "When your app logs across multiple subsystems/categories",
 			},
+			{
+				Command:     `xcw discover -a com.example.myapp --since 10m --extract logfmt`,
+				Description: "List key/value fields found in messages",
+				When:        "Before writing --where 'fields.<key>...' filters",
+			},
+			{
+				Command:     `xcw discover --file 'artifacts/*.ndjson.gz'`,
+				Description: "Discover from recorded sessions without a simulator",
+				When:        "In CI jobs that only have recordings as artifacts",
+			},
+			{
+				Command:     `xcw discover -a com.example.myapp --since 10m -f ndjson | jq -r '.suggested_filters.command'`,
+				Description: "Get a tail command with the noisiest Apple subsystems filtered out",
+				When:        "Going from discovery straight to a clean tail",
+			},
+			{
+				Command:     `xcw discover -a com.example.myapp --since 1h -f ndjson | jq '.templates[] | {id, count, template}'`,
+				Description: "List the most common message templates with stable IDs",
+				When:        "To find noisy messages or pick a pattern for --exclude",
+			},
 		},
 	},
 	"list": {
@@ -229,6 +302,11 @@ var commandExamples = map[string]CommandExamples{
 				Output:      `{"type":"doctor","all_passed":true,"checks":[...]}`,
 				When:        "Verify xcw is set up correctly",
 			},
+			{
+				Command:     `xcw doctor --rules rules.yaml`,
+				Description: "Also validate a watch rules file",
+				When:        "Checking rules before starting a long watch",
+			},
 		},
 	},
 	"analyze": {
@@ -241,6 +319,74 @@ var commandExamples = map[string]CommandExamples{
 				Output:      `{"type":"analysis","summary":{...},"patterns":[...]}`,
 				When:        "Post-process recorded logs",
 			},
+			{
+				Command:     `xcw analyze session.ndjson --extract logfmt --where 'fields.status>=500'`,
+				Description: "Analyze only requests that failed server-side",
+				When:        "When messages carry key=value pairs",
+			},
+			{
+				Command:     `xcw analyze session.ndjson --group`,
+				Description: "Coalesce stack traces before pattern detection",
+				When:        "When one crash shows up as dozens of patterns",
+			},
+			{
+				Command:     `xcw analyze session.ndjson --histogram -f text`,
+				Description: "Draw sparklines of log volume and errors over time",
+				Output:      "Timeline (30s buckets, 10:00:00 to 10:09:30):\n  all     |▃ ▃     █          ▃| max 3\n  errors  |        █           | max 3",
+				When:        "Seeing when in a long recording the errors clustered",
+			},
+			{
+				Command:     `xcw analyze session.ndjson --persist-patterns --app-build $BUILD_NUMBER --fail-on-regression`,
+				Description: "Fail CI when a pattern is new in this build, came back, or rose in rate",
+				Output:      `{"type":"analysis","app_build":"1234","new_in_build_count":1,"returned_count":0,"rate_increase_count":0,...}`,
+				When:        "On every PR build",
+			},
+			{
+				Command:     `xcw analyze session.ndjson --report junit > xcw-junit.xml`,
+				Description: "Emit error/fault patterns as failing JUnit test cases",
+				Output:      `<testsuite name="xcw analyze session.ndjson" tests="2" failures="2" ...>`,
+				When:        "GitLab/Jenkins test reports",
+			},
+			{
+				Command:     `xcw analyze session.ndjson --report sarif --report-file xcw.sarif`,
+				Description: "Write fault patterns as SARIF results (subsystem/category as location)",
+				When:        "Code-scanning dashboards",
+			},
+		},
+	},
+	"diff": {
+		Name:        "diff",
+		Description: "Compare two NDJSON recordings or two sessions",
+		Examples: []Example{
+			{
+				Command:     `xcw diff before.ndjson after.ndjson`,
+				Description: "Show patterns only in one run and per-subsystem deltas",
+				Output:      `{"type":"diff_result","only_in_a":[...],"only_in_b":[...],"subsystems":[...],"levels":[...]}`,
+				When:        "Check what a change did to the app's logging",
+			},
+			{
+				Command:     `xcw diff session.ndjson --session-a 1 --session-b 2`,
+				Description: "Compare two app sessions within one recording",
+				When:        "After a relaunch behaves differently",
+			},
+		},
+	},
+	"expect": {
+		Name:        "expect",
+		Description: "Check logs against YAML expectations for CI",
+		Examples: []Example{
+			{
+				Command:     `xcw expect expect.yaml -s "iPhone 17 Pro" -a com.example.myapp`,
+				Description: "Tail until all expectations are decided; exit 1 on failure",
+				Output:      `{"type":"expectation_failed","name":"no faults","kind":"not_see","reason":"matched a log entry that must not appear",...}`,
+				When:        "UI test or smoke run in CI",
+			},
+			{
+				Command:     `xcw expect expect.yaml --file session.ndjson`,
+				Description: "Check a recorded session",
+				Output:      `{"type":"expect_result","passed":true,"passed_count":3,"failed_count":0,...}`,
+				When:        "Re-check a recording after changing the rules",
+			},
 		},
 	},
 	"replay": {
@@ -259,6 +405,65 @@ var commandExamples = map[string]CommandExamples{
 			},
 		},
 	},
+	"search": {
+		Name:        "search",
+		Description: "Search recorded sessions through the session index",
+		Examples: []Example{
+			{
+				Command:     `xcw search keychain --since 7d -l fault`,
+				Description: "Find faults mentioning keychain across a week of recordings",
+				When:        "Check whether a failure happened before without scanning every file",
+				Output:      `{"type":"search_result","query":"keychain","total":3,"returned":3,"files_searched":2,"files_skipped":38,...}`,
+			},
+			{
+				Command:     `xcw search "timed out" --subsystem com.example.net --limit 20`,
+				Description: "Newest 20 matches of a phrase in one subsystem",
+			},
+		},
+	},
+	"trace": {
+		Name:        "trace",
+		Description: "Follow one trace, request or activity across a recording",
+		Examples: []Example{
+			{
+				Command:     `xcw trace 4bf92f3577b34da6a3ce929d0e0e4736 --latest -f text`,
+				Description: "Timeline of every entry carrying a W3C traceparent trace ID, with per-span durations",
+				When:        "Following one network request through app, extension and daemon logs",
+			},
+			{
+				Command:     `xcw trace req-8812 --file session.ndjson --extract-trace-regex 'request_id=(?P<trace>[\w-]+)'`,
+				Description: "Gather entries by a custom request ID",
+				When:        "The app logs its own request IDs instead of traceparent",
+				Output:      `{"type":"trace","id":"req-8812","kind":"trace","entries":14,"errors":1,"duration_ms":845,"spans":[...]}`,
+			},
+			{
+				Command:     `xcw trace 0x1a2b --latest`,
+				Description: "Gather an os_activity and its nested activities",
+				When:        "Code uses os_activity; take the activity_id from a log entry",
+			},
+		},
+	},
+	"sql": {
+		Name:        "sql",
+		Description: "Load recordings into SQLite and query them with SQL",
+		Examples: []Example{
+			{
+				Command:     `xcw sql --latest "select subsystem, count(*) n from logs where severity >= 3 group by subsystem order by n desc"`,
+				Description: "Count errors and faults per subsystem in the latest session",
+				When:        "Grouping or joins that --where cannot express",
+				Output:      `{"type":"sql_row","row":{"subsystem":"com.example.net","n":12}}`,
+			},
+			{
+				Command:     `xcw sql --file session.ndjson --db logs.db`,
+				Description: "Export a recording to a SQLite database",
+				When:        "Keep the data for repeated queries or other SQLite tools",
+			},
+			{
+				Command:     `xcw sql --db logs.db "select pattern, count from patterns order by count desc limit 10" -f text`,
+				Description: "Print the top error patterns as a table",
+			},
+		},
+	},
 	"sessions": {
 		Name:        "sessions",
 		Description: "Manage session log files",
@@ -278,6 +483,36 @@ var commandExamples = map[string]CommandExamples{
 				Description: "Delete old sessions, keep 10 most recent",
 				When:        "Free up disk space",
 			},
+			{
+				Command:     `xcw sessions clean --max-total-size 2GB`,
+				Description: "Delete the oldest session files until the directory fits 2GB",
+				When:        "Enforce a disk budget for long-running recordings",
+			},
+			{
+				Command:     `xcw sessions index`,
+				Description: "Index new or changed recordings for xcw search",
+				When:        "Prepare the index ahead of time (xcw search also updates it)",
+			},
+		},
+	},
+	"serve": {
+		Name:        "serve",
+		Description: "Serve JSON-RPC 2.0 over stdin/stdout (one message per line)",
+		Examples: []Example{
+			{
+				Command:     `xcw serve`,
+				Description: "Start a long-running JSON-RPC server for an agent",
+				When:        "Keep tails, ring buffers and session state alive across agent questions",
+			},
+			{
+				Command:     `{"jsonrpc":"2.0","id":1,"method":"tail.start","params":{"booted":true,"app":"com.example.myapp"}}`,
+				Description: "Start a tail; events arrive as tail.event notifications carrying tail_id",
+				Output:      `{"jsonrpc":"2.0","method":"tail.event","params":{"tail_id":"tail-abc","event":{"type":"log",...}}}`,
+			},
+			{
+				Command:     `{"jsonrpc":"2.0","id":2,"method":"query","params":{"booted":true,"app":"com.example.myapp","since":"5m"}}`,
+				Description: "One-shot query; result.events holds the NDJSON events",
+			},
 		},
 	},
 	"schema": {
@@ -374,7 +609,7 @@ var commandExamples = map[string]CommandExamples{
 			{
 				Command:     `xcw config`,
 				Description: "Show effective configuration (default: show)",
-				Output:      `{"type":"config","config_file":"~/.config/xcw/config.yaml","sources":{...}}`,
+				Output:      `{"type":"config","config_file":"~/.config/xcw/config.yaml","presets":[{"name":"networking","source":"~/.config/xcw/config.yaml",...}],"sources":{...}}`,
 			},
 			{
 				Command:     `xcw config path`,
@@ -434,6 +669,17 @@ var commandExamples = map[string]CommandExamples{
 				Description: "Summarize last 30 minutes with a regex filter",
 				When:        "Reduce noise and focus on errors during a run",
 			},
+			{
+				Command:     `xcw summary -a com.example.myapp --window 10m --report sarif > xcw.sarif`,
+				Description: "Summarize fault patterns as SARIF",
+				When:        "Upload recent faults to a code-scanning dashboard",
+			},
+			{
+				Command:     `xcw summary -a com.example.myapp --window 10m --bucket 30s`,
+				Description: "Add per-30s counts by level and top subsystem",
+				Output:      `{"type":"analysis","summary":{"bucketSize":"30s","histogram":[{"start":"...","totalCount":42,"errorCount":7,"topSubsystem":"com.example.net","topSubsystemCount":30},...]}}`,
+				When:        "Finding when errors started without replaying the logs",
+			},
 		},
 	},
 	"clear": {
@@ -521,7 +767,7 @@ func (c *ExamplesCmd) outputJSON(globals *Globals) error {
 		}
 	} else {
 		// All commands
-		for _, cmd := range []string{"tail", "query", "watch", "summary", "discover", "list", "apps", "pick", "launch", "ui", "clear", "doctor", "config", "schema", "log-schema", "handoff", "completion", "examples", "update", "version", "analyze", "replay", "sessions"} {
+		for _, cmd := range exampleCommandOrder {
 			if examples, ok := commandExamples[cmd]; ok {
 				all.Commands = append(all.Commands, examples)
 			}
@@ -546,14 +792,14 @@ func (c *ExamplesCmd) outputText(globals *Globals) error {
 		if examples, ok := commandExamples[c.Command]; ok {
 			c.formatCommandExamples(&sb, examples)
 		} else {
-			return fmt.Errorf("unknown command: %s\nAvailable: tail, query, watch, summary, discover, list, apps, pick, launch, ui, clear, doctor, config, schema, log-schema, handoff, completion, examples, update, version, analyze, replay, sessions", c.Command)
+			return fmt.Errorf("unknown command: %s\nAvailable: %s", c.Command, strings.Join(exampleCommandOrder, ", "))
 		}
 	} else {
 		// All commands
 		sb.WriteString("XCW USAGE EXAMPLES\n")
 		sb.WriteString("==================\n\n")
 
-		for _, cmd := range []string{"tail", "query", "watch", "summary", "discover", "list", "apps", "pick", "launch", "ui", "clear", "doctor", "config", "schema", "log-schema", "handoff", "completion", "examples", "update", "version", "analyze", "replay", "sessions"} {
+		for _, cmd := range exampleCommandOrder {
 			if examples, ok := commandExamples[cmd]; ok {
 				c.formatCommandExamples(&sb, examples)
 				sb.WriteString("\n")
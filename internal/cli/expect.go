@@ -0,0 +1,329 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/vburojevic/xcw/internal/domain"
+	"github.com/vburojevic/xcw/internal/filter"
+	"github.com/vburojevic/xcw/internal/output"
+	"github.com/vburojevic/xcw/internal/simulator"
+)
+
+// defaultExpectTimeout bounds a live expect run when neither --max-duration
+// nor the rules file's timeout is set.
+const defaultExpectTimeout = 60 * time.Second
+
+// expectTickInterval is how often within deadlines are checked while streaming.
+const expectTickInterval = 100 * time.Millisecond
+
+// ExpectCmd checks logs against declarative expectations and exits non-zero on failure
+type ExpectCmd struct {
+	Rules        string   `arg:"" required:"" help:"YAML file with expectations (see, not_see, count, order)"`
+	File         string   `help:"Check a recorded NDJSON file instead of streaming from a simulator (- for stdin; .gz/.zst are decompressed)"`
+	Simulator    string   `short:"s" help:"Simulator name or UDID"`
+	Booted       bool     `short:"b" help:"Use booted simulator (error if multiple)"`
+	App          string   `short:"a" help:"App bundle identifier to filter logs (required unless --predicate or --all)"`
+	All          bool     `help:"Allow streaming without --app/--predicate (can be very noisy)"`
+	Predicate    string   `help:"Raw NSPredicate filter (overrides --app)"`
+	Extract      []string `help:"Extract key/value fields from messages so expectations can use fields.<key>: logfmt, json, regex (can be repeated)"`
+	ExtractRegex []string `help:"Regex with named groups for field extraction, e.g. 'took (?P<ms>[0-9]+)ms' (can be repeated; implies --extract regex)"`
+	MaxDuration  string   `help:"Stop after duration (default: the rules file's timeout, else 60s live and no limit with --file); measured in log time with --file"`
+	FailFast     bool     `help:"Stop at the first failed expectation"`
+}
+
+// expectRun tracks the state shared by the live and file modes.
+type expectRun struct {
+	globals   *Globals
+	evaluator *filter.ExpectEvaluator
+	extractor *filter.FieldExtractor
+	writer    *output.NDJSONWriter
+	tailID    string
+	failFast  bool
+	checked   int
+	failed    int
+}
+
+// Run executes the expect command
+func (c *ExpectCmd) Run(globals *Globals) error {
+	data, err := os.ReadFile(c.Rules)
+	if err != nil {
+		return c.outputError(globals, "FILE_NOT_FOUND", fmt.Sprintf("cannot read expectations file: %s", err))
+	}
+	rules, err := filter.ParseExpectations(data)
+	if err != nil {
+		return c.outputError(globals, "INVALID_EXPECTATIONS", err.Error(), "use a top-level 'expectations:' list; each item sets one of see, not_see, count (with max) or order")
+	}
+	evaluator, err := filter.NewExpectEvaluator(rules)
+	if err != nil {
+		return c.outputError(globals, "INVALID_EXPECTATIONS", err.Error(), hintForFilter(err))
+	}
+	extractor, err := filter.NewFieldExtractor(c.Extract, c.ExtractRegex)
+	if err != nil {
+		return c.outputError(globals, "INVALID_FILTER", err.Error(), hintForExtract(err))
+	}
+
+	// Zero means no explicit limit: live runs fall back to defaultExpectTimeout,
+	// file runs check the whole recording
+	var maxDuration time.Duration
+	timeout := c.MaxDuration
+	if timeout == "" {
+		timeout = rules.Timeout
+	}
+	if timeout != "" {
+		maxDuration, err = time.ParseDuration(timeout)
+		if err != nil || maxDuration <= 0 {
+			return c.outputError(globals, "INVALID_MAX_DURATION", fmt.Sprintf("invalid max duration: %q", timeout))
+		}
+	}
+
+	run := &expectRun{
+		globals:   globals,
+		evaluator: evaluator,
+		extractor: extractor,
+		writer:    output.NewNDJSONWriter(globals.Stdout),
+		failFast:  c.FailFast,
+	}
+
+	var stopReason string
+	if c.File != "" {
+		stopReason, err = c.runFile(run, maxDuration)
+	} else {
+		if maxDuration == 0 {
+			maxDuration = defaultExpectTimeout
+		}
+		stopReason, err = c.runLive(run, maxDuration)
+	}
+	if err != nil {
+		return err
+	}
+	return run.finish(stopReason)
+}
+
+// runFile evaluates a recorded NDJSON file using entry timestamps as the
+// clock. A zero maxDuration reads the whole file.
+func (c *ExpectCmd) runFile(run *expectRun, maxDuration time.Duration) (string, error) {
+	globals := run.globals
+	rec, cerr := readRecordingFile(globals, c.File)
+	if cerr != nil {
+		return "", c.outputError(globals, cerr.Code, cerr.Message)
+	}
+
+	var start, last time.Time
+	for i := range rec.Entries {
+		entry := &rec.Entries[i]
+		if start.IsZero() {
+			start = entry.Timestamp
+			run.evaluator.Start(start)
+		}
+		if maxDuration > 0 && entry.Timestamp.Sub(start) > maxDuration {
+			if err := run.emit(run.evaluator.Tick(start.Add(maxDuration))); err != nil {
+				return "", err
+			}
+			return "max_duration", nil
+		}
+		last = entry.Timestamp
+		if reason, err := run.observe(entry, entry.Timestamp); err != nil || reason != "" {
+			return reason, err
+		}
+	}
+	if err := run.emit(run.evaluator.Tick(last)); err != nil {
+		return "", err
+	}
+	return "end_of_file", nil
+}
+
+// runLive streams from a simulator until all expectations are decided or the deadline passes
+func (c *ExpectCmd) runLive(run *expectRun, maxDuration time.Duration) (string, error) {
+	globals := run.globals
+	ctx, stop := signal.NotifyContext(globals.baseContext(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	clk := clock.New()
+
+	if globals.FlagProvided("simulator") && globals.FlagProvided("booted") {
+		return "", c.outputError(globals, "INVALID_FLAGS", "--simulator and --booted are mutually exclusive")
+	}
+	if err := validateAppPredicateAll(c.App, c.Predicate, c.All, false); err != nil {
+		return "", outputErrorCommon(globals, err.Code, err.Message, err.Hint)
+	}
+
+	mgr := newSimulatorManager(globals)
+	device, err := resolveSimulatorDevice(ctx, mgr, c.Simulator, c.Booted)
+	if err != nil {
+		return "", c.outputError(globals, "DEVICE_NOT_FOUND", err.Error(), hintForStreamOrQuery(err))
+	}
+
+	minLevel, maxLevel := resolveLevels("", "", globals.Level)
+	streamer := simulator.NewStreamer(mgr)
+	opts := simulator.StreamOptions{
+		BundleID:     c.App,
+		MinLevel:     minLevel,
+		MaxLevel:     maxLevel,
+		BufferSize:   100,
+		RawPredicate: c.Predicate,
+		Verbose:      globals.Verbose,
+	}
+	run.tailID = generateTailID()
+
+	if !globals.Quiet {
+		msg := fmt.Sprintf("Checking expectations from %s against %s (max %s)", c.Rules, device.Name, maxDuration)
+		if globals.Format == "ndjson" {
+			if err := run.writer.WriteInfo(msg, device.Name, device.UDID, "", "expect"); err != nil {
+				return "", err
+			}
+		} else if _, err := fmt.Fprintln(globals.Stderr, msg); err != nil {
+			globals.Debug("failed to write expect info: %v", err)
+		}
+	}
+
+	if err := streamer.Start(ctx, device.UDID, opts); err != nil {
+		return "", c.outputError(globals, "STREAM_FAILED", err.Error(), hintForStreamOrQuery(err))
+	}
+	defer func() {
+		if err := streamer.Stop(); err != nil {
+			globals.Debug("failed to stop streamer: %v", err)
+		}
+	}()
+
+	run.evaluator.Start(clk.Now())
+	cutoffTimer := clk.Timer(maxDuration)
+	defer cutoffTimer.Stop()
+	ticker := clk.Ticker(expectTickInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return "interrupted", nil
+
+		case <-cutoffTimer.C:
+			return "max_duration", nil
+
+		case now := <-ticker.C:
+			if err := run.emit(run.evaluator.Tick(now)); err != nil {
+				return "", err
+			}
+			if reason := run.stopReason(); reason != "" {
+				return reason, nil
+			}
+
+		case entry := <-streamer.Logs():
+			entry.TailID = run.tailID
+			if reason, err := run.observe(&entry, clk.Now()); err != nil || reason != "" {
+				return reason, err
+			}
+
+		case err := <-streamer.Errors():
+			if globals.Format == "ndjson" {
+				if werr := run.writer.WriteWarning(err.Error()); werr != nil {
+					return "", werr
+				}
+				continue
+			}
+			emitWarning(globals, output.NewEmitter(globals.Stdout), err.Error())
+		}
+	}
+}
+
+// observe evaluates one entry and returns a stop reason once the run can end
+func (r *expectRun) observe(entry *domain.LogEntry, now time.Time) (string, error) {
+	r.extractor.Extract(entry)
+	r.checked++
+	if err := r.emit(r.evaluator.Observe(entry, now)); err != nil {
+		return "", err
+	}
+	return r.stopReason(), nil
+}
+
+func (r *expectRun) stopReason() string {
+	if r.failFast && r.failed > 0 {
+		return "fail_fast"
+	}
+	if r.evaluator.Done() {
+		return "all_decided"
+	}
+	return ""
+}
+
+// emit writes expectation_failed events (ndjson) or FAIL lines (text)
+func (r *expectRun) emit(failures []domain.ExpectationFailed) error {
+	for i := range failures {
+		f := &failures[i]
+		f.TailID = r.tailID
+		r.failed++
+		if r.globals.Format == "ndjson" {
+			if err := r.writer.WriteExpectationFailed(f); err != nil {
+				return err
+			}
+			continue
+		}
+		line := fmt.Sprintf("FAIL %s: %s", f.Name, f.Reason)
+		if f.Entry != nil {
+			line += fmt.Sprintf(" [%s] %s", f.Entry.Level, f.Entry.Message)
+		}
+		if _, err := fmt.Fprintln(r.globals.Stdout, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// finish decides pending expectations, writes expect_result and returns an error when any failed
+func (r *expectRun) finish(stopReason string) error {
+	if err := r.emit(r.evaluator.Finish(time.Now())); err != nil {
+		return err
+	}
+
+	result := &domain.ExpectResult{
+		Timestamp:    time.Now().UTC().Format(time.RFC3339Nano),
+		LogsChecked:  r.checked,
+		StopReason:   stopReason,
+		TailID:       r.tailID,
+		Expectations: r.evaluator.Statuses(),
+	}
+	result.Total = len(result.Expectations)
+	for _, s := range result.Expectations {
+		switch s.Status {
+		case filter.ExpectPassed:
+			result.PassedCount++
+		case filter.ExpectFailed:
+			result.FailedCount++
+		}
+	}
+	result.Passed = result.FailedCount == 0
+
+	if r.globals.Format == "ndjson" {
+		if err := r.writer.WriteExpectResult(result); err != nil {
+			return err
+		}
+	} else {
+		for _, s := range result.Expectations {
+			if s.Status == filter.ExpectPassed {
+				if _, err := fmt.Fprintf(r.globals.Stdout, "PASS %s (%d matched)\n", s.Name, s.Count); err != nil {
+					return err
+				}
+			}
+		}
+		verdict := "PASSED"
+		if !result.Passed {
+			verdict = "FAILED"
+		}
+		if _, err := fmt.Fprintf(r.globals.Stdout, "%s: %d/%d expectations passed (%d logs checked, %s)\n",
+			verdict, result.PassedCount, result.Total, result.LogsChecked, stopReason); err != nil {
+			return err
+		}
+	}
+
+	if !result.Passed {
+		return fmt.Errorf("%d of %d expectations failed", result.FailedCount, result.Total)
+	}
+	return nil
+}
+
+func (c *ExpectCmd) outputError(globals *Globals, code, message string, hint ...string) error {
+	return outputErrorCommon(globals, code, message, hint...)
+}
@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vburojevic/xcw/internal/config"
+)
+
+func TestExpect_WithStubXcrun(t *testing.T) {
+	stubDir := t.TempDir()
+	xcrunPath := filepath.Join(stubDir, "xcrun")
+
+	// Stub xcrun simctl calls used by ExpectCmd:
+	// - list devices --json (device resolution)
+	// - spawn <udid> log stream (streaming)
+	script := `#!/bin/sh
+set -eu
+
+if [ "$#" -ge 4 ] && [ "$1" = "simctl" ] && [ "$2" = "list" ] && [ "$3" = "devices" ] && [ "$4" = "--json" ]; then
+  cat <<'EOF'
+{
+  "devices": {
+    "com.apple.CoreSimulator.SimRuntime.iOS-17-0": [
+      {
+        "udid": "TEST-UDID-123",
+        "name": "iPhone 17 Pro",
+        "state": "Booted",
+        "isAvailable": true,
+        "deviceTypeIdentifier": "com.apple.CoreSimulator.SimDeviceType.iPhone-17-Pro",
+        "dataPath": "/tmp",
+        "logPath": "/tmp"
+      }
+    ]
+  }
+}
+EOF
+  exit 0
+fi
+
+if [ "$#" -ge 5 ] && [ "$1" = "simctl" ] && [ "$2" = "spawn" ] && [ "$4" = "log" ] && [ "$5" = "stream" ]; then
+  echo '{"timestamp":"2025-12-15 00:00:00.000000+0000","messageType":"Default","processImagePath":"/Applications/MyApp.app/MyApp","processID":123,"threadID":1,"subsystem":"com.example.myapp","category":"auth","eventMessage":"Login succeeded","eventType":"logEvent","processImageUUID":"UUID-123","senderImagePath":""}'
+  # Keep the process alive; ExpectCmd should stop once every expectation is decided.
+  exec sleep 60
+fi
+
+echo "stub: unsupported xcrun args: $*" >&2
+exit 1
+`
+	require.NoError(t, os.WriteFile(xcrunPath, []byte(script), 0o755))
+	rules := filepath.Join(stubDir, "expect.yaml")
+	require.NoError(t, os.WriteFile(rules, []byte("expectations:\n  - see: message~\"Login succeeded\"\n    within: 5s\n"), 0o644))
+
+	t.Setenv("PATH", stubDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	globals := &Globals{
+		Format: "ndjson",
+		Level:  "debug",
+		Quiet:  true,
+		Stdout: &stdout,
+		Stderr: &stderr,
+		Config: config.Default(),
+	}
+	cmd := &ExpectCmd{
+		Rules:       rules,
+		Booted:      true,
+		App:         "com.example.myapp",
+		MaxDuration: "5s",
+	}
+
+	require.NoError(t, cmd.Run(globals))
+
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	require.Len(t, lines, 1)
+
+	var result map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &result))
+	require.Equal(t, "expect_result", result["type"])
+	require.Equal(t, true, result["passed"])
+	require.Equal(t, "all_decided", result["stop_reason"])
+	require.NotEmpty(t, result["tail_id"])
+}
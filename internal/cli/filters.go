@@ -1,7 +1,9 @@
 package cli
 
 import (
+	"fmt"
 	"regexp"
+	"time"
 
 	"github.com/vburojevic/xcw/internal/domain"
 	"github.com/vburojevic/xcw/internal/filter"
@@ -39,6 +41,24 @@ func buildFilters(patternStr string, exclude []string, where []string) (*regexp.
 	return pattern, excludePatterns, whereFilter, nil
 }
 
+// buildCoalescer parses --group/--group-window; returns nil when grouping is off.
+func buildCoalescer(group bool, window string) (*filter.Coalescer, error) {
+	if !group {
+		return nil, nil
+	}
+	var d time.Duration
+	if window != "" {
+		var err error
+		if d, err = time.ParseDuration(window); err != nil {
+			return nil, fmt.Errorf("invalid group window: %w", err)
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("invalid group window: must be > 0")
+		}
+	}
+	return filter.NewCoalescer(d), nil
+}
+
 // resolveLevels picks min/max level given cmd overrides and globals
 func resolveLevels(minOverride, maxOverride string, globalsMin string) (domain.LogLevel, domain.LogLevel) {
 	minLevel := globalsMin
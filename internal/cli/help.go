@@ -142,6 +142,7 @@ func buildDocumentation() *HelpOutput {
 					{Command: `xcw tail -s "iPhone 17 Pro" -a com.example.myapp`, Description: "Basic streaming to stdout"},
 					{Command: `xcw tail -s "iPhone 17 Pro" -a com.example.myapp --tmux`, Description: "Background with tmux (returns session name)"},
 					{Command: `xcw tail -s "iPhone 17 Pro" -a com.example.myapp --output file.ndjson`, Description: "Stream to file"},
+					{Command: `xcw tail -s "iPhone 17 Pro" -a com.example.myapp --session-dir ~/.xcw/sessions --compress zstd --max-file-size 200MB`, Description: "Record compressed, starting a new .partN file every 200MB (emits rotation with part)"},
 					{Command: `xcw tail -s "iPhone 17 Pro" -a com.example.myapp --max-duration 5m`, Description: "Stream for 5 minutes and stop (emits cutoff_reached)"},
 					{Command: `xcw tail -s "iPhone 17 Pro" -a com.example.myapp --max-logs 1000`, Description: "Stop after 1000 logs (emits cutoff_reached)"},
 					{Command: `xcw tail -s "iPhone 17 Pro" -a com.example.myapp -l error`, Description: "Only error/fault level"},
@@ -151,13 +152,20 @@ func buildDocumentation() *HelpOutput {
 					{Command: `xcw tail -s "iPhone 17 Pro" -a com.example.myapp --where level=error`, Description: "Filter by field/expression (=, !=, ~, !~, >=, <=, ^, $, AND/OR/NOT, parentheses, /regex/i)"},
 					{Command: `xcw tail -s "iPhone 17 Pro" -a com.example.myapp --where '(level=error OR level=fault) AND message~timeout'`, Description: "Boolean where expression"},
 					{Command: `xcw tail -s "iPhone 17 Pro" -a com.example.myapp --where "message~timeout"`, Description: "Filter messages containing 'timeout'"},
+					{Command: `xcw tail -s "iPhone 17 Pro" -a com.example.myapp --extract-trace traceparent --where 'trace_id!=""'`, Description: "Tag entries with trace_id/span_id from W3C traceparent headers in messages"},
 					{Command: `xcw tail -s "iPhone 17 Pro" -a com.example.myapp --dedupe`, Description: "Collapse repeated identical messages"},
+					{Command: `xcw tail -s "iPhone 17 Pro" -a com.example.myapp --group`, Description: "Coalesce stack traces into log_group events"},
 					{Command: `xcw tail -s "iPhone 17 Pro" -a com.example.myapp --process MyApp --process MyAppExtension`, Description: "Filter by process name"},
 					{Command: `xcw tail -s "iPhone 17 Pro" -a com.example.myapp -x noise -x spam`, Description: "Exclude multiple patterns"},
 					{Command: `xcw tail -s "iPhone 17 Pro" -a com.example.myapp --session-idle 60s`, Description: "Force a new session boundary after 60s of inactivity"},
 					{Command: `xcw tail -s "iPhone 17 Pro" --predicate 'process == \"MyApp\"'`, Description: "Stream without -a using a raw predicate (advanced)"},
+					{Command: `xcw tail --all-booted -a com.example.myapp`, Description: "Tail every booted simulator; log lines carry udid/simulator, sessions tracked per device"},
+					{Command: `xcw tail -s "iPhone 17 Pro" -s "iPad Air" -a com.example.myapp`, Description: "Tail several simulators (repeat -s)"},
+					{Command: `xcw tail -s "iPhone 17 Pro" -a com.example.myapp --serve 127.0.0.1:7070`, Description: "Share one stream over HTTP: GET /events (SSE), /buffer, /stats, /sessions (loopback only unless --serve-public)"},
+					{Command: `xcw tail -s "iPhone 17 Pro" -a com.example.myapp --otlp-endpoint http://localhost:4318`, Description: "Also forward logs to an OpenTelemetry collector (OTLP/HTTP JSON; --otlp-protocol grpc for :4317)"},
+					{Command: `xcw tail -s "iPhone 17 Pro" -a com.example.myapp --anomalies --anomaly-sensitivity high`, Description: "Learn baseline rates and emit anomaly events for error spikes, silent subsystems and new patterns"},
 				},
-				OutputTypes:     []string{"log", "session_start", "session_end", "ready", "summary", "heartbeat", "cutoff_reached", "tmux", "error"},
+				OutputTypes:     []string{"log", "session_start", "session_end", "crash_detected", "anomaly", "ready", "summary", "heartbeat", "cutoff_reached", "tmux", "error"},
 				RelatedCommands: []string{"query", "watch", "analyze", "discover"},
 			},
 			"query": {
@@ -167,7 +175,11 @@ func buildDocumentation() *HelpOutput {
 					{Command: `xcw query -s "iPhone 17 Pro" -a com.example.myapp --since 5m`, Description: "Last 5 minutes"},
 					{Command: `xcw query -s "iPhone 17 Pro" -a com.example.myapp --since 5m -l error`, Description: "Errors only"},
 					{Command: `xcw query -s "iPhone 17 Pro" -a com.example.myapp --since 10m --analyze`, Description: "With pattern analysis"},
+					{Command: `xcw query -a com.example.myapp --since 10m --analyze --report junit --report-file xcw-junit.xml`, Description: "Also write error patterns as JUnit XML for CI"},
 					{Command: `xcw query -s "iPhone 17 Pro" -a com.example.myapp --since 10m --where '(level=error OR level=fault) AND message~timeout'`, Description: "Where expression"},
+					{Command: `xcw query -s "iPhone 17 Pro" -a com.example.myapp --since 10m --extract logfmt --where 'fields.status>=500'`, Description: "Filter on extracted key=value fields"},
+					{Command: `xcw query -s "iPhone 17 Pro" -a com.example.myapp --since 1h --where 'level in (error, fault) AND timestamp > now-10m'`, Description: "Lists and relative time comparisons"},
+					{Command: `xcw query -s "iPhone 17 Pro" -a com.example.myapp --since 10m --preset networking`, Description: "Apply a named filter preset from config 'filters:'"},
 					{Command: `xcw query -s "iPhone 17 Pro" -a com.example.myapp --since 5m --dry-run-json`, Description: "Print resolved query options as JSON and exit"},
 				},
 				OutputTypes:     []string{"log", "analysis", "error"},
@@ -179,6 +191,8 @@ func buildDocumentation() *HelpOutput {
 				Examples: []ExampleDoc{
 					{Command: `xcw summary -a com.example.myapp --window 5m`, Description: "Analyze the last 5 minutes of logs"},
 					{Command: `xcw summary -s "iPhone 17 Pro" -a com.example.myapp --window 30m -p "error|fatal"`, Description: "Analyze last 30 minutes with pattern filter"},
+					{Command: `xcw summary -a com.example.myapp --window 10m --report sarif > xcw.sarif`, Description: "Fault patterns as SARIF for code scanning"},
+					{Command: `xcw summary -a com.example.myapp --window 10m --histogram`, Description: "Add a time-bucketed histogram showing when errors clustered"},
 				},
 				OutputTypes:     []string{"analysis", "error"},
 				RelatedCommands: []string{"query", "tail", "analyze", "discover"},
@@ -191,8 +205,12 @@ func buildDocumentation() *HelpOutput {
 					{Command: `xcw watch -s "iPhone 17 Pro" -a com.example.myapp --on-pattern 'crash|fatal:./notify.sh' --cooldown 10s`, Description: "Run a command when a regex matches the message"},
 					{Command: `xcw watch -s "iPhone 17 Pro" -a com.example.myapp --where level>=error --on-error "./notify.sh" --max-duration 5m`, Description: "Watch for 5 minutes and stop"},
 					{Command: `xcw watch -s "iPhone 17 Pro" -a com.example.myapp --where level>=error --on-error "./notify.sh" --dry-run-json`, Description: "Print resolved stream options and triggers as JSON and exit"},
+					{Command: `xcw watch -s "iPhone 17 Pro" -a com.example.myapp --on-error-webhook https://hooks.slack.com/services/T000/B000/XXXX --webhook-format slack`, Description: "Post error-level logs with their preceding lines to a Slack channel"},
+					{Command: `xcw watch -s "iPhone 17 Pro" -a com.example.myapp --rules rules.yaml`, Description: "Run named rules with thresholds, cooldowns and command/webhook/marker actions"},
+					{Command: `xcw watch -s "iPhone 17 Pro" -a com.example.myapp --on-fault "./notify.sh" --otlp-endpoint localhost:4317 --otlp-protocol grpc`, Description: "Forward watched logs to an OpenTelemetry collector over gRPC"},
+					{Command: `xcw watch -s "iPhone 17 Pro" -a com.example.myapp --anomalies --anomaly-warmup 2m`, Description: "Emit anomaly events once two minutes of baseline have been learned"},
 				},
-				OutputTypes:     []string{"log", "trigger", "trigger_result", "trigger_error", "cutoff_reached", "tmux", "error"},
+				OutputTypes:     []string{"log", "trigger", "trigger_result", "trigger_error", "marker", "anomaly", "cutoff_reached", "tmux", "error"},
 				RelatedCommands: []string{"tail", "query", "discover"},
 			},
 			"list": {
@@ -265,9 +283,10 @@ func buildDocumentation() *HelpOutput {
 			},
 			"doctor": {
 				Description: "Check system requirements and configuration",
-				Usage:       "xcw doctor",
+				Usage:       "xcw doctor [--rules FILE]",
 				Examples: []ExampleDoc{
 					{Command: `xcw doctor`, Description: "Run all checks"},
+					{Command: `xcw doctor --rules rules.yaml`, Description: "Also validate a watch rules file"},
 				},
 				OutputTypes: []string{"doctor", "error"},
 			},
@@ -285,9 +304,37 @@ func buildDocumentation() *HelpOutput {
 				Usage:       "xcw analyze FILE [flags]",
 				Examples: []ExampleDoc{
 					{Command: `xcw analyze session.ndjson`, Description: "Analyze recorded logs"},
+					{Command: `xcw analyze session.ndjson --extract logfmt --where 'fields.status>=500'`, Description: "Extract key=value fields and analyze matching entries"},
+					{Command: `xcw analyze session.ndjson --group`, Description: "Count each multi-line crash dump as one pattern"},
+					{Command: `xcw analyze session.ndjson --histogram -f text`, Description: "Draw a timeline of volume and errors over the recording"},
+					{Command: `xcw analyze session.ndjson --bucket 30s`, Description: "Add summary.histogram with per-30s level counts and top subsystem"},
+					{Command: `xcw analyze session.ndjson --persist-patterns --fail-on-regression`, Description: "CI gate: fail on patterns new in this build, returned, or rising"},
+					{Command: `xcw analyze session.ndjson --report junit > xcw-junit.xml`, Description: "Error patterns as failing JUnit test cases (GitLab, Jenkins)"},
+					{Command: `xcw analyze session.ndjson --report sarif --report-file xcw.sarif`, Description: "Write fault patterns as SARIF and keep the analysis output"},
 				},
 				OutputTypes:     []string{"analysis", "error"},
-				RelatedCommands: []string{"tail", "replay"},
+				RelatedCommands: []string{"tail", "replay", "diff"},
+			},
+			"diff": {
+				Description: "Compare two NDJSON recordings, or two sessions within one recording",
+				Usage:       "xcw diff FILE-A [FILE-B] [flags]",
+				Examples: []ExampleDoc{
+					{Command: `xcw diff before.ndjson after.ndjson`, Description: "Patterns only in one run, plus subsystem/category/level deltas"},
+					{Command: `xcw diff session.ndjson --session-a 1 --session-b 2`, Description: "Compare two sessions (app relaunches) in one recording"},
+				},
+				OutputTypes:     []string{"diff_result", "error"},
+				RelatedCommands: []string{"analyze", "sessions"},
+			},
+			"expect": {
+				Description: "Check logs against declarative YAML expectations (see within, not_see, count max, order); exit non-zero on failure",
+				Usage:       "xcw expect RULES [--file FILE | -s SIM -a APP] [flags]",
+				Examples: []ExampleDoc{
+					{Command: `xcw expect expect.yaml -s "iPhone 17 Pro" -a com.example.myapp`, Description: "Tail the app until every expectation is decided or the timeout passes"},
+					{Command: `xcw expect expect.yaml --file session.ndjson`, Description: "Check a recorded run (deadlines use log timestamps)"},
+					{Command: `xcw expect expect.yaml -a com.example.myapp --fail-fast --max-duration 2m`, Description: "Stop at the first failure"},
+				},
+				OutputTypes:     []string{"expectation_failed", "expect_result", "info", "warning", "error"},
+				RelatedCommands: []string{"tail", "watch", "replay"},
 			},
 			"replay": {
 				Description: "Replay a recorded NDJSON log file with timing",
@@ -299,23 +346,75 @@ func buildDocumentation() *HelpOutput {
 				OutputTypes:     []string{"log", "error"},
 				RelatedCommands: []string{"analyze", "tail"},
 			},
+			"search": {
+				Description: "Search recorded sessions through an index kept next to the recordings. New or changed recordings are indexed first; files and entries ruled out by time, level, subsystem or text are never decoded.",
+				Usage:       "xcw search [TEXT...] [flags]",
+				Examples: []ExampleDoc{
+					{Command: `xcw search keychain --since 7d -l fault`, Description: "Faults mentioning keychain in the last week"},
+					{Command: `xcw search "timed out" --subsystem com.example.net`, Description: "Phrase search within one subsystem"},
+					{Command: `xcw search --session 3 --where level=error --limit 0`, Description: "All errors of one session"},
+				},
+				OutputTypes:     []string{"log", "search_result", "error"},
+				RelatedCommands: []string{"sessions", "analyze", "replay"},
+			},
+			"sql": {
+				Description: "Load NDJSON recordings (plain, gzip or zstd) into SQLite and run SQL over them. Tables: logs (one row per entry, including its message template as pattern and template_id), fields (--extract key/values), sessions (session_start joined with session_end), patterns (error/fault patterns per file), files. Uses the sqlite3 shell; without --db a temporary database is used.",
+				Usage:       "xcw sql [QUERY] --file FILE... [--db PATH]",
+				Examples: []ExampleDoc{
+					{Command: `xcw sql --latest "select subsystem, count(*) n from logs where severity >= 3 group by subsystem order by n desc"`, Description: "Errors and faults per subsystem in the latest session"},
+					{Command: `xcw sql --file a.ndjson --file b.ndjson.gz --db logs.db`, Description: "Load recordings into a database to keep"},
+					{Command: `xcw sql --db logs.db "select s.version, count(*) from logs l join sessions s using (file, session) where l.level = 'Fault' group by s.version" -f text`, Description: "Join logs with sessions; print a table"},
+					{Command: `xcw sql --schema`, Description: "Show the table definitions"},
+				},
+				OutputTypes:     []string{"sql_row", "sql_result", "error"},
+				RelatedCommands: []string{"search", "analyze", "sessions"},
+			},
+			"trace": {
+				Description: "Gather every entry of one trace or activity from recordings in time order, with per-span durations. The ID is matched against trace_id (W3C traceparent by default, or --extract-trace-regex for custom request IDs), then against activity_id including nested activities.",
+				Usage:       "xcw trace ID --file FILE... | --latest",
+				Examples: []ExampleDoc{
+					{Command: `xcw trace 4bf92f3577b34da6a3ce929d0e0e4736 --latest`, Description: "Entries carrying this traceparent trace ID, followed by a trace summary"},
+					{Command: `xcw trace req-8812 --file session.ndjson --extract-trace-regex 'request_id=(?P<trace>[\w-]+)'`, Description: "Follow a custom request ID"},
+					{Command: `xcw trace 0x1a2b --latest -f text`, Description: "An os_activity and its nested activities as a timeline"},
+				},
+				OutputTypes:     []string{"log", "trace", "error"},
+				RelatedCommands: []string{"search", "analyze", "tail"},
+			},
 			"sessions": {
 				Description: "Manage session log files",
-				Usage:       "xcw sessions [list|show|clean]",
+				Usage:       "xcw sessions [list|show|clean|index]",
 				Examples: []ExampleDoc{
 					{Command: `xcw sessions list`, Description: "List recent sessions"},
 					{Command: `xcw sessions show --latest`, Description: "Path to latest session"},
+					{Command: `xcw sessions show --latest --cat`, Description: "Print the latest session (gzip/zstd decompressed)"},
 					{Command: `xcw sessions clean --keep 10`, Description: "Keep 10 most recent"},
+					{Command: `xcw sessions clean --max-total-size 2GB`, Description: "Delete oldest files until the directory fits 2GB"},
+					{Command: `xcw sessions index`, Description: "Index new or changed recordings for xcw search"},
+					{Command: `xcw sessions index --rebuild`, Description: "Rebuild the search index from scratch"},
+				},
+				OutputTypes: []string{"session", "session_index", "info", "error"},
+			},
+			"serve": {
+				Description: "Long-running JSON-RPC 2.0 server on stdin/stdout. Methods: tail.start, tail.stop, query, discover, summary, list. Params are command flags as an object (e.g. {\"app\":\"com.example.myapp\",\"since\":\"5m\"}). Tail events arrive as tail.event notifications ({tail_id, event}) followed by tail.exit.",
+				Usage:       "xcw serve",
+				Examples: []ExampleDoc{
+					{Command: `xcw serve`, Description: "Serve JSON-RPC; keeps tails and their session state alive across requests"},
+					{Command: `{"jsonrpc":"2.0","id":1,"method":"tail.start","params":{"booted":true,"app":"com.example.myapp"}}`, Description: "Start a tail (result: {tail_id})"},
+					{Command: `{"jsonrpc":"2.0","id":2,"method":"tail.stop","params":{"tail_id":"tail-abc"}}`, Description: "Stop a tail"},
 				},
-				OutputTypes: []string{"session", "info", "error"},
+				RelatedCommands: []string{"tail", "query", "discover"},
 			},
 			"discover": {
 				Description: "Discover what subsystems, categories, and processes exist in logs. Essential first step for understanding an app's logging landscape.",
-				Usage:       "xcw discover -s SIMULATOR [-a APP] --since DURATION",
+				Usage:       "xcw discover -s SIMULATOR [-a APP] --since DURATION | xcw discover --file RECORDING... [--since DURATION]",
 				Examples: []ExampleDoc{
 					{Command: `xcw discover -s "iPhone 17 Pro" --since 5m`, Description: "Discover all logs from last 5 minutes"},
 					{Command: `xcw discover -s "iPhone 17 Pro" -a com.example.myapp --since 10m`, Description: "Discover logs for specific app"},
 					{Command: `xcw discover -b --since 1h --top-n 30`, Description: "More items, booted sim, 1 hour"},
+					{Command: `xcw discover -a com.example.myapp --since 10m -f ndjson | jq -r '.suggested_filters.command'`, Description: "Tail command that drops the noisiest Apple subsystems"},
+					{Command: `xcw discover --file 'artifacts/*.ndjson.gz'`, Description: "Discover from recordings offline (no simulator needed, e.g. in Linux CI); --since counts back from the last entry"},
+					{Command: `cat session.ndjson | xcw discover --file -`, Description: "Discover from a recording on stdin"},
+					{Command: `xcw discover -a com.example.myapp --since 1h -f ndjson | jq '.templates[0]'`, Description: "Most common message template with its ID and parameter samples (masks from 'patterns:' in config)"},
 				},
 				OutputTypes:     []string{"discovery", "error"},
 				RelatedCommands: []string{"tail", "query"},
@@ -394,6 +493,21 @@ func buildDocumentation() *HelpOutput {
 				},
 				When: "Each log entry during tail or query",
 			},
+			"log_group": {
+				Description: "Coalesced multi-line message (stack trace, exception dump) from one pid/tid. Same fields as log plus the original lines.",
+				Example: map[string]interface{}{
+					"type":          "log_group",
+					"schemaVersion": 1,
+					"timestamp":     "2024-01-15T10:30:45.123Z",
+					"level":         "Error",
+					"process":       "MyApp",
+					"pid":           1234,
+					"tid":           5678,
+					"message":       "*** Terminating app due to uncaught exception\n0 CoreFoundation 0x1a2b3c",
+					"lines":         []string{"*** Terminating app due to uncaught exception", "0 CoreFoundation 0x1a2b3c"},
+				},
+				When: "With --group, instead of one log event per continuation line",
+			},
 			"metadata": {
 				Description: "Tool metadata emitted at start of tail for agents.",
 				Example: map[string]interface{}{
@@ -495,6 +609,24 @@ func buildDocumentation() *HelpOutput {
 				},
 				When: "When xcw tail detects the app was relaunched (before session_start)",
 			},
+			"crash_detected": {
+				Description: "The tracked app crashed: uncaught exception, EXC_* exception, Swift fatal error, or PID gone right after a fault. Includes the final buffered lines and a matching .ips report path (waits up to 10s for the report to be written).",
+				Example: map[string]interface{}{
+					"type":             "crash_detected",
+					"schemaVersion":    1,
+					"timestamp":        "2024-01-15T10:30:46.003Z",
+					"tail_id":          "tail-abc",
+					"session":          1,
+					"pid":              12345,
+					"process":          "MyApp",
+					"trigger":          "exception_message",
+					"exception_type":   "NSInvalidArgumentException",
+					"exception_reason": "-[__NSCFNumber length]: unrecognized selector sent to instance 0x8000000000000000",
+					"binary_uuid":      "C0FFEE...",
+					"ips_path":         "~/Library/Logs/DiagnosticReports/MyApp-2024-01-15-103046.ips",
+				},
+				When: "During xcw tail --app (disable with --no-crash-detect); exit_after_fault crashes are emitted before session_end",
+			},
 			"clear_buffer": {
 				Description: "Instructs consumers to reset caches at a session boundary (start/end/idle rollover).",
 				Example: map[string]interface{}{
@@ -548,6 +680,55 @@ func buildDocumentation() *HelpOutput {
 				},
 				When: "When --analyze flag is used with query or analyze command",
 			},
+			"diff_result": {
+				Description: "Comparison of two recordings or sessions: normalized patterns only in A or B, and count/rate deltas per subsystem, category and level",
+				Example: map[string]interface{}{
+					"type":          "diff_result",
+					"schemaVersion": 1,
+					"a":             map[string]interface{}{"label": "before.ndjson", "total": 1200, "patterns": 85, "duration_seconds": 60},
+					"b":             map[string]interface{}{"label": "after.ndjson", "total": 1350, "patterns": 90, "duration_seconds": 60},
+					"only_in_b": []map[string]interface{}{
+						{"pattern": "Request <n> timed out", "count": 12, "level": "Error", "sample": "Request 42 timed out"},
+					},
+					"levels": []map[string]interface{}{
+						{"key": "Error", "count_a": 3, "count_b": 15, "delta": 12, "rate_a": 3, "rate_b": 15, "rate_delta_pct": 400},
+					},
+				},
+				When: "Output of the diff command",
+			},
+			"expectation_failed": {
+				Description: "An expectation from the xcw expect rules file failed; entry holds the offending log line when there is one",
+				Example: map[string]interface{}{
+					"type":          "expectation_failed",
+					"schemaVersion": 1,
+					"timestamp":     "2024-01-15T10:30:45.123Z",
+					"name":          "no faults",
+					"kind":          "not_see",
+					"expression":    "level=Fault",
+					"reason":        "matched a log entry that must not appear",
+					"count":         1,
+					"entry":         map[string]interface{}{"level": "Fault", "message": "Database corrupted"},
+				},
+				When: "As soon as xcw expect detects a failure (see deadlines, not_see/count matches, out-of-order steps) or at the end for unmet see/order",
+			},
+			"expect_result": {
+				Description: "Final verdict of xcw expect with per-expectation status; the command exits non-zero when passed is false",
+				Example: map[string]interface{}{
+					"type":          "expect_result",
+					"schemaVersion": 1,
+					"passed":        false,
+					"total":         3,
+					"passed_count":  2,
+					"failed_count":  1,
+					"logs_checked":  812,
+					"stop_reason":   "max_duration",
+					"expectations": []map[string]interface{}{
+						{"name": "login", "kind": "see", "status": "passed", "count": 1},
+						{"name": "no faults", "kind": "not_see", "status": "failed", "count": 1, "reason": "matched a log entry that must not appear"},
+					},
+				},
+				When: "Last line of every xcw expect run",
+			},
 			"heartbeat": {
 				Description: "Keepalive message for stream health",
 				Example: map[string]interface{}{
@@ -639,6 +820,40 @@ func buildDocumentation() *HelpOutput {
 				},
 				When: "After a watch trigger command exits",
 			},
+			"marker": {
+				Description: "Emitted by the marker action of a watch rule (--rules) when its threshold is reached",
+				Example: map[string]interface{}{
+					"type":          "marker",
+					"schemaVersion": 1,
+					"timestamp":     "2024-01-15T10:30:45.456Z",
+					"tail_id":       "tail-abc",
+					"session":       1,
+					"rule":          "network errors",
+					"message":       "network degraded",
+					"matches":       5,
+					"window":        "1m0s",
+					"entry":         map[string]interface{}{"type": "log", "level": "Error", "message": "request timed out"},
+				},
+				When: "A watch rule with a marker action fired",
+			},
+			"anomaly": {
+				Description: "Emitted by tail/watch --anomalies when error rates spike above their EWMA baseline, a usually busy subsystem goes silent, or a new message template appears",
+				Example: map[string]interface{}{
+					"type":          "anomaly",
+					"schemaVersion": 1,
+					"timestamp":     "2024-01-15T10:30:50Z",
+					"tail_id":       "tail-abc",
+					"session":       1,
+					"kind":          "error_spike",
+					"subsystem":     "com.example.myapp.network",
+					"window":        "10s",
+					"observed":      14,
+					"baseline":      0.8,
+					"score":         8.4,
+					"message":       "14 errors in 10s from com.example.myapp.network (baseline 0.8)",
+				},
+				When: "After the warmup, when a rate window closes or a new pattern is seen",
+			},
 			"trigger_error": {
 				Description: "Emitted when a watch trigger fails to execute or exits non-zero",
 				Example: map[string]interface{}{
@@ -655,7 +870,7 @@ func buildDocumentation() *HelpOutput {
 				When: "After a watch trigger command fails",
 			},
 			"discovery": {
-				Description: "Log discovery results showing subsystems, categories, processes, and levels",
+				Description: "Log discovery results showing subsystems and categories (with level breakdowns, samples and top templates), processes, levels, and suggested noise filters",
 				Example: map[string]interface{}{
 					"type":          "discovery",
 					"schemaVersion": 1,
@@ -663,15 +878,28 @@ func buildDocumentation() *HelpOutput {
 					"time_range":    map[string]string{"start": "2024-01-15T10:25:45Z", "end": "2024-01-15T10:30:45Z"},
 					"total_count":   1250,
 					"subsystems": []map[string]interface{}{
-						{"name": "com.example.myapp", "count": 450, "levels": map[string]int{"debug": 300, "info": 100, "error": 50}},
+						{"name": "com.example.myapp", "count": 450, "levels": map[string]int{"Debug": 300, "Info": 100, "Error": 50},
+							"first_seen": "2024-01-15T10:25:46Z", "last_seen": "2024-01-15T10:30:44Z",
+							"samples":   []string{"Loaded 24 items", "Request 17 to https://api.example.com/feed failed: timeout"},
+							"templates": []map[string]interface{}{{"id": "5d0e7b1a93c4", "template": "Loaded <n> items", "count": 120}}},
 					},
 					"categories": []map[string]interface{}{
-						{"name": "network", "count": 300},
+						{"name": "network", "count": 300, "levels": map[string]int{"Info": 260, "Error": 40}},
 					},
 					"processes": []map[string]interface{}{
 						{"name": "MyApp", "count": 800},
 					},
-					"levels": map[string]int{"debug": 700, "info": 350, "error": 80},
+					"levels": map[string]int{"Debug": 700, "Info": 350, "Error": 80},
+					"suggested_filters": map[string]interface{}{
+						"exclude_subsystems": []string{"com.apple.network"},
+						"where":              []string{"subsystem not in (com.apple.UIKit) OR level>=error"},
+						"command":            "xcw tail -a com.example.myapp --exclude-subsystem com.apple.network --where 'subsystem not in (com.apple.UIKit) OR level>=error'",
+						"removes":            310,
+					},
+					"templates": []map[string]interface{}{
+						{"id": "3f9a1c07be52", "template": "Request <n> to <url> failed: <*>", "count": 42, "level": "Error", "subsystem": "com.example.myapp", "example": "Request 17 to https://api.example.com/feed failed: timeout",
+							"params": []map[string]interface{}{{"position": 1, "token": "<n>", "samples": []string{"17", "18"}}, {"position": 5, "token": "<*>", "samples": []string{"timeout", "cancelled"}}}},
+					},
 				},
 				When: "From xcw discover command",
 			},
@@ -814,20 +1042,26 @@ func buildDocumentation() *HelpOutput {
 			},
 		},
 		ErrorCodes: map[string]ErrorCodeDoc{
-			"DEVICE_NOT_FOUND":    {Description: "Simulator not found by name or UDID", Recovery: "Run 'xcw list' to see available simulators"},
-			"NO_BOOTED_SIMULATOR": {Description: "No booted simulator when --booted used", Recovery: "Boot a simulator in Xcode or use 'xcrun simctl boot'"},
-			"INVALID_FLAGS":       {Description: "--simulator and --booted used together", Recovery: "Use only one of --simulator or --booted"},
-			"FILTER_REQUIRED":     {Description: "No source filter provided (refusing to run unfiltered by default)", Recovery: "Provide -a/--app or --predicate, or pass --all to intentionally stream/query all logs"},
-			"INVALID_PATTERN":     {Description: "Regex pattern compilation failed", Recovery: "Check regex syntax"},
-			"INVALID_FILTER":      {Description: "Filter parsing/compilation failed", Recovery: "Check regex/--where syntax; quote complex expressions"},
-			"INVALID_DURATION":    {Description: "Duration parsing failed", Recovery: "Use format like '5m', '1h', '30s'"},
-			"STREAM_FAILED":       {Description: "Log streaming failed", Recovery: "Check simulator is running and accessible"},
-			"QUERY_FAILED":        {Description: "Historical log query failed", Recovery: "Check simulator is running"},
-			"FILE_NOT_FOUND":      {Description: "Input file not found", Recovery: "Check file path exists"},
-			"TMUX_NOT_INSTALLED":  {Description: "tmux not installed", Recovery: "Install with 'brew install tmux'"},
-			"TMUX_ERROR":          {Description: "tmux operation failed", Recovery: "Check tmux is working: 'tmux list-sessions'"},
-			"LIST_APPS_FAILED":    {Description: "Failed to list apps", Recovery: "Check simulator is booted"},
-			"TUI_FAILED":          {Description: "TUI exited with an error", Recovery: "Rerun with -v for debug output or use 'xcw tail' for non-interactive streaming"},
+			"DEVICE_NOT_FOUND":     {Description: "Simulator not found by name or UDID", Recovery: "Run 'xcw list' to see available simulators"},
+			"NO_BOOTED_SIMULATOR":  {Description: "No booted simulator when --booted used", Recovery: "Boot a simulator in Xcode or use 'xcrun simctl boot'"},
+			"INVALID_FLAGS":        {Description: "--simulator and --booted used together", Recovery: "Use only one of --simulator or --booted"},
+			"FILTER_REQUIRED":      {Description: "No source filter provided (refusing to run unfiltered by default)", Recovery: "Provide -a/--app or --predicate, or pass --all to intentionally stream/query all logs"},
+			"INVALID_PATTERN":      {Description: "Regex pattern compilation failed", Recovery: "Check regex syntax"},
+			"INVALID_FILTER":       {Description: "Filter parsing/compilation failed", Recovery: "Check regex/--where syntax; quote complex expressions"},
+			"INVALID_PRESET":       {Description: "Unknown --preset name", Recovery: "Check 'filters:' in the config file (xcw config show lists presets)"},
+			"INVALID_DURATION":     {Description: "Duration parsing failed", Recovery: "Use format like '5m', '1h', '30s'"},
+			"STREAM_FAILED":        {Description: "Log streaming failed", Recovery: "Check simulator is running and accessible"},
+			"QUERY_FAILED":         {Description: "Historical log query failed", Recovery: "Check simulator is running"},
+			"FILE_NOT_FOUND":       {Description: "Input file not found", Recovery: "Check file path exists"},
+			"TMUX_NOT_INSTALLED":   {Description: "tmux not installed", Recovery: "Install with 'brew install tmux'"},
+			"TMUX_ERROR":           {Description: "tmux operation failed", Recovery: "Check tmux is working: 'tmux list-sessions'"},
+			"SQLITE_NOT_INSTALLED": {Description: "sqlite3 not found (needed by xcw sql)", Recovery: "sqlite3 ships with macOS; otherwise 'brew install sqlite'"},
+			"SQL_FAILED":           {Description: "Loading recordings or running the SQL query failed", Recovery: "Check the query against 'xcw sql --schema'"},
+			"INVALID_RULES":        {Description: "The watch rules file is missing or invalid", Recovery: "Validate it with 'xcw doctor --rules FILE'"},
+			"TRACE_NOT_FOUND":      {Description: "No entries carry the trace or activity ID", Recovery: "Check the ID; custom request IDs need --extract-trace-regex"},
+			"LIST_APPS_FAILED":     {Description: "Failed to list apps", Recovery: "Check simulator is booted"},
+			"TUI_FAILED":           {Description: "TUI exited with an error", Recovery: "Rerun with -v for debug output or use 'xcw tail' for non-interactive streaming"},
+			"SERVE_FAILED":         {Description: "tail --serve could not bind its HTTP address", Recovery: "Pick a free address, e.g. --serve 127.0.0.1:7071"},
 		},
 		Workflows: []WorkflowDoc{
 			{
@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/vburojevic/xcw/internal/domain"
+	"github.com/vburojevic/xcw/internal/output"
+)
+
+// HistogramFlags adds a time-bucketed volume histogram to commands that summarize logs.
+type HistogramFlags struct {
+	Histogram bool   `help:"Add a volume histogram to the summary: per-bucket level counts and top subsystem (text output draws a timeline)"`
+	Bucket    string `help:"Histogram bucket size, e.g. '10s' or '1m' (default: a round size giving about 30 buckets; implies --histogram)"`
+}
+
+// addHistogram fills summary.Histogram when --histogram or --bucket is set.
+func (f HistogramFlags) addHistogram(globals *Globals, analyzer *output.Analyzer, summary *domain.LogSummary, entries []domain.LogEntry) error {
+	if !f.Histogram && f.Bucket == "" {
+		return nil
+	}
+	var bucket time.Duration
+	if f.Bucket != "" {
+		d, err := time.ParseDuration(f.Bucket)
+		if err != nil || d <= 0 {
+			return outputErrorCommon(globals, "INVALID_DURATION", fmt.Sprintf("invalid bucket size %q", f.Bucket), "use a positive duration such as '10s' or '1m'")
+		}
+		bucket = d
+	}
+	if err := analyzer.AddHistogram(summary, entries, bucket); err != nil {
+		return outputErrorCommon(globals, "INVALID_FLAGS", err.Error(), "use a larger --bucket or omit it to size buckets automatically")
+	}
+	return nil
+}
+
+// sparkLevels are the bar heights of a timeline, lowest first
+var sparkLevels = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline renders values as bars scaled to the largest one; zero is blank.
+func sparkline(values []int) string {
+	maxV := 0
+	for _, v := range values {
+		maxV = max(maxV, v)
+	}
+	var b strings.Builder
+	for _, v := range values {
+		if v <= 0 {
+			b.WriteRune(' ')
+			continue
+		}
+		b.WriteRune(sparkLevels[(v*len(sparkLevels)-1)/maxV])
+	}
+	return b.String()
+}
+
+// printTimeline writes the histogram as sparklines of all entries and of
+// errors/faults, followed by the busiest error bucket.
+func printTimeline(w io.Writer, summary *domain.LogSummary) error {
+	buckets := summary.Histogram
+	if len(buckets) == 0 {
+		return nil
+	}
+	layout := "15:04:05"
+	if buckets[len(buckets)-1].Start.Sub(buckets[0].Start) >= 24*time.Hour {
+		layout = "01-02 15:04"
+	}
+	totals := make([]int, len(buckets))
+	errCounts := make([]int, len(buckets))
+	peak, maxTotal := 0, 0
+	for i, b := range buckets {
+		totals[i] = b.TotalCount
+		errCounts[i] = b.ErrorCount + b.FaultCount
+		maxTotal = max(maxTotal, b.TotalCount)
+		if errCounts[i] > errCounts[peak] {
+			peak = i
+		}
+	}
+
+	lines := []string{
+		fmt.Sprintf("Timeline (%s buckets, %s to %s):", summary.BucketSize, buckets[0].Start.Format(layout), buckets[len(buckets)-1].Start.Format(layout)),
+		fmt.Sprintf("  all     |%s| max %d", sparkline(totals), maxTotal),
+		fmt.Sprintf("  errors  |%s| max %d", sparkline(errCounts), errCounts[peak]),
+	}
+	if errCounts[peak] > 0 {
+		line := fmt.Sprintf("  Errors peaked at %s: %d of %d entries", buckets[peak].Start.Format(layout), errCounts[peak], buckets[peak].TotalCount)
+		if buckets[peak].TopSubsystem != "" {
+			line += fmt.Sprintf(" (top subsystem %s)", buckets[peak].TopSubsystem)
+		}
+		lines = append(lines, line)
+	}
+	for _, line := range lines {
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -48,6 +48,10 @@ func (c *LaunchCmd) Run(globals *Globals) error {
 		return c.outputError(globals, "INVALID_FLAGS", "--simulator and --booted are mutually exclusive")
 	}
 
+	if err := rejectSourceFile(globals, "launch"); err != nil {
+		return err
+	}
+
 	// Find the simulator
 	mgr := simulator.NewManager()
 	device, err := resolveSimulatorDevice(ctx, mgr, c.Simulator, c.Booted)
@@ -1,7 +1,6 @@
 package cli
 
 import (
-	"context"
 	"encoding/json"
 	"fmt"
 	"strings"
@@ -10,7 +9,6 @@ import (
 	"github.com/olekukonko/tablewriter/tw"
 	"github.com/vburojevic/xcw/internal/domain"
 	"github.com/vburojevic/xcw/internal/output"
-	"github.com/vburojevic/xcw/internal/simulator"
 )
 
 // ListCmd lists available simulators
@@ -21,8 +19,8 @@ type ListCmd struct {
 
 // Run executes the list command
 func (c *ListCmd) Run(globals *Globals) error {
-	ctx := context.Background()
-	mgr := simulator.NewManager()
+	ctx := globals.baseContext()
+	mgr := newSimulatorManager(globals)
 
 	var devices []domain.Device
 	var err error
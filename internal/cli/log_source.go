@@ -0,0 +1,26 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/vburojevic/xcw/internal/simulator"
+)
+
+// newSimulatorManager returns a manager backed by the log source selected via
+// --source-file (a recorded capture), or xcrun by default.
+func newSimulatorManager(globals *Globals) *simulator.Manager {
+	if globals != nil && globals.SourceFile != "" {
+		return simulator.NewManagerWithSource(simulator.NewFileSource(globals.SourceFile))
+	}
+	return simulator.NewManager()
+}
+
+// rejectSourceFile fails commands that drive simctl directly (installing,
+// listing or launching apps), which a recorded capture cannot stand in for.
+func rejectSourceFile(globals *Globals, command string) error {
+	if globals == nil || globals.SourceFile == "" {
+		return nil
+	}
+	return outputErrorCommon(globals, "INVALID_FLAGS", fmt.Sprintf("--source-file is not supported by %s (it needs simctl)", command),
+		"run it on a Mac with Xcode, or drop --source-file")
+}
@@ -0,0 +1,153 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vburojevic/xcw/internal/config"
+)
+
+func TestQueryFromSourceFile(t *testing.T) {
+	// No xcrun on PATH: the recorded capture is the only log source.
+	t.Setenv("PATH", t.TempDir())
+
+	capture := filepath.Join(t.TempDir(), "capture.ndjson")
+	lines := []string{
+		`{"timestamp":"2025-12-15 00:00:00.000000+0000","messageType":"Error","processImagePath":"/Applications/MyApp.app/MyApp","processID":123,"threadID":1,"subsystem":"com.example.myapp","category":"network","eventMessage":"Request failed","eventType":"logEvent"}`,
+		`{"timestamp":"2025-12-15 00:00:01.000000+0000","messageType":"Error","processImagePath":"/usr/libexec/other","processID":7,"threadID":1,"subsystem":"com.apple.other","category":"misc","eventMessage":"Unrelated","eventType":"logEvent"}`,
+	}
+	require.NoError(t, os.WriteFile(capture, []byte(strings.Join(lines, "\n")+"\n"), 0o644))
+
+	var stdout, stderr bytes.Buffer
+	globals := &Globals{
+		Format:     "ndjson",
+		Level:      "debug",
+		Quiet:      true,
+		Stdout:     &stdout,
+		Stderr:     &stderr,
+		Config:     config.Default(),
+		SourceFile: capture,
+	}
+	cmd := &QueryCmd{
+		Booted: true,
+		App:    "com.example.myapp",
+		Since:  "5m",
+		Limit:  10,
+	}
+	require.NoError(t, cmd.Run(globals))
+
+	var messages []string
+	for _, line := range strings.Split(strings.TrimSpace(stdout.String()), "\n") {
+		var v map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &v))
+		if v["type"] == "log" {
+			messages = append(messages, v["message"].(string))
+		}
+	}
+	require.Equal(t, []string{"Request failed"}, messages)
+}
+
+func TestQueryExtractFieldsFromSourceFile(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	capture := filepath.Join(t.TempDir(), "capture.ndjson")
+	lines := []string{
+		`{"timestamp":"2025-12-15 00:00:00.000000+0000","messageType":"Default","processImagePath":"/Applications/MyApp.app/MyApp","processID":123,"threadID":1,"subsystem":"com.example.myapp","category":"network","eventMessage":"request_id=a1 status=200","eventType":"logEvent"}`,
+		`{"timestamp":"2025-12-15 00:00:01.000000+0000","messageType":"Default","processImagePath":"/Applications/MyApp.app/MyApp","processID":123,"threadID":1,"subsystem":"com.example.myapp","category":"network","eventMessage":"request_id=b2 status=502","eventType":"logEvent"}`,
+	}
+	require.NoError(t, os.WriteFile(capture, []byte(strings.Join(lines, "\n")+"\n"), 0o644))
+
+	globals, stdout, _ := testGlobals("ndjson")
+	globals.Level = "debug"
+	globals.SourceFile = capture
+	cmd := &QueryCmd{
+		Booted:  true,
+		App:     "com.example.myapp",
+		Since:   "5m",
+		Limit:   10,
+		Extract: []string{"logfmt"},
+		Where:   []string{"fields.status>=500"},
+	}
+	require.NoError(t, cmd.Run(globals))
+
+	var logs []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(stdout.String()), "\n") {
+		var v map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &v))
+		if v["type"] == "log" {
+			logs = append(logs, v)
+		}
+	}
+	require.Len(t, logs, 1)
+	require.Equal(t, map[string]any{"request_id": "b2", "status": "502"}, logs[0]["fields"])
+}
+
+func TestListAndAppsWithSourceFile(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	capture := filepath.Join(t.TempDir(), "capture.ndjson")
+	require.NoError(t, os.WriteFile(capture, []byte(`{"timestamp":"2025-12-15 00:00:00.000000+0000","messageType":"Default","processImagePath":"/Applications/MyApp.app/MyApp","processID":1,"eventMessage":"hi","eventType":"logEvent"}`+"\n"), 0o644))
+
+	var stdout bytes.Buffer
+	globals := &Globals{Format: "ndjson", Level: "debug", Stdout: &stdout, Stderr: &bytes.Buffer{}, Config: config.Default(), SourceFile: capture}
+	require.NoError(t, (&ListCmd{}).Run(globals))
+	require.Contains(t, stdout.String(), `"type":"simulator"`)
+
+	stdout.Reset()
+	require.Error(t, (&AppsCmd{Booted: true}).Run(globals))
+	require.Contains(t, stdout.String(), "--source-file is not supported by apps")
+}
+
+func TestTailAnomaliesFromSourceFile(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	// One error every 10s for five minutes, then a burst: a spike only when
+	// windows follow the recorded timestamps rather than the wall clock.
+	base := time.Date(2025, 12, 15, 0, 0, 0, 0, time.UTC)
+	var lines []string
+	add := func(at time.Duration, msg string) {
+		lines = append(lines, fmt.Sprintf(`{"timestamp":%q,"messageType":"Error","processImagePath":"/Applications/MyApp.app/MyApp","processID":123,"threadID":1,"subsystem":"com.example.myapp","category":"network","eventMessage":%q,"eventType":"logEvent"}`,
+			base.Add(at).Format("2006-01-02 15:04:05.000000-0700"), msg))
+	}
+	for i := 0; i < 30; i++ {
+		add(time.Duration(i)*10*time.Second, "Request failed")
+	}
+	for i := 0; i < 20; i++ {
+		add(300*time.Second+time.Duration(i)*100*time.Millisecond, "Request failed")
+	}
+	add(320*time.Second, "Request failed")
+	capture := filepath.Join(t.TempDir(), "capture.ndjson")
+	require.NoError(t, os.WriteFile(capture, []byte(strings.Join(lines, "\n")+"\n"), 0o644))
+
+	globals, _, _ := testGlobals("ndjson")
+	globals.SourceFile = capture
+	out := &syncBuffer{}
+	globals.Stdout = out
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	globals.Context = ctx
+
+	cmd := &TailCmd{App: "com.example.myapp", BufferSize: 100}
+	cmd.NoAgentHints = true
+	cmd.Anomalies = true
+	done := make(chan error, 1)
+	go func() { done <- cmd.Run(globals) }()
+
+	require.Eventually(t, func() bool {
+		for _, m := range out.messages(t) {
+			if m["type"] == "anomaly" && m["kind"] == "error_spike" && m["subsystem"] == nil {
+				return m["timestamp"] == "2025-12-15T00:05:10Z"
+			}
+		}
+		return false
+	}, 5*time.Second, 20*time.Millisecond)
+	cancel()
+	<-done
+}
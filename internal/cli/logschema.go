@@ -21,15 +21,20 @@ func (c *LogSchemaCmd) Run(globals *Globals) error {
 		Type:          "log_schema",
 		SchemaVersion: output.SchemaVersion,
 		Fields: map[string]string{
-			"timestamp": "ISO8601 UTC",
-			"level":     "Debug|Info|Default|Error|Fault",
-			"process":   "Process name",
-			"pid":       "Process ID",
-			"subsystem": "Subsystem (bundle id)",
-			"category":  "Category",
-			"message":   "Log message",
-			"session":   "Session number",
-			"tail_id":   "Tail invocation identifier",
+			"timestamp":          "ISO8601 UTC",
+			"level":              "Debug|Info|Default|Error|Fault",
+			"process":            "Process name",
+			"pid":                "Process ID",
+			"subsystem":          "Subsystem (bundle id)",
+			"category":           "Category",
+			"message":            "Log message",
+			"session":            "Session number",
+			"tail_id":            "Tail invocation identifier",
+			"activity_id":        "os_activity identifier (when logged under an activity)",
+			"parent_activity_id": "Parent os_activity identifier",
+			"os_trace_id":        "Unified logging trace identifier",
+			"trace_id":           "Distributed trace/request ID from the message (--extract-trace)",
+			"span_id":            "Span ID from the message (--extract-trace)",
 		},
 		Example: map[string]interface{}{
 			"type":          "log",
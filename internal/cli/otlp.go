@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/vburojevic/xcw/internal/domain"
+	"github.com/vburojevic/xcw/internal/otlp"
+)
+
+// otlpCloseTimeout bounds how long tail/watch wait on exit for queued entries
+// to reach the collector.
+const otlpCloseTimeout = 5 * time.Second
+
+// OTLPFlags groups OpenTelemetry log export flags shared by tail and watch.
+type OTLPFlags struct {
+	OTLPEndpoint string   `name:"otlp-endpoint" help:"Forward emitted log entries as OTLP logs to this collector (e.g., 'http://localhost:4318'; 'localhost:4317' with --otlp-protocol grpc)"`
+	OTLPProtocol string   `name:"otlp-protocol" default:"http/json" enum:"http/json,grpc" help:"OTLP transport: http/json or grpc"`
+	OTLPHeader   []string `name:"otlp-header" help:"Header or gRPC metadata sent with each export as key=value (can be repeated; e.g., 'authorization=Bearer TOKEN')"`
+}
+
+// newExporter starts an OTLP exporter, or returns nil when no endpoint is set.
+// The app and device become resource attributes; tail_id and session are
+// added per entry.
+func (f *OTLPFlags) newExporter(app, version, build string, device *domain.Device) (*otlp.Exporter, error) {
+	if f.OTLPEndpoint == "" {
+		return nil, nil
+	}
+	headers, err := f.headers()
+	if err != nil {
+		return nil, err
+	}
+	service := app
+	if service == "" {
+		service = "xcw"
+	}
+	resource := map[string]string{
+		"service.name":    service,
+		"service.version": version,
+		"app.build":       build,
+	}
+	if device != nil {
+		resource["device.id"] = device.UDID
+		resource["device.model.name"] = device.Name
+		resource["xcw.runtime"] = device.RuntimeIdentifier
+	}
+	return otlp.New(otlp.Config{
+		Endpoint:     f.OTLPEndpoint,
+		Protocol:     f.OTLPProtocol,
+		Headers:      headers,
+		Resource:     resource,
+		ScopeVersion: Version,
+	})
+}
+
+// headers parses --otlp-header values. It is also used to validate the flags
+// before anything is started.
+func (f *OTLPFlags) headers() (map[string]string, error) {
+	headers := make(map[string]string, len(f.OTLPHeader))
+	for _, h := range f.OTLPHeader {
+		k, v, ok := strings.Cut(h, "=")
+		if !ok || strings.TrimSpace(k) == "" {
+			return nil, fmt.Errorf("invalid --otlp-header %q: expected key=value", h)
+		}
+		headers[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return headers, nil
+}
+
+// closeExporter flushes exp and reports through warn when entries did not
+// reach the collector.
+func closeExporter(globals *Globals, exp *otlp.Exporter, warn func(msg string)) {
+	if exp == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), otlpCloseTimeout)
+	defer cancel()
+	if err := exp.Close(ctx); err != nil {
+		globals.Debug("OTLP exporter close: %v", err)
+	}
+	stats := exp.Stats()
+	globals.Debug("OTLP export: %d exported, %d dropped, %d failed", stats.Exported, stats.Dropped, stats.Failed)
+	if stats.Dropped > 0 || stats.Failed > 0 {
+		msg := fmt.Sprintf("otlp_export_incomplete exported=%d dropped=%d failed=%d", stats.Exported, stats.Dropped, stats.Failed)
+		if stats.LastErr != nil {
+			msg += fmt.Sprintf(" err=%s", stats.LastErr)
+		}
+		warn(msg)
+	}
+}
@@ -102,7 +102,7 @@ func (c *PickCmd) Run(globals *Globals) error {
 }
 
 func (c *PickCmd) pickSimulator(ctx context.Context, globals *Globals) error {
-	mgr := simulator.NewManager()
+	mgr := newSimulatorManager(globals)
 	devices, err := mgr.ListDevices(ctx)
 	if err != nil {
 		return c.outputError(globals, "LIST_FAILED", err.Error())
@@ -137,6 +137,9 @@ func (c *PickCmd) pickSimulator(ctx context.Context, globals *Globals) error {
 }
 
 func (c *PickCmd) pickApp(ctx context.Context, globals *Globals) error {
+	if err := rejectSourceFile(globals, "pick app"); err != nil {
+		return err
+	}
 	mgr := simulator.NewManager()
 
 	// Find simulator for app listing
@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/vburojevic/xcw/internal/config"
+)
+
+// presetTarget points at the filter fields of a command that --preset expands into.
+type presetTarget struct {
+	Pattern          *string
+	Exclude          *[]string
+	ExcludeSubsystem *[]string
+	Subsystem        *[]string
+	Category         *[]string
+	Process          *[]string
+	Where            *[]string
+	MinLevel         *string
+	MaxLevel         *string
+}
+
+// applyPresets expands the named filter presets (from config "filters:") into dst.
+// List filters (subsystems, categories, processes, excludes) are appended, so
+// a value from any preset or flag matches: OR within one list filter, AND
+// across filters. Where clauses are appended too and are all ANDed. The first
+// pattern fills an empty --pattern; further patterns become message~ where
+// clauses, so every pattern must match. Levels given on the command line win;
+// otherwise the last preset that sets a level wins.
+func applyPresets(cfg *config.Config, names []string, dst presetTarget) error {
+	minFromFlag := *dst.MinLevel != ""
+	maxFromFlag := *dst.MaxLevel != ""
+
+	for _, name := range names {
+		p, ok := cfg.Preset(name)
+		if !ok {
+			return fmt.Errorf("unknown filter preset %q", name)
+		}
+
+		if p.Pattern != "" {
+			if *dst.Pattern == "" {
+				*dst.Pattern = p.Pattern
+			} else if *dst.Pattern != p.Pattern {
+				*dst.Where = append(*dst.Where, "message~"+strconv.Quote(p.Pattern))
+			}
+		}
+		*dst.Exclude = append(*dst.Exclude, p.Exclude...)
+		*dst.ExcludeSubsystem = append(*dst.ExcludeSubsystem, p.ExcludeSubsystems...)
+		*dst.Subsystem = append(*dst.Subsystem, p.Subsystems...)
+		*dst.Category = append(*dst.Category, p.Categories...)
+		*dst.Process = append(*dst.Process, p.Processes...)
+		*dst.Where = append(*dst.Where, p.Where...)
+		if p.MinLevel != "" && !minFromFlag {
+			*dst.MinLevel = p.MinLevel
+		}
+		if p.MaxLevel != "" && !maxFromFlag {
+			*dst.MaxLevel = p.MaxLevel
+		}
+	}
+	return nil
+}
+
+func hintForPreset(cfg *config.Config) string {
+	names := cfg.PresetNames()
+	if len(names) == 0 {
+		return "Define presets under 'filters:' in .xcw.yaml (see `xcw config generate`)"
+	}
+	return "Available presets: " + strings.Join(names, ", ") + " (see `xcw config show`)"
+}
@@ -0,0 +1,153 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vburojevic/xcw/internal/config"
+)
+
+func presetTestConfig() *config.Config {
+	cfg := config.Default()
+	cfg.Filters = map[string]config.FilterPreset{
+		"networking": {
+			Pattern:    "HTTP",
+			Subsystems: []string{"com.example.network"},
+			Where:      []string{"fields.status>=500"},
+			MinLevel:   "info",
+		},
+		"no-noise": {
+			Pattern:           "request",
+			Exclude:           []string{"heartbeat"},
+			ExcludeSubsystems: []string{"com.apple.*"},
+			MinLevel:          "error",
+		},
+	}
+	return cfg
+}
+
+func TestApplyPresets(t *testing.T) {
+	cfg := presetTestConfig()
+
+	t.Run("merges presets in order", func(t *testing.T) {
+		var pattern, minLevel, maxLevel string
+		var exclude, excludeSub, subsystem, category, process []string
+		where := []string{"level!=debug"}
+		err := applyPresets(cfg, []string{"Networking", "no-noise"}, presetTarget{
+			Pattern: &pattern, Exclude: &exclude, ExcludeSubsystem: &excludeSub,
+			Subsystem: &subsystem, Category: &category, Process: &process,
+			Where: &where, MinLevel: &minLevel, MaxLevel: &maxLevel,
+		})
+		require.NoError(t, err)
+		require.Equal(t, "HTTP", pattern)
+		require.Equal(t, []string{"level!=debug", "fields.status>=500", `message~"request"`}, where)
+		require.Equal(t, []string{"heartbeat"}, exclude)
+		require.Equal(t, []string{"com.apple.*"}, excludeSub)
+		require.Equal(t, []string{"com.example.network"}, subsystem)
+		require.Equal(t, "error", minLevel, "later preset wins")
+		require.Empty(t, maxLevel)
+	})
+
+	t.Run("list filters from flags and presets are merged", func(t *testing.T) {
+		var pattern, minLevel, maxLevel string
+		var exclude, excludeSub, category, process, where []string
+		subsystem := []string{"com.example.ui"}
+		err := applyPresets(cfg, []string{"networking"}, presetTarget{
+			Pattern: &pattern, Exclude: &exclude, ExcludeSubsystem: &excludeSub,
+			Subsystem: &subsystem, Category: &category, Process: &process,
+			Where: &where, MinLevel: &minLevel, MaxLevel: &maxLevel,
+		})
+		require.NoError(t, err)
+		// Either subsystem matches (OR), while the preset's where clause still applies (AND)
+		require.Equal(t, []string{"com.example.ui", "com.example.network"}, subsystem)
+		require.Equal(t, []string{"fields.status>=500"}, where)
+	})
+
+	t.Run("flags win over preset levels", func(t *testing.T) {
+		pattern, minLevel, maxLevel := "", "debug", ""
+		var exclude, excludeSub, subsystem, category, process, where []string
+		err := applyPresets(cfg, []string{"networking"}, presetTarget{
+			Pattern: &pattern, Exclude: &exclude, ExcludeSubsystem: &excludeSub,
+			Subsystem: &subsystem, Category: &category, Process: &process,
+			Where: &where, MinLevel: &minLevel, MaxLevel: &maxLevel,
+		})
+		require.NoError(t, err)
+		require.Equal(t, "debug", minLevel)
+	})
+
+	t.Run("unknown preset", func(t *testing.T) {
+		var pattern, minLevel, maxLevel string
+		var exclude, excludeSub, subsystem, category, process, where []string
+		err := applyPresets(cfg, []string{"missing"}, presetTarget{
+			Pattern: &pattern, Exclude: &exclude, ExcludeSubsystem: &excludeSub,
+			Subsystem: &subsystem, Category: &category, Process: &process,
+			Where: &where, MinLevel: &minLevel, MaxLevel: &maxLevel,
+		})
+		require.EqualError(t, err, `unknown filter preset "missing"`)
+		require.Equal(t, "Available presets: networking, no-noise (see `xcw config show`)", hintForPreset(cfg))
+	})
+}
+
+func TestQueryPresetDryRunJSON(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	capture := filepath.Join(t.TempDir(), "capture.ndjson")
+	require.NoError(t, os.WriteFile(capture, nil, 0o644))
+
+	globals, stdout, _ := testGlobals("ndjson")
+	globals.SourceFile = capture
+	globals.Config = presetTestConfig()
+	cmd := &QueryCmd{
+		Booted:     true,
+		App:        "com.example.myapp",
+		Since:      "5m",
+		Limit:      10,
+		Preset:     []string{"networking"},
+		DryRunJSON: true,
+	}
+	require.NoError(t, cmd.Run(globals))
+
+	var opts map[string]any
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &opts))
+	require.Equal(t, "HTTP", opts["Pattern"])
+	require.Equal(t, []any{"com.example.network"}, opts["Subsystems"])
+	require.Equal(t, []any{"fields.status>=500"}, opts["Where"])
+	require.Equal(t, "Info", opts["MinLevel"])
+	require.Equal(t, []any{"networking"}, opts["Presets"])
+}
+
+func TestQueryUnknownPreset(t *testing.T) {
+	globals, stdout, _ := testGlobals("ndjson")
+	globals.Config = presetTestConfig()
+	cmd := &QueryCmd{Booted: true, App: "com.example.myapp", Since: "5m", Preset: []string{"nope"}}
+	require.Error(t, cmd.Run(globals))
+	require.Contains(t, stdout.String(), `"code":"INVALID_PRESET"`)
+	require.Contains(t, stdout.String(), "Available presets: networking, no-noise")
+}
+
+func TestConfigShowListsPresets(t *testing.T) {
+	globals, stdout, _ := testGlobals("ndjson")
+	globals.Config = presetTestConfig()
+	globals.ConfigFile = "/tmp/.xcw.yaml"
+	globals.ConfigSources = map[string]string{}
+	require.NoError(t, (&ConfigShowCmd{}).Run(globals))
+
+	var out struct {
+		Presets []presetInfo `json:"presets"`
+	}
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &out))
+	require.Len(t, out.Presets, 2)
+	require.Equal(t, "networking", out.Presets[0].Name)
+	require.Equal(t, "/tmp/.xcw.yaml", out.Presets[0].Source)
+	require.Equal(t, []string{"fields.status>=500"}, out.Presets[0].Where)
+
+	textGlobals, textOut, _ := testGlobals("text")
+	textGlobals.Config = presetTestConfig()
+	textGlobals.ConfigFile = "/tmp/.xcw.yaml"
+	textGlobals.ConfigSources = map[string]string{}
+	require.NoError(t, (&ConfigShowCmd{}).Run(textGlobals))
+	require.True(t, strings.Contains(textOut.String(), "  no-noise (/tmp/.xcw.yaml)\n    pattern: request\n    exclude: heartbeat"))
+}
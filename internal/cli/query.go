@@ -1,58 +1,76 @@
 package cli
 
 import (
-	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/vburojevic/xcw/internal/config"
 	"github.com/vburojevic/xcw/internal/domain"
+	"github.com/vburojevic/xcw/internal/filter"
 	"github.com/vburojevic/xcw/internal/output"
 	"github.com/vburojevic/xcw/internal/simulator"
 )
 
 // QueryCmd queries historical logs from a simulator
 type QueryCmd struct {
-	Simulator        string   `short:"s" help:"Simulator name or UDID"`
-	Booted           bool     `short:"b" help:"Use booted simulator (error if multiple)"`
-	App              string   `short:"a" help:"App bundle identifier to filter logs (required unless --predicate or --all)"`
-	All              bool     `help:"Allow querying without --app/--predicate (can be very noisy)"`
-	Since            string   `default:"5m" help:"How far back to query (e.g., '5m', '1h', '30s')"`
-	Until            string   `help:"End time for query (RFC3339 or relative like '1m')"`
-	Pattern          string   `short:"p" aliases:"filter" help:"Regex pattern to filter log messages"`
-	Exclude          []string `short:"x" help:"Regex pattern to exclude from log messages (can be repeated)"`
-	ExcludeSubsystem []string `help:"Exclude logs from subsystem (can be repeated, supports * wildcard)"`
-	Limit            int      `default:"1000" help:"Maximum number of logs to return"`
-	Subsystem        []string `help:"Filter by subsystem (can be repeated)"`
-	Category         []string `help:"Filter by category (can be repeated)"`
-	Process          []string `help:"Filter by process name (can be repeated)"`
-	MinLevel         string   `help:"Minimum log level: debug, info, default, error, fault (overrides global --level)"`
-	MaxLevel         string   `help:"Maximum log level: debug, info, default, error, fault (optional; unset = no max)"`
-	Predicate        string   `help:"Raw NSPredicate filter (overrides --app, --subsystem, --category)"`
-	DryRunJSON       bool     `help:"Print resolved query options as JSON and exit (no query; ndjson output only)"`
-	Analyze          bool     `help:"Include AI-friendly analysis summary"`
-	PersistPatterns  bool     `help:"Save detected patterns for future reference (marks new vs known)"`
-	PatternFile      string   `help:"Custom pattern file path (default: ~/.xcw/patterns.json)"`
-	Where            []string `short:"w" help:"Field filter expression (supports AND/OR/NOT, parentheses). Operators: =, !=, ~, !~, >=, <=, ^, $. Regex literals: /pattern/i"`
+	Simulator         string   `short:"s" help:"Simulator name or UDID"`
+	Booted            bool     `short:"b" help:"Use booted simulator (error if multiple)"`
+	App               string   `short:"a" help:"App bundle identifier to filter logs (required unless --predicate or --all)"`
+	All               bool     `help:"Allow querying without --app/--predicate (can be very noisy)"`
+	Since             string   `default:"5m" help:"How far back to query (e.g., '5m', '1h', '30s')"`
+	Until             string   `help:"End time for query (RFC3339 or relative like '1m')"`
+	Pattern           string   `short:"p" aliases:"filter" help:"Regex pattern to filter log messages"`
+	Exclude           []string `short:"x" help:"Regex pattern to exclude from log messages (can be repeated)"`
+	ExcludeSubsystem  []string `help:"Exclude logs from subsystem (can be repeated, supports * wildcard)"`
+	Limit             int      `default:"1000" help:"Maximum number of logs to return"`
+	Subsystem         []string `help:"Filter by subsystem (can be repeated)"`
+	Category          []string `help:"Filter by category (can be repeated)"`
+	Process           []string `help:"Filter by process name (can be repeated)"`
+	MinLevel          string   `help:"Minimum log level: debug, info, default, error, fault (overrides global --level)"`
+	MaxLevel          string   `help:"Maximum log level: debug, info, default, error, fault (optional; unset = no max)"`
+	Predicate         string   `help:"Raw NSPredicate filter (overrides --app, --subsystem, --category)"`
+	DryRunJSON        bool     `help:"Print resolved query options as JSON and exit (no query; ndjson output only)"`
+	Analyze           bool     `help:"Include AI-friendly analysis summary"`
+	PersistPatterns   bool     `help:"Save detected patterns for future reference (marks new vs known)"`
+	PatternFile       string   `help:"Custom pattern file path (default: ~/.xcw/patterns.json)"`
+	Where             []string `short:"w" help:"Field filter expression (supports AND/OR/NOT, parentheses). Operators: =, !=, ~, !~, >, >=, <, <=, ^, $, in (...). Fields include timestamp (now-30s), len(message), fields.<key>. Regex literals: /pattern/i"`
+	Preset            []string `help:"Apply a named filter preset from the config 'filters:' section (can be repeated)"`
+	Extract           []string `help:"Extract key/value fields from messages into 'fields': logfmt, json, regex (can be repeated). Use in --where as fields.<key>"`
+	ExtractRegex      []string `help:"Regex with named groups for field extraction, e.g. 'took (?P<ms>[0-9]+)ms' (can be repeated; implies --extract regex)"`
+	ExtractTrace      []string `help:"Extract distributed trace context from messages into trace_id/span_id: traceparent (W3C), regex (can be repeated). Use in --where as trace_id"`
+	ExtractTraceRegex []string `help:"Regex with a (?P<trace>...) group and optional (?P<span>...)/(?P<parent>...) groups, e.g. 'request_id=(?P<trace>[0-9a-f-]+)' (can be repeated; implies --extract-trace regex)"`
+	Group             bool     `help:"Coalesce multi-line messages (stack traces, exception dumps) from the same pid/tid into one 'log_group' event"`
+	GroupWindow       string   `help:"Maximum gap between continuation lines for --group (default: 10ms)"`
+
+	ReportFlags
 }
 
 // Run executes the query command
 func (c *QueryCmd) Run(globals *Globals) error {
 	applyQueryDefaults(globals.Config, c)
 
-	ctx := context.Background()
+	ctx := globals.baseContext()
 
 	// Validate mutual exclusivity of flags
 	if globals.FlagProvided("simulator") && globals.FlagProvided("booted") {
 		return c.outputError(globals, "INVALID_FLAGS", "--simulator and --booted are mutually exclusive")
 	}
+	if err := applyPresets(globals.Config, c.Preset, presetTarget{
+		Pattern: &c.Pattern, Exclude: &c.Exclude, ExcludeSubsystem: &c.ExcludeSubsystem,
+		Subsystem: &c.Subsystem, Category: &c.Category, Process: &c.Process,
+		Where: &c.Where, MinLevel: &c.MinLevel, MaxLevel: &c.MaxLevel,
+	}); err != nil {
+		return c.outputError(globals, "INVALID_PRESET", err.Error(), hintForPreset(globals.Config))
+	}
 	if err := validateAppPredicateAll(c.App, c.Predicate, c.All, len(c.Subsystem) > 0 || len(c.Category) > 0); err != nil {
 		return outputErrorCommon(globals, err.Code, err.Message, err.Hint)
 	}
 
 	// Find the simulator
-	mgr := simulator.NewManager()
+	mgr := newSimulatorManager(globals)
 	device, err := resolveSimulatorDevice(ctx, mgr, c.Simulator, c.Booted)
 	if err != nil {
 		return c.outputError(globals, "DEVICE_NOT_FOUND", err.Error(), hintForStreamOrQuery(err))
@@ -79,10 +97,25 @@ func (c *QueryCmd) Run(globals *Globals) error {
 	if err != nil {
 		return c.outputError(globals, "INVALID_FILTER", err.Error(), hintForFilter(err))
 	}
+	extractor, err := filter.NewFieldExtractor(c.Extract, c.ExtractRegex)
+	if err != nil {
+		return c.outputError(globals, "INVALID_FILTER", err.Error(), hintForExtract(err))
+	}
+	traceExtractor, err := filter.NewTraceExtractor(c.ExtractTrace, c.ExtractTraceRegex)
+	if err != nil {
+		return c.outputError(globals, "INVALID_FILTER", err.Error(), hintForExtractTrace(err))
+	}
+	grouper, err := buildCoalescer(c.Group, c.GroupWindow)
+	if err != nil {
+		return c.outputError(globals, "INVALID_GROUP_WINDOW", err.Error(), "use a positive duration such as '10ms' or '50ms'")
+	}
+	if err := c.validateReport(globals); err != nil {
+		return err
+	}
 
-	// Output query info if not quiet
+	// Output query info if not quiet (stdout stays a valid report when --report replaces it)
 	if !globals.Quiet && !c.DryRunJSON {
-		if globals.Format == "ndjson" {
+		if globals.Format == "ndjson" && !c.reportReplacesOutput() {
 			if err := output.NewNDJSONWriter(globals.Stdout).WriteInfo(
 				fmt.Sprintf("Querying logs from %s", device.Name),
 				device.Name, device.UDID, c.Since, ""); err != nil {
@@ -105,7 +138,7 @@ func (c *QueryCmd) Run(globals *Globals) error {
 	minLevel, maxLevel := resolveLevels(c.MinLevel, c.MaxLevel, globals.Level)
 
 	// Create query reader
-	reader := simulator.NewQueryReader()
+	reader := simulator.NewQueryReaderWithSource(mgr.Source())
 	var diagEmitter *output.Emitter
 	if globals.Format == "ndjson" {
 		diagEmitter = output.NewEmitter(globals.Stdout)
@@ -135,6 +168,10 @@ func (c *QueryCmd) Run(globals *Globals) error {
 		if globals.Format != "ndjson" {
 			return c.outputError(globals, "INVALID_FLAGS", "--dry-run-json requires ndjson output", "add --format ndjson or remove --dry-run-json")
 		}
+		var groupWindow string
+		if grouper != nil {
+			groupWindow = grouper.Window().String()
+		}
 		enc := json.NewEncoder(globals.Stdout)
 		enc.SetIndent("", "  ")
 		return enc.Encode(struct {
@@ -152,6 +189,9 @@ func (c *QueryCmd) Run(globals *Globals) error {
 			Limit             int
 			RawPredicate      string
 			Where             []string
+			Extract           []string
+			Presets           []string `json:",omitempty"`
+			GroupWindow       string   `json:",omitempty"`
 		}{
 			BundleID:          c.App,
 			Subsystems:        c.Subsystem,
@@ -167,6 +207,9 @@ func (c *QueryCmd) Run(globals *Globals) error {
 			Limit:             c.Limit,
 			RawPredicate:      c.Predicate,
 			Where:             c.Where,
+			Extract:           extractor.Modes(),
+			Presets:           c.Preset,
+			GroupWindow:       groupWindow,
 		})
 	}
 
@@ -179,6 +222,19 @@ func (c *QueryCmd) Run(globals *Globals) error {
 	}
 	globals.Debug("Query returned %d entries", len(entries))
 
+	// Coalesce multi-line messages before extraction so fields and where see the whole group.
+	if grouper != nil {
+		entries = filter.CoalesceEntries(entries, grouper.Window())
+		globals.Debug("After grouping: %d entries", len(entries))
+	}
+
+	if extractor != nil || traceExtractor != nil {
+		for i := range entries {
+			extractor.Extract(&entries[i])
+			traceExtractor.Extract(&entries[i])
+		}
+	}
+
 	// Apply where filter after the query reader's filtering (pattern/exclude are already applied there).
 	if whereFilter != nil {
 		globals.Debug("Where filter: %d clause(s)", len(c.Where))
@@ -192,6 +248,38 @@ func (c *QueryCmd) Run(globals *Globals) error {
 		entries = filtered
 	}
 
+	// Analyze once so output and --report share a single pattern store update (--report implies --analyze)
+	var (
+		analyzer *output.Analyzer
+		summary  *domain.LogSummary
+		patterns []output.PatternMatch
+		enhanced []output.EnhancedPatternMatch
+	)
+	if c.Analyze || c.Report != "" {
+		var err error
+		analyzer, err = newAnalyzer(globals)
+		if err != nil {
+			return c.outputError(globals, "INVALID_PATTERN", err.Error(), "check the patterns section of your config")
+		}
+		summary = analyzer.Summarize(entries)
+		patterns = analyzer.DetectPatterns(entries)
+		if c.PersistPatterns {
+			store := output.NewPatternStore(c.PatternFile)
+			enhanced = store.RecordPatterns(patterns)
+			if err := store.Save(); err != nil {
+				globals.Debug("Failed to save patterns: %v", err)
+			}
+		}
+	}
+	if c.Report != "" {
+		if err := c.writeReport(globals, output.NewEnhancedSummaryOutput(summary, reportPatterns(patterns, enhanced)), "xcw query"); err != nil {
+			return err
+		}
+		if c.reportReplacesOutput() {
+			return nil
+		}
+	}
+
 	// Create output writer
 	if globals.Format == "ndjson" {
 		writer := output.NewNDJSONWriter(globals.Stdout)
@@ -205,23 +293,15 @@ func (c *QueryCmd) Run(globals *Globals) error {
 
 		// Output analysis if requested
 		if c.Analyze {
-			analyzer := output.NewAnalyzer()
-			summary := analyzer.Summarize(entries)
-			patterns := analyzer.DetectPatterns(entries)
-
 			if c.PersistPatterns {
-				// Use pattern store for enhanced analysis
-				store := output.NewPatternStore(c.PatternFile)
-				enhanced := store.RecordPatterns(patterns)
-				if err := store.Save(); err != nil {
-					globals.Debug("Failed to save patterns: %v", err)
-				}
 				analysisOutput := output.NewEnhancedSummaryOutput(summary, enhanced)
+				analysisOutput.Fields = analyzer.SummarizeFields(entries, 0)
 				if err := writer.WriteRaw(analysisOutput); err != nil {
 					return err
 				}
 			} else {
 				analysisOutput := output.NewSummaryOutput(summary, patterns)
+				analysisOutput.Fields = analyzer.SummarizeFields(entries, 0)
 				if err := writer.WriteRaw(analysisOutput); err != nil {
 					return err
 				}
@@ -246,20 +326,11 @@ func (c *QueryCmd) Run(globals *Globals) error {
 		}
 
 		if c.Analyze {
-			analyzer := output.NewAnalyzer()
-			summary := analyzer.Summarize(entries)
-			patterns := analyzer.DetectPatterns(entries)
 			if _, err := fmt.Fprintf(globals.Stdout, "Errors: %d, Faults: %d\n", summary.ErrorCount, summary.FaultCount); err != nil {
 				return err
 			}
 
 			if c.PersistPatterns && len(patterns) > 0 {
-				store := output.NewPatternStore(c.PatternFile)
-				enhanced := store.RecordPatterns(patterns)
-				if err := store.Save(); err != nil {
-					globals.Debug("Failed to save patterns: %v", err)
-				}
-
 				if _, err := fmt.Fprintln(globals.Stdout, "\nError Patterns:"); err != nil {
 					return err
 				}
@@ -321,7 +392,7 @@ func applyQueryDefaults(cfg *config.Config, c *QueryCmd) {
 }
 
 // parseTimeOrDuration parses a time string as either RFC3339 or a duration offset from now
-// Examples: "2024-01-15T10:30:00Z" (absolute), "5m" (5 minutes ago), "1h" (1 hour ago)
+// Examples: "2024-01-15T10:30:00Z" (absolute), "5m" (5 minutes ago), "1h" (1 hour ago), "7d" (7 days ago)
 func parseTimeOrDuration(s string) (time.Time, error) {
 	// Try RFC3339 first
 	if t, err := time.Parse(time.RFC3339, s); err == nil {
@@ -332,6 +403,11 @@ func parseTimeOrDuration(s string) (time.Time, error) {
 	if d, err := time.ParseDuration(s); err == nil {
 		return time.Now().Add(-d), nil
 	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.ParseFloat(days, 64); err == nil && n >= 0 {
+			return time.Now().Add(-time.Duration(n * float64(24*time.Hour))), nil
+		}
+	}
 
-	return time.Time{}, fmt.Errorf("must be RFC3339 (e.g., 2024-01-15T10:30:00Z) or duration (e.g., 5m, 1h)")
+	return time.Time{}, fmt.Errorf("must be RFC3339 (e.g., 2024-01-15T10:30:00Z) or duration (e.g., 5m, 1h, 7d)")
 }
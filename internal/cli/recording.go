@@ -0,0 +1,126 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/vburojevic/xcw/internal/domain"
+)
+
+// recording holds the log entries and app metadata read from an NDJSON recording
+type recording struct {
+	Entries []domain.LogEntry
+	App     string // App bundle ID from the first session_start carrying one
+	Version string // App version from the first session_start carrying one
+	Build   string // App build from the first session_start carrying one
+	Starts  []domain.SessionStart
+	Ends    []domain.SessionEnd
+	// Truncated is set when the data ended mid-stream (a compressed
+	// recording still being written); Entries holds what was read before.
+	Truncated bool
+}
+
+// readRecordingFile opens and parses a recording, plain or compressed; "-"
+// reads stdin. Errors carry FILE_NOT_FOUND or READ_ERROR.
+func readRecordingFile(globals *Globals, path string) (*recording, *CLIError) {
+	var (
+		r   io.ReadCloser
+		err error
+	)
+	if path == "-" {
+		r, err = decompress(os.Stdin)
+	} else {
+		r, err = openRecording(path)
+	}
+	if err != nil {
+		return nil, &CLIError{Code: "FILE_NOT_FOUND", Message: fmt.Sprintf("cannot open file: %s", err)}
+	}
+	defer func() {
+		if err := r.Close(); err != nil {
+			globals.Debug("Failed to close %s: %v", path, err)
+		}
+	}()
+	rec, err := readRecording(globals, r)
+	if err != nil {
+		return nil, &CLIError{Code: "READ_ERROR", Message: fmt.Sprintf("error reading %s: %s", path, err)}
+	}
+	if rec.Truncated {
+		emitWarning(globals, nil, fmt.Sprintf("%s ends mid-stream (still being written?); using the %d entries read before the cut", path, len(rec.Entries)))
+	}
+	return rec, nil
+}
+
+// readRecording parses log and log_group lines from an NDJSON recording.
+// session_start and session_end are kept; other event types are skipped.
+// Compressed data that ends early is not an error: the entries before the
+// cut are returned with Truncated set.
+func readRecording(globals *Globals, r io.Reader) (*recording, error) {
+	rec := &recording{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var typeCheck struct {
+			Type    string `json:"type"`
+			App     string `json:"app"`
+			Version string `json:"version"`
+			Build   string `json:"build"`
+		}
+		if json.Unmarshal(line, &typeCheck) == nil && typeCheck.Type != "" && typeCheck.Type != "log" && typeCheck.Type != "log_group" {
+			switch typeCheck.Type {
+			case "session_start":
+				if rec.App == "" {
+					rec.App = typeCheck.App
+				}
+				if rec.Version == "" {
+					rec.Version = typeCheck.Version
+				}
+				if rec.Build == "" {
+					rec.Build = typeCheck.Build
+				}
+				var start domain.SessionStart
+				if json.Unmarshal(line, &start) == nil {
+					rec.Starts = append(rec.Starts, start)
+				}
+			case "session_end":
+				var end domain.SessionEnd
+				if json.Unmarshal(line, &end) == nil {
+					rec.Ends = append(rec.Ends, end)
+				}
+			}
+			// Skip non-log entries (summaries, heartbeats, etc.)
+			continue
+		}
+
+		var entry domain.LogEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			globals.Debug("Skipping unparseable line %d: %v", lineNum, err)
+			continue
+		}
+
+		// Skip if no timestamp (invalid entry)
+		if entry.Timestamp.IsZero() {
+			continue
+		}
+
+		rec.Entries = append(rec.Entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		if !errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, err
+		}
+		rec.Truncated = true
+	}
+	return rec, nil
+}
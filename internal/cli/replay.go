@@ -5,7 +5,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"os"
 	"os/signal"
 	"syscall"
 	"time"
@@ -16,7 +15,7 @@ import (
 
 // ReplayCmd replays a recorded NDJSON log file
 type ReplayCmd struct {
-	File     string  `arg:"" required:"" help:"NDJSON log file to replay"`
+	File     string  `arg:"" required:"" help:"NDJSON log file to replay (.gz/.zst are decompressed)"`
 	Realtime bool    `help:"Replay with original timing (sleep between entries)"`
 	Speed    float64 `default:"1.0" help:"Playback speed multiplier (e.g., 2.0 for 2x speed)"`
 	Follow   bool    `help:"Follow file for new entries (like tail -f)"`
@@ -27,8 +26,12 @@ func (c *ReplayCmd) Run(globals *Globals) error {
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
+	if c.Follow && isCompressedPath(c.File) {
+		return c.outputError(globals, "INVALID_FLAGS", "--follow does not support compressed files")
+	}
+
 	// Open input file
-	file, err := os.Open(c.File)
+	file, err := openRecording(c.File)
 	if err != nil {
 		return c.outputError(globals, "FILE_NOT_FOUND", fmt.Sprintf("cannot open file: %s", err))
 	}
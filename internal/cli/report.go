@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/vburojevic/xcw/internal/output"
+)
+
+// ReportFlags adds CI report output (JUnit XML, SARIF) to commands that analyze logs.
+type ReportFlags struct {
+	Report     string `help:"Write the analysis as a CI report: junit (error/fault patterns as failing test cases) or sarif (fault patterns as results)"`
+	ReportFile string `help:"Write the --report to this file and keep normal output; without it the report replaces stdout output"`
+}
+
+// validateReport checks --report/--report-file before any work is done.
+func (f ReportFlags) validateReport(globals *Globals) error {
+	if f.ReportFile != "" && f.Report == "" {
+		return outputErrorCommon(globals, "INVALID_FLAGS", "--report-file requires --report", "add --report junit or --report sarif")
+	}
+	if f.Report != "" && !slices.Contains(output.ReportFormats, f.Report) {
+		return outputErrorCommon(globals, "INVALID_FLAGS", fmt.Sprintf("unknown report format %q", f.Report), "use --report "+strings.Join(output.ReportFormats, " or --report "))
+	}
+	return nil
+}
+
+// reportReplacesOutput reports whether the report goes to stdout instead of the normal output.
+func (f ReportFlags) reportReplacesOutput() bool {
+	return f.Report != "" && f.ReportFile == ""
+}
+
+// writeReport writes the analysis to --report-file, or to stdout when no file is set.
+func (f ReportFlags) writeReport(globals *Globals, analysis *output.EnhancedSummaryOutput, suite string) error {
+	if f.ReportFile == "" {
+		return output.WriteReport(globals.Stdout, f.Report, analysis, suite, Version)
+	}
+
+	file, err := os.Create(f.ReportFile)
+	if err != nil {
+		return outputErrorCommon(globals, "FILE_CREATE_ERROR", fmt.Sprintf("failed to create report file: %s", err))
+	}
+	w := bufio.NewWriter(file)
+	if err := output.WriteReport(w, f.Report, analysis, suite, Version); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// reportPatterns returns the patterns a report is built from: the annotated
+// ones when --persist-patterns ran, otherwise the plain detected ones.
+func reportPatterns(patterns []output.PatternMatch, enhanced []output.EnhancedPatternMatch) []output.EnhancedPatternMatch {
+	if enhanced != nil {
+		return enhanced
+	}
+	return output.EnhancePatterns(patterns)
+}
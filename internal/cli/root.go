@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -17,6 +18,7 @@ type CLI struct {
 	Quiet           bool       `short:"q" help:"Suppress non-log output (only emit log entries)"`
 	Verbose         bool       `short:"v" help:"Show debug output (predicates, reconnections, internal state)"`
 	MachineFriendly bool       `help:"Preset for AI agents: ndjson, quiet=false, agent hints on, no prompts"`
+	SourceFile      string     `help:"Read logs from a recorded 'log stream --style ndjson' capture instead of xcrun (no simulator required)"`
 	Version         VersionCmd `cmd:"" help:"Show version information"`
 	Update          UpdateCmd  `cmd:"" help:"Show how to upgrade xcw"`
 
@@ -34,7 +36,12 @@ type CLI struct {
 	Launch     LaunchCmd     `cmd:"" help:"Launch app and capture stdout/stderr (print statements)"`
 	Pick       PickCmd       `cmd:"" help:"Interactively pick a simulator or app"`
 	Analyze    AnalyzeCmd    `cmd:"" help:"Analyze a recorded NDJSON log file"`
+	Diff       DiffCmd       `cmd:"" help:"Compare two NDJSON recordings or two sessions"`
+	Expect     ExpectCmd     `cmd:"" help:"Check logs against YAML expectations; exit non-zero on failure (CI)"`
 	Replay     ReplayCmd     `cmd:"" help:"Replay a recorded NDJSON log file"`
+	Search     SearchCmd     `cmd:"" help:"Search recorded sessions through the session index"`
+	SQL        SQLCmd        `cmd:"" name:"sql" help:"Load recordings into SQLite and run SQL queries over them"`
+	Trace      TraceCmd      `cmd:"" help:"Gather every entry of one trace or activity from recordings, in time order"`
 	Schema     SchemaCmd     `cmd:"" help:"Output JSON Schema for xcw output types"`
 	LogSchema  LogSchemaCmd  `cmd:"" help:"Output minimal log schema for agents"`
 	Handoff    HandoffCmd    `cmd:"" help:"Emit a machine-readable handoff blob for agents"`
@@ -43,6 +50,7 @@ type CLI struct {
 	Completion CompletionCmd `cmd:"" help:"Generate shell completions"`
 	UI         UICmd         `cmd:"" help:"Interactive TUI log viewer"`
 	Sessions   SessionsCmd   `cmd:"" help:"Manage session log files"`
+	Serve      ServeCmd      `cmd:"" help:"Serve JSON-RPC 2.0 over stdin/stdout for agent integrations"`
 }
 
 // Globals holds shared state for all commands
@@ -62,6 +70,11 @@ type Globals struct {
 	ConfigFile string
 	// ConfigSources maps config keys to their effective source: flag|env|config|default.
 	ConfigSources map[string]string
+	// SourceFile, when set, replaces xcrun with a recorded NDJSON capture as the log source.
+	SourceFile string
+	// Context, when set, is the parent context for command execution (used by serve
+	// to cancel commands it started). Nil means context.Background().
+	Context context.Context
 }
 
 // NewGlobals creates a new Globals instance from CLI flags
@@ -75,6 +88,7 @@ func NewGlobals(cli *CLI) *Globals {
 		Stderr:  os.Stderr,
 		Config:  config.Default(),
 	}
+	g.SourceFile = cli.SourceFile
 	if cli.MachineFriendly {
 		g.Format = "ndjson"
 		// Keep Quiet as provided; agents often want session banners/warnings
@@ -98,6 +112,7 @@ func NewGlobalsWithConfig(cli *CLI, cfg *config.Config) *Globals {
 		Stderr:  os.Stderr,
 		Config:  cfg,
 	}
+	g.SourceFile = cli.SourceFile
 
 	// Apply config values if CLI flags weren't explicitly set
 	if cfg != nil {
@@ -125,6 +140,14 @@ func NewGlobalsWithConfig(cli *CLI, cfg *config.Config) *Globals {
 	return g
 }
 
+// baseContext returns the parent context commands should derive from.
+func (g *Globals) baseContext() context.Context {
+	if g == nil || g.Context == nil {
+		return context.Background()
+	}
+	return g.Context
+}
+
 func (g *Globals) FlagProvided(name string) bool {
 	if g == nil || g.FlagsSet == nil {
 		return false
@@ -2,69 +2,217 @@ package cli
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 )
 
-// rotation manages per-session file rotation for tail.
+// rotation manages per-session file rotation for tail. Within a session it
+// optionally compresses output and starts a new part file once the current
+// one exceeds maxSize bytes or is older than maxAge.
 type rotation struct {
-	pathBuilder    func(int) (string, error)
+	pathBuilder func(int) (string, error)
+	compress    string
+	maxSize     int64
+	maxAge      time.Duration
+	// onPart is called after a size/age rotation opens part 2, 3, ...
+	onPart func(path string, part int) error
+	now    func() time.Time
+
 	outputFile     *os.File
+	counter        *countingWriter
+	compressor     io.WriteCloser
 	bufferedWriter *bufio.Writer
+	basePath       string
+	part           int
+	opened         time.Time
+	lineStart      bool
 }
 
 func newRotation(pb func(int) (string, error)) *rotation {
-	return &rotation{pathBuilder: pb}
+	return &rotation{pathBuilder: pb, now: time.Now}
 }
 
-func (r *rotation) Open(session int) (writer *bufio.Writer, file *os.File, path string, err error) {
-	if r.pathBuilder == nil {
-		return nil, nil, "", nil
+// configureRotation applies --compress, --max-file-size and --max-file-age to r.
+func (f *TailOutputFlags) configureRotation(r *rotation) error {
+	if f.Compress == "" && f.MaxFileSize == "" && f.MaxFileAge == "" {
+		return nil
 	}
-
-	if r.bufferedWriter != nil {
-		if err := r.bufferedWriter.Flush(); err != nil {
-			return nil, nil, "", fmt.Errorf("failed to flush previous output: %w", err)
+	if f.Output == "" && f.SessionDir == "" && f.SessionPrefix == "" {
+		return errors.New("--compress, --max-file-size and --max-file-age require --output or --session-dir")
+	}
+	if err := validateCompression(f.Compress); err != nil {
+		return err
+	}
+	r.compress = f.Compress
+	if f.MaxFileSize != "" {
+		size, err := parseByteSize(f.MaxFileSize)
+		if err != nil {
+			return fmt.Errorf("invalid --max-file-size: %w", err)
 		}
+		r.maxSize = size
 	}
-	if r.outputFile != nil {
-		if err := r.outputFile.Close(); err != nil {
-			return nil, nil, "", fmt.Errorf("failed to close previous output: %w", err)
+	if f.MaxFileAge != "" {
+		age, err := time.ParseDuration(f.MaxFileAge)
+		if err != nil || age <= 0 {
+			return fmt.Errorf("invalid --max-file-age %q", f.MaxFileAge)
 		}
+		r.maxAge = age
+	}
+	return nil
+}
+
+// Open closes the current file and starts the first part of a session. The
+// returned writer stays valid across part rotations.
+func (r *rotation) Open(session int) (writer io.Writer, path string, err error) {
+	if r.pathBuilder == nil {
+		return nil, "", nil
+	}
+	if err := r.closeFile(); err != nil {
+		return nil, "", err
 	}
 
-	path, err = r.pathBuilder(session)
+	r.basePath, err = r.pathBuilder(session)
 	if err != nil {
-		return nil, nil, "", fmt.Errorf("failed to build path: %w", err)
+		return nil, "", fmt.Errorf("failed to build path: %w", err)
+	}
+	r.part = 1
+	path, err = r.openPart()
+	if err != nil {
+		return nil, "", err
+	}
+	return r, path, nil
+}
+
+// Write writes p to the current part, first starting a new part when a limit
+// was reached. Rotation only happens on line boundaries, so no empty trailing
+// part is created when the stream ends.
+func (r *rotation) Write(p []byte) (int, error) {
+	if r.bufferedWriter == nil {
+		return 0, errors.New("output file is not open")
+	}
+	if r.lineStart && r.limitReached() {
+		if err := r.closeFile(); err != nil {
+			return 0, err
+		}
+		r.part++
+		path, err := r.openPart()
+		if err != nil {
+			return 0, err
+		}
+		if r.onPart != nil {
+			if err := r.onPart(path, r.part); err != nil {
+				return 0, err
+			}
+		}
+	}
+	n, err := r.bufferedWriter.Write(p)
+	if n > 0 {
+		r.lineStart = p[n-1] == '\n'
+	}
+	return n, err
+}
+
+// limitReached reports whether the current part reached --max-file-size or
+// --max-file-age. Plain output is measured exactly (bytes written plus
+// buffered). Compressed output is measured by the compressed bytes already on
+// disk, so the limit is approximate: a part can end up larger by what the
+// buffer and compressor still hold when it is closed.
+func (r *rotation) limitReached() bool {
+	size := r.counter.n
+	if r.compressor == nil {
+		size += int64(r.bufferedWriter.Buffered())
+	}
+	if r.maxSize > 0 && size >= r.maxSize {
+		return true
+	}
+	return r.maxAge > 0 && r.now().Sub(r.opened) >= r.maxAge
+}
+
+func (r *rotation) openPart() (string, error) {
+	path := partPath(r.basePath, r.part)
+	if ext := compressionExt(r.compress); !strings.HasSuffix(path, ext) {
+		path += ext
 	}
 
 	// Ensure directory exists
 	dir := filepath.Dir(path)
 	if dir != "." && dir != "" {
 		if mkErr := os.MkdirAll(dir, 0o755); mkErr != nil {
-			return nil, nil, "", fmt.Errorf("failed to create output dir: %w", mkErr)
+			return "", fmt.Errorf("failed to create output dir: %w", mkErr)
 		}
 	}
 
-	r.outputFile, err = os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
 	if err != nil {
-		return nil, nil, "", fmt.Errorf("failed to create output file: %w", err)
+		return "", fmt.Errorf("failed to create output file: %w", err)
+	}
+	r.outputFile = file
+	r.counter = &countingWriter{w: file}
+	var w io.Writer = r.counter
+	if r.compress != "" {
+		r.compressor, err = newCompressor(r.counter, r.compress)
+		if err != nil {
+			_ = file.Close()
+			r.outputFile = nil
+			return "", err
+		}
+		w = r.compressor
 	}
-	r.bufferedWriter = bufio.NewWriterSize(r.outputFile, 64*1024)
-	return r.bufferedWriter, r.outputFile, path, nil
+	r.bufferedWriter = bufio.NewWriterSize(w, 64*1024)
+	r.opened = r.now()
+	r.lineStart = true
+	return path, nil
 }
 
-func (r *rotation) Close() error {
+// partPath returns the path of a numbered part: part 1 is base itself, later
+// parts insert ".partN" before the extension.
+func partPath(base string, part int) string {
+	if part <= 1 {
+		return base
+	}
+	ext := filepath.Ext(base)
+	return fmt.Sprintf("%s.part%d%s", strings.TrimSuffix(base, ext), part, ext)
+}
+
+func (r *rotation) closeFile() error {
 	if r.bufferedWriter != nil {
 		if err := r.bufferedWriter.Flush(); err != nil {
-			return err
+			return fmt.Errorf("failed to flush previous output: %w", err)
+		}
+		r.bufferedWriter = nil
+	}
+	if r.compressor != nil {
+		if err := r.compressor.Close(); err != nil {
+			return fmt.Errorf("failed to finish compressed output: %w", err)
 		}
+		r.compressor = nil
 	}
 	if r.outputFile != nil {
 		if err := r.outputFile.Close(); err != nil {
-			return err
+			return fmt.Errorf("failed to close previous output: %w", err)
 		}
+		r.outputFile = nil
 	}
 	return nil
 }
+
+func (r *rotation) Close() error {
+	return r.closeFile()
+}
+
+// countingWriter counts bytes written to the underlying file
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
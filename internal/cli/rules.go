@@ -0,0 +1,157 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/vburojevic/xcw/internal/filter"
+	"github.com/vburojevic/xcw/internal/output"
+)
+
+// watchRules is a loaded --rules file: the evaluator plus each rule's
+// resolved actions, keyed by rule name.
+type watchRules struct {
+	evaluator *filter.RuleEvaluator
+	actions   map[string][]ruleAction
+}
+
+// ruleAction is one resolved action; exactly one field is set.
+type ruleAction struct {
+	command string
+	webhook *webhookSink
+	marker  string
+}
+
+// describe renders the action for banners and --dry-run-json
+func (a ruleAction) describe() string {
+	switch {
+	case a.webhook != nil:
+		return "webhook: " + a.webhook.command()
+	case a.marker != "":
+		return "marker: " + a.marker
+	default:
+		return "command: " + a.command
+	}
+}
+
+// ruleSummary is the --dry-run-json view of a rule
+type ruleSummary struct {
+	Name      string   `json:"name"`
+	Where     string   `json:"where"`
+	Threshold string   `json:"threshold"`
+	Cooldown  string   `json:"cooldown"`
+	Actions   []string `json:"actions"`
+}
+
+// loadWatchRules reads and validates a rules file. Webhook actions inherit
+// the --webhook-format and --webhook-template defaults. They do not inherit
+// --webhook-header: each rule posts to its own URL, and credentials meant for
+// the --on-*-webhook endpoints must not leak to other hosts.
+func loadWatchRules(path string, defaultCooldown time.Duration, hookFormat, hookTemplate string) (*watchRules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read rules file: %w", err)
+	}
+	file, err := filter.ParseRules(data)
+	if err != nil {
+		return nil, err
+	}
+	evaluator, err := filter.NewRuleEvaluator(file, defaultCooldown)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := &watchRules{evaluator: evaluator, actions: make(map[string][]ruleAction)}
+	for _, r := range evaluator.Rules() {
+		for i, spec := range r.Actions {
+			action := ruleAction{command: spec.Command, marker: spec.Marker}
+			if spec.Webhook != "" {
+				format, tmpl := hookFormat, hookTemplate
+				if spec.Format != "" {
+					format = spec.Format
+				}
+				if spec.Template != "" {
+					tmpl = spec.Template
+				}
+				opts, err := newWebhookOptions(format, tmpl, nil)
+				if err != nil {
+					return nil, fmt.Errorf("rule %s: action %d: %w", r.Name, i+1, err)
+				}
+				for k, v := range spec.Headers {
+					opts.headers[k] = v
+				}
+				if action.webhook, err = newWebhookSink(spec.Webhook, opts); err != nil {
+					return nil, fmt.Errorf("rule %s: action %d: %w", r.Name, i+1, err)
+				}
+			}
+			rules.actions[r.Name] = append(rules.actions[r.Name], action)
+		}
+	}
+	return rules, nil
+}
+
+// hasWebhooks reports whether any rule posts to a webhook
+func (r *watchRules) hasWebhooks() bool {
+	if r == nil {
+		return false
+	}
+	for _, actions := range r.actions {
+		for _, a := range actions {
+			if a.webhook != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// summaries describes the rules for --dry-run-json
+func (r *watchRules) summaries() []ruleSummary {
+	if r == nil {
+		return nil
+	}
+	var out []ruleSummary
+	for _, rule := range r.evaluator.Rules() {
+		s := ruleSummary{
+			Name:      rule.Name,
+			Where:     rule.Where,
+			Threshold: rule.Threshold(),
+			Cooldown:  rule.Cooldown.String(),
+		}
+		for _, a := range r.actions[rule.Name] {
+			s.Actions = append(s.Actions, a.describe())
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+// emitMarker writes a marker event for a rule that fired at now
+func (c *WatchCmd) emitMarker(globals *Globals, writeStdout func(fn func(w *output.NDJSONWriter) error) error, firing filter.RuleFiring, message string, now time.Time) {
+	entry := firing.Entry
+	if globals.Format == "ndjson" {
+		marker := &output.MarkerOutput{
+			Timestamp: now.UTC().Format(time.RFC3339Nano),
+			TailID:    entry.TailID,
+			Session:   entry.Session,
+			Rule:      firing.Rule.Name,
+			Message:   message,
+			Matches:   firing.Matches,
+			Entry:     output.NewOutputEntry(entry),
+		}
+		if firing.Rule.Window > 0 {
+			marker.Window = firing.Rule.Window.String()
+		}
+		if err := writeStdout(func(w *output.NDJSONWriter) error { return w.WriteMarker(marker) }); err != nil {
+			globals.Debug("failed to write marker: %v", err)
+		}
+		return
+	}
+	if globals.Quiet {
+		return
+	}
+	if _, err := fmt.Fprintf(globals.Stderr, "[MARKER:%s] %s (%d matches; last: %s)\n", firing.Rule.Name, message, firing.Matches, truncateRunes(entry.Message, 200)); err != nil {
+		globals.Debug("failed to write marker: %v", err)
+	}
+}
@@ -9,7 +9,7 @@ import (
 
 // SchemaCmd outputs JSON Schema for xcw output types
 type SchemaCmd struct {
-	Type      []string `short:"t" help:"Output types to include (log,summary,analysis,heartbeat,stats,metadata,ready,session_start,session_end,clear_buffer,agent_hints,cutoff_reached,reconnect_notice,gap_detected,gap_filled,error,rotation,console,discovery,simulator,tmux,info,warning,trigger,trigger_error,trigger_result,doctor,app,apps_summary,pick,update,config,config_path,session,session_debug). Default: all"`
+	Type      []string `short:"t" help:"Output types to include (log,summary,analysis,diff_result,expectation_failed,expect_result,heartbeat,stats,metadata,ready,session_start,session_end,crash_detected,clear_buffer,agent_hints,cutoff_reached,reconnect_notice,gap_detected,gap_filled,error,rotation,console,discovery,simulator,tmux,info,warning,trigger,trigger_error,trigger_result,marker,anomaly,doctor,app,apps_summary,pick,update,config,config_path,session,session_debug,session_index,search_result,sql_row,sql_result,trace). Default: all"`
 	Changelog bool     `help:"Output schema changelog instead of full schema"`
 }
 
@@ -31,41 +31,52 @@ func (c *SchemaCmd) Run(globals *Globals) error {
 	}
 
 	schemas := map[string]interface{}{
-		"log":              logSchema(),
-		"summary":          summarySchema(),
-		"analysis":         analysisSchema(),
-		"heartbeat":        heartbeatSchema(),
-		"stats":            statsSchema(),
-		"metadata":         metadataSchema(),
-		"ready":            readySchema(),
-		"session_start":    sessionStartSchema(),
-		"session_end":      sessionEndSchema(),
-		"clear_buffer":     clearBufferSchema(),
-		"agent_hints":      agentHintsSchema(),
-		"cutoff_reached":   cutoffSchema(),
-		"reconnect_notice": reconnectSchema(),
-		"gap_detected":     gapDetectedSchema(),
-		"gap_filled":       gapFilledSchema(),
-		"error":            errorSchema(),
-		"rotation":         rotationSchema(),
-		"console":          consoleSchema(),
-		"discovery":        discoverySchema(),
-		"simulator":        simulatorSchema(),
-		"tmux":             tmuxSchema(),
-		"info":             infoSchema(),
-		"warning":          warningSchema(),
-		"trigger":          triggerSchema(),
-		"trigger_error":    triggerErrorSchema(),
-		"trigger_result":   triggerResultSchema(),
-		"doctor":           doctorSchema(),
-		"app":              appSchema(),
-		"apps_summary":     appsSummarySchema(),
-		"pick":             pickSchema(),
-		"update":           updateSchema(),
-		"config":           configSchema(),
-		"config_path":      configPathSchema(),
-		"session":          sessionSchema(),
-		"session_debug":    sessionDebugSchema(),
+		"log":                logSchema(),
+		"summary":            summarySchema(),
+		"analysis":           analysisSchema(),
+		"diff_result":        diffResultSchema(),
+		"expectation_failed": expectationFailedSchema(),
+		"expect_result":      expectResultSchema(),
+		"heartbeat":          heartbeatSchema(),
+		"stats":              statsSchema(),
+		"metadata":           metadataSchema(),
+		"ready":              readySchema(),
+		"session_start":      sessionStartSchema(),
+		"session_end":        sessionEndSchema(),
+		"crash_detected":     crashDetectedSchema(),
+		"clear_buffer":       clearBufferSchema(),
+		"agent_hints":        agentHintsSchema(),
+		"cutoff_reached":     cutoffSchema(),
+		"reconnect_notice":   reconnectSchema(),
+		"gap_detected":       gapDetectedSchema(),
+		"gap_filled":         gapFilledSchema(),
+		"error":              errorSchema(),
+		"rotation":           rotationSchema(),
+		"console":            consoleSchema(),
+		"discovery":          discoverySchema(),
+		"simulator":          simulatorSchema(),
+		"tmux":               tmuxSchema(),
+		"info":               infoSchema(),
+		"warning":            warningSchema(),
+		"trigger":            triggerSchema(),
+		"trigger_error":      triggerErrorSchema(),
+		"trigger_result":     triggerResultSchema(),
+		"marker":             markerSchema(),
+		"anomaly":            anomalySchema(),
+		"doctor":             doctorSchema(),
+		"app":                appSchema(),
+		"apps_summary":       appsSummarySchema(),
+		"pick":               pickSchema(),
+		"update":             updateSchema(),
+		"config":             configSchema(),
+		"config_path":        configPathSchema(),
+		"session":            sessionSchema(),
+		"session_debug":      sessionDebugSchema(),
+		"session_index":      sessionIndexSchema(),
+		"search_result":      searchResultSchema(),
+		"sql_row":            sqlRowSchema(),
+		"sql_result":         sqlResultSchema(),
+		"trace":              traceSchema(),
 	}
 
 	// Determine which schemas to output
@@ -75,12 +86,16 @@ func (c *SchemaCmd) Run(globals *Globals) error {
 			"log",
 			"summary",
 			"analysis",
+			"diff_result",
+			"expectation_failed",
+			"expect_result",
 			"heartbeat",
 			"stats",
 			"metadata",
 			"ready",
 			"session_start",
 			"session_end",
+			"crash_detected",
 			"clear_buffer",
 			"agent_hints",
 			"cutoff_reached",
@@ -98,6 +113,8 @@ func (c *SchemaCmd) Run(globals *Globals) error {
 			"trigger",
 			"trigger_error",
 			"trigger_result",
+			"marker",
+			"anomaly",
 			"doctor",
 			"app",
 			"apps_summary",
@@ -107,6 +124,11 @@ func (c *SchemaCmd) Run(globals *Globals) error {
 			"config_path",
 			"session",
 			"session_debug",
+			"session_index",
+			"search_result",
+			"sql_row",
+			"sql_result",
+			"trace",
 		}
 	}
 
@@ -149,8 +171,9 @@ func logSchema() map[string]interface{} {
 		"description": "A single log entry from the iOS Simulator",
 		"properties": map[string]interface{}{
 			"type": map[string]interface{}{
-				"type":  "string",
-				"const": "log",
+				"type":        "string",
+				"enum":        []string{"log", "log_group"},
+				"description": "log_group marks a coalesced multi-line message (--group)",
 			},
 			"schemaVersion": schemaVersionProperty(),
 			"tail_id": map[string]interface{}{
@@ -175,6 +198,10 @@ func logSchema() map[string]interface{} {
 				"type":        "integer",
 				"description": "Process ID",
 			},
+			"tid": map[string]interface{}{
+				"type":        "integer",
+				"description": "Thread ID",
+			},
 			"subsystem": map[string]interface{}{
 				"type":        "string",
 				"description": "Subsystem identifier (usually bundle ID)",
@@ -187,10 +214,56 @@ func logSchema() map[string]interface{} {
 				"type":        "string",
 				"description": "The log message content",
 			},
+			"lines": map[string]interface{}{
+				"type":        "array",
+				"description": "Original messages of a log_group, in order; message holds them joined by newlines",
+				"items": map[string]interface{}{
+					"type": "string",
+				},
+			},
+			"fields": map[string]interface{}{
+				"type":        "object",
+				"description": "Key/value pairs extracted from the message (--extract logfmt|json|regex); query as fields.<key> in --where",
+				"additionalProperties": map[string]interface{}{
+					"type": "string",
+				},
+			},
 			"session": map[string]interface{}{
 				"type":        "integer",
 				"description": "Session number (1, 2, 3...) when session tracking is active",
 			},
+			"activity_id": map[string]interface{}{
+				"type":        "integer",
+				"description": "os_activity identifier the entry was logged under",
+			},
+			"parent_activity_id": map[string]interface{}{
+				"type":        "integer",
+				"description": "Parent os_activity identifier",
+			},
+			"os_trace_id": map[string]interface{}{
+				"type":        "integer",
+				"description": "Unified logging trace identifier",
+			},
+			"trace_id": map[string]interface{}{
+				"type":        "string",
+				"description": "Distributed trace or request ID found in the message (--extract-trace)",
+			},
+			"span_id": map[string]interface{}{
+				"type":        "string",
+				"description": "Span ID found in the message (--extract-trace)",
+			},
+			"parent_span_id": map[string]interface{}{
+				"type":        "string",
+				"description": "Parent span ID found in the message (--extract-trace regex with a parent group)",
+			},
+			"udid": map[string]interface{}{
+				"type":        "string",
+				"description": "Simulator UDID the entry came from (multi-simulator tail only)",
+			},
+			"simulator": map[string]interface{}{
+				"type":        "string",
+				"description": "Simulator name the entry came from (multi-simulator tail only)",
+			},
 		},
 		"required": []string{"type", "schemaVersion", "timestamp", "level", "process", "pid", "message"},
 	}
@@ -257,6 +330,29 @@ func summarySchema() map[string]interface{} {
 				"items":       map[string]interface{}{"type": "string"},
 				"description": "Most common fault messages",
 			},
+			"bucketSize": map[string]interface{}{
+				"type":        "string",
+				"description": "Histogram bucket size (with --histogram/--bucket)",
+			},
+			"histogram": map[string]interface{}{
+				"type":        "array",
+				"description": "Time-bucketed volume, oldest first, empty buckets included (with --histogram/--bucket)",
+				"items": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"start":             map[string]interface{}{"type": "string", "format": "date-time"},
+						"totalCount":        map[string]interface{}{"type": "integer"},
+						"debugCount":        map[string]interface{}{"type": "integer"},
+						"infoCount":         map[string]interface{}{"type": "integer"},
+						"defaultCount":      map[string]interface{}{"type": "integer"},
+						"errorCount":        map[string]interface{}{"type": "integer"},
+						"faultCount":        map[string]interface{}{"type": "integer"},
+						"topSubsystem":      map[string]interface{}{"type": "string", "description": "Subsystem with the most entries in the bucket"},
+						"topSubsystemCount": map[string]interface{}{"type": "integer"},
+					},
+					"required": []string{"start", "totalCount"},
+				},
+			},
 		},
 		"required": []string{"type", "schemaVersion", "totalCount"},
 	}
@@ -331,6 +427,10 @@ func rotationSchema() map[string]interface{} {
 				"type":        "integer",
 				"description": "Session number for this file",
 			},
+			"part": map[string]interface{}{
+				"type":        "integer",
+				"description": "Part number when the session was split by --max-file-size/--max-file-age (2, 3, ...)",
+			},
 		},
 		"required": []string{"type", "schemaVersion", "path"},
 	}
@@ -364,6 +464,7 @@ func errorSchema() map[string]interface{} {
 					"INVALID_TRIGGER",
 					"INVALID_TRIGGER_PATTERN",
 					"INVALID_TRIGGER_TIMEOUT",
+					"INVALID_RULES",
 					"STREAM_FAILED",
 					"QUERY_FAILED",
 					"LIST_FAILED",
@@ -375,6 +476,9 @@ func errorSchema() map[string]interface{} {
 					"DEVICE_NOT_BOOTED",
 					"TMUX_NOT_INSTALLED",
 					"TMUX_ERROR",
+					"SQLITE_NOT_INSTALLED",
+					"SQL_FAILED",
+					"TRACE_NOT_FOUND",
 					"SESSION_NOT_FOUND",
 					"SESSION_DIR_ERROR",
 					"SESSION_ERROR",
@@ -503,7 +607,7 @@ func triggerSchema() map[string]interface{} {
 			},
 			"trigger": map[string]interface{}{
 				"type":        "string",
-				"description": "Type of trigger (error, fault, or pattern:regex)",
+				"description": "Type of trigger (error, fault, pattern:regex, or rule:name)",
 			},
 			"command": map[string]interface{}{
 				"type":        "string",
@@ -721,6 +825,15 @@ func sessionSchema() map[string]interface{} {
 				"type":        "string",
 				"description": "Session prefix (usually app bundle ID)",
 			},
+			"part": map[string]interface{}{
+				"type":        "integer",
+				"description": "Part number when the session was split by size/age (2, 3, ...)",
+			},
+			"compression": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{"gzip", "zstd"},
+				"description": "Compression codec of the file, when compressed",
+			},
 		},
 		"required": []string{"type", "schemaVersion", "path", "name", "timestamp", "size"},
 	}
@@ -770,6 +883,237 @@ func sessionDebugSchema() map[string]interface{} {
 	}
 }
 
+func sessionIndexSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "object",
+		"title":       "Session Index",
+		"description": "Result of updating the session search index (xcw sessions index)",
+		"properties": map[string]interface{}{
+			"type": map[string]interface{}{
+				"type":  "string",
+				"const": "session_index",
+			},
+			"schemaVersion": schemaVersionProperty(),
+			"index_dir": map[string]interface{}{
+				"type":        "string",
+				"description": "Directory holding the index",
+			},
+			"files": map[string]interface{}{
+				"type":        "integer",
+				"description": "Recordings covered by the index",
+			},
+			"indexed": map[string]interface{}{
+				"type":        "integer",
+				"description": "Recordings (re)indexed in this run",
+			},
+			"skipped": map[string]interface{}{
+				"type":        "integer",
+				"description": "Unchanged recordings reused from the index",
+			},
+			"removed": map[string]interface{}{
+				"type":        "integer",
+				"description": "Index entries dropped for deleted recordings",
+			},
+			"entries": map[string]interface{}{
+				"type":        "integer",
+				"description": "Log entries indexed in this run",
+			},
+			"failed": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+				"description": "Recordings that could not be read (e.g. compressed files still being written); retried on the next update",
+			},
+			"duration_ms": map[string]interface{}{
+				"type": "integer",
+			},
+		},
+		"required": []string{"type", "schemaVersion", "index_dir", "files", "indexed", "skipped", "removed", "entries", "duration_ms"},
+	}
+}
+
+func searchResultSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "object",
+		"title":       "Search Result",
+		"description": "Summary emitted by xcw search after the matching log lines",
+		"properties": map[string]interface{}{
+			"type": map[string]interface{}{
+				"type":  "string",
+				"const": "search_result",
+			},
+			"schemaVersion": schemaVersionProperty(),
+			"query": map[string]interface{}{
+				"type":        "string",
+				"description": "Search text",
+			},
+			"total": map[string]interface{}{
+				"type":        "integer",
+				"description": "Matching entries before --limit",
+			},
+			"returned": map[string]interface{}{
+				"type":        "integer",
+				"description": "Log lines emitted (newest first)",
+			},
+			"truncated": map[string]interface{}{
+				"type":        "boolean",
+				"description": "True when --limit dropped older matches",
+			},
+			"files_searched": map[string]interface{}{
+				"type":        "integer",
+				"description": "Recordings whose index segment was read",
+			},
+			"files_skipped": map[string]interface{}{
+				"type":        "integer",
+				"description": "Recordings ruled out by the index manifest",
+			},
+			"files_failed": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+				"description": "Recordings that could not be indexed this time (e.g. compressed files still being written); their previous index, if any, is searched and they are retried on the next search",
+			},
+			"files": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+				"description": "Recordings containing the returned matches",
+			},
+			"duration_ms": map[string]interface{}{
+				"type": "integer",
+			},
+		},
+		"required": []string{"type", "schemaVersion", "total", "returned", "files_searched", "files_skipped", "duration_ms"},
+	}
+}
+
+func sqlRowSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "object",
+		"title":       "SQL Row",
+		"description": "One result row of xcw sql; row keys follow the query's column order",
+		"properties": map[string]interface{}{
+			"type": map[string]interface{}{
+				"type":  "string",
+				"const": "sql_row",
+			},
+			"schemaVersion": schemaVersionProperty(),
+			"row": map[string]interface{}{
+				"type":        "object",
+				"description": "Column name to value (string, number or null)",
+			},
+		},
+		"required": []string{"type", "schemaVersion", "row"},
+	}
+}
+
+func sqlResultSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "object",
+		"title":       "SQL Result",
+		"description": "Summary emitted by xcw sql after the result rows",
+		"properties": map[string]interface{}{
+			"type": map[string]interface{}{
+				"type":  "string",
+				"const": "sql_result",
+			},
+			"schemaVersion": schemaVersionProperty(),
+			"database": map[string]interface{}{
+				"type":        "string",
+				"description": "Database path when --db was given",
+			},
+			"loaded": map[string]interface{}{
+				"type":        "array",
+				"description": "Recordings loaded in this run",
+				"items": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"file":     map[string]interface{}{"type": "string"},
+						"entries":  map[string]interface{}{"type": "integer"},
+						"sessions": map[string]interface{}{"type": "integer"},
+					},
+					"required": []string{"file", "entries", "sessions"},
+				},
+			},
+			"columns": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+				"description": "Result columns (absent when no rows)",
+			},
+			"rows": map[string]interface{}{
+				"type":        "integer",
+				"description": "Number of sql_row lines emitted",
+			},
+			"duration_ms": map[string]interface{}{
+				"type": "integer",
+			},
+		},
+		"required": []string{"type", "schemaVersion", "rows", "duration_ms"},
+	}
+}
+
+func traceSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "object",
+		"title":       "Trace",
+		"description": "Summary emitted by xcw trace after the trace's log entries",
+		"properties": map[string]interface{}{
+			"type": map[string]interface{}{
+				"type":  "string",
+				"const": "trace",
+			},
+			"schemaVersion": schemaVersionProperty(),
+			"id": map[string]interface{}{
+				"type":        "string",
+				"description": "Trace or activity ID that was looked up",
+			},
+			"kind": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{"trace", "activity"},
+				"description": "trace: matched trace_id; activity: matched activity_id and its nested activities",
+			},
+			"files": map[string]interface{}{
+				"type":  "array",
+				"items": map[string]interface{}{"type": "string"},
+			},
+			"entries": map[string]interface{}{
+				"type": "integer",
+			},
+			"errors": map[string]interface{}{
+				"type":        "integer",
+				"description": "Error and fault entries",
+			},
+			"start": map[string]interface{}{
+				"type":   "string",
+				"format": "date-time",
+			},
+			"end": map[string]interface{}{
+				"type":   "string",
+				"format": "date-time",
+			},
+			"duration_ms": map[string]interface{}{
+				"type": "integer",
+			},
+			"spans": map[string]interface{}{
+				"type":        "array",
+				"description": "One item per span_id (trace) or activity_id (activity), in order of first entry",
+				"items": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"id":          map[string]interface{}{"type": "string"},
+						"parent_id":   map[string]interface{}{"type": "string"},
+						"start":       map[string]interface{}{"type": "string", "format": "date-time"},
+						"end":         map[string]interface{}{"type": "string", "format": "date-time"},
+						"duration_ms": map[string]interface{}{"type": "integer"},
+						"entries":     map[string]interface{}{"type": "integer"},
+						"errors":      map[string]interface{}{"type": "integer"},
+						"processes":   map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+					},
+					"required": []string{"id", "start", "end", "duration_ms", "entries"},
+				},
+			},
+		},
+		"required": []string{"type", "schemaVersion", "id", "kind", "files", "entries", "errors", "start", "end", "duration_ms"},
+	}
+}
+
 func analysisSchema() map[string]interface{} {
 	return map[string]interface{}{
 		"type":        "object",
@@ -795,6 +1139,10 @@ func analysisSchema() map[string]interface{} {
 				"description": "Detected error/fault patterns",
 				"items": map[string]interface{}{
 					"type": "object",
+					"properties": map[string]interface{}{
+						"template_id": map[string]interface{}{"type": "string", "description": "Stable template ID, as in discover templates[].id"},
+						"params":      templateParamsSchema(),
+					},
 				},
 			},
 			"new_pattern_count": map[string]interface{}{
@@ -805,11 +1153,106 @@ func analysisSchema() map[string]interface{} {
 				"type":        "integer",
 				"description": "Number of previously known patterns (when persistence enabled)",
 			},
+			"app": map[string]interface{}{
+				"type":        "string",
+				"description": "App bundle ID the run was recorded under (--app or session_start); history is compared per app",
+			},
+			"app_version": map[string]interface{}{
+				"type":        "string",
+				"description": "App version the run was recorded under (--app-version or session_start)",
+			},
+			"app_build": map[string]interface{}{
+				"type":        "string",
+				"description": "App build the run was recorded under (--app-build or session_start)",
+			},
+			"new_in_build_count": map[string]interface{}{
+				"type":        "integer",
+				"description": "Patterns first seen in this app version/build (patterns[].new_in_build)",
+			},
+			"returned_count": map[string]interface{}{
+				"type":        "integer",
+				"description": "Known patterns that came back after --regression-runs clean runs (patterns[].returned)",
+			},
+			"rate_increase_count": map[string]interface{}{
+				"type":        "integer",
+				"description": "Patterns whose rate per 1000 entries rose more than --rate-increase percent (patterns[].rate_increased)",
+			},
+			"fields": fieldStatsSchema(),
 		},
 		"required": []string{"type", "schemaVersion", "timestamp", "summary"},
 	}
 }
 
+func diffResultSchema() map[string]interface{} {
+	side := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"label":            map[string]interface{}{"type": "string", "description": "Recording path"},
+			"session":          map[string]interface{}{"type": "integer", "description": "Session filter (when --session-a/--session-b is used)"},
+			"total":            map[string]interface{}{"type": "integer", "description": "Log entries on this side"},
+			"patterns":         map[string]interface{}{"type": "integer", "description": "Distinct normalized message patterns"},
+			"duration_seconds": map[string]interface{}{"type": "number", "description": "Span between first and last entry"},
+		},
+		"required": []string{"label", "total", "patterns", "duration_seconds"},
+	}
+	pattern := map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"pattern": map[string]interface{}{"type": "string", "description": "Normalized message (variable values masked)"},
+				"count":   map[string]interface{}{"type": "integer"},
+				"level":   map[string]interface{}{"type": "string", "description": "Highest level seen for the pattern"},
+				"sample":  map[string]interface{}{"type": "string", "description": "One original message"},
+			},
+			"required": []string{"pattern", "count", "level", "sample"},
+		},
+	}
+	delta := map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"key":            map[string]interface{}{"type": "string", "description": "Subsystem, category or level; (none) when empty"},
+				"count_a":        map[string]interface{}{"type": "integer"},
+				"count_b":        map[string]interface{}{"type": "integer"},
+				"delta":          map[string]interface{}{"type": "integer", "description": "count_b - count_a"},
+				"rate_a":         map[string]interface{}{"type": "number", "description": "Entries per minute on side A"},
+				"rate_b":         map[string]interface{}{"type": "number", "description": "Entries per minute on side B"},
+				"rate_delta_pct": map[string]interface{}{"type": "number", "description": "Rate change from A to B in percent (omitted when A is 0)"},
+			},
+			"required": []string{"key", "count_a", "count_b", "delta", "rate_a", "rate_b"},
+		},
+	}
+	return map[string]interface{}{
+		"type":        "object",
+		"title":       "DiffResult",
+		"description": "Comparison of two recordings or two sessions (xcw diff)",
+		"properties": map[string]interface{}{
+			"type": map[string]interface{}{
+				"type":  "string",
+				"const": "diff_result",
+			},
+			"schemaVersion": schemaVersionProperty(),
+			"timestamp": map[string]interface{}{
+				"type":        "string",
+				"format":      "date-time",
+				"description": "When the diff was produced",
+			},
+			"a":               side,
+			"b":               side,
+			"only_in_a":       pattern,
+			"only_in_b":       pattern,
+			"only_in_a_count": map[string]interface{}{"type": "integer", "description": "Patterns only in A before --limit"},
+			"only_in_b_count": map[string]interface{}{"type": "integer", "description": "Patterns only in B before --limit"},
+			"subsystems":      delta,
+			"categories":      delta,
+			"levels":          delta,
+		},
+		"required": []string{"type", "schemaVersion", "timestamp", "a", "b", "only_in_a", "only_in_b", "subsystems", "categories", "levels"},
+	}
+}
+
 func statsSchema() map[string]interface{} {
 	return map[string]interface{}{
 		"type":        "object",
@@ -1052,6 +1495,200 @@ func sessionEndSchema() map[string]interface{} {
 	}
 }
 
+func crashDetectedSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "object",
+		"title":       "Crash Detected",
+		"description": "Emitted when the tracked app appears to have crashed",
+		"properties": map[string]interface{}{
+			"type": map[string]interface{}{
+				"type":  "string",
+				"const": "crash_detected",
+			},
+			"schemaVersion": schemaVersionProperty(),
+			"timestamp": map[string]interface{}{
+				"type":        "string",
+				"format":      "date-time",
+				"description": "Timestamp of the log entry that triggered detection",
+			},
+			"tail_id": map[string]interface{}{
+				"type":        "string",
+				"description": "Tail invocation identifier",
+			},
+			"session": map[string]interface{}{
+				"type":        "integer",
+				"description": "Session the crash ended",
+			},
+			"pid": map[string]interface{}{
+				"type":        "integer",
+				"description": "Process ID that crashed",
+			},
+			"process": map[string]interface{}{
+				"type":        "string",
+				"description": "Process name",
+			},
+			"trigger": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{"exception_message", "exit_after_fault"},
+				"description": "What signalled the crash",
+			},
+			"exception_type": map[string]interface{}{
+				"type":        "string",
+				"description": "Exception type (e.g. EXC_BAD_ACCESS, NSInvalidArgumentException, SwiftFatalError)",
+			},
+			"exception_reason": map[string]interface{}{
+				"type":        "string",
+				"description": "Exception reason parsed from the messages",
+			},
+			"signal": map[string]interface{}{
+				"type":        "string",
+				"description": "Signal from the matching .ips report (e.g. SIGABRT)",
+			},
+			"binary_uuid": map[string]interface{}{
+				"type":        "string",
+				"description": "Mach-O UUID from process image",
+			},
+			"ips_path": map[string]interface{}{
+				"type":        "string",
+				"description": "Matching .ips crash report in the DiagnosticReports directory",
+			},
+			"lines": map[string]interface{}{
+				"type":        "array",
+				"description": "Final buffered log entries from the crashed PID (--crash-lines)",
+				"items": map[string]interface{}{
+					"type": "object",
+				},
+			},
+		},
+		"required": []string{"type", "schemaVersion", "timestamp", "pid", "trigger"},
+	}
+}
+
+func expectationFailedSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "object",
+		"title":       "Expectation Failed",
+		"description": "Emitted by xcw expect as soon as an expectation fails",
+		"properties": map[string]interface{}{
+			"type": map[string]interface{}{
+				"type":  "string",
+				"const": "expectation_failed",
+			},
+			"schemaVersion": schemaVersionProperty(),
+			"timestamp": map[string]interface{}{
+				"type":        "string",
+				"format":      "date-time",
+				"description": "When the failure was detected (log time with --file)",
+			},
+			"name": map[string]interface{}{
+				"type":        "string",
+				"description": "Expectation name (defaults to '<kind> #<n>')",
+			},
+			"kind": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{"see", "not_see", "count", "order"},
+				"description": "Expectation kind",
+			},
+			"expression": map[string]interface{}{
+				"type":        "string",
+				"description": "Where expression; order steps are joined with ' -> '",
+			},
+			"reason": map[string]interface{}{
+				"type":        "string",
+				"description": "Why the expectation failed",
+			},
+			"count": map[string]interface{}{
+				"type":        "integer",
+				"description": "Matching entries seen so far (order: steps matched)",
+			},
+			"max": map[string]interface{}{
+				"type":        "integer",
+				"description": "Allowed matches (count expectations)",
+			},
+			"within": map[string]interface{}{
+				"type":        "string",
+				"description": "Deadline (see expectations)",
+			},
+			"entry": map[string]interface{}{
+				"type":        "object",
+				"description": "Log entry that caused the failure (not_see, count, order)",
+			},
+			"tail_id": map[string]interface{}{
+				"type":        "string",
+				"description": "Tail invocation identifier (live mode)",
+			},
+			"session": map[string]interface{}{
+				"type":        "integer",
+				"description": "Session of the offending entry",
+			},
+		},
+		"required": []string{"type", "schemaVersion", "timestamp", "name", "kind", "expression", "reason", "count"},
+	}
+}
+
+func expectResultSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "object",
+		"title":       "Expect Result",
+		"description": "Final verdict of xcw expect; the command exits non-zero when passed is false",
+		"properties": map[string]interface{}{
+			"type": map[string]interface{}{
+				"type":  "string",
+				"const": "expect_result",
+			},
+			"schemaVersion": schemaVersionProperty(),
+			"timestamp": map[string]interface{}{
+				"type":        "string",
+				"format":      "date-time",
+				"description": "When the run finished",
+			},
+			"passed": map[string]interface{}{
+				"type":        "boolean",
+				"description": "True when every expectation passed",
+			},
+			"total": map[string]interface{}{
+				"type":        "integer",
+				"description": "Number of expectations",
+			},
+			"passed_count": map[string]interface{}{
+				"type": "integer",
+			},
+			"failed_count": map[string]interface{}{
+				"type": "integer",
+			},
+			"logs_checked": map[string]interface{}{
+				"type":        "integer",
+				"description": "Log entries evaluated",
+			},
+			"stop_reason": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{"all_decided", "max_duration", "end_of_file", "fail_fast", "interrupted"},
+				"description": "Why checking stopped",
+			},
+			"tail_id": map[string]interface{}{
+				"type":        "string",
+				"description": "Tail invocation identifier (live mode)",
+			},
+			"expectations": map[string]interface{}{
+				"type":        "array",
+				"description": "Outcome per expectation in file order",
+				"items": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"name":   map[string]interface{}{"type": "string"},
+						"kind":   map[string]interface{}{"type": "string"},
+						"status": map[string]interface{}{"type": "string", "enum": []string{"passed", "failed"}},
+						"count":  map[string]interface{}{"type": "integer"},
+						"reason": map[string]interface{}{"type": "string"},
+					},
+					"required": []string{"name", "kind", "status", "count"},
+				},
+			},
+		},
+		"required": []string{"type", "schemaVersion", "timestamp", "passed", "total", "passed_count", "failed_count", "logs_checked", "stop_reason", "expectations"},
+	}
+}
+
 func clearBufferSchema() map[string]interface{} {
 	return map[string]interface{}{
 		"type":        "object",
@@ -1335,7 +1972,12 @@ func discoverySchema() map[string]interface{} {
 			"schemaVersion": schemaVersionProperty(),
 			"app": map[string]interface{}{
 				"type":        "string",
-				"description": "App bundle identifier (when filtering by app)",
+				"description": "App bundle identifier (when filtering by app, or from session_start in --file recordings)",
+			},
+			"files": map[string]interface{}{
+				"type":        "array",
+				"description": "Recordings read with --file (base names; \"(stdin)\" for -)",
+				"items":       map[string]interface{}{"type": "string"},
 			},
 			"time_range": map[string]interface{}{
 				"type":        "object",
@@ -1357,17 +1999,13 @@ func discoverySchema() map[string]interface{} {
 			},
 			"subsystems": map[string]interface{}{
 				"type":        "array",
-				"description": "Subsystem aggregates",
-				"items": map[string]interface{}{
-					"type": "object",
-				},
+				"description": "Subsystem aggregates, most entries first",
+				"items":       discoverySourceSchema(),
 			},
 			"categories": map[string]interface{}{
 				"type":        "array",
-				"description": "Category aggregates",
-				"items": map[string]interface{}{
-					"type": "object",
-				},
+				"description": "Category aggregates, most entries first",
+				"items":       discoverySourceSchema(),
 			},
 			"processes": map[string]interface{}{
 				"type":        "array",
@@ -1380,11 +2018,105 @@ func discoverySchema() map[string]interface{} {
 				"type":        "object",
 				"description": "Level histogram",
 			},
+			"fields": fieldStatsSchema(),
+			"templates": map[string]interface{}{
+				"type":        "array",
+				"description": "Message templates mined from all entries, most frequent first (up to --top-n)",
+				"items": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"id":        map[string]interface{}{"type": "string", "description": "Stable template ID (hash of the masked message that started the template, unchanged as tokens become <*> and independent of other templates)"},
+						"template":  map[string]interface{}{"type": "string", "description": "Message with variable tokens as <*> or mask names like <n>, <uuid>"},
+						"count":     map[string]interface{}{"type": "integer"},
+						"level":     map[string]interface{}{"type": "string", "description": "Highest level seen for the template"},
+						"subsystem": map[string]interface{}{"type": "string", "description": "Most common subsystem"},
+						"example":   map[string]interface{}{"type": "string", "description": "First message seen with the template"},
+						"params":    templateParamsSchema(),
+					},
+					"required": []string{"id", "template", "count", "level", "example"},
+				},
+			},
+			"suggested_filters": map[string]interface{}{
+				"type":        "object",
+				"description": "Filters dropping the noisiest Apple subsystems (each at least 5% of entries); omitted when there are none",
+				"properties": map[string]interface{}{
+					"exclude_subsystems": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}, "description": "Values for --exclude-subsystem"},
+					"where":              map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}, "description": "Values for --where; keeps errors and faults from noisy subsystems that logged them"},
+					"command":            map[string]interface{}{"type": "string", "description": "xcw tail command applying the filters"},
+					"removes":            map[string]interface{}{"type": "integer", "description": "Entries in this discovery the filters would drop"},
+				},
+				"required": []string{"command", "removes"},
+			},
 		},
 		"required": []string{"type", "schemaVersion", "time_range", "total_count"},
 	}
 }
 
+// discoverySourceSchema describes a discovered subsystem or category.
+func discoverySourceSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"name":       map[string]interface{}{"type": "string"},
+			"count":      map[string]interface{}{"type": "integer"},
+			"levels":     map[string]interface{}{"type": "object", "description": "Entry count per level"},
+			"first_seen": map[string]interface{}{"type": "string", "format": "date-time"},
+			"last_seen":  map[string]interface{}{"type": "string", "format": "date-time"},
+			"samples":    map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}, "description": "Up to 3 distinct messages, first seen first"},
+			"templates": map[string]interface{}{
+				"type":        "array",
+				"description": "Up to 3 most frequent message templates",
+				"items": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"id":       map[string]interface{}{"type": "string"},
+						"template": map[string]interface{}{"type": "string"},
+						"count":    map[string]interface{}{"type": "integer"},
+					},
+					"required": []string{"id", "template", "count"},
+				},
+			},
+		},
+		"required": []string{"name", "count"},
+	}
+}
+
+// templateParamsSchema describes the parameter slots of a message template.
+func templateParamsSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "array",
+		"description": "Variable token positions with up to 3 sample values",
+		"items": map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"position": map[string]interface{}{"type": "integer", "description": "Token index (0-based, whitespace separated)"},
+				"token":    map[string]interface{}{"type": "string", "description": "Placeholder in the template"},
+				"samples":  map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+			},
+			"required": []string{"position", "token"},
+		},
+	}
+}
+
+// fieldStatsSchema describes aggregated extracted-field statistics.
+func fieldStatsSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "array",
+		"description": "Extracted message fields (--extract), most frequent first",
+		"items": map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"name":    map[string]interface{}{"type": "string"},
+				"count":   map[string]interface{}{"type": "integer"},
+				"samples": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+				"min":     map[string]interface{}{"type": "number", "description": "Minimum value (numeric fields only)"},
+				"max":     map[string]interface{}{"type": "number", "description": "Maximum value (numeric fields only)"},
+			},
+			"required": []string{"name", "count"},
+		},
+	}
+}
+
 func simulatorSchema() map[string]interface{} {
 	return map[string]interface{}{
 		"type":        "object",
@@ -1468,7 +2200,7 @@ func triggerErrorSchema() map[string]interface{} {
 			},
 			"trigger": map[string]interface{}{
 				"type":        "string",
-				"description": "Type of trigger (error, fault, or pattern:regex)",
+				"description": "Type of trigger (error, fault, pattern:regex, or rule:name)",
 			},
 			"command": map[string]interface{}{
 				"type":        "string",
@@ -1483,6 +2215,129 @@ func triggerErrorSchema() map[string]interface{} {
 	}
 }
 
+func markerSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "object",
+		"title":       "Marker",
+		"description": "Emitted by the marker action of a watch rule when its threshold is reached",
+		"properties": map[string]interface{}{
+			"type": map[string]interface{}{
+				"type":  "string",
+				"const": "marker",
+			},
+			"schemaVersion": schemaVersionProperty(),
+			"timestamp": map[string]interface{}{
+				"type":        "string",
+				"format":      "date-time",
+				"description": "Timestamp when the rule fired",
+			},
+			"tail_id": map[string]interface{}{
+				"type":        "string",
+				"description": "Tail invocation identifier",
+			},
+			"session": map[string]interface{}{
+				"type":        "integer",
+				"description": "Session number (when available)",
+			},
+			"rule": map[string]interface{}{
+				"type":        "string",
+				"description": "Rule name",
+			},
+			"message": map[string]interface{}{
+				"type":        "string",
+				"description": "Marker text from the rules file",
+			},
+			"matches": map[string]interface{}{
+				"type":        "integer",
+				"description": "Matching entries that reached the threshold",
+			},
+			"window": map[string]interface{}{
+				"type":        "string",
+				"description": "Threshold window (omitted when the threshold has none)",
+			},
+			"entry": map[string]interface{}{
+				"type":        "object",
+				"description": "Log entry that fired the rule",
+			},
+		},
+		"required": []string{"type", "schemaVersion", "timestamp", "rule", "message", "matches", "entry"},
+	}
+}
+
+func anomalySchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "object",
+		"title":       "Anomaly",
+		"description": "Emitted by tail and watch --anomalies when the stream departs from its learned baseline",
+		"properties": map[string]interface{}{
+			"type": map[string]interface{}{
+				"type":  "string",
+				"const": "anomaly",
+			},
+			"schemaVersion": schemaVersionProperty(),
+			"timestamp": map[string]interface{}{
+				"type":        "string",
+				"format":      "date-time",
+				"description": "Time the anomaly was detected (end of the rate window, or the new entry)",
+			},
+			"tail_id": map[string]interface{}{
+				"type":        "string",
+				"description": "Tail invocation identifier",
+			},
+			"session": map[string]interface{}{
+				"type":        "integer",
+				"description": "Session number (when available)",
+			},
+			"kind": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{"error_spike", "subsystem_silent", "new_pattern"},
+				"description": "What departed from the baseline",
+			},
+			"subsystem": map[string]interface{}{
+				"type":        "string",
+				"description": "Subsystem concerned (omitted for the whole stream)",
+			},
+			"pattern": map[string]interface{}{
+				"type":        "string",
+				"description": "Message template (new_pattern)",
+			},
+			"template_id": map[string]interface{}{
+				"type":        "string",
+				"description": "Template ID, as in discover templates[].id (new_pattern)",
+			},
+			"example": map[string]interface{}{
+				"type":        "string",
+				"description": "First message seen with the pattern (new_pattern)",
+			},
+			"level": map[string]interface{}{
+				"type":        "string",
+				"description": "Level of the example entry (new_pattern)",
+			},
+			"window": map[string]interface{}{
+				"type":        "string",
+				"description": "Rate window the baseline is learned over (e.g. 10s)",
+			},
+			"observed": map[string]interface{}{
+				"type":        "integer",
+				"description": "Entries counted in the anomalous window",
+			},
+			"baseline": map[string]interface{}{
+				"type":        "number",
+				"description": "Expected entries per window (EWMA)",
+			},
+			"score": map[string]interface{}{
+				"type":        "number",
+				"description": "Standard deviations above the baseline (error_spike)",
+			},
+			"message": map[string]interface{}{
+				"type":        "string",
+				"description": "Human-readable summary",
+			},
+		},
+		"required": []string{"type", "schemaVersion", "timestamp", "kind", "window", "observed", "baseline", "message"},
+	}
+}
+
 func triggerResultSchema() map[string]interface{} {
 	return map[string]interface{}{
 		"type":        "object",
@@ -1513,7 +2368,7 @@ func triggerResultSchema() map[string]interface{} {
 			},
 			"trigger": map[string]interface{}{
 				"type":        "string",
-				"description": "Type of trigger (error, fault, or pattern:regex)",
+				"description": "Type of trigger (error, fault, pattern:regex, or rule:name)",
 			},
 			"command": map[string]interface{}{
 				"type":        "string",
@@ -1521,7 +2376,11 @@ func triggerResultSchema() map[string]interface{} {
 			},
 			"exit_code": map[string]interface{}{
 				"type":        "integer",
-				"description": "Exit code from the trigger command (-1 when unavailable)",
+				"description": "Exit code from the trigger command (-1 when unavailable; 0 for a delivered webhook)",
+			},
+			"status_code": map[string]interface{}{
+				"type":        "integer",
+				"description": "HTTP status returned by a webhook trigger",
 			},
 			"duration_ms": map[string]interface{}{
 				"type":        "integer",
@@ -1619,6 +2478,32 @@ func configSchema() map[string]interface{} {
 				"type":        "object",
 				"description": "Watch defaults section",
 			},
+			"patterns": map[string]interface{}{
+				"type":        "object",
+				"description": "Message templating settings from the 'patterns:' section (masks, similarity)",
+			},
+			"presets": map[string]interface{}{
+				"type":        "array",
+				"description": "Named filter presets from the 'filters:' section (applied with --preset)",
+				"items": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"name":               map[string]interface{}{"type": "string"},
+						"source":             map[string]interface{}{"type": "string", "description": "Config file that defines the preset"},
+						"description":        map[string]interface{}{"type": "string"},
+						"pattern":            map[string]interface{}{"type": "string"},
+						"exclude":            map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+						"exclude_subsystems": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+						"subsystems":         map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+						"categories":         map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+						"processes":          map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+						"where":              map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+						"min_level":          map[string]interface{}{"type": "string"},
+						"max_level":          map[string]interface{}{"type": "string"},
+					},
+					"required": []string{"name", "source"},
+				},
+			},
 			"sources": map[string]interface{}{
 				"type":        "object",
 				"description": "Per-key provenance map: flag|env|config|default",
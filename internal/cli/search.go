@@ -0,0 +1,185 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/vburojevic/xcw/internal/domain"
+	"github.com/vburojevic/xcw/internal/filter"
+	"github.com/vburojevic/xcw/internal/index"
+	"github.com/vburojevic/xcw/internal/output"
+)
+
+// SearchCmd searches recorded sessions through the session index
+type SearchCmd struct {
+	Query     []string `arg:"" optional:"" help:"Text that must appear in the message (case-insensitive; several words must all appear)"`
+	Dir       string   `help:"Session directory (default: ~/.xcw/sessions)"`
+	Since     string   `help:"Only entries newer than this: duration back from now (e.g., '7d', '12h') or RFC3339 time"`
+	Until     string   `help:"Only entries older than this: duration back from now or RFC3339 time"`
+	MinLevel  string   `help:"Minimum log level: debug, info, default, error, fault (default: all levels unless global --level is set)"`
+	MaxLevel  string   `help:"Maximum log level: debug, info, default, error, fault"`
+	Subsystem []string `help:"Only these subsystems (can be repeated)"`
+	Process   []string `help:"Only these processes (can be repeated)"`
+	Session   int      `help:"Only this session number"`
+	TailID    string   `help:"Only entries from this tail invocation"`
+	Where     []string `short:"w" help:"Additional field filter expression applied to candidates (same syntax as tail --where)"`
+	Limit     int      `default:"100" help:"Maximum matches to output, newest first (0 = all)"`
+	NoUpdate  bool     `help:"Search the existing index without indexing new or changed recordings first"`
+}
+
+// SearchResult is the NDJSON summary written after search hits
+type SearchResult struct {
+	Type          string   `json:"type"`
+	SchemaVersion int      `json:"schemaVersion"`
+	Query         string   `json:"query,omitempty"`
+	Total         int      `json:"total"`
+	Returned      int      `json:"returned"`
+	Truncated     bool     `json:"truncated,omitempty"`
+	FilesSearched int      `json:"files_searched"`
+	FilesSkipped  int      `json:"files_skipped"`
+	FilesFailed   []string `json:"files_failed,omitempty"`
+	Files         []string `json:"files,omitempty"`
+	DurationMs    int64    `json:"duration_ms"`
+}
+
+// Run executes the search command
+func (c *SearchCmd) Run(globals *Globals) error {
+	started := time.Now()
+	q := index.Query{Terms: c.Query, Subsystems: c.Subsystem, Processes: c.Process, Session: c.Session, TailID: c.TailID, Limit: c.Limit}
+	var err error
+	if c.Since != "" {
+		if q.Since, err = parseTimeOrDuration(c.Since); err != nil {
+			return c.outputError(globals, "INVALID_SINCE", fmt.Sprintf("invalid --since: %s", err))
+		}
+	}
+	if c.Until != "" {
+		if q.Until, err = parseTimeOrDuration(c.Until); err != nil {
+			return c.outputError(globals, "INVALID_UNTIL", fmt.Sprintf("invalid --until: %s", err))
+		}
+	}
+	globalMin := ""
+	if globals.FlagProvided("level") {
+		globalMin = globals.Level
+	}
+	if c.MinLevel != "" || c.MaxLevel != "" || globalMin != "" {
+		q.MinLevel, q.MaxLevel = resolveLevels(c.MinLevel, c.MaxLevel, globalMin)
+	}
+	where, err := filter.NewWhereFilter(c.Where)
+	if err != nil {
+		return c.outputError(globals, "INVALID_FILTER", err.Error(), hintForFilter(err))
+	}
+
+	var store *index.Store
+	var stats index.UpdateStats
+	if c.NoUpdate {
+		store, err = index.Open(sessionIndexDir(c.Dir))
+	} else {
+		store, stats, err = updateSessionIndex(globals, c.Dir, false)
+	}
+	if err != nil {
+		return c.outputError(globals, "INDEX_ERROR", err.Error(), "rebuild with: xcw sessions index --rebuild")
+	}
+
+	var match func(*domain.LogEntry) bool
+	if where != nil {
+		match = where.Match
+	}
+	res, err := store.Search(q, match)
+	if err != nil {
+		return c.outputError(globals, "INDEX_ERROR", err.Error(), "rebuild with: xcw sessions index --rebuild")
+	}
+
+	result := &SearchResult{
+		Type:          "search_result",
+		SchemaVersion: output.SchemaVersion,
+		Query:         strings.Join(c.Query, " "),
+		Total:         res.Total,
+		Returned:      len(res.Hits),
+		Truncated:     res.Truncated,
+		FilesSearched: res.FilesSearched,
+		FilesSkipped:  res.FilesSkipped,
+		FilesFailed:   stats.Failed,
+	}
+	seen := map[string]bool{}
+	for _, h := range res.Hits {
+		if !seen[h.File] {
+			seen[h.File] = true
+			result.Files = append(result.Files, h.File)
+		}
+	}
+	result.DurationMs = time.Since(started).Milliseconds()
+
+	if globals.Format == "ndjson" {
+		w := output.NewNDJSONWriter(globals.Stdout)
+		for i := range res.Hits {
+			if err := w.Write(&res.Hits[i].Entry); err != nil {
+				return err
+			}
+		}
+		return w.WriteRaw(result)
+	}
+
+	tw := output.NewTextWriter(globals.Stdout)
+	for i := range res.Hits {
+		if err := tw.Write(&res.Hits[i].Entry); err != nil {
+			return err
+		}
+	}
+	summary := fmt.Sprintf("%d match(es)", result.Total)
+	if result.Truncated {
+		summary += fmt.Sprintf(", newest %d shown", result.Returned)
+	}
+	if _, err := fmt.Fprintf(globals.Stdout, "%s in %d file(s) (%d skipped by index), %dms\n",
+		summary, result.FilesSearched, result.FilesSkipped, result.DurationMs); err != nil {
+		return err
+	}
+	return printFailedRecordings(globals, result.FilesFailed)
+}
+
+// printFailedRecordings notes recordings the index could not read this time
+func printFailedRecordings(globals *Globals, failed []string) error {
+	if len(failed) == 0 {
+		return nil
+	}
+	_, err := fmt.Fprintf(globals.Stdout, "Could not read %d file(s), will retry: %s\n", len(failed), strings.Join(failed, ", "))
+	return err
+}
+
+func (c *SearchCmd) outputError(globals *Globals, code, message string, hint ...string) error {
+	return outputErrorCommon(globals, code, message, hint...)
+}
+
+// sessionIndexDir returns the index directory for a session directory
+func sessionIndexDir(dir string) string {
+	if dir == "" {
+		dir = GetDefaultSessionDir()
+	}
+	return filepath.Join(dir, index.DirName)
+}
+
+// updateSessionIndex brings the index of a session directory up to date
+func updateSessionIndex(globals *Globals, dir string, rebuild bool) (*index.Store, index.UpdateStats, error) {
+	store, err := index.Open(sessionIndexDir(dir))
+	if err != nil {
+		return nil, index.UpdateStats{}, err
+	}
+	sessions, err := ListSessions(dir)
+	if err != nil {
+		return nil, index.UpdateStats{}, err
+	}
+	sources := make([]index.Source, 0, len(sessions))
+	for _, s := range sessions {
+		sources = append(sources, index.Source{Path: s.Path, Size: s.Size, ModTime: s.ModTime})
+	}
+	stats, err := store.Update(sources, func(path string) ([]domain.LogEntry, error) {
+		rec, err := readRecordingFile(globals, path)
+		if err != nil {
+			globals.Debug("Not indexing %s: %s", path, err.Message)
+			return nil, err
+		}
+		return rec.Entries, nil
+	}, rebuild)
+	return store, stats, err
+}
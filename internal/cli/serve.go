@@ -0,0 +1,490 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/alecthomas/kong"
+)
+
+// JSON-RPC 2.0 error codes
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcCommandFailed  = -32000
+)
+
+// rpcCommands maps synchronous JSON-RPC methods to xcw commands.
+var rpcCommands = map[string]string{
+	"query":    "query",
+	"discover": "discover",
+	"summary":  "summary",
+	"list":     "list",
+}
+
+// ServeCmd speaks JSON-RPC 2.0 (one message per line) on stdin/stdout so agents
+// can keep a single xcw process, and its tails' ring buffers and session state,
+// alive across questions.
+type ServeCmd struct {
+	in io.Reader
+}
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+type rpcNotification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params"`
+}
+
+// rpcEventsResult is the result of synchronous methods: the NDJSON events the
+// equivalent CLI command would have printed.
+type rpcEventsResult struct {
+	Events []json.RawMessage `json:"events"`
+}
+
+type rpcTailEvent struct {
+	TailID string          `json:"tail_id"`
+	Event  json.RawMessage `json:"event"`
+}
+
+type rpcTailExit struct {
+	TailID string `json:"tail_id"`
+	Error  string `json:"error,omitempty"`
+}
+
+type rpcTailResult struct {
+	TailID  string `json:"tail_id"`
+	Stopped bool   `json:"stopped,omitempty"`
+}
+
+type rpcTail struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+type rpcServer struct {
+	globals *Globals
+	ctx     context.Context
+
+	outMu sync.Mutex
+	out   io.Writer
+
+	tailsMu sync.Mutex
+	tails   map[string]*rpcTail
+
+	wg sync.WaitGroup
+}
+
+// Run executes the serve command
+func (c *ServeCmd) Run(globals *Globals) error {
+	ctx, stop := signal.NotifyContext(globals.baseContext(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	in := c.in
+	if in == nil {
+		in = os.Stdin
+	}
+	s := &rpcServer{
+		globals: globals,
+		ctx:     ctx,
+		out:     globals.Stdout,
+		tails:   make(map[string]*rpcTail),
+	}
+	return s.serve(in)
+}
+
+func (s *rpcServer) serve(in io.Reader) error {
+	lines := make(chan []byte)
+	readErr := make(chan error, 1)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(in)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			line := append([]byte(nil), scanner.Bytes()...)
+			select {
+			case lines <- line:
+			case <-s.ctx.Done():
+				return
+			}
+		}
+		readErr <- scanner.Err()
+	}()
+
+	var err error
+loop:
+	for {
+		select {
+		case <-s.ctx.Done():
+			break loop
+		case line, ok := <-lines:
+			if !ok {
+				err = <-readErr
+				break loop
+			}
+			if len(bytes.TrimSpace(line)) == 0 {
+				continue
+			}
+			s.handleLine(line)
+		}
+	}
+
+	s.wg.Wait()
+	s.stopAllTails()
+	return err
+}
+
+func (s *rpcServer) handleLine(line []byte) {
+	var req rpcRequest
+	if err := json.Unmarshal(line, &req); err != nil {
+		s.respondError(json.RawMessage("null"), rpcParseError, fmt.Sprintf("parse error: %s", err), nil)
+		return
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		id := req.ID
+		if len(id) == 0 {
+			id = json.RawMessage("null")
+		}
+		s.respondError(id, rpcInvalidRequest, "invalid request: jsonrpc must be \"2.0\" and method is required", nil)
+		return
+	}
+
+	switch req.Method {
+	case "tail.start":
+		s.startTail(req)
+	case "tail.stop":
+		s.stopTail(req)
+	default:
+		command, ok := rpcCommands[req.Method]
+		if !ok {
+			s.respondError(req.ID, rpcMethodNotFound, fmt.Sprintf("method not found: %s", req.Method), nil)
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.runCommand(req, command)
+		}()
+	}
+}
+
+func (s *rpcServer) runCommand(req rpcRequest, command string) {
+	args, err := rpcParamsToArgs(req.Params)
+	if err != nil {
+		s.respondError(req.ID, rpcInvalidParams, err.Error(), nil)
+		return
+	}
+	var buf bytes.Buffer
+	run, err := s.prepare(command, args, &buf, nil)
+	if err != nil {
+		s.respondError(req.ID, rpcInvalidParams, err.Error(), nil)
+		return
+	}
+	runErr := run(s.ctx)
+	result := rpcEventsResult{Events: splitEvents(buf.Bytes())}
+	if runErr != nil {
+		s.respondError(req.ID, rpcCommandFailed, runErr.Error(), result)
+		return
+	}
+	s.respond(req.ID, result)
+}
+
+func (s *rpcServer) startTail(req rpcRequest) {
+	args, err := rpcParamsToArgs(req.Params)
+	if err != nil {
+		s.respondError(req.ID, rpcInvalidParams, err.Error(), nil)
+		return
+	}
+	tailID := generateTailID()
+	w := &rpcTailWriter{server: s, tailID: tailID}
+	run, err := s.prepare("tail", args, w, func(cli *CLI) { cli.Tail.tailID = tailID })
+	if err != nil {
+		s.respondError(req.ID, rpcInvalidParams, err.Error(), nil)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(s.ctx)
+	t := &rpcTail{cancel: cancel, done: make(chan struct{})}
+	s.tailsMu.Lock()
+	s.tails[tailID] = t
+	s.tailsMu.Unlock()
+
+	s.respond(req.ID, rpcTailResult{TailID: tailID})
+
+	go func() {
+		defer close(t.done)
+		defer cancel()
+		exit := rpcTailExit{TailID: tailID}
+		if err := run(ctx); err != nil {
+			exit.Error = err.Error()
+		}
+		w.flush()
+		s.tailsMu.Lock()
+		delete(s.tails, tailID)
+		s.tailsMu.Unlock()
+		s.notify("tail.exit", exit)
+	}()
+}
+
+func (s *rpcServer) stopTail(req rpcRequest) {
+	var params struct {
+		TailID string `json:"tail_id"`
+	}
+	if len(req.Params) > 0 {
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			s.respondError(req.ID, rpcInvalidParams, fmt.Sprintf("invalid params: %s", err), nil)
+			return
+		}
+	}
+	s.tailsMu.Lock()
+	t, ok := s.tails[params.TailID]
+	s.tailsMu.Unlock()
+	if !ok {
+		s.respondError(req.ID, rpcInvalidParams, fmt.Sprintf("unknown tail_id: %q", params.TailID), nil)
+		return
+	}
+	t.cancel()
+	<-t.done
+	s.respond(req.ID, rpcTailResult{TailID: params.TailID, Stopped: true})
+}
+
+func (s *rpcServer) stopAllTails() {
+	s.tailsMu.Lock()
+	tails := make([]*rpcTail, 0, len(s.tails))
+	for _, t := range s.tails {
+		tails = append(tails, t)
+	}
+	s.tailsMu.Unlock()
+	for _, t := range tails {
+		t.cancel()
+		<-t.done
+	}
+}
+
+// prepare parses args for command through the regular kong grammar so flags,
+// defaults and validation match the CLI exactly, and returns a runner that
+// executes the command with NDJSON output directed at stdout.
+func (s *rpcServer) prepare(command string, args []string, stdout io.Writer, configure func(*CLI)) (func(ctx context.Context) error, error) {
+	var cli CLI
+	parser, err := kong.New(&cli,
+		kong.Name("xcw"),
+		kong.Exit(func(int) {}),
+		kong.Writers(io.Discard, io.Discard),
+	)
+	if err != nil {
+		return nil, err
+	}
+	kctx, err := parser.Parse(append([]string{command}, args...))
+	if err != nil {
+		return nil, fmt.Errorf("invalid params: %s", err)
+	}
+	if configure != nil {
+		configure(&cli)
+	}
+
+	flagsSet := map[string]bool{}
+	for _, p := range kctx.Path {
+		if p.Flag != nil {
+			flagsSet[p.Flag.Name] = true
+		}
+	}
+
+	return func(ctx context.Context) error {
+		g := *s.globals
+		g.Format = "ndjson"
+		g.Stdout = stdout
+		g.FlagsSet = flagsSet
+		g.Context = ctx
+		if flagsSet["level"] {
+			g.Level = cli.Level
+		}
+		if flagsSet["quiet"] {
+			g.Quiet = cli.Quiet
+		}
+		if cli.SourceFile != "" {
+			g.SourceFile = cli.SourceFile
+		}
+		return kctx.Run(&g, kctx)
+	}, nil
+}
+
+func (s *rpcServer) respond(id json.RawMessage, result interface{}) {
+	if len(id) == 0 {
+		return
+	}
+	s.write(rpcResponse{JSONRPC: "2.0", ID: id, Result: result})
+}
+
+func (s *rpcServer) respondError(id json.RawMessage, code int, message string, data interface{}) {
+	if len(id) == 0 {
+		return
+	}
+	s.write(rpcResponse{JSONRPC: "2.0", ID: id, Error: &rpcError{Code: code, Message: message, Data: data}})
+}
+
+func (s *rpcServer) notify(method string, params interface{}) {
+	s.write(rpcNotification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+func (s *rpcServer) write(v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		s.globals.Debug("serve: failed to encode message: %v", err)
+		return
+	}
+	s.outMu.Lock()
+	defer s.outMu.Unlock()
+	if _, err := s.out.Write(append(data, '\n')); err != nil {
+		s.globals.Debug("serve: failed to write message: %v", err)
+	}
+}
+
+// rpcTailWriter turns each NDJSON line a tail writes into a tail.event notification.
+type rpcTailWriter struct {
+	server *rpcServer
+	tailID string
+	mu     sync.Mutex
+	buf    []byte
+}
+
+func (w *rpcTailWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.emit(w.buf[:i])
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+func (w *rpcTailWriter) flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.emit(w.buf)
+	w.buf = nil
+}
+
+func (w *rpcTailWriter) emit(line []byte) {
+	if events := splitEvents(line); len(events) > 0 {
+		w.server.notify("tail.event", rpcTailEvent{TailID: w.tailID, Event: events[0]})
+	}
+}
+
+// splitEvents splits NDJSON output into raw events. Non-JSON lines are kept as
+// JSON strings so nothing the command printed is lost.
+func splitEvents(data []byte) []json.RawMessage {
+	events := []json.RawMessage{}
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		if json.Valid(line) {
+			events = append(events, json.RawMessage(append([]byte(nil), line...)))
+			continue
+		}
+		quoted, _ := json.Marshal(string(line))
+		events = append(events, quoted)
+	}
+	return events
+}
+
+// rpcParamsToArgs converts a params object into CLI flags: {"app": "x",
+// "subsystem": ["a", "b"], "booted": true} becomes --app=x --subsystem=a
+// --subsystem=b --booted. Keys may use snake_case or kebab-case. An "args"
+// array is appended verbatim for anything not expressible as flags.
+func rpcParamsToArgs(raw json.RawMessage) ([]string, error) {
+	if len(bytes.TrimSpace(raw)) == 0 || string(bytes.TrimSpace(raw)) == "null" {
+		return nil, nil
+	}
+	var params map[string]interface{}
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return nil, fmt.Errorf("invalid params: expected an object: %s", err)
+	}
+
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		if k != "args" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var args []string
+	for _, k := range keys {
+		flag := "--" + strings.ReplaceAll(k, "_", "-")
+		values, ok := params[k].([]interface{})
+		if !ok {
+			values = []interface{}{params[k]}
+		}
+		for _, v := range values {
+			switch val := v.(type) {
+			case bool:
+				if val {
+					args = append(args, flag)
+				} else {
+					args = append(args, flag+"=false")
+				}
+			case string:
+				args = append(args, flag+"="+val)
+			case float64:
+				args = append(args, flag+"="+strconv.FormatFloat(val, 'f', -1, 64))
+			case nil:
+			default:
+				return nil, fmt.Errorf("invalid params: unsupported value for %q", k)
+			}
+		}
+	}
+
+	if extra, ok := params["args"]; ok {
+		list, ok := extra.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid params: \"args\" must be an array of strings")
+		}
+		for _, a := range list {
+			str, ok := a.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid params: \"args\" must be an array of strings")
+			}
+			args = append(args, str)
+		}
+	}
+	return args, nil
+}
@@ -0,0 +1,170 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// syncBuffer is a goroutine-safe bytes.Buffer for capturing serve output.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) messages(t *testing.T) []map[string]any {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var out []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(b.buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var v map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &v), line)
+		out = append(out, v)
+	}
+	return out
+}
+
+func writeServeCapture(t *testing.T) string {
+	t.Helper()
+	capture := filepath.Join(t.TempDir(), "capture.ndjson")
+	line := `{"timestamp":"2025-12-15 00:00:00.000000+0000","messageType":"Error","processImagePath":"/Applications/MyApp.app/MyApp","processID":123,"threadID":1,"subsystem":"com.example.myapp","category":"network","eventMessage":"Request failed","eventType":"logEvent"}`
+	require.NoError(t, os.WriteFile(capture, []byte(line+"\n"), 0o644))
+	return capture
+}
+
+func findResponse(msgs []map[string]any, id float64) map[string]any {
+	for _, m := range msgs {
+		if v, ok := m["id"].(float64); ok && v == id {
+			return m
+		}
+	}
+	return nil
+}
+
+func TestServeSyncMethods(t *testing.T) {
+	globals, _, _ := testGlobals("ndjson")
+	globals.SourceFile = writeServeCapture(t)
+	out := &syncBuffer{}
+	globals.Stdout = out
+
+	in := strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"query","params":{"app":"com.example.myapp","quiet":true}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"nope"}`,
+		`not json`,
+		`{"jsonrpc":"2.0","id":3,"method":"query","params":{"no_such_flag":true}}`,
+	}, "\n") + "\n"
+
+	cmd := &ServeCmd{in: strings.NewReader(in)}
+	require.NoError(t, cmd.Run(globals))
+
+	msgs := out.messages(t)
+
+	resp := findResponse(msgs, 1)
+	require.NotNil(t, resp)
+	events := resp["result"].(map[string]any)["events"].([]any)
+	require.Len(t, events, 1)
+	assert.Equal(t, "Request failed", events[0].(map[string]any)["message"])
+
+	resp = findResponse(msgs, 2)
+	require.NotNil(t, resp)
+	assert.EqualValues(t, rpcMethodNotFound, resp["error"].(map[string]any)["code"])
+
+	resp = findResponse(msgs, 3)
+	require.NotNil(t, resp)
+	assert.EqualValues(t, rpcInvalidParams, resp["error"].(map[string]any)["code"])
+
+	var sawParseError bool
+	for _, m := range msgs {
+		if e, ok := m["error"].(map[string]any); ok && e["code"] == float64(rpcParseError) {
+			sawParseError = true
+		}
+	}
+	assert.True(t, sawParseError)
+}
+
+func TestServeTailStartStop(t *testing.T) {
+	globals, _, _ := testGlobals("ndjson")
+	globals.SourceFile = writeServeCapture(t)
+	out := &syncBuffer{}
+	globals.Stdout = out
+
+	pr, pw := io.Pipe()
+	cmd := &ServeCmd{in: pr}
+	done := make(chan error, 1)
+	go func() { done <- cmd.Run(globals) }()
+
+	_, err := io.WriteString(pw, `{"jsonrpc":"2.0","id":1,"method":"tail.start","params":{"app":"com.example.myapp"}}`+"\n")
+	require.NoError(t, err)
+
+	var tailID string
+	require.Eventually(t, func() bool {
+		msgs := out.messages(t)
+		if resp := findResponse(msgs, 1); resp != nil {
+			tailID, _ = resp["result"].(map[string]any)["tail_id"].(string)
+		}
+		if tailID == "" {
+			return false
+		}
+		for _, m := range msgs {
+			if m["method"] != "tail.event" {
+				continue
+			}
+			params := m["params"].(map[string]any)
+			event := params["event"].(map[string]any)
+			if params["tail_id"] == tailID && event["type"] == "log" && event["tail_id"] == tailID {
+				return true
+			}
+		}
+		return false
+	}, 5*time.Second, 20*time.Millisecond)
+
+	_, err = io.WriteString(pw, `{"jsonrpc":"2.0","id":2,"method":"tail.stop","params":{"tail_id":"`+tailID+`"}}`+"\n")
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return findResponse(out.messages(t), 2) != nil
+	}, 5*time.Second, 20*time.Millisecond)
+
+	require.NoError(t, pw.Close())
+	require.NoError(t, <-done)
+
+	msgs := out.messages(t)
+	assert.Equal(t, true, findResponse(msgs, 2)["result"].(map[string]any)["stopped"])
+	var sawExit bool
+	for _, m := range msgs {
+		if m["method"] == "tail.exit" && m["params"].(map[string]any)["tail_id"] == tailID {
+			sawExit = true
+		}
+	}
+	assert.True(t, sawExit)
+}
+
+func TestRPCParamsToArgs(t *testing.T) {
+	args, err := rpcParamsToArgs(json.RawMessage(`{"app":"com.x","subsystem":["a","b"],"booted":true,"all":false,"limit":50,"exclude_subsystem":"c","args":["--since","1m"]}`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"--all=false", "--app=com.x", "--booted", "--exclude-subsystem=c", "--limit=50", "--subsystem=a", "--subsystem=b", "--since", "1m"}, args)
+
+	_, err = rpcParamsToArgs(json.RawMessage(`[1,2]`))
+	assert.Error(t, err)
+
+	args, err = rpcParamsToArgs(nil)
+	require.NoError(t, err)
+	assert.Empty(t, args)
+}
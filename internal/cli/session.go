@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -18,11 +19,39 @@ const SessionTimestampFormat = "20060102-150405"
 
 // SessionFile represents a session log file
 type SessionFile struct {
-	Path      string    `json:"path"`
-	Name      string    `json:"name"`
-	Timestamp time.Time `json:"timestamp"`
-	Size      int64     `json:"size"`
-	Prefix    string    `json:"prefix,omitempty"`
+	Path        string    `json:"path"`
+	Name        string    `json:"name"`
+	Timestamp   time.Time `json:"timestamp"`
+	Size        int64     `json:"size"`
+	Prefix      string    `json:"prefix,omitempty"`
+	Part        int       `json:"part,omitempty"`
+	Compression string    `json:"compression,omitempty"`
+	ModTime     time.Time `json:"-"`
+}
+
+// sessionPartRe matches the ".partN" suffix added by size/age rotation
+var sessionPartRe = regexp.MustCompile(`\.part([0-9]+)$`)
+
+// parseSessionName splits a session filename into its stem (without
+// extensions and part suffix), part number and compression codec.
+// ok is false for files that are not NDJSON recordings.
+func parseSessionName(name string) (stem string, part int, compression string, ok bool) {
+	switch {
+	case strings.HasSuffix(name, ".gz"):
+		compression, name = compressGzip, strings.TrimSuffix(name, ".gz")
+	case strings.HasSuffix(name, ".zst"):
+		compression, name = compressZstd, strings.TrimSuffix(name, ".zst")
+	}
+	if !strings.HasSuffix(name, ".ndjson") {
+		return "", 0, "", false
+	}
+	stem = strings.TrimSuffix(name, ".ndjson")
+	part = 1
+	if m := sessionPartRe.FindStringSubmatch(stem); m != nil {
+		part, _ = strconv.Atoi(m[1])
+		stem = strings.TrimSuffix(stem, m[0])
+	}
+	return stem, part, compression, true
 }
 
 // GetDefaultSessionDir returns the default session directory path
@@ -92,7 +121,8 @@ func ListSessions(dir string) ([]SessionFile, error) {
 		}
 
 		name := entry.Name()
-		if !strings.HasSuffix(name, ".ndjson") {
+		stem, part, compression, ok := parseSessionName(name)
+		if !ok {
 			continue
 		}
 
@@ -101,24 +131,28 @@ func ListSessions(dir string) ([]SessionFile, error) {
 			continue
 		}
 
-		// Parse timestamp from filename (format: 20060102-150405-prefix.ndjson)
+		// Parse timestamp from filename (format: 20060102-150405-prefix[.partN].ndjson[.gz|.zst])
 		session := SessionFile{
-			Path: filepath.Join(dir, name),
-			Name: name,
-			Size: info.Size(),
+			Path:        filepath.Join(dir, name),
+			Name:        name,
+			Size:        info.Size(),
+			Compression: compression,
+			ModTime:     info.ModTime(),
+		}
+		if part > 1 {
+			session.Part = part
 		}
 
 		// Try to parse timestamp from filename
-		if len(name) >= 15 {
-			timestampStr := name[:15] // "20060102-150405"
+		if len(stem) >= 15 {
+			timestampStr := stem[:15] // "20060102-150405"
 			if t, err := time.Parse(SessionTimestampFormat, timestampStr); err == nil {
 				session.Timestamp = t
 			}
 
-			// Extract prefix (everything between timestamp and .ndjson)
-			if len(name) > 16 {
-				prefixPart := name[16 : len(name)-7] // Skip "20060102-150405-" and ".ndjson"
-				session.Prefix = prefixPart
+			// Extract prefix (everything after "20060102-150405-")
+			if len(stem) > 16 {
+				session.Prefix = stem[16:]
 			}
 		}
 
@@ -130,9 +164,12 @@ func ListSessions(dir string) ([]SessionFile, error) {
 		sessions = append(sessions, session)
 	}
 
-	// Sort by timestamp, newest first
+	// Sort by timestamp, newest first; later parts of a session come first
 	sort.Slice(sessions, func(i, j int) bool {
-		return sessions[i].Timestamp.After(sessions[j].Timestamp)
+		if !sessions[i].Timestamp.Equal(sessions[j].Timestamp) {
+			return sessions[i].Timestamp.After(sessions[j].Timestamp)
+		}
+		return sessions[i].Part > sessions[j].Part
 	})
 
 	return sessions, nil
@@ -152,17 +189,21 @@ func LatestSession(dir string) (*SessionFile, error) {
 
 // CleanOldSessions removes old session files, keeping the specified number
 func CleanOldSessions(dir string, keep int) ([]string, error) {
+	return CleanSessions(dir, keep, 0)
+}
+
+// CleanSessions removes session files beyond the newest keep sessions (all
+// parts of a rotated session count as one) and then, when maxBytes > 0, the
+// oldest remaining files until the total size fits the budget. The budget
+// never removes the newest file.
+func CleanSessions(dir string, keep int, maxBytes int64) ([]string, error) {
 	sessions, err := ListSessions(dir)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(sessions) <= keep {
-		return nil, nil // Nothing to delete
-	}
-
 	var deleted []string
-	for _, session := range sessions[keep:] {
+	for _, session := range sessionsToClean(sessions, keep, maxBytes) {
 		if err := os.Remove(session.Path); err != nil {
 			return deleted, fmt.Errorf("failed to remove %s: %w", session.Path, err)
 		}
@@ -171,3 +212,54 @@ func CleanOldSessions(dir string, keep int) ([]string, error) {
 
 	return deleted, nil
 }
+
+// sessionStem returns the name shared by all parts of a rotated session
+func sessionStem(s SessionFile) string {
+	stem, _, _, ok := parseSessionName(s.Name)
+	if !ok {
+		return s.Name
+	}
+	return stem
+}
+
+// countSessions counts sessions, the parts of a rotated session being one
+func countSessions(sessions []SessionFile) int {
+	stems := make(map[string]bool, len(sessions))
+	for _, s := range sessions {
+		stems[sessionStem(s)] = true
+	}
+	return len(stems)
+}
+
+// sessionsToClean selects files for deletion from sessions (newest first).
+// keep counts sessions, so every part of the newest keep sessions is kept;
+// the size budget then drops the oldest kept files.
+func sessionsToClean(sessions []SessionFile, keep int, maxBytes int64) []SessionFile {
+	keepStems := make(map[string]bool, max(keep, 0))
+	drop := make([]bool, len(sessions))
+	var total int64
+	newest := true
+	for i, s := range sessions {
+		stem := sessionStem(s)
+		if !keepStems[stem] && len(keepStems) < keep {
+			keepStems[stem] = true
+		}
+		if !keepStems[stem] {
+			drop[i] = true
+			continue
+		}
+		total += s.Size
+		if maxBytes > 0 && total > maxBytes && !newest {
+			drop[i] = true
+		}
+		newest = false
+	}
+
+	var out []SessionFile
+	for i, s := range sessions {
+		if drop[i] {
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -10,6 +10,7 @@ type tailSessionTracker interface {
 	CheckEntry(entry *domain.LogEntry) *session.SessionChange
 	GetFinalSummary() *domain.SessionEnd
 	ForceRollover(alert string) *session.SessionChange
+	Sessions() []session.SessionRecord
 }
 
 type noopSessionTracker struct{}
@@ -22,3 +23,4 @@ func (t *noopSessionTracker) GetFinalSummary() *domain.SessionEnd { return nil }
 func (t *noopSessionTracker) ForceRollover(alert string) *session.SessionChange {
 	return nil
 }
+func (t *noopSessionTracker) Sessions() []session.SessionRecord { return nil }
@@ -4,9 +4,10 @@ import (
 	"bufio"
 	"encoding/json"
 	"fmt"
-	"os"
+	"io"
 	"path/filepath"
 	"strconv"
+	"time"
 
 	"github.com/vburojevic/xcw/internal/output"
 )
@@ -16,6 +17,7 @@ type SessionsCmd struct {
 	List  SessionsListCmd  `cmd:"" default:"1" help:"List session files"`
 	Show  SessionsShowCmd  `cmd:"" help:"Show path to a session file"`
 	Clean SessionsCleanCmd `cmd:"" help:"Delete old session files"`
+	Index SessionsIndexCmd `cmd:"" help:"Build or update the search index over session files"`
 }
 
 // SessionsListCmd lists session files
@@ -67,6 +69,8 @@ func (c *SessionsListCmd) Run(globals *Globals) error {
 				Timestamp:     s.Timestamp.Format("2006-01-02T15:04:05Z07:00"),
 				Size:          s.Size,
 				Prefix:        s.Prefix,
+				Part:          s.Part,
+				Compression:   s.Compression,
 			}
 			if err := enc.Encode(so); err != nil {
 				return err
@@ -107,6 +111,7 @@ type SessionsShowCmd struct {
 	Index  int    `arg:"" optional:"" help:"Session index from list (1-based)"`
 	Dir    string `help:"Session directory (default: ~/.xcw/sessions)"`
 	Latest bool   `help:"Show most recent session"`
+	Cat    bool   `help:"Print the session's contents instead of its path (gzip/zstd files are decompressed)"`
 }
 
 // Run executes the sessions show command
@@ -139,6 +144,27 @@ func (c *SessionsShowCmd) Run(globals *Globals) error {
 		session = &sessions[c.Index-1]
 	}
 
+	if c.Cat {
+		scanner, closer, err := CatSession(session.Path, 0)
+		if err != nil {
+			return c.outputError(globals, "SESSION_ERROR", err.Error())
+		}
+		defer func() {
+			if err := closer.Close(); err != nil {
+				globals.Debug("Failed to close session file: %v", err)
+			}
+		}()
+		for scanner.Scan() {
+			if _, err := fmt.Fprintln(globals.Stdout, scanner.Text()); err != nil {
+				return err
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return c.outputError(globals, "READ_ERROR", fmt.Sprintf("error reading session: %s", err))
+		}
+		return nil
+	}
+
 	if globals.Format == "ndjson" {
 		enc := json.NewEncoder(globals.Stdout)
 		so := SessionOutput{
@@ -149,6 +175,8 @@ func (c *SessionsShowCmd) Run(globals *Globals) error {
 			Timestamp:     session.Timestamp.Format("2006-01-02T15:04:05Z07:00"),
 			Size:          session.Size,
 			Prefix:        session.Prefix,
+			Part:          session.Part,
+			Compression:   session.Compression,
 		}
 		if err := enc.Encode(so); err != nil {
 			return err
@@ -169,13 +197,22 @@ func (c *SessionsShowCmd) outputError(globals *Globals, code, message string) er
 
 // SessionsCleanCmd deletes old session files
 type SessionsCleanCmd struct {
-	Dir    string `help:"Session directory (default: ~/.xcw/sessions)"`
-	Keep   int    `default:"10" help:"Number of sessions to keep"`
-	DryRun bool   `help:"Show what would be deleted without deleting"`
+	Dir          string `help:"Session directory (default: ~/.xcw/sessions)"`
+	Keep         int    `default:"10" help:"Number of sessions to keep (all .partN files of a rotated session count as one)"`
+	MaxTotalSize string `help:"Also delete the oldest files until the directory fits this budget (e.g., '2GB'); the newest file is always kept"`
+	DryRun       bool   `help:"Show what would be deleted without deleting"`
 }
 
 // Run executes the sessions clean command
 func (c *SessionsCleanCmd) Run(globals *Globals) error {
+	var maxBytes int64
+	if c.MaxTotalSize != "" {
+		var err error
+		if maxBytes, err = parseByteSize(c.MaxTotalSize); err != nil {
+			return c.outputError(globals, "INVALID_FLAGS", fmt.Sprintf("invalid --max-total-size: %s", err))
+		}
+	}
+
 	if c.DryRun {
 		// Show what would be deleted
 		sessions, err := ListSessions(c.Dir)
@@ -183,22 +220,22 @@ func (c *SessionsCleanCmd) Run(globals *Globals) error {
 			return c.outputError(globals, "LIST_SESSIONS_ERROR", err.Error())
 		}
 
-		if len(sessions) <= c.Keep {
+		toDelete := sessionsToClean(sessions, c.Keep, maxBytes)
+		if len(toDelete) == 0 {
 			if globals.Format == "ndjson" {
 				if err := output.NewNDJSONWriter(globals.Stdout).WriteInfo(
-					fmt.Sprintf("Nothing to clean (have %d, keeping %d)", len(sessions), c.Keep),
+					fmt.Sprintf("Nothing to clean (have %d, keeping %d)", countSessions(sessions), c.Keep),
 					"", "", "", ""); err != nil {
 					return err
 				}
 			} else {
-				if _, err := fmt.Fprintf(globals.Stdout, "Nothing to clean (have %d sessions, keeping %d)\n", len(sessions), c.Keep); err != nil {
+				if _, err := fmt.Fprintf(globals.Stdout, "Nothing to clean (have %d sessions, keeping %d)\n", countSessions(sessions), c.Keep); err != nil {
 					return err
 				}
 			}
 			return nil
 		}
 
-		toDelete := sessions[c.Keep:]
 		if globals.Format == "ndjson" {
 			for _, s := range toDelete {
 				if err := output.NewNDJSONWriter(globals.Stdout).WriteInfo(
@@ -221,7 +258,7 @@ func (c *SessionsCleanCmd) Run(globals *Globals) error {
 	}
 
 	// Actually delete
-	deleted, err := CleanOldSessions(c.Dir, c.Keep)
+	deleted, err := CleanSessions(c.Dir, c.Keep, maxBytes)
 	if err != nil {
 		return c.outputError(globals, "CLEAN_ERROR", err.Error())
 	}
@@ -262,6 +299,60 @@ func (c *SessionsCleanCmd) outputError(globals *Globals, code, message string) e
 	return outputErrorCommon(globals, code, message)
 }
 
+// SessionsIndexCmd builds or updates the session search index
+type SessionsIndexCmd struct {
+	Dir     string `help:"Session directory (default: ~/.xcw/sessions)"`
+	Rebuild bool   `help:"Re-index every session file instead of only new or changed ones"`
+}
+
+// Run executes the sessions index command
+func (c *SessionsIndexCmd) Run(globals *Globals) error {
+	started := time.Now()
+	store, stats, err := updateSessionIndex(globals, c.Dir, c.Rebuild)
+	if err != nil {
+		return c.outputError(globals, "INDEX_ERROR", err.Error())
+	}
+
+	out := SessionIndexOutput{
+		Type:          "session_index",
+		SchemaVersion: output.SchemaVersion,
+		IndexDir:      store.Dir(),
+		Files:         stats.Files,
+		Indexed:       stats.Indexed,
+		Skipped:       stats.Skipped,
+		Removed:       stats.Removed,
+		Entries:       stats.Entries,
+		Failed:        stats.Failed,
+		DurationMs:    time.Since(started).Milliseconds(),
+	}
+	if globals.Format == "ndjson" {
+		return output.NewNDJSONWriter(globals.Stdout).WriteRaw(out)
+	}
+	if _, err := fmt.Fprintf(globals.Stdout, "Indexed %d of %d session file(s) (%d unchanged, %d removed), %d entries, %dms\nIndex: %s\n",
+		out.Indexed, out.Files, out.Skipped, out.Removed, out.Entries, out.DurationMs, out.IndexDir); err != nil {
+		return err
+	}
+	return printFailedRecordings(globals, out.Failed)
+}
+
+func (c *SessionsIndexCmd) outputError(globals *Globals, code, message string) error {
+	return outputErrorCommon(globals, code, message)
+}
+
+// SessionIndexOutput is the NDJSON output of sessions index
+type SessionIndexOutput struct {
+	Type          string   `json:"type"`
+	SchemaVersion int      `json:"schemaVersion"`
+	IndexDir      string   `json:"index_dir"`
+	Files         int      `json:"files"`
+	Indexed       int      `json:"indexed"`
+	Skipped       int      `json:"skipped"`
+	Removed       int      `json:"removed"`
+	Entries       int      `json:"entries"`
+	Failed        []string `json:"failed,omitempty"`
+	DurationMs    int64    `json:"duration_ms"`
+}
+
 // SessionOutput is the NDJSON output format for session info
 type SessionOutput struct {
 	Type          string `json:"type"`
@@ -271,6 +362,8 @@ type SessionOutput struct {
 	Timestamp     string `json:"timestamp"`
 	Size          int64  `json:"size"`
 	Prefix        string `json:"prefix,omitempty"`
+	Part          int    `json:"part,omitempty"`
+	Compression   string `json:"compression,omitempty"`
 }
 
 // formatSize formats bytes into human-readable format
@@ -287,9 +380,10 @@ func formatSize(bytes int64) string {
 	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
 }
 
-// CatSession reads and outputs the contents of a session file
-func CatSession(path string, tail int) (*bufio.Scanner, *os.File, error) {
-	file, err := os.Open(path)
+// CatSession reads and outputs the contents of a session file, decompressing
+// gzip/zstd files
+func CatSession(path string, tail int) (*bufio.Scanner, io.Closer, error) {
+	file, err := openRecording(path)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -0,0 +1,326 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/vburojevic/xcw/internal/output"
+)
+
+// SQLCmd loads recordings into SQLite and runs ad-hoc queries over them
+type SQLCmd struct {
+	Query  string   `arg:"" optional:"" help:"SQL to run (tables: logs, fields, sessions, patterns, files)"`
+	File   []string `help:"Recording to load (.ndjson, .ndjson.gz, .ndjson.zst); can be repeated"`
+	Latest bool     `help:"Load the most recent session recording"`
+	Dir    string   `help:"Session directory for --latest (default: ~/.xcw/sessions)"`
+	DB     string   `help:"SQLite database to load into and query; kept after the run (default: temporary database)"`
+	Schema bool     `help:"Print the table definitions and exit"`
+}
+
+// SQLRow is one result row; Row keeps the column order of the query
+type SQLRow struct {
+	Type          string          `json:"type"`
+	SchemaVersion int             `json:"schemaVersion"`
+	Row           json.RawMessage `json:"row"`
+}
+
+// SQLResult is the NDJSON summary written after the rows
+type SQLResult struct {
+	Type          string          `json:"type"`
+	SchemaVersion int             `json:"schemaVersion"`
+	Database      string          `json:"database,omitempty"`
+	Loaded        []SQLLoadedFile `json:"loaded,omitempty"`
+	Columns       []string        `json:"columns,omitempty"`
+	Rows          int             `json:"rows"`
+	DurationMs    int64           `json:"duration_ms"`
+}
+
+// SQLLoadedFile reports what was loaded from one recording
+type SQLLoadedFile struct {
+	File     string `json:"file"`
+	Entries  int    `json:"entries"`
+	Sessions int    `json:"sessions"`
+}
+
+// Run executes the sql command
+func (c *SQLCmd) Run(globals *Globals) error {
+	started := time.Now()
+	if c.Schema {
+		_, err := io.WriteString(globals.Stdout, sqlSchema)
+		return err
+	}
+
+	query := strings.TrimSpace(c.Query)
+	if strings.HasPrefix(query, ".") {
+		return c.outputError(globals, "INVALID_FLAGS", "sqlite3 dot-commands are not supported",
+			"use --schema to see the tables, or open the --db file with sqlite3 directly")
+	}
+	files := c.File
+	if c.Latest {
+		s, err := LatestSession(c.Dir)
+		if err != nil {
+			return c.outputError(globals, "SESSION_ERROR", err.Error())
+		}
+		if s == nil {
+			return c.outputError(globals, "NO_SESSIONS", "no session files found")
+		}
+		files = append(files, s.Path)
+	}
+	if len(files) == 0 && c.DB == "" {
+		return c.outputError(globals, "INVALID_FLAGS", "nothing to query: pass --file, --latest or --db")
+	}
+	if query == "" && c.DB == "" {
+		return c.outputError(globals, "INVALID_FLAGS", "no query given; pass a query, or --db to keep the loaded database")
+	}
+
+	sqlite, err := exec.LookPath("sqlite3")
+	if err != nil {
+		return c.outputError(globals, "SQLITE_NOT_INSTALLED", "sqlite3 not found in PATH",
+			"sqlite3 ships with macOS; otherwise install it with: brew install sqlite")
+	}
+
+	db := c.DB
+	if db == "" {
+		tmp, err := os.CreateTemp("", "xcw-*.db")
+		if err != nil {
+			return c.outputError(globals, "FILE_CREATE_ERROR", fmt.Sprintf("failed to create temporary database: %s", err))
+		}
+		db = tmp.Name()
+		_ = tmp.Close()
+		defer func() {
+			if err := os.Remove(db); err != nil {
+				globals.Debug("Failed to remove %s: %v", db, err)
+			}
+		}()
+	}
+
+	result := &SQLResult{Type: "sql_result", SchemaVersion: output.SchemaVersion, Database: c.DB}
+	if len(files) > 0 {
+		recs := make([]sqlRecording, 0, len(files))
+		for _, path := range files {
+			rec, err := c.readFile(globals, path)
+			if err != nil {
+				return err
+			}
+			recs = append(recs, *rec)
+			result.Loaded = append(result.Loaded, SQLLoadedFile{
+				File:     rec.Name,
+				Entries:  len(rec.Entries),
+				Sessions: len(mergeSessionEvents(rec.Starts, rec.Ends)),
+			})
+		}
+		analyzer, err := newAnalyzer(globals)
+		if err != nil {
+			return c.outputError(globals, "INVALID_PATTERN", err.Error(), "check the patterns section of your config")
+		}
+		if err := loadSQLite(sqlite, db, recs, analyzer); err != nil {
+			return c.outputError(globals, "SQL_FAILED", fmt.Sprintf("loading recordings failed: %s", err))
+		}
+	}
+
+	var rows []json.RawMessage
+	if query != "" {
+		rows, err = runSQLiteQuery(sqlite, db, query)
+		if err != nil {
+			return c.outputError(globals, "SQL_FAILED", err.Error(), "run `xcw sql --schema` to see the tables")
+		}
+		if len(rows) > 0 {
+			result.Columns, _, err = sqlRowColumns(rows[0])
+			if err != nil {
+				return c.outputError(globals, "SQL_FAILED", fmt.Sprintf("unexpected sqlite3 output: %s", err))
+			}
+		}
+	}
+	result.Rows = len(rows)
+	result.DurationMs = time.Since(started).Milliseconds()
+
+	if globals.Format == "ndjson" {
+		w := output.NewNDJSONWriter(globals.Stdout)
+		for _, row := range rows {
+			if err := w.WriteRaw(&SQLRow{Type: "sql_row", SchemaVersion: output.SchemaVersion, Row: row}); err != nil {
+				return err
+			}
+		}
+		return w.WriteRaw(result)
+	}
+
+	if query == "" {
+		entries := 0
+		for _, f := range result.Loaded {
+			entries += f.Entries
+		}
+		_, err := fmt.Fprintf(globals.Stdout, "Loaded %d entries from %d file(s) into %s\n", entries, len(result.Loaded), c.DB)
+		return err
+	}
+	return writeSQLTable(globals.Stdout, rows)
+}
+
+func (c *SQLCmd) readFile(globals *Globals, path string) (*sqlRecording, error) {
+	rec, cerr := readRecordingFile(globals, path)
+	if cerr != nil {
+		return nil, c.outputError(globals, cerr.Code, cerr.Message)
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		abs = path
+	}
+	return &sqlRecording{Name: filepath.Base(path), Path: abs, recording: rec}, nil
+}
+
+func (c *SQLCmd) outputError(globals *Globals, code, message string, hint ...string) error {
+	return outputErrorCommon(globals, code, message, hint...)
+}
+
+// loadSQLite streams the load script for recs into sqlite3
+func loadSQLite(sqlite, db string, recs []sqlRecording, analyzer *output.Analyzer) error {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(writeLoadScript(pw, recs, analyzer, time.Now()))
+	}()
+	_, err := runSQLite(sqlite, []string{"-batch", "-bail", db}, pr)
+	// Unblock the script writer if sqlite3 stopped reading early
+	_ = pr.Close()
+	return err
+}
+
+// runSQLiteQuery runs query and returns the result rows as JSON objects
+func runSQLiteQuery(sqlite, db, query string) ([]json.RawMessage, error) {
+	out, err := runSQLite(sqlite, []string{"-batch", "-bail", "-json", db}, strings.NewReader(query+"\n"))
+	if err != nil {
+		return nil, err
+	}
+	// Each statement that returns rows prints one JSON array
+	var rows []json.RawMessage
+	dec := json.NewDecoder(bytes.NewReader(out))
+	for {
+		var batch []json.RawMessage
+		if err := dec.Decode(&batch); err == io.EOF {
+			return rows, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("unexpected sqlite3 output: %w", err)
+		}
+		rows = append(rows, batch...)
+	}
+}
+
+func runSQLite(sqlite string, args []string, stdin io.Reader) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(sqlite, args...)
+	cmd.Stdin = stdin
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, errors.New(msg)
+		}
+		return nil, err
+	}
+	// sqlite3 may exit 0 after reporting an error in batch mode
+	if msg := strings.TrimSpace(stderr.String()); msg != "" {
+		return nil, errors.New(msg)
+	}
+	return stdout.Bytes(), nil
+}
+
+// sqlRowColumns decodes a JSON row object preserving column order
+func sqlRowColumns(row json.RawMessage) (columns []string, values []string, err error) {
+	dec := json.NewDecoder(bytes.NewReader(row))
+	dec.UseNumber()
+	if _, err := dec.Token(); err != nil {
+		return nil, nil, err
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return nil, nil, fmt.Errorf("unexpected token %v", tok)
+		}
+		var v interface{}
+		if err := dec.Decode(&v); err != nil {
+			return nil, nil, err
+		}
+		columns = append(columns, key)
+		if v == nil {
+			values = append(values, "NULL")
+		} else {
+			values = append(values, fmt.Sprint(v))
+		}
+	}
+	return columns, values, nil
+}
+
+// writeSQLTable prints rows as an aligned text table
+func writeSQLTable(w io.Writer, rows []json.RawMessage) error {
+	if len(rows) == 0 {
+		_, err := fmt.Fprintln(w, "(no rows)")
+		return err
+	}
+	columns, _, err := sqlRowColumns(rows[0])
+	if err != nil {
+		return err
+	}
+	cells := make([][]string, 0, len(rows))
+	widths := make([]int, len(columns))
+	for i, col := range columns {
+		widths[i] = len(col)
+	}
+	for _, row := range rows {
+		_, values, err := sqlRowColumns(row)
+		if err != nil {
+			return err
+		}
+		for i, v := range values {
+			v = strings.ReplaceAll(v, "\n", " ")
+			values[i] = v
+			if i < len(widths) && len(v) > widths[i] {
+				widths[i] = len(v)
+			}
+		}
+		cells = append(cells, values)
+	}
+
+	line := func(values []string) error {
+		var b strings.Builder
+		for i := range columns {
+			v := ""
+			if i < len(values) {
+				v = values[i]
+			}
+			if i == len(columns)-1 {
+				b.WriteString(v)
+			} else {
+				fmt.Fprintf(&b, "%-*s  ", widths[i], v)
+			}
+		}
+		_, err := fmt.Fprintln(w, strings.TrimRight(b.String(), " "))
+		return err
+	}
+	if err := line(columns); err != nil {
+		return err
+	}
+	rule := make([]string, len(columns))
+	for i := range columns {
+		rule[i] = strings.Repeat("-", widths[i])
+	}
+	if err := line(rule); err != nil {
+		return err
+	}
+	for _, values := range cells {
+		if err := line(values); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "(%d row(s))\n", len(rows))
+	return err
+}
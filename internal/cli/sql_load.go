@@ -0,0 +1,246 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vburojevic/xcw/internal/domain"
+	"github.com/vburojevic/xcw/internal/output"
+)
+
+// sqlSchema is the layout recordings are loaded into. Timestamps are UTC
+// ISO-8601 text, so SQLite's date functions and string ordering both work.
+const sqlSchema = `CREATE TABLE IF NOT EXISTS files (
+  name TEXT PRIMARY KEY,      -- recording file name
+  path TEXT NOT NULL,
+  entries INTEGER NOT NULL,
+  first_timestamp TEXT,
+  last_timestamp TEXT,
+  loaded_at TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS logs (
+  id INTEGER PRIMARY KEY,
+  file TEXT NOT NULL,         -- files.name
+  timestamp TEXT NOT NULL,
+  level TEXT NOT NULL,        -- Debug, Info, Default, Error, Fault
+  severity INTEGER NOT NULL,  -- 0 (debug) .. 4 (fault), for level >= comparisons
+  process TEXT,
+  pid INTEGER,
+  tid INTEGER,
+  subsystem TEXT,
+  category TEXT,
+  message TEXT,
+  pattern TEXT,               -- message template, variable tokens as <*> or <n>, <uuid>, ...
+  template_id TEXT,           -- template ID, as in discover templates[].id
+  process_path TEXT,
+  sender_path TEXT,
+  event_type TEXT,
+  session INTEGER,
+  tail_id TEXT,
+  dedupe_count INTEGER
+);
+CREATE TABLE IF NOT EXISTS fields (
+  log_id INTEGER NOT NULL,    -- logs.id
+  key TEXT NOT NULL,
+  value TEXT
+);
+CREATE TABLE IF NOT EXISTS sessions (
+  file TEXT NOT NULL,
+  session INTEGER NOT NULL,
+  tail_id TEXT,
+  app TEXT,
+  simulator TEXT,
+  udid TEXT,
+  pid INTEGER,
+  previous_pid INTEGER,
+  alert TEXT,
+  version TEXT,
+  build TEXT,
+  started_at TEXT,
+  total_logs INTEGER,         -- from session_end; NULL while the session was still running
+  errors INTEGER,
+  faults INTEGER,
+  duration_seconds INTEGER
+);
+CREATE TABLE IF NOT EXISTS patterns (
+  file TEXT NOT NULL,
+  pattern TEXT NOT NULL,      -- error/fault pattern, as in logs.pattern
+  template_id TEXT NOT NULL,  -- as in logs.template_id
+  count INTEGER NOT NULL,
+  level TEXT NOT NULL,        -- most severe level seen
+  subsystem TEXT,             -- subsystem of the first occurrence
+  first_seen TEXT NOT NULL,
+  last_seen TEXT NOT NULL,
+  sample TEXT
+);
+CREATE INDEX IF NOT EXISTS logs_file ON logs(file);
+CREATE INDEX IF NOT EXISTS logs_timestamp ON logs(timestamp);
+CREATE INDEX IF NOT EXISTS fields_log_id ON fields(log_id);
+`
+
+// sqlTimeFormat keeps a fixed width so text comparison orders by time
+const sqlTimeFormat = "2006-01-02T15:04:05.000000Z"
+
+// sqlRecording is a parsed recording ready to load
+type sqlRecording struct {
+	Name string
+	Path string
+	*recording
+}
+
+// writeLoadScript writes SQL that creates the schema and (re)loads recs.
+// Rows from an earlier load of the same file name are replaced.
+func writeLoadScript(w io.Writer, recs []sqlRecording, analyzer *output.Analyzer, now time.Time) error {
+	bw := bufio.NewWriterSize(w, 256*1024)
+	if _, err := bw.WriteString(sqlSchema); err != nil {
+		return err
+	}
+	for _, rec := range recs {
+		file := sqlText(rec.Name)
+		fmt.Fprintf(bw, "BEGIN;\n")
+		fmt.Fprintf(bw, "DELETE FROM fields WHERE log_id IN (SELECT id FROM logs WHERE file = %s);\n", file)
+		for _, table := range []string{"logs", "sessions", "patterns"} {
+			fmt.Fprintf(bw, "DELETE FROM %s WHERE file = %s;\n", table, file)
+		}
+		fmt.Fprintf(bw, "DELETE FROM files WHERE name = %s;\n", file)
+
+		var first, last time.Time
+		var patterns []*sqlPattern
+		byPattern := map[string]*sqlPattern{}
+		templates := analyzer.EntryTemplates(rec.Entries)
+		for i := range rec.Entries {
+			e := &rec.Entries[i]
+			if first.IsZero() || e.Timestamp.Before(first) {
+				first = e.Timestamp
+			}
+			if e.Timestamp.After(last) {
+				last = e.Timestamp
+			}
+			pattern, templateID := templates[i].Template, templates[i].ID
+			if e.Level.Priority() >= domain.LogLevelError.Priority() {
+				p := byPattern[templateID]
+				if p == nil {
+					p = &sqlPattern{pattern: pattern, templateID: templateID, level: e.Level, subsystem: e.Subsystem, first: e.Timestamp, sample: e.Message}
+					byPattern[templateID] = p
+					patterns = append(patterns, p)
+				}
+				p.count++
+				if e.Timestamp.Before(p.first) {
+					p.first = e.Timestamp
+				}
+				if e.Timestamp.After(p.last) {
+					p.last = e.Timestamp
+				}
+				if e.Level.Priority() > p.level.Priority() {
+					p.level = e.Level
+				}
+			}
+			fmt.Fprintf(bw, "INSERT INTO logs (file, timestamp, level, severity, process, pid, tid, subsystem, category, message, pattern, template_id, process_path, sender_path, event_type, session, tail_id, dedupe_count) VALUES (%s, %s, %s, %d, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s);\n",
+				file, sqlTime(e.Timestamp), sqlText(string(e.Level)), e.Level.Priority(), sqlText(e.Process),
+				sqlInt(e.PID), sqlInt(e.TID), sqlText(e.Subsystem), sqlText(e.Category), sqlText(e.Message),
+				sqlText(pattern), sqlText(templateID), sqlText(e.ProcessPath), sqlText(e.SenderPath), sqlText(e.EventType), sqlInt(e.Session),
+				sqlText(e.TailID), sqlInt(e.DedupeCount))
+			for k, v := range e.Fields {
+				fmt.Fprintf(bw, "INSERT INTO fields (log_id, key, value) SELECT max(id), %s, %s FROM logs;\n", sqlText(k), sqlText(v))
+			}
+		}
+
+		for _, s := range mergeSessionEvents(rec.Starts, rec.Ends) {
+			total, errs, faults, duration := "NULL", "NULL", "NULL", "NULL"
+			if s.end != nil {
+				total, errs = strconv.Itoa(s.end.TotalLogs), strconv.Itoa(s.end.Errors)
+				faults, duration = strconv.Itoa(s.end.Faults), strconv.Itoa(s.end.DurationSeconds)
+			}
+			fmt.Fprintf(bw, "INSERT INTO sessions (file, session, tail_id, app, simulator, udid, pid, previous_pid, alert, version, build, started_at, total_logs, errors, faults, duration_seconds) VALUES (%s, %d, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s);\n",
+				file, s.Session, sqlText(s.TailID), sqlText(s.App), sqlText(s.Simulator), sqlText(s.UDID),
+				sqlInt(s.PID), sqlInt(s.PreviousPID), sqlText(s.Alert), sqlText(s.Version), sqlText(s.Build),
+				sqlText(s.Timestamp), total, errs, faults, duration)
+		}
+
+		for _, p := range patterns {
+			fmt.Fprintf(bw, "INSERT INTO patterns (file, pattern, template_id, count, level, subsystem, first_seen, last_seen, sample) VALUES (%s, %s, %s, %d, %s, %s, %s, %s, %s);\n",
+				file, sqlText(p.pattern), sqlText(p.templateID), p.count, sqlText(string(p.level)), sqlText(p.subsystem), sqlTime(p.first), sqlTime(p.last), sqlText(p.sample))
+		}
+
+		firstTS, lastTS := "NULL", "NULL"
+		if !first.IsZero() {
+			firstTS, lastTS = sqlTime(first), sqlTime(last)
+		}
+		fmt.Fprintf(bw, "INSERT INTO files (name, path, entries, first_timestamp, last_timestamp, loaded_at) VALUES (%s, %s, %d, %s, %s, %s);\n",
+			file, sqlText(rec.Path), len(rec.Entries), firstTS, lastTS, sqlTime(now))
+		if _, err := bw.WriteString("COMMIT;\n"); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// sqlPattern aggregates the error and fault entries sharing a pattern
+type sqlPattern struct {
+	pattern     string
+	templateID  string
+	count       int
+	level       domain.LogLevel
+	subsystem   string
+	first, last time.Time
+	sample      string
+}
+
+// sessionRow is one sessions table row built from session_start and the
+// matching session_end
+type sessionRow struct {
+	domain.SessionStart
+	end *domain.SessionSummary
+}
+
+// mergeSessionEvents pairs session_end events with their session_start by
+// session number and tail ID, in order of first appearance.
+func mergeSessionEvents(starts []domain.SessionStart, ends []domain.SessionEnd) []*sessionRow {
+	type key struct {
+		session int
+		tailID  string
+	}
+	var rows []*sessionRow
+	byKey := map[key]*sessionRow{}
+	for _, s := range starts {
+		row := &sessionRow{SessionStart: s}
+		rows = append(rows, row)
+		byKey[key{s.Session, s.TailID}] = row
+	}
+	for _, e := range ends {
+		summary := e.Summary
+		row, ok := byKey[key{e.Session, e.TailID}]
+		if !ok {
+			row = &sessionRow{SessionStart: domain.SessionStart{Session: e.Session, PID: e.PID, TailID: e.TailID}}
+			rows = append(rows, row)
+			byKey[key{e.Session, e.TailID}] = row
+		}
+		row.end = &summary
+	}
+	return rows
+}
+
+// sqlText quotes s as an SQL string literal; empty strings become NULL
+func sqlText(s string) string {
+	if s == "" {
+		return "NULL"
+	}
+	s = strings.ReplaceAll(s, "\x00", "")
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// sqlInt formats n; zero (an omitted JSON field) becomes NULL
+func sqlInt(n int) string {
+	if n == 0 {
+		return "NULL"
+	}
+	return strconv.Itoa(n)
+}
+
+func sqlTime(t time.Time) string {
+	return "'" + t.UTC().Format(sqlTimeFormat) + "'"
+}
@@ -1,7 +1,6 @@
 package cli
 
 import (
-	"context"
 	"fmt"
 	"regexp"
 	"time"
@@ -17,14 +16,20 @@ type SummaryCmd struct {
 	App       string `short:"a" required:"" help:"App bundle identifier to filter logs"`
 	Window    string `default:"5m" help:"Time window for summary (e.g., '5m', '1h')"`
 	Pattern   string `short:"p" aliases:"filter" help:"Regex pattern to filter log messages"`
+
+	ReportFlags
+	HistogramFlags
 }
 
 // Run executes the summary command
 func (c *SummaryCmd) Run(globals *Globals) error {
-	ctx := context.Background()
+	ctx := globals.baseContext()
+	if err := c.validateReport(globals); err != nil {
+		return err
+	}
 
 	// Find the simulator
-	mgr := simulator.NewManager()
+	mgr := newSimulatorManager(globals)
 	device, err := resolveSimulatorDevice(ctx, mgr, c.Simulator, false)
 	if err != nil {
 		return c.outputError(globals, "DEVICE_NOT_FOUND", err.Error(), hintForStreamOrQuery(err))
@@ -46,7 +51,7 @@ func (c *SummaryCmd) Run(globals *Globals) error {
 	}
 
 	// Query recent logs
-	reader := simulator.NewQueryReader()
+	reader := simulator.NewQueryReaderWithSource(mgr.Source())
 	var diagEmitter *output.Emitter
 	if globals.Format == "ndjson" {
 		diagEmitter = output.NewEmitter(globals.Stdout)
@@ -70,9 +75,24 @@ func (c *SummaryCmd) Run(globals *Globals) error {
 	}
 
 	// Analyze logs
-	analyzer := output.NewAnalyzer()
+	analyzer, err := newAnalyzer(globals)
+	if err != nil {
+		return c.outputError(globals, "INVALID_PATTERN", err.Error(), "check the patterns section of your config")
+	}
 	summary := analyzer.Summarize(entries)
 	patterns := analyzer.DetectPatterns(entries)
+	if err := c.addHistogram(globals, analyzer, summary, entries); err != nil {
+		return err
+	}
+
+	if c.Report != "" {
+		if err := c.writeReport(globals, output.NewEnhancedSummaryOutput(summary, output.EnhancePatterns(patterns)), "xcw summary "+c.App); err != nil {
+			return err
+		}
+		if c.reportReplacesOutput() {
+			return nil
+		}
+	}
 
 	// Output results
 	if globals.Format == "ndjson" {
@@ -120,6 +140,15 @@ func (c *SummaryCmd) Run(globals *Globals) error {
 		}
 	}
 
+	if len(summary.Histogram) > 0 {
+		if _, err := fmt.Fprintln(globals.Stdout); err != nil {
+			return err
+		}
+		if err := printTimeline(globals.Stdout, summary); err != nil {
+			return err
+		}
+	}
+
 	if len(summary.TopErrors) > 0 {
 		if _, err := fmt.Fprintln(globals.Stdout, "\nTop Errors:"); err != nil {
 			return err
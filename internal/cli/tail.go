@@ -1,7 +1,6 @@
 package cli
 
 import (
-	"context"
 	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
@@ -32,9 +31,12 @@ type TailCmd struct {
 	TailFilterFlags
 	TailOutputFlags
 	TailAgentFlags
+	OTLPFlags
+	AnomalyFlags
 
-	Simulator   string   `short:"s" help:"Simulator name or UDID"`
+	Simulator   []string `short:"s" sep:"none" help:"Simulator name or UDID (repeat to tail several simulators at once)"`
 	Booted      bool     `short:"b" help:"Use booted simulator (error if multiple)"`
+	AllBooted   bool     `help:"Tail every booted simulator at once (events carry udid/simulator)"`
 	App         string   `short:"a" help:"App bundle identifier to filter logs (required unless --predicate or --all)"`
 	All         bool     `help:"Allow streaming without --app/--predicate (can be very noisy)"`
 	Subsystem   []string `help:"Filter by subsystem (can be repeated)"`
@@ -43,6 +45,15 @@ type TailCmd struct {
 	BufferSize  int      `default:"100" help:"Number of recent logs to buffer"`
 	Recorder    string   `short:"r" help:"Write raw log stream to file (with sessions)"`
 	SessionName string   `help:"Custom session name for recording"`
+
+	// tailID overrides the generated tail ID (set by serve so notifications and
+	// events share one ID).
+	tailID string
+	// presets records the expanded --preset names for --dry-run-json.
+	presets []string
+	// tagDevice marks entries with the simulator's UDID and name (set for
+	// each device of a multi-device tail).
+	tagDevice bool
 }
 
 // Run executes the tail command
@@ -50,11 +61,14 @@ func (c *TailCmd) Run(globals *Globals) error {
 	// Disable styles when stdout is not a TTY
 	maybeNoStyle(globals)
 	applyTailDefaults(globals.Config, c)
-	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	ctx, stop := signal.NotifyContext(globals.baseContext(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
 	// Unique ID for this tail invocation (carried on all events)
-	tailID := generateTailID()
+	tailID := c.tailID
+	if tailID == "" {
+		tailID = generateTailID()
+	}
 	var log *agentLogger
 	clk := clock.New()
 
@@ -65,9 +79,30 @@ func (c *TailCmd) Run(globals *Globals) error {
 	if err := validateFlags(globals, c.DryRunJSON, c.Tmux); err != nil {
 		return err
 	}
+	if len(c.Preset) > 0 {
+		if err := applyPresets(globals.Config, c.Preset, presetTarget{
+			Pattern: &c.Pattern, Exclude: &c.Exclude, ExcludeSubsystem: &c.ExcludeSubsystem,
+			Subsystem: &c.Subsystem, Category: &c.Category, Process: &c.Process,
+			Where: &c.Where, MinLevel: &c.MinLevel, MaxLevel: &c.MaxLevel,
+		}); err != nil {
+			return c.outputError(globals, "INVALID_PRESET", err.Error(), hintForPreset(globals.Config))
+		}
+		// Expanded once; per-device children inherit the merged filters.
+		c.presets, c.Preset = c.Preset, nil
+	}
 	if err := validateAppPredicateAll(c.App, c.Predicate, c.All, len(c.Subsystem) > 0 || len(c.Category) > 0); err != nil {
 		return outputErrorCommon(globals, err.Code, err.Message, err.Hint)
 	}
+	if c.Serve != "" && globals.Format != "ndjson" {
+		return c.outputError(globals, "INVALID_FLAGS", "--serve requires --format ndjson")
+	}
+	if c.Serve != "" {
+		addr, err := resolveServeAddr(c.Serve, c.ServePublic)
+		if err != nil {
+			return c.outputError(globals, "INVALID_FLAGS", err.Error(), "serve on 127.0.0.1, or add --serve-public to expose the stream to the network")
+		}
+		c.Serve = addr
+	}
 	if c.Resume {
 		if globals.Format != "ndjson" {
 			return c.outputError(globals, "INVALID_FLAGS", "--resume requires --format ndjson")
@@ -77,9 +112,17 @@ func (c *TailCmd) Run(globals *Globals) error {
 		}
 	}
 
-	// Find the simulator
-	mgr := simulator.NewManager()
-	device, err := resolveSimulatorDevice(ctx, mgr, c.Simulator, c.Booted)
+	rotator := newRotation(nil)
+	if err := c.configureRotation(rotator); err != nil {
+		return c.outputError(globals, "INVALID_FLAGS", err.Error())
+	}
+
+	// Find the simulator(s)
+	mgr := newSimulatorManager(globals)
+	if c.multiDevice() {
+		return c.runMultiDevice(ctx, globals, mgr, tailID)
+	}
+	device, err := resolveSimulatorDevice(ctx, mgr, c.simulatorArg(), c.Booted)
 	if err != nil {
 		return c.outputError(globals, "DEVICE_NOT_FOUND", err.Error(), hintForStreamOrQuery(err))
 	}
@@ -113,10 +156,19 @@ func (c *TailCmd) Run(globals *Globals) error {
 		}
 	}
 
+	// With --serve, everything written to stdout (or the output file) is also
+	// published to SSE clients. Work on a copy so the caller's Globals is untouched.
+	var hub *eventHub
+	if c.Serve != "" && !c.DryRunJSON {
+		hub = newEventHub()
+		g := *globals
+		g.Stdout = hub.tee(globals.Stdout)
+		globals = &g
+	}
+
 	// Determine output destination
 	var outputWriter io.Writer = globals.Stdout
 	var tmuxMgr *tmux.Manager
-	rotator := newRotation(nil)
 
 	// Determine output file path builder (supports per-session rotation)
 	var pathBuilder func(session int) (string, error)
@@ -145,13 +197,22 @@ func (c *TailCmd) Run(globals *Globals) error {
 			return nil
 		}
 		rotator.pathBuilder = pathBuilder
-		bw, file, path, err := rotator.Open(sessionNum)
+		fw, path, err := rotator.Open(sessionNum)
 		if err != nil {
 			return c.outputError(globals, "FILE_CREATE_ERROR", err.Error())
 		}
-		_ = file
-		if bw != nil {
-			outputWriter = bw
+		if fw != nil {
+			outputWriter = fw
+		}
+		rotator.onPart = func(path string, part int) error {
+			if globals.Quiet {
+				return nil
+			}
+			if globals.Format == "ndjson" {
+				return output.NewNDJSONWriter(globals.Stdout).WriteRotationPart(path, tailID, sessionNum, part)
+			}
+			_, err := fmt.Fprintf(globals.Stderr, "Writing logs to %s\n", path)
+			return err
 		}
 		if !globals.Quiet && path != "" {
 			if globals.Format == "ndjson" {
@@ -277,6 +338,18 @@ func (c *TailCmd) Run(globals *Globals) error {
 	}
 	// Pattern/exclude are applied in the simulator streamer; keep pipeline for where-only filtering.
 	pipeline := filter.NewPipeline(nil, nil, whereFilter)
+	extractor, err := filter.NewFieldExtractor(c.Extract, c.ExtractRegex)
+	if err != nil {
+		return c.outputError(globals, "INVALID_FILTER", err.Error(), hintForExtract(err))
+	}
+	traceExtractor, err := filter.NewTraceExtractor(c.ExtractTrace, c.ExtractTraceRegex)
+	if err != nil {
+		return c.outputError(globals, "INVALID_FILTER", err.Error(), hintForExtractTrace(err))
+	}
+	grouper, err := buildCoalescer(c.Group, c.GroupWindow)
+	if err != nil {
+		return c.outputError(globals, "INVALID_GROUP_WINDOW", err.Error(), "use a positive duration such as '10ms' or '50ms'")
+	}
 
 	// Determine log level (command-specific overrides global)
 	minLevel, maxLevel := resolveLevels(c.MinLevel, c.MaxLevel, globals.Level)
@@ -299,9 +372,33 @@ func (c *TailCmd) Run(globals *Globals) error {
 	}
 
 	if c.DryRunJSON {
+		var groupWindow string
+		if grouper != nil {
+			groupWindow = grouper.Window().String()
+		}
+		dryRun := struct {
+			simulator.StreamOptions
+			UDID            string   `json:"UDID,omitempty"`
+			Simulator       string   `json:"Simulator,omitempty"`
+			Pattern         string   `json:"Pattern,omitempty"`
+			ExcludePatterns []string `json:"ExcludePatterns,omitempty"`
+			Where           []string `json:"Where,omitempty"`
+			Presets         []string `json:"Presets,omitempty"`
+			GroupWindow     string   `json:"GroupWindow,omitempty"`
+		}{
+			StreamOptions:   opts,
+			Pattern:         c.Pattern,
+			ExcludePatterns: c.Exclude,
+			Where:           c.Where,
+			Presets:         c.presets,
+			GroupWindow:     groupWindow,
+		}
+		if c.tagDevice {
+			dryRun.UDID, dryRun.Simulator = device.UDID, device.Name
+		}
 		enc := json.NewEncoder(globals.Stdout)
 		enc.SetIndent("", "  ")
-		return enc.Encode(opts)
+		return enc.Encode(dryRun)
 	}
 
 	globals.Debug("Stream options: BundleID=%s, MinLevel=%s, BufferSize=%d", opts.BundleID, opts.MinLevel, opts.BufferSize)
@@ -324,6 +421,9 @@ func (c *TailCmd) Run(globals *Globals) error {
 
 	var emitter *output.Emitter
 	setWriter := func(w io.Writer) {
+		if hub != nil && w != globals.Stdout {
+			w = hub.tee(w)
+		}
 		if globals.Format == "ndjson" {
 			emitter = output.NewEmitter(w)
 			writer = emitter
@@ -334,6 +434,17 @@ func (c *TailCmd) Run(globals *Globals) error {
 	}
 	setWriter(outputWriter)
 
+	exporter, err := c.newExporter(c.App, appVersion, appBuild, device)
+	if err != nil {
+		return c.outputError(globals, "INVALID_FLAGS", err.Error())
+	}
+	defer closeExporter(globals, exporter, func(msg string) { emitWarning(globals, emitter, msg) })
+
+	anomalies, err := c.newAnomalyDetector()
+	if err != nil {
+		return c.outputError(globals, "INVALID_FLAGS", err.Error())
+	}
+
 	// Create session tracker for detecting app relaunches (only meaningful when tailing an app)
 	var sessionTracker tailSessionTracker
 	if c.App != "" {
@@ -345,6 +456,12 @@ func (c *TailCmd) Run(globals *Globals) error {
 	}
 	log = newAgentLogger(globals, tailID, sessionTracker.CurrentSession)
 
+	// Crash detection follows the tracked app, like session tracking
+	var crashDetector *session.CrashDetector
+	if c.App != "" && !c.NoCrashDetect {
+		crashDetector = session.NewCrashDetector(resolveCrashReportsDir(c.CrashReportsDir))
+	}
+
 	// Emit metadata for agents
 	if emitter != nil {
 		if err := emitter.Metadata(Version, Commit, ""); err != nil {
@@ -384,6 +501,13 @@ func (c *TailCmd) Run(globals *Globals) error {
 		cutoffTimer = clk.Timer(dur)
 		defer cutoffTimer.Stop()
 	}
+	// Flush a pending multi-line group once no continuation arrived for a full window
+	var groupTicker *clock.Ticker
+	groupIdle := true
+	if grouper != nil {
+		groupTicker = clk.Ticker(grouper.Window())
+		defer groupTicker.Stop()
+	}
 	var maxLogs = c.MaxLogs
 	var resumeMaxGap time.Duration
 	resumeLimit := c.ResumeLimit
@@ -432,6 +556,71 @@ func (c *TailCmd) Run(globals *Globals) error {
 		dedupeFilter = filter.NewDedupeFilter(dedupeWindow)
 	}
 
+	emitCrash := func(crash *domain.CrashDetected) error {
+		if crash == nil {
+			return nil
+		}
+		crash.TailID = tailID
+		crash.Lines = crashLines(streamer.GetBufferedLogs(), crash.PID, c.CrashLines)
+		if log != nil {
+			log.Debug("crash detected pid=%d trigger=%s type=%s", crash.PID, crash.Trigger, crash.ExceptionType)
+		}
+		if emitter != nil {
+			return emitter.CrashDetected(crash)
+		}
+		msg := fmt.Sprintf("💥 CRASH: %s (PID: %d) %s %s", crash.Process, crash.PID, crash.ExceptionType, crash.ExceptionReason)
+		if crash.IPSPath != "" {
+			msg += " - report: " + crash.IPSPath
+		}
+		if _, err := fmt.Fprintf(globals.Stderr, "%s\n", warnStyle.Render(strings.TrimSpace(msg))); err != nil {
+			globals.Debug("failed to write crash banner: %v", err)
+		}
+		return nil
+	}
+
+	// Crashes wait for their .ips report, which is written after the process died
+	var crashTicker *clock.Ticker
+	if crashDetector != nil {
+		crashTicker = clk.Ticker(time.Second)
+		defer crashTicker.Stop()
+	}
+	emitPendingCrashes := func(crashes []*domain.CrashDetected) error {
+		for _, crash := range crashes {
+			if err := emitCrash(crash); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	emitAnomalies := func(found []*domain.Anomaly) error {
+		for _, a := range found {
+			a.TailID = tailID
+			a.Session = sessionTracker.CurrentSession()
+			if emitter != nil {
+				if err := emitter.Anomaly(a); err != nil {
+					return err
+				}
+				continue
+			}
+			if globals.Quiet {
+				continue
+			}
+			if _, err := fmt.Fprintf(globals.Stderr, "%s\n", warnStyle.Render(anomalyText(a))); err != nil {
+				globals.Debug("failed to write anomaly: %v", err)
+			}
+		}
+		return nil
+	}
+
+	// Close anomaly windows even when no entries arrive, so silence is noticed
+	var anomalyTicker *clock.Ticker
+	observedAt, tickAnomalies := anomalyClock(globals)
+	if anomalies != nil && tickAnomalies {
+		anomalyTicker = clk.Ticker(anomalies.Window())
+		defer anomalyTicker.Stop()
+	}
+
 	emitHints := func() {
 		if c.NoAgentHints {
 			return
@@ -512,6 +701,11 @@ func (c *TailCmd) Run(globals *Globals) error {
 
 			// Session changed - emit events
 			if sessionChange.EndSession != nil {
+				if crashDetector != nil {
+					if err := emitCrash(crashDetector.ProcessExited(sessionChange.EndSession.PID, sessionChange.EndSession.Session)); err != nil {
+						return false, false, err
+					}
+				}
 				// Output session end with summary
 				if emitter != nil {
 					if err := emitter.SessionEnd(sessionChange.EndSession); err != nil {
@@ -577,6 +771,17 @@ func (c *TailCmd) Run(globals *Globals) error {
 			}
 		}
 
+		// Crash signatures are checked before filtering so a hidden line still counts
+		if crashDetector != nil {
+			if err := emitCrash(crashDetector.Observe(entry, sessionTracker.CurrentSession())); err != nil {
+				return false, false, err
+			}
+		}
+
+		// Extract message fields before where filtering so fields.<key> resolves
+		extractor.Extract(entry)
+		traceExtractor.Extract(entry)
+
 		// Apply where filter if enabled
 		if pipeline != nil && !pipeline.Match(entry) {
 			return false, false, nil
@@ -599,10 +804,21 @@ func (c *TailCmd) Run(globals *Globals) error {
 		// Set session number on entry
 		entry.Session = sessionTracker.CurrentSession()
 		entry.TailID = tailID
+		if c.tagDevice {
+			entry.UDID, entry.Simulator = device.UDID, device.Name
+		}
 
 		if err := writer.Write(entry); err != nil {
 			return false, false, err
 		}
+		if exporter != nil {
+			exporter.Export(entry)
+		}
+		if anomalies != nil {
+			if err := emitAnomalies(anomalies.Observe(entry, observedAt(entry, clk.Now()))); err != nil {
+				return false, false, err
+			}
+		}
 
 		logsSinceLast++
 		totalLogs++
@@ -612,6 +828,11 @@ func (c *TailCmd) Run(globals *Globals) error {
 		}
 
 		if maxLogs > 0 && totalLogs >= maxLogs {
+			if crashDetector != nil {
+				if err := emitPendingCrashes(crashDetector.Flush()); err != nil {
+					return false, true, err
+				}
+			}
 			if emitter != nil {
 				final := sessionTracker.GetFinalSummary()
 				sessionNum := sessionTracker.CurrentSession()
@@ -690,7 +911,7 @@ func (c *TailCmd) Run(globals *Globals) error {
 			return nil
 		}
 
-		reader := simulator.NewQueryReader()
+		reader := simulator.NewQueryReaderWithSource(mgr.Source())
 		opts := simulator.QueryOptions{
 			BundleID:          c.App,
 			Subsystems:        c.Subsystem,
@@ -716,6 +937,9 @@ func (c *TailCmd) Run(globals *Globals) error {
 		if err != nil {
 			return err
 		}
+		if grouper != nil {
+			entries = filter.CoalesceEntries(entries, grouper.Window())
+		}
 
 		filled := 0
 		for i := range entries {
@@ -811,6 +1035,25 @@ func (c *TailCmd) Run(globals *Globals) error {
 	}()
 	globals.Debug("Log stream started successfully")
 
+	if hub != nil {
+		httpServer := newTailHTTPServer(hub, streamer, sessionTracker, tailID)
+		if err := httpServer.Start(c.Serve); err != nil {
+			return c.outputError(globals, "SERVE_FAILED", err.Error(), "choose a free address, e.g. --serve 127.0.0.1:7071")
+		}
+		defer func() {
+			if err := httpServer.Close(); err != nil {
+				globals.Debug("failed to stop HTTP server: %v", err)
+			}
+		}()
+		if !globals.Quiet {
+			if err := output.NewNDJSONWriter(globals.Stdout).WriteInfo(
+				fmt.Sprintf("Serving HTTP on http://%s (GET /events, /buffer, /stats, /sessions)", httpServer.Addr()),
+				device.Name, device.UDID, "", ""); err != nil {
+				return err
+			}
+		}
+	}
+
 	// Emit ready event when --wait-for-launch is used (signals log capture is active)
 	if c.WaitForLaunch {
 		if emitter != nil {
@@ -849,6 +1092,18 @@ func (c *TailCmd) Run(globals *Globals) error {
 	for {
 		select {
 		case <-ctx.Done():
+			if grouper != nil {
+				if done, ok := grouper.Flush(); ok {
+					if stop, _, err := handleEntry(&done, true); err != nil || stop {
+						return err
+					}
+				}
+			}
+			if crashDetector != nil {
+				if err := emitPendingCrashes(crashDetector.Flush()); err != nil {
+					return err
+				}
+			}
 			// Output final summary
 			if err := c.outputSummary(writer, streamer, tailID, clk.Now()); err != nil {
 				return err
@@ -869,6 +1124,14 @@ func (c *TailCmd) Run(globals *Globals) error {
 			return nil
 
 		case entry := <-streamer.Logs():
+			if grouper != nil {
+				groupIdle = false
+				done, ok := grouper.Add(entry)
+				if !ok {
+					continue
+				}
+				entry = done
+			}
 			stop, _, err := handleEntry(&entry, true)
 			if err != nil {
 				return err
@@ -913,6 +1176,46 @@ func (c *TailCmd) Run(globals *Globals) error {
 				emitWarning(globals, emitter, err.Error())
 			}
 
+		case <-func() <-chan time.Time {
+			if groupTicker != nil {
+				return groupTicker.C
+			}
+			return nil
+		}():
+			if !groupIdle {
+				groupIdle = true
+				continue
+			}
+			if done, ok := grouper.Flush(); ok {
+				stop, _, err := handleEntry(&done, true)
+				if err != nil {
+					return err
+				}
+				if stop {
+					return nil
+				}
+			}
+
+		case <-func() <-chan time.Time {
+			if crashTicker != nil {
+				return crashTicker.C
+			}
+			return nil
+		}():
+			if err := emitPendingCrashes(crashDetector.Pending(clk.Now())); err != nil {
+				return err
+			}
+
+		case <-func() <-chan time.Time {
+			if anomalyTicker != nil {
+				return anomalyTicker.C
+			}
+			return nil
+		}():
+			if err := emitAnomalies(anomalies.Tick(clk.Now())); err != nil {
+				return err
+			}
+
 		case <-func() <-chan time.Time {
 			if summaryTicker != nil {
 				return summaryTicker.C
@@ -934,6 +1237,11 @@ func (c *TailCmd) Run(globals *Globals) error {
 		}():
 			// cutoff takes precedence
 			if cutoffTimer != nil {
+				if crashDetector != nil {
+					if err := emitPendingCrashes(crashDetector.Flush()); err != nil {
+						return err
+					}
+				}
 				if emitter != nil {
 					final := sessionTracker.GetFinalSummary()
 					sessionNum := sessionTracker.CurrentSession()
@@ -1021,6 +1329,11 @@ func (c *TailCmd) Run(globals *Globals) error {
 							return err
 						}
 					}
+					if sessionChange.EndSession != nil && crashDetector != nil {
+						if err := emitCrash(crashDetector.ProcessExited(sessionChange.EndSession.PID, sessionChange.EndSession.Session)); err != nil {
+							return err
+						}
+					}
 					if sessionChange.EndSession != nil && emitter != nil {
 						if err := emitter.SessionEnd(sessionChange.EndSession); err != nil {
 							return err
@@ -1141,15 +1454,44 @@ func maybeNoStyle(globals *Globals) {
 	}
 }
 
+// resolveCrashReportsDir returns the DiagnosticReports directory searched for .ips files.
+func resolveCrashReportsDir(dir string) string {
+	if dir != "" {
+		return dir
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, session.DefaultDiagnosticReportsDir)
+}
+
+// crashLines returns the last n buffered entries logged by pid.
+func crashLines(buffered []domain.LogEntry, pid, n int) []domain.LogEntry {
+	if n <= 0 {
+		return nil
+	}
+	lines := make([]domain.LogEntry, 0, n)
+	for i := len(buffered) - 1; i >= 0 && len(lines) < n; i-- {
+		if buffered[i].PID == pid {
+			lines = append(lines, buffered[i])
+		}
+	}
+	for i, j := 0, len(lines)-1; i < j; i, j = i+1, j-1 {
+		lines[i], lines[j] = lines[j], lines[i]
+	}
+	return lines
+}
+
 func applyTailDefaults(cfg *config.Config, c *TailCmd) {
 	if cfg == nil {
 		return
 	}
-	if c.Simulator == "" {
+	if len(c.Simulator) == 0 && !c.AllBooted {
 		if cfg.Tail.Simulator != "" {
-			c.Simulator = cfg.Tail.Simulator
+			c.Simulator = []string{cfg.Tail.Simulator}
 		} else if cfg.Defaults.Simulator != "" {
-			c.Simulator = cfg.Defaults.Simulator
+			c.Simulator = []string{cfg.Defaults.Simulator}
 		}
 	}
 	if c.App == "" && c.Predicate == "" && cfg.Tail.App != "" {
@@ -1170,6 +1512,9 @@ func applyTailDefaults(cfg *config.Config, c *TailCmd) {
 	if len(c.Where) == 0 && len(cfg.Tail.Where) > 0 {
 		c.Where = append(c.Where, cfg.Tail.Where...)
 	}
+	if c.CrashReportsDir == "" && cfg.Tail.CrashReportsDir != "" {
+		c.CrashReportsDir = cfg.Tail.CrashReportsDir
+	}
 	if c.BufferSize == 100 && cfg.Defaults.BufferSize != 0 {
 		c.BufferSize = cfg.Defaults.BufferSize
 	}
@@ -2,15 +2,22 @@ package cli
 
 // TailFilterFlags groups filtering-related flags for tail while keeping flag names intact via embedding.
 type TailFilterFlags struct {
-	Pattern          string   `short:"p" aliases:"filter" help:"Regex pattern to filter log messages"`
-	Exclude          []string `short:"x" help:"Regex pattern to exclude from log messages (can be repeated)"`
-	ExcludeSubsystem []string `help:"Exclude logs from subsystem (can be repeated, supports * wildcard)"`
-	MinLevel         string   `help:"Minimum log level: debug, info, default, error, fault (overrides global --level)"`
-	MaxLevel         string   `help:"Maximum log level: debug, info, default, error, fault (optional; unset = no max)"`
-	Where            []string `short:"w" help:"Field filter expression (supports AND/OR/NOT, parentheses). Operators: =, !=, ~, !~, >=, <=, ^, $. Regex literals: /pattern/i"`
-	Dedupe           bool     `help:"Collapse repeated identical messages"`
-	DedupeWindow     string   `help:"Time window for deduplication (e.g., '5s', '1m'). Without this, only consecutive duplicates are collapsed"`
-	Process          []string `help:"Filter by process name (can be repeated)"`
+	Pattern           string   `short:"p" aliases:"filter" help:"Regex pattern to filter log messages"`
+	Exclude           []string `short:"x" help:"Regex pattern to exclude from log messages (can be repeated)"`
+	ExcludeSubsystem  []string `help:"Exclude logs from subsystem (can be repeated, supports * wildcard)"`
+	MinLevel          string   `help:"Minimum log level: debug, info, default, error, fault (overrides global --level)"`
+	MaxLevel          string   `help:"Maximum log level: debug, info, default, error, fault (optional; unset = no max)"`
+	Where             []string `short:"w" help:"Field filter expression (supports AND/OR/NOT, parentheses). Operators: =, !=, ~, !~, >, >=, <, <=, ^, $, in (...). Fields include timestamp (now-30s), len(message), fields.<key>. Regex literals: /pattern/i"`
+	Extract           []string `help:"Extract key/value fields from messages into 'fields': logfmt, json, regex (can be repeated). Use in --where as fields.<key>"`
+	ExtractRegex      []string `help:"Regex with named groups for field extraction, e.g. 'took (?P<ms>[0-9]+)ms' (can be repeated; implies --extract regex)"`
+	ExtractTrace      []string `help:"Extract distributed trace context from messages into trace_id/span_id: traceparent (W3C), regex (can be repeated). Use in --where as trace_id"`
+	ExtractTraceRegex []string `help:"Regex with a (?P<trace>...) group and optional (?P<span>...)/(?P<parent>...) groups, e.g. 'request_id=(?P<trace>[0-9a-f-]+)' (can be repeated; implies --extract-trace regex)"`
+	Preset            []string `help:"Apply a named filter preset from the config 'filters:' section (can be repeated)"`
+	Dedupe            bool     `help:"Collapse repeated identical messages"`
+	DedupeWindow      string   `help:"Time window for deduplication (e.g., '5s', '1m'). Without this, only consecutive duplicates are collapsed"`
+	Process           []string `help:"Filter by process name (can be repeated)"`
+	Group             bool     `help:"Coalesce multi-line messages (stack traces, exception dumps) from the same pid/tid into one 'log_group' event"`
+	GroupWindow       string   `help:"Maximum gap between continuation lines for --group (default: 10ms)"`
 }
 
 // TailOutputFlags groups output flags (files, tmux, summaries, heartbeats).
@@ -18,22 +25,30 @@ type TailOutputFlags struct {
 	Output          string `short:"o" help:"Write output to explicit file path"`
 	SessionDir      string `help:"Directory for session files (default: ~/.xcw/sessions)"`
 	SessionPrefix   string `help:"Prefix for session filename (default: app bundle ID)"`
+	Compress        string `help:"Compress output files: gzip or zstd (adds .gz/.zst; analyze, replay and sessions read them transparently)"`
+	MaxFileSize     string `help:"Start a new part file once the current output file reaches this size (e.g., '100MB', '1GB')"`
+	MaxFileAge      string `help:"Start a new part file once the current output file is older than this (e.g., '1h')"`
 	Tmux            bool   `help:"Output to tmux session"`
 	Session         string `help:"Custom tmux session name (default: xcw-<simulator>)"`
 	SummaryInterval string `help:"Emit periodic summaries (e.g., '30s', '1m')"`
 	Heartbeat       string `help:"Emit periodic heartbeat messages (e.g., '10s', '30s')"`
+	Serve           string `help:"Serve live events over HTTP on this address (e.g., '127.0.0.1:7070'; ':7070' binds 127.0.0.1): GET /events (SSE), /buffer, /stats, /sessions (NDJSON only)"`
+	ServePublic     bool   `help:"Allow --serve on non-loopback addresses; the endpoints have no authentication and expose the full log stream"`
 }
 
 // TailAgentFlags groups agent/control flags.
 type TailAgentFlags struct {
-	WaitForLaunch bool   `help:"Start streaming immediately, emit 'ready' event when capture is active"`
-	NoAgentHints  bool   `help:"Suppress agent_hints banners (leave off for AI agents)"`
-	DryRunJSON    bool   `help:"Print resolved stream options as JSON and exit (no streaming)"`
-	MaxDuration   string `help:"Stop after duration (e.g., '5m') emitting cutoff_reached (agent-safe cutoff)"`
-	MaxLogs       int    `help:"Stop after N logs emitting cutoff_reached (agent-safe cutoff)"`
-	SessionIdle   string `help:"Emit session boundary after idle period with no logs (e.g., '60s')"`
-	Resume        bool   `help:"Backfill gaps on reconnect/restart via 'query' (NDJSON only; requires --app)"`
-	ResumeState   string `help:"Path to resume state file (default: ~/.xcw/resume/<bundle_id>.json)"`
-	ResumeMaxGap  string `default:"5m" help:"Maximum gap to backfill when --resume is enabled (e.g., '5m', '30s')"`
-	ResumeLimit   int    `default:"5000" help:"Maximum number of logs to backfill per gap when --resume is enabled"`
+	WaitForLaunch   bool   `help:"Start streaming immediately, emit 'ready' event when capture is active"`
+	NoAgentHints    bool   `help:"Suppress agent_hints banners (leave off for AI agents)"`
+	DryRunJSON      bool   `help:"Print resolved stream options as JSON and exit (no streaming)"`
+	MaxDuration     string `help:"Stop after duration (e.g., '5m') emitting cutoff_reached (agent-safe cutoff)"`
+	MaxLogs         int    `help:"Stop after N logs emitting cutoff_reached (agent-safe cutoff)"`
+	SessionIdle     string `help:"Emit session boundary after idle period with no logs (e.g., '60s')"`
+	Resume          bool   `help:"Backfill gaps on reconnect/restart via 'query' (NDJSON only; requires --app)"`
+	ResumeState     string `help:"Path to resume state file (default: ~/.xcw/resume/<bundle_id>.json)"`
+	ResumeMaxGap    string `default:"5m" help:"Maximum gap to backfill when --resume is enabled (e.g., '5m', '30s')"`
+	ResumeLimit     int    `default:"5000" help:"Maximum number of logs to backfill per gap when --resume is enabled"`
+	NoCrashDetect   bool   `help:"Disable crash_detected events (uncaught exceptions, EXC_* signals, exit right after a fault)"`
+	CrashLines      int    `default:"50" help:"Number of buffered log lines to include in crash_detected events"`
+	CrashReportsDir string `help:"Directory searched for matching .ips crash reports (default: ~/Library/Logs/DiagnosticReports)"`
 }
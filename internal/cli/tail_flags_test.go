@@ -27,7 +27,7 @@ func TestTailFlagsParse(t *testing.T) {
 	})
 	require.NoError(t, err)
 
-	require.Equal(t, "iPhone 17 Pro", c.Tail.Simulator)
+	require.Equal(t, []string{"iPhone 17 Pro"}, c.Tail.Simulator)
 	require.Equal(t, "com.example.app", c.Tail.App)
 	require.Equal(t, "timeout", c.Tail.Pattern)
 	require.Contains(t, c.Tail.Where, "level=error")
@@ -0,0 +1,275 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/vburojevic/xcw/internal/output"
+	"github.com/vburojevic/xcw/internal/session"
+	"github.com/vburojevic/xcw/internal/simulator"
+)
+
+// sseClientBuffer is the number of events queued per SSE client. Slow clients
+// drop events rather than stall the tail.
+const sseClientBuffer = 256
+
+// eventHub fans NDJSON lines out to SSE subscribers.
+type eventHub struct {
+	mu      sync.Mutex
+	clients map[chan []byte]struct{}
+	closed  bool
+}
+
+func newEventHub() *eventHub {
+	return &eventHub{clients: make(map[chan []byte]struct{})}
+}
+
+func (h *eventHub) subscribe() (<-chan []byte, func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	ch := make(chan []byte, sseClientBuffer)
+	if h.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	h.clients[ch] = struct{}{}
+	return ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if _, ok := h.clients[ch]; ok {
+			delete(h.clients, ch)
+			close(ch)
+		}
+	}
+}
+
+func (h *eventHub) publish(line []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.clients {
+		select {
+		case ch <- line:
+		default:
+		}
+	}
+}
+
+// close disconnects all subscribers.
+func (h *eventHub) close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for ch := range h.clients {
+		delete(h.clients, ch)
+		close(ch)
+	}
+}
+
+// tee returns a writer that writes to w and publishes each complete line.
+func (h *eventHub) tee(w io.Writer) io.Writer {
+	return &hubWriter{w: w, hub: h}
+}
+
+type hubWriter struct {
+	w       io.Writer
+	hub     *eventHub
+	mu      sync.Mutex
+	pending []byte
+}
+
+func (t *hubWriter) Write(p []byte) (int, error) {
+	n, err := t.w.Write(p)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.pending = append(t.pending, p[:n]...)
+	for {
+		i := bytes.IndexByte(t.pending, '\n')
+		if i < 0 {
+			break
+		}
+		if i > 0 {
+			t.hub.publish(append([]byte(nil), t.pending[:i]...))
+		}
+		t.pending = t.pending[i+1:]
+	}
+	return n, err
+}
+
+// tailHTTPServer exposes a running tail over HTTP: live events as Server-Sent
+// Events plus snapshots of the ring buffer, stream stats and sessions.
+type tailHTTPServer struct {
+	hub      *eventHub
+	streamer *simulator.Streamer
+	tracker  tailSessionTracker
+	tailID   string
+	srv      *http.Server
+	ln       net.Listener
+}
+
+// tailSessions is the GET /sessions response.
+type tailSessions struct {
+	TailID   string                  `json:"tail_id"`
+	Sessions []session.SessionRecord `json:"sessions"`
+}
+
+func newTailHTTPServer(hub *eventHub, streamer *simulator.Streamer, tracker tailSessionTracker, tailID string) *tailHTTPServer {
+	s := &tailHTTPServer{hub: hub, streamer: streamer, tracker: tracker, tailID: tailID}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/events", s.handleEvents)
+	mux.HandleFunc("/buffer", s.handleBuffer)
+	mux.HandleFunc("/stats", s.handleStats)
+	mux.HandleFunc("/sessions", s.handleSessions)
+	s.srv = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	return s
+}
+
+// resolveServeAddr binds an empty host to 127.0.0.1 and rejects
+// non-loopback hosts unless public is set, since the server has no auth.
+func resolveServeAddr(addr string, public bool) (string, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", fmt.Errorf("invalid --serve address %q: %w", addr, err)
+	}
+	if host == "" {
+		if public {
+			return addr, nil
+		}
+		return net.JoinHostPort("127.0.0.1", port), nil
+	}
+	if ip := net.ParseIP(host); public || host == "localhost" || ip != nil && ip.IsLoopback() {
+		return addr, nil
+	}
+	return "", fmt.Errorf("--serve %s is not a loopback address; add --serve-public to expose it", addr)
+}
+
+// Start binds addr and serves in the background.
+func (s *tailHTTPServer) Start(addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	s.ln = ln
+	go func() {
+		_ = s.srv.Serve(ln)
+	}()
+	return nil
+}
+
+// Addr returns the bound address (useful when listening on port 0).
+func (s *tailHTTPServer) Addr() string {
+	if s.ln == nil {
+		return ""
+	}
+	return s.ln.Addr().String()
+}
+
+// Close disconnects SSE clients and shuts the server down.
+func (s *tailHTTPServer) Close() error {
+	s.hub.close()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := s.srv.Shutdown(ctx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func (s *tailHTTPServer) handleEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	events, unsubscribe := s.hub.subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	if _, err := fmt.Fprintf(w, ": connected tail_id=%s\n\n", s.tailID); err != nil {
+		return
+	}
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case line, ok := <-events:
+			if !ok {
+				return
+			}
+			if _, err := fmt.Fprintf(w, "data: %s\n\n", line); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func (s *tailHTTPServer) handleBuffer(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	writer := output.NewNDJSONWriter(w)
+	for _, entry := range s.streamer.GetBufferedLogs() {
+		e := entry
+		e.TailID = s.tailID
+		if err := writer.Write(&e); err != nil {
+			return
+		}
+	}
+}
+
+func (s *tailHTTPServer) handleStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	diag := s.streamer.GetDiagnostics()
+	writeHTTPJSON(w, &output.StreamStats{
+		Type:                "stats",
+		SchemaVersion:       output.SchemaVersion,
+		Timestamp:           time.Now().UTC().Format(time.RFC3339Nano),
+		TailID:              s.tailID,
+		Session:             s.tracker.CurrentSession(),
+		Reconnects:          diag.Reconnects,
+		ParseDrops:          diag.ParseDrops,
+		TimestampParseDrops: diag.TimestampParseDrops,
+		ChannelDrops:        diag.ChannelDrops,
+		Buffered:            diag.Buffered,
+	})
+}
+
+func (s *tailHTTPServer) handleSessions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	sessions := s.tracker.Sessions()
+	if sessions == nil {
+		sessions = []session.SessionRecord{}
+	}
+	writeHTTPJSON(w, tailSessions{TailID: s.tailID, Sessions: sessions})
+}
+
+func writeHTTPJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(v)
+}
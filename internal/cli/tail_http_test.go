@@ -0,0 +1,129 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTailServeHTTP(t *testing.T) {
+	globals, _, _ := testGlobals("ndjson")
+	globals.SourceFile = writeServeCapture(t)
+	out := &syncBuffer{}
+	globals.Stdout = out
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	globals.Context = ctx
+
+	cmd := &TailCmd{App: "com.example.myapp", BufferSize: 10}
+	cmd.Serve = "127.0.0.1:0"
+	cmd.NoAgentHints = true
+	done := make(chan error, 1)
+	go func() { done <- cmd.Run(globals) }()
+
+	var base string
+	require.Eventually(t, func() bool {
+		for _, m := range out.messages(t) {
+			msg, _ := m["message"].(string)
+			if m["type"] == "info" && strings.HasPrefix(msg, "Serving HTTP on ") {
+				base = strings.Fields(strings.TrimPrefix(msg, "Serving HTTP on "))[0]
+				return true
+			}
+		}
+		return false
+	}, 5*time.Second, 20*time.Millisecond)
+
+	get := func(path string) *http.Response {
+		resp, err := http.Get(base + path)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		return resp
+	}
+
+	require.Eventually(t, func() bool {
+		resp := get("/buffer")
+		defer func() { _ = resp.Body.Close() }()
+		body, _ := io.ReadAll(resp.Body)
+		return strings.Contains(string(body), `"message":"Request failed"`)
+	}, 5*time.Second, 20*time.Millisecond)
+
+	resp := get("/stats")
+	var stats map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&stats))
+	_ = resp.Body.Close()
+	assert.Equal(t, "stats", stats["type"])
+	assert.EqualValues(t, 1, stats["buffered"])
+
+	resp = get("/sessions")
+	var sessions tailSessions
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&sessions))
+	_ = resp.Body.Close()
+	require.Len(t, sessions.Sessions, 1)
+	assert.True(t, sessions.Sessions[0].Active)
+
+	// SSE: subscribe, then trigger an event by stopping the tail (summary/cutoff are emitted on exit).
+	resp = get("/events")
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	reader := bufio.NewReader(resp.Body)
+	line, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(line, ": connected"))
+
+	cancel()
+	var sawCutoff bool
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			break
+		}
+		if strings.HasPrefix(line, "data: ") && strings.Contains(line, `"type":"cutoff_reached"`) {
+			sawCutoff = true
+		}
+	}
+	_ = resp.Body.Close()
+	assert.True(t, sawCutoff)
+	require.NoError(t, <-done)
+}
+
+func TestTailServeRequiresNDJSON(t *testing.T) {
+	globals, _, _ := testGlobals("text")
+	cmd := &TailCmd{App: "com.example.myapp"}
+	cmd.Serve = ":0"
+	require.Error(t, cmd.Run(globals))
+}
+
+func TestResolveServeAddr(t *testing.T) {
+	tests := []struct {
+		addr    string
+		public  bool
+		want    string
+		wantErr bool
+	}{
+		{addr: ":7070", want: "127.0.0.1:7070"},
+		{addr: "127.0.0.1:0", want: "127.0.0.1:0"},
+		{addr: "localhost:7070", want: "localhost:7070"},
+		{addr: "[::1]:7070", want: "[::1]:7070"},
+		{addr: "0.0.0.0:7070", wantErr: true},
+		{addr: "192.168.1.5:7070", wantErr: true},
+		{addr: "0.0.0.0:7070", public: true, want: "0.0.0.0:7070"},
+		{addr: ":7070", public: true, want: ":7070"},
+		{addr: "7070", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := resolveServeAddr(tt.addr, tt.public)
+		if tt.wantErr {
+			assert.Error(t, err, tt.addr)
+			continue
+		}
+		require.NoError(t, err, tt.addr)
+		assert.Equal(t, tt.want, got)
+	}
+}
@@ -0,0 +1,180 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/vburojevic/xcw/internal/domain"
+	"github.com/vburojevic/xcw/internal/output"
+	"github.com/vburojevic/xcw/internal/simulator"
+	"golang.org/x/sync/errgroup"
+)
+
+// multiDevice reports whether this tail fans in several simulators.
+func (c *TailCmd) multiDevice() bool {
+	return c.AllBooted || len(c.Simulator) > 1
+}
+
+// simulatorArg returns the single simulator selector (first -s), if any.
+func (c *TailCmd) simulatorArg() string {
+	if len(c.Simulator) == 0 {
+		return ""
+	}
+	return c.Simulator[0]
+}
+
+// runMultiDevice runs one tail per device under a shared tail_id and merges
+// their output. Each device keeps its own streamer, ring buffer and session
+// tracker; log entries carry udid and simulator, as do session_start and
+// ready events.
+func (c *TailCmd) runMultiDevice(ctx context.Context, globals *Globals, mgr *simulator.Manager, tailID string) error {
+	if c.AllBooted && (len(c.Simulator) > 0 || c.Booted) {
+		return c.outputError(globals, "INVALID_FLAGS", "--all-booted cannot be combined with --simulator or --booted")
+	}
+	if c.Tmux || c.Resume || c.Serve != "" || c.Anomalies {
+		return c.outputError(globals, "INVALID_FLAGS", "--tmux, --resume, --serve and --anomalies support a single simulator only", "drop the flag or tail one simulator")
+	}
+
+	devices, err := c.resolveDevices(ctx, mgr)
+	if err != nil {
+		return c.outputError(globals, "DEVICE_NOT_FOUND", err.Error(), hintForStreamOrQuery(err))
+	}
+	for _, d := range devices {
+		globals.Debug("Tailing device: %s (UDID: %s)", d.Name, d.UDID)
+	}
+
+	child := func(d domain.Device) *TailCmd {
+		cc := *c
+		cc.Simulator = []string{d.UDID}
+		cc.AllBooted = false
+		cc.Booted = false
+		cc.tailID = tailID
+		cc.tagDevice = true
+		// The parent owns the (merged) output file.
+		cc.Output = ""
+		cc.SessionDir = ""
+		cc.SessionPrefix = ""
+		cc.Compress = ""
+		cc.MaxFileSize = ""
+		cc.MaxFileAge = ""
+		return &cc
+	}
+
+	if c.DryRunJSON {
+		// One resolved-options object per device
+		for _, d := range devices {
+			if err := child(d).Run(globals); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// Merged output: stdout, or a single file when --output/--session-dir is set.
+	var out io.Writer = globals.Stdout
+	if c.Output != "" || c.SessionDir != "" || c.SessionPrefix != "" {
+		path := c.Output
+		if path == "" {
+			prefix := c.SessionPrefix
+			if prefix == "" {
+				prefix = c.App
+			}
+			if path, err = GenerateSessionPath(c.SessionDir, prefix); err != nil {
+				return c.outputError(globals, "FILE_CREATE_ERROR", err.Error())
+			}
+		}
+		rotator := newRotation(func(int) (string, error) { return path, nil })
+		if err := c.configureRotation(rotator); err != nil {
+			return c.outputError(globals, "INVALID_FLAGS", err.Error())
+		}
+		fw, opened, err := rotator.Open(1)
+		if err != nil {
+			return c.outputError(globals, "FILE_CREATE_ERROR", err.Error())
+		}
+		defer func() {
+			if err := rotator.Close(); err != nil {
+				globals.Debug("failed to close output file: %v", err)
+			}
+		}()
+		out = fw
+		if !globals.Quiet {
+			if globals.Format == "ndjson" {
+				if err := output.NewNDJSONWriter(globals.Stdout).WriteInfo(fmt.Sprintf("Writing logs to %s", opened), "", "", "", ""); err != nil {
+					return err
+				}
+			} else if _, err := fmt.Fprintf(globals.Stderr, "Writing logs to %s\n", opened); err != nil {
+				globals.Debug("failed to write output path: %v", err)
+			}
+		}
+	}
+	shared := &lockedWriter{w: out}
+
+	group, groupCtx := errgroup.WithContext(ctx)
+	for _, d := range devices {
+		g := *globals
+		g.Stdout = shared
+		g.Context = groupCtx
+		cc := child(d)
+		group.Go(func() error {
+			return cc.Run(&g)
+		})
+	}
+	return group.Wait()
+}
+
+// resolveDevices returns the devices selected by --all-booted or repeated -s,
+// de-duplicated by UDID.
+func (c *TailCmd) resolveDevices(ctx context.Context, mgr *simulator.Manager) ([]domain.Device, error) {
+	if c.AllBooted {
+		booted, err := mgr.ListBootedDevices(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(booted) == 0 {
+			return nil, fmt.Errorf("no booted simulator found")
+		}
+		return booted, nil
+	}
+	var devices []domain.Device
+	seen := make(map[string]bool)
+	for _, sel := range c.Simulator {
+		var found []domain.Device
+		if simulatorArgIsBooted(sel) {
+			booted, err := mgr.ListBootedDevices(ctx)
+			if err != nil {
+				return nil, err
+			}
+			if len(booted) == 0 {
+				return nil, fmt.Errorf("no booted simulator found")
+			}
+			found = booted
+		} else {
+			d, err := mgr.FindDevice(ctx, sel)
+			if err != nil {
+				return nil, err
+			}
+			found = []domain.Device{*d}
+		}
+		for _, d := range found {
+			if !seen[d.UDID] {
+				seen[d.UDID] = true
+				devices = append(devices, d)
+			}
+		}
+	}
+	return devices, nil
+}
+
+// lockedWriter serializes writes from several device tails.
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(p)
+}
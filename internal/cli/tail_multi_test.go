@@ -0,0 +1,132 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vburojevic/xcw/internal/config"
+)
+
+func TestTailAllBooted_WithStubXcrun(t *testing.T) {
+	stubTwoBootedSimulators(t)
+
+	var stdout, stderr bytes.Buffer
+	globals := &Globals{
+		Format: "ndjson",
+		Level:  "debug",
+		Quiet:  true,
+		Stdout: &stdout,
+		Stderr: &stderr,
+		Config: config.Default(),
+	}
+	cmd := &TailCmd{
+		AllBooted: true,
+		App:       "com.example.myapp",
+		TailAgentFlags: TailAgentFlags{
+			MaxDuration:  "5s",
+			MaxLogs:      1,
+			NoAgentHints: true,
+		},
+	}
+	require.NoError(t, cmd.Run(globals))
+
+	tailIDs := map[string]bool{}
+	logsByUDID := map[string]string{}
+	sessionsByUDID := map[string]bool{}
+	for _, line := range strings.Split(strings.TrimSpace(stdout.String()), "\n") {
+		var v map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &v), line)
+		udid, _ := v["udid"].(string)
+		switch v["type"] {
+		case "log":
+			require.NotEmpty(t, udid, "log missing udid: %s", line)
+			require.NotEmpty(t, v["simulator"], "log missing simulator: %s", line)
+			logsByUDID[udid] = v["message"].(string)
+			tailIDs[v["tail_id"].(string)] = true
+		case "session_start":
+			sessionsByUDID[udid] = true
+		}
+	}
+
+	assert.Equal(t, map[string]string{"UDID-A": "hello from UDID-A", "UDID-B": "hello from UDID-B"}, logsByUDID)
+	assert.Len(t, tailIDs, 1, "devices should share one tail_id")
+	assert.True(t, sessionsByUDID["UDID-A"] && sessionsByUDID["UDID-B"], "sessions are tracked per device")
+}
+
+// stubTwoBootedSimulators puts an xcrun on PATH with two booted simulators
+// whose log streams each emit one line naming their UDID.
+func stubTwoBootedSimulators(t *testing.T) {
+	t.Helper()
+	stubDir := t.TempDir()
+	xcrunPath := filepath.Join(stubDir, "xcrun")
+
+	// Two booted simulators; each log stream emits one line tagged with its UDID.
+	script := `#!/bin/sh
+set -eu
+
+if [ "$#" -ge 4 ] && [ "$1" = "simctl" ] && [ "$2" = "list" ] && [ "$3" = "devices" ] && [ "$4" = "--json" ]; then
+  cat <<'EOF'
+{
+  "devices": {
+    "com.apple.CoreSimulator.SimRuntime.iOS-17-0": [
+      {"udid": "UDID-A", "name": "iPhone A", "state": "Booted", "isAvailable": true},
+      {"udid": "UDID-B", "name": "iPhone B", "state": "Booted", "isAvailable": true},
+      {"udid": "UDID-C", "name": "iPhone C", "state": "Shutdown", "isAvailable": true}
+    ]
+  }
+}
+EOF
+  exit 0
+fi
+
+if [ "$#" -ge 2 ] && [ "$1" = "simctl" ] && [ "$2" = "get_app_container" ]; then
+  exit 1
+fi
+
+if [ "$#" -ge 5 ] && [ "$1" = "simctl" ] && [ "$2" = "spawn" ] && [ "$4" = "log" ] && [ "$5" = "stream" ]; then
+  echo '{"timestamp":"2025-12-14 22:00:00.000000+0000","messageType":"Error","processImagePath":"/Applications/MyApp.app/MyApp","processID":123,"threadID":1,"subsystem":"com.example.myapp","category":"network","eventMessage":"hello from '"$3"'","eventType":"logEvent","processImageUUID":"UUID-123","senderImagePath":""}'
+  exec sleep 60
+fi
+
+echo "stub: unsupported xcrun args: $*" >&2
+exit 1
+`
+	require.NoError(t, os.WriteFile(xcrunPath, []byte(script), 0o755))
+	t.Setenv("PATH", stubDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestTailAllBootedRejectsSimulator(t *testing.T) {
+	globals, _, _ := testGlobals("ndjson")
+	cmd := &TailCmd{AllBooted: true, Simulator: []string{"x"}, App: "com.example.myapp"}
+	require.Error(t, cmd.Run(globals))
+}
+
+func TestTailAllBootedDryRunJSON_WithStubXcrun(t *testing.T) {
+	stubTwoBootedSimulators(t)
+
+	globals, stdout, _ := testGlobals("ndjson")
+	globals.Quiet = true
+	cmd := &TailCmd{AllBooted: true, App: "com.example.myapp", TailAgentFlags: TailAgentFlags{DryRunJSON: true}}
+	require.NoError(t, cmd.Run(globals))
+
+	var udids []string
+	dec := json.NewDecoder(stdout)
+	for dec.More() {
+		var v struct {
+			BundleID  string
+			UDID      string
+			Simulator string
+		}
+		require.NoError(t, dec.Decode(&v))
+		assert.Equal(t, "com.example.myapp", v.BundleID)
+		assert.NotEmpty(t, v.Simulator)
+		udids = append(udids, v.UDID)
+	}
+	assert.Equal(t, []string{"UDID-A", "UDID-B"}, udids)
+}
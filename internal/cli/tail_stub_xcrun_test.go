@@ -3,12 +3,20 @@ package cli
 import (
 	"bytes"
 	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+
 	"github.com/vburojevic/xcw/internal/config"
 )
 
@@ -174,3 +182,224 @@ exit 1
 	require.Equal(t, "cutoff_reached", last["type"])
 	require.Equal(t, "max_duration", last["reason"])
 }
+
+func TestTailCrashDetected_WithStubXcrun(t *testing.T) {
+	stubDir := t.TempDir()
+	xcrunPath := filepath.Join(stubDir, "xcrun")
+
+	script := `#!/bin/sh
+set -eu
+
+if [ "$#" -ge 4 ] && [ "$1" = "simctl" ] && [ "$2" = "list" ] && [ "$3" = "devices" ] && [ "$4" = "--json" ]; then
+  cat <<'EOF'
+{
+  "devices": {
+    "com.apple.CoreSimulator.SimRuntime.iOS-17-0": [
+      {
+        "udid": "TEST-UDID-123",
+        "name": "iPhone 17 Pro",
+        "state": "Booted",
+        "isAvailable": true,
+        "deviceTypeIdentifier": "com.apple.CoreSimulator.SimDeviceType.iPhone-17-Pro",
+        "dataPath": "/tmp",
+        "logPath": "/tmp"
+      }
+    ]
+  }
+}
+EOF
+  exit 0
+fi
+
+if [ "$#" -ge 2 ] && [ "$1" = "simctl" ] && [ "$2" = "get_app_container" ]; then
+  echo "stub: no app container" >&2
+  exit 1
+fi
+
+if [ "$#" -ge 5 ] && [ "$1" = "simctl" ] && [ "$2" = "spawn" ] && [ "$4" = "log" ] && [ "$5" = "stream" ]; then
+  echo '{"timestamp":"2025-12-14 22:00:00.000000+0000","messageType":"Info","processImagePath":"/Applications/MyApp.app/MyApp","processID":123,"threadID":1,"subsystem":"com.example.myapp","category":"ui","eventMessage":"Tapped checkout","eventType":"logEvent","processImageUUID":"UUID-123","senderImagePath":""}'
+  echo '{"timestamp":"2025-12-14 22:00:00.002000+0000","messageType":"Fault","processImagePath":"/Applications/MyApp.app/MyApp","processID":123,"threadID":1,"subsystem":"com.example.myapp","category":"","eventMessage":"*** Terminating app due to uncaught exception '"'"'NSRangeException'"'"', reason: '"'"'index 3 beyond bounds'"'"'","eventType":"logEvent","processImageUUID":"UUID-123","senderImagePath":""}'
+  exec sleep 60
+fi
+
+echo "stub: unsupported xcrun args: $*" >&2
+exit 1
+`
+	require.NoError(t, os.WriteFile(xcrunPath, []byte(script), 0o755))
+
+	t.Setenv("PATH", stubDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	globals := &Globals{
+		Format: "ndjson",
+		Level:  "debug",
+		Quiet:  true,
+		Stdout: &stdout,
+		Stderr: &stderr,
+		Config: config.Default(),
+	}
+	cmd := &TailCmd{
+		Booted: true,
+		App:    "com.example.myapp",
+		TailAgentFlags: TailAgentFlags{
+			MaxDuration:     "5s",
+			MaxLogs:         2,
+			NoAgentHints:    true,
+			CrashLines:      10,
+			CrashReportsDir: t.TempDir(),
+		},
+	}
+
+	require.NoError(t, cmd.Run(globals))
+
+	var crash map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(stdout.String()), "\n") {
+		var v map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &v))
+		if v["type"] == "crash_detected" {
+			crash = v
+		}
+	}
+	require.NotNil(t, crash, "expected crash_detected event")
+	require.Equal(t, "exception_message", crash["trigger"])
+	require.Equal(t, "NSRangeException", crash["exception_type"])
+	require.Equal(t, "index 3 beyond bounds", crash["exception_reason"])
+	require.Equal(t, "UUID-123", crash["binary_uuid"])
+	lines, ok := crash["lines"].([]any)
+	require.True(t, ok)
+	require.NotEmpty(t, lines)
+}
+
+func TestTailOTLPExport_WithStubXcrun(t *testing.T) {
+	stubDir := t.TempDir()
+	script := `#!/bin/sh
+set -eu
+
+if [ "$#" -ge 4 ] && [ "$1" = "simctl" ] && [ "$2" = "list" ] && [ "$3" = "devices" ] && [ "$4" = "--json" ]; then
+  echo '{"devices":{"com.apple.CoreSimulator.SimRuntime.iOS-17-0":[{"udid":"TEST-UDID-123","name":"iPhone 17 Pro","state":"Booted","isAvailable":true}]}}'
+  exit 0
+fi
+
+if [ "$#" -ge 5 ] && [ "$1" = "simctl" ] && [ "$2" = "spawn" ] && [ "$4" = "log" ] && [ "$5" = "stream" ]; then
+  echo '{"timestamp":"2025-12-14 22:00:00.000000+0000","messageType":"Error","processImagePath":"/Applications/MyApp.app/MyApp","processID":123,"threadID":1,"subsystem":"com.example.myapp","category":"network","eventMessage":"Connection failed","eventType":"logEvent"}'
+  exec sleep 60
+fi
+
+echo "stub: unsupported xcrun args: $*" >&2
+exit 1
+`
+	require.NoError(t, os.WriteFile(filepath.Join(stubDir, "xcrun"), []byte(script), 0o755))
+	t.Setenv("PATH", stubDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	var mu sync.Mutex
+	var received []*collogspb.ExportLogsServiceRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		req := &collogspb.ExportLogsServiceRequest{}
+		if err := protojson.Unmarshal(body, req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		received = append(received, req)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	var stdout, stderr bytes.Buffer
+	globals := &Globals{Format: "ndjson", Level: "debug", Quiet: true, Stdout: &stdout, Stderr: &stderr, Config: config.Default()}
+	cmd := &TailCmd{
+		Booted:         true,
+		App:            "com.example.myapp",
+		TailAgentFlags: TailAgentFlags{MaxDuration: "5s", MaxLogs: 1, NoAgentHints: true},
+		OTLPFlags:      OTLPFlags{OTLPEndpoint: srv.URL, OTLPProtocol: "http/json"},
+	}
+	require.NoError(t, cmd.Run(globals))
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, received, 1)
+	rl := received[0].ResourceLogs
+	require.Len(t, rl, 1)
+	res := map[string]string{}
+	for _, kv := range rl[0].Resource.Attributes {
+		res[kv.Key] = kv.Value.GetStringValue()
+	}
+	require.Equal(t, "com.example.myapp", res["service.name"])
+	require.Equal(t, "TEST-UDID-123", res["device.id"])
+	require.NotEmpty(t, res["xcw.tail_id"])
+	lr := rl[0].ScopeLogs[0].LogRecords
+	require.Len(t, lr, 1)
+	require.Equal(t, "Connection failed", lr[0].Body.GetStringValue())
+	require.Equal(t, "Error", lr[0].SeverityText)
+}
+
+func TestTailOTLPExport_InvalidHeader(t *testing.T) {
+	f := OTLPFlags{OTLPEndpoint: "http://localhost:4318", OTLPHeader: []string{"no-equals"}}
+	_, err := f.newExporter("com.example.myapp", "", "", nil)
+	require.ErrorContains(t, err, "expected key=value")
+}
+
+func TestTailAnomalies_WithStubXcrun(t *testing.T) {
+	stubDir := t.TempDir()
+	script := `#!/bin/sh
+set -eu
+
+if [ "$#" -ge 4 ] && [ "$1" = "simctl" ] && [ "$2" = "list" ] && [ "$3" = "devices" ] && [ "$4" = "--json" ]; then
+  echo '{"devices":{"com.apple.CoreSimulator.SimRuntime.iOS-17-0":[{"udid":"TEST-UDID-123","name":"iPhone 17 Pro","state":"Booted","isAvailable":true}]}}'
+  exit 0
+fi
+
+if [ "$#" -ge 5 ] && [ "$1" = "simctl" ] && [ "$2" = "spawn" ] && [ "$4" = "log" ] && [ "$5" = "stream" ]; then
+  echo '{"timestamp":"2025-12-14 22:00:00.000000+0000","messageType":"Error","processImagePath":"/Applications/MyApp.app/MyApp","processID":123,"threadID":1,"subsystem":"com.example.myapp","category":"network","eventMessage":"Connection failed","eventType":"logEvent"}'
+  sleep 0.3
+  echo '{"timestamp":"2025-12-14 22:00:01.000000+0000","messageType":"Error","processImagePath":"/Applications/MyApp.app/MyApp","processID":123,"threadID":1,"subsystem":"com.example.myapp","category":"storage","eventMessage":"Disk quota exceeded for user 42","eventType":"logEvent"}'
+  exec sleep 60
+fi
+
+echo "stub: unsupported xcrun args: $*" >&2
+exit 1
+`
+	require.NoError(t, os.WriteFile(filepath.Join(stubDir, "xcrun"), []byte(script), 0o755))
+	t.Setenv("PATH", stubDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	var stdout, stderr bytes.Buffer
+	globals := &Globals{Format: "ndjson", Level: "debug", Quiet: true, Stdout: &stdout, Stderr: &stderr, Config: config.Default()}
+	cmd := &TailCmd{
+		Booted:         true,
+		App:            "com.example.myapp",
+		TailAgentFlags: TailAgentFlags{MaxDuration: "5s", MaxLogs: 2, NoAgentHints: true},
+		AnomalyFlags:   AnomalyFlags{Anomalies: true, AnomalySensitivity: "medium", AnomalyWindow: "100ms", AnomalyWarmup: "100ms"},
+	}
+	require.NoError(t, cmd.Run(globals))
+
+	var anomalies []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(stdout.String()), "\n") {
+		var v map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &v))
+		if v["type"] == "anomaly" {
+			anomalies = append(anomalies, v)
+		}
+	}
+	require.Len(t, anomalies, 1, "only the pattern seen after warmup is new")
+	require.Equal(t, "new_pattern", anomalies[0]["kind"])
+	require.Equal(t, "Disk quota exceeded for user 42", anomalies[0]["example"])
+	require.Equal(t, "Error", anomalies[0]["level"])
+	require.NotEmpty(t, anomalies[0]["tail_id"])
+}
+
+func TestAnomalyFlags_Validation(t *testing.T) {
+	d, err := (&AnomalyFlags{}).newAnomalyDetector()
+	require.NoError(t, err)
+	require.Nil(t, d)
+
+	_, err = (&AnomalyFlags{Anomalies: true, AnomalyWindow: "soon"}).newAnomalyDetector()
+	require.ErrorContains(t, err, "--anomaly-window")
+	_, err = (&AnomalyFlags{Anomalies: true, AnomalyWarmup: "-1m"}).newAnomalyDetector()
+	require.ErrorContains(t, err, "--anomaly-warmup")
+
+	d, err = (&AnomalyFlags{Anomalies: true, AnomalySensitivity: "low", AnomalyWindow: "30s"}).newAnomalyDetector()
+	require.NoError(t, err)
+	require.Equal(t, 30*time.Second, d.Window())
+}
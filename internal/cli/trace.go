@@ -0,0 +1,299 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vburojevic/xcw/internal/domain"
+	"github.com/vburojevic/xcw/internal/filter"
+	"github.com/vburojevic/xcw/internal/output"
+)
+
+// TraceCmd gathers every entry of one trace or activity from recordings
+type TraceCmd struct {
+	ID                string   `arg:"" required:"" help:"Trace ID or request ID (from --extract-trace), or os_activity ID (decimal or 0x hex)"`
+	File              []string `help:"Recording to search (.ndjson, .ndjson.gz, .ndjson.zst); can be repeated"`
+	Latest            bool     `help:"Search the most recent session recording"`
+	Dir               string   `help:"Session directory for --latest (default: ~/.xcw/sessions)"`
+	ExtractTrace      []string `default:"traceparent" help:"Trace context to look for in messages: traceparent (W3C), regex (can be repeated)"`
+	ExtractTraceRegex []string `help:"Regex with a (?P<trace>...) group and optional (?P<span>...)/(?P<parent>...) groups, e.g. 'request_id=(?P<trace>[0-9a-f-]+)' (can be repeated; implies --extract-trace regex)"`
+}
+
+// TraceResult is the NDJSON summary written after the trace's entries
+type TraceResult struct {
+	Type          string      `json:"type"`
+	SchemaVersion int         `json:"schemaVersion"`
+	ID            string      `json:"id"`
+	Kind          string      `json:"kind"` // "trace" or "activity"
+	Files         []string    `json:"files"`
+	Entries       int         `json:"entries"`
+	Errors        int         `json:"errors"`
+	Start         string      `json:"start"`
+	End           string      `json:"end"`
+	DurationMs    int64       `json:"duration_ms"`
+	Spans         []TraceSpan `json:"spans,omitempty"`
+}
+
+// TraceSpan summarises the entries of one span or activity within a trace
+type TraceSpan struct {
+	ID         string   `json:"id"`
+	ParentID   string   `json:"parent_id,omitempty"`
+	Start      string   `json:"start"`
+	End        string   `json:"end"`
+	DurationMs int64    `json:"duration_ms"`
+	Entries    int      `json:"entries"`
+	Errors     int      `json:"errors,omitempty"`
+	Processes  []string `json:"processes,omitempty"`
+
+	start, end time.Time
+	depth      int
+}
+
+// Run executes the trace command
+func (c *TraceCmd) Run(globals *Globals) error {
+	id := strings.TrimSpace(c.ID)
+	if id == "" {
+		return c.outputError(globals, "INVALID_FLAGS", "trace ID is empty")
+	}
+	traceExtractor, err := filter.NewTraceExtractor(c.ExtractTrace, c.ExtractTraceRegex)
+	if err != nil {
+		return c.outputError(globals, "INVALID_FILTER", err.Error(), hintForExtractTrace(err))
+	}
+
+	files := c.File
+	if c.Latest {
+		s, err := LatestSession(c.Dir)
+		if err != nil {
+			return c.outputError(globals, "SESSION_ERROR", err.Error())
+		}
+		if s == nil {
+			return c.outputError(globals, "NO_SESSIONS", "no session files found")
+		}
+		files = append(files, s.Path)
+	}
+	if len(files) == 0 {
+		return c.outputError(globals, "INVALID_FLAGS", "nothing to search: pass --file or --latest")
+	}
+
+	var entries []domain.LogEntry
+	for _, path := range files {
+		rec, err := c.readFile(globals, path)
+		if err != nil {
+			return err
+		}
+		for i := range rec.Entries {
+			traceExtractor.Extract(&rec.Entries[i])
+		}
+		entries = append(entries, rec.Entries...)
+	}
+
+	kind, matched := matchTrace(entries, id)
+	if len(matched) == 0 {
+		return c.outputError(globals, "TRACE_NOT_FOUND", fmt.Sprintf("no entries for trace or activity %q", id),
+			"trace IDs come from messages: use --extract-trace-regex for custom request IDs, or pass an activity_id from the log output")
+	}
+	sort.SliceStable(matched, func(i, j int) bool { return matched[i].Timestamp.Before(matched[j].Timestamp) })
+
+	result := buildTraceResult(id, kind, matched)
+	for _, path := range files {
+		result.Files = append(result.Files, filepath.Base(path))
+	}
+
+	if globals.Format == "ndjson" {
+		w := output.NewNDJSONWriter(globals.Stdout)
+		for i := range matched {
+			if err := w.Write(&matched[i]); err != nil {
+				return err
+			}
+		}
+		return w.WriteRaw(result)
+	}
+	return writeTraceText(globals, result, matched)
+}
+
+func (c *TraceCmd) readFile(globals *Globals, path string) (*recording, error) {
+	rec, err := readRecordingFile(globals, path)
+	if err != nil {
+		return nil, c.outputError(globals, err.Code, err.Message)
+	}
+	return rec, nil
+}
+
+func (c *TraceCmd) outputError(globals *Globals, code, message string, hint ...string) error {
+	return outputErrorCommon(globals, code, message, hint...)
+}
+
+// matchTrace returns the entries of trace id, or failing that of activity id
+// and every activity nested under it.
+func matchTrace(entries []domain.LogEntry, id string) (string, []domain.LogEntry) {
+	var matched []domain.LogEntry
+	for _, e := range entries {
+		if e.TraceID != "" && strings.EqualFold(e.TraceID, id) {
+			matched = append(matched, e)
+		}
+	}
+	if len(matched) > 0 {
+		return "trace", matched
+	}
+
+	root, err := strconv.ParseUint(id, 0, 64)
+	if err != nil || root == 0 {
+		return "", nil
+	}
+	children := make(map[uint64][]uint64)
+	seen := make(map[[2]uint64]bool)
+	for _, e := range entries {
+		edge := [2]uint64{e.ParentActivityID, e.ActivityID}
+		if e.ActivityID != 0 && e.ParentActivityID != 0 && !seen[edge] {
+			seen[edge] = true
+			children[e.ParentActivityID] = append(children[e.ParentActivityID], e.ActivityID)
+		}
+	}
+	activities := map[uint64]bool{root: true}
+	queue := []uint64{root}
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+		for _, child := range children[next] {
+			if !activities[child] {
+				activities[child] = true
+				queue = append(queue, child)
+			}
+		}
+	}
+	for _, e := range entries {
+		if activities[e.ActivityID] {
+			matched = append(matched, e)
+		}
+	}
+	return "activity", matched
+}
+
+// buildTraceResult summarises time-ordered entries of one trace. Spans are
+// keyed by span_id for traces and by activity_id for activities.
+func buildTraceResult(id, kind string, entries []domain.LogEntry) *TraceResult {
+	start, end := entries[0].Timestamp, entries[len(entries)-1].Timestamp
+	result := &TraceResult{
+		Type:          "trace",
+		SchemaVersion: output.SchemaVersion,
+		ID:            id,
+		Kind:          kind,
+		Entries:       len(entries),
+		Start:         start.UTC().Format(time.RFC3339Nano),
+		End:           end.UTC().Format(time.RFC3339Nano),
+		DurationMs:    end.Sub(start).Milliseconds(),
+	}
+
+	spans := make(map[string]*TraceSpan)
+	var order []*TraceSpan
+	for _, e := range entries {
+		failed := e.Level == domain.LogLevelError || e.Level == domain.LogLevelFault
+		if failed {
+			result.Errors++
+		}
+		spanID, parentID := traceSpanKey(kind, &e)
+		if spanID == "" {
+			continue
+		}
+		s, ok := spans[spanID]
+		if !ok {
+			s = &TraceSpan{ID: spanID, ParentID: parentID, start: e.Timestamp}
+			spans[spanID] = s
+			order = append(order, s)
+		}
+		if s.ParentID == "" {
+			s.ParentID = parentID
+		}
+		s.end = e.Timestamp
+		s.Entries++
+		if failed {
+			s.Errors++
+		}
+		if e.Process != "" && !containsString(s.Processes, e.Process) {
+			s.Processes = append(s.Processes, e.Process)
+		}
+	}
+
+	for _, s := range order {
+		s.Start = s.start.UTC().Format(time.RFC3339Nano)
+		s.End = s.end.UTC().Format(time.RFC3339Nano)
+		s.DurationMs = s.end.Sub(s.start).Milliseconds()
+		for p, hops := spans[s.ParentID], 0; p != nil && hops < len(order); p, hops = spans[p.ParentID], hops+1 {
+			s.depth++
+		}
+		result.Spans = append(result.Spans, *s)
+	}
+	return result
+}
+
+func traceSpanKey(kind string, e *domain.LogEntry) (id, parent string) {
+	if kind == "trace" {
+		return e.SpanID, e.ParentSpanID
+	}
+	if e.ActivityID == 0 {
+		return "", ""
+	}
+	id = strconv.FormatUint(e.ActivityID, 10)
+	if e.ParentActivityID != 0 {
+		parent = strconv.FormatUint(e.ParentActivityID, 10)
+	}
+	return id, parent
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// writeTraceText prints the trace as a timeline followed by its spans
+func writeTraceText(globals *Globals, result *TraceResult, entries []domain.LogEntry) error {
+	w := globals.Stdout
+	if _, err := fmt.Fprintf(w, "%s %s: %d entries, %d error(s), %d span(s), %s\n\n",
+		result.Kind, result.ID, result.Entries, result.Errors, len(result.Spans), formatTraceMs(result.DurationMs)); err != nil {
+		return err
+	}
+	start := entries[0].Timestamp
+	for _, e := range entries {
+		span, _ := traceSpanKey(result.Kind, &e)
+		if span != "" {
+			span = " [" + span + "]"
+		}
+		if _, err := fmt.Fprintf(w, "+%9s  %-7s %s%s  %s\n",
+			formatTraceMs(e.Timestamp.Sub(start).Milliseconds()), e.Level, e.Process, span, e.Message); err != nil {
+			return err
+		}
+	}
+	if len(result.Spans) == 0 {
+		return nil
+	}
+	if _, err := fmt.Fprintln(w, "\nSpans:"); err != nil {
+		return err
+	}
+	for _, s := range result.Spans {
+		offset := s.start.Sub(start).Milliseconds()
+		errs := ""
+		if s.Errors > 0 {
+			errs = fmt.Sprintf(", %d error(s)", s.Errors)
+		}
+		if _, err := fmt.Fprintf(w, "  %s%s  +%s  %s  %d entries%s  %s\n",
+			strings.Repeat("  ", s.depth), s.ID, formatTraceMs(offset), formatTraceMs(s.DurationMs), s.Entries, errs, strings.Join(s.Processes, ",")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func formatTraceMs(ms int64) string {
+	if ms < 1000 {
+		return fmt.Sprintf("%dms", ms)
+	}
+	return fmt.Sprintf("%.3fs", float64(ms)/1000)
+}
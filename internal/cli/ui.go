@@ -9,6 +9,7 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/vburojevic/xcw/internal/domain"
+	"github.com/vburojevic/xcw/internal/filter"
 	"github.com/vburojevic/xcw/internal/simulator"
 	"github.com/vburojevic/xcw/internal/tui"
 )
@@ -25,6 +26,7 @@ type UICmd struct {
 	Subsystem        []string `help:"Filter by subsystem (can be repeated)"`
 	Category         []string `help:"Filter by category (can be repeated)"`
 	Predicate        string   `help:"Raw NSPredicate filter (overrides --app, --subsystem, --category)"`
+	Preset           []string `help:"Apply a named filter preset from the config 'filters:' section (can be repeated)"`
 	BufferSize       int      `default:"1000" help:"Number of recent logs to buffer"`
 }
 
@@ -37,12 +39,31 @@ func (c *UICmd) Run(globals *Globals) error {
 	if globals.FlagProvided("simulator") && globals.FlagProvided("booted") {
 		return outputErrorCommon(globals, "INVALID_FLAGS", "--simulator and --booted are mutually exclusive", "use only one of --simulator or --booted")
 	}
+
+	// Presets may set filters the UI has no flags for (where, process, levels).
+	var excludes []string
+	if c.Exclude != "" {
+		excludes = append(excludes, c.Exclude)
+	}
+	var processes, where []string
+	var minLevelName, maxLevelName string
+	if err := applyPresets(globals.Config, c.Preset, presetTarget{
+		Pattern: &c.Pattern, Exclude: &excludes, ExcludeSubsystem: &c.ExcludeSubsystem,
+		Subsystem: &c.Subsystem, Category: &c.Category, Process: &processes,
+		Where: &where, MinLevel: &minLevelName, MaxLevel: &maxLevelName,
+	}); err != nil {
+		return outputErrorCommon(globals, "INVALID_PRESET", err.Error(), hintForPreset(globals.Config))
+	}
+	whereFilter, err := filter.NewWhereFilter(where)
+	if err != nil {
+		return outputErrorCommon(globals, "INVALID_FILTER", err.Error(), hintForFilter(err))
+	}
 	if err := validateAppPredicateAll(c.App, c.Predicate, c.All, len(c.Subsystem) > 0 || len(c.Category) > 0); err != nil {
 		return outputErrorCommon(globals, err.Code, err.Message, err.Hint)
 	}
 
 	// Find the simulator
-	mgr := simulator.NewManager()
+	mgr := newSimulatorManager(globals)
 	device, err := resolveSimulatorDevice(ctx, mgr, c.Simulator, c.Booted)
 	if err != nil {
 		return outputErrorCommon(globals, "DEVICE_NOT_FOUND", err.Error(), hintForStreamOrQuery(err))
@@ -58,15 +79,16 @@ func (c *UICmd) Run(globals *Globals) error {
 		}
 	}
 
-	// Compile exclude pattern regex if provided
+	// Compile exclude pattern regexes if provided
 	var excludePatterns []*regexp.Regexp
-	if c.Exclude != "" {
-		excludePattern, err := regexp.Compile(c.Exclude)
+	for _, x := range excludes {
+		excludePattern, err := regexp.Compile(x)
 		if err != nil {
 			return outputErrorCommon(globals, "INVALID_PATTERN", fmt.Sprintf("invalid exclude regex pattern: %v", err), "check regex syntax")
 		}
 		excludePatterns = append(excludePatterns, excludePattern)
 	}
+	minLevel, maxLevel := resolveLevels(minLevelName, maxLevelName, globals.Level)
 
 	// Create streamer
 	streamer := simulator.NewStreamer(mgr)
@@ -74,7 +96,9 @@ func (c *UICmd) Run(globals *Globals) error {
 		BundleID:          c.App,
 		Subsystems:        c.Subsystem,
 		Categories:        c.Category,
-		MinLevel:          domain.ParseLogLevel(globals.Level),
+		Processes:         processes,
+		MinLevel:          minLevel,
+		MaxLevel:          maxLevel,
 		Pattern:           pattern,
 		ExcludePatterns:   excludePatterns,
 		ExcludeSubsystems: c.ExcludeSubsystem,
@@ -98,7 +122,11 @@ func (c *UICmd) Run(globals *Globals) error {
 	if appLabel == "" {
 		appLabel = "all logs"
 	}
-	model := tui.New(appLabel, device.Name, streamer.Logs(), streamer.Errors())
+	logs := streamer.Logs()
+	if whereFilter != nil {
+		logs = whereFilteredLogs(ctx, logs, whereFilter)
+	}
+	model := tui.New(appLabel, device.Name, logs, streamer.Errors())
 
 	// Run the TUI
 	p := tea.NewProgram(model, tea.WithAltScreen())
@@ -115,3 +143,23 @@ func (c *UICmd) Run(globals *Globals) error {
 
 	return nil
 }
+
+// whereFilteredLogs forwards entries matching f; the streamer applies every
+// other filter itself.
+func whereFilteredLogs(ctx context.Context, in <-chan domain.LogEntry, f *filter.WhereFilter) <-chan domain.LogEntry {
+	out := make(chan domain.LogEntry, cap(in))
+	go func() {
+		defer close(out)
+		for entry := range in {
+			if !f.Match(&entry) {
+				continue
+			}
+			select {
+			case out <- entry:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
@@ -42,7 +42,12 @@ type WatchCmd struct {
 	ExcludeSubsystem    []string `help:"Exclude logs from subsystem (can be repeated, supports * wildcard)"`
 	MinLevel            string   `help:"Minimum log level: debug, info, default, error, fault (overrides global --level)"`
 	MaxLevel            string   `help:"Maximum log level: debug, info, default, error, fault (optional; unset = no max)"`
-	Where               []string `short:"w" help:"Field filter expression (supports AND/OR/NOT, parentheses). Operators: =, !=, ~, !~, >=, <=, ^, $. Regex literals: /pattern/i"`
+	Where               []string `short:"w" help:"Field filter expression (supports AND/OR/NOT, parentheses). Operators: =, !=, ~, !~, >, >=, <, <=, ^, $, in (...). Fields include timestamp (now-30s), len(message), fields.<key>. Regex literals: /pattern/i"`
+	ExtractTrace        []string `help:"Extract distributed trace context from messages into trace_id/span_id: traceparent (W3C), regex (can be repeated). Use in --where as trace_id"`
+	ExtractTraceRegex   []string `help:"Regex with a (?P<trace>...) group and optional (?P<span>...)/(?P<parent>...) groups, e.g. 'request_id=(?P<trace>[0-9a-f-]+)' (can be repeated; implies --extract-trace regex)"`
+	Preset              []string `help:"Apply a named filter preset from the config 'filters:' section (can be repeated)"`
+	Extract             []string `help:"Extract key/value fields from messages into 'fields': logfmt, json, regex (can be repeated). Use in --where as fields.<key>"`
+	ExtractRegex        []string `help:"Regex with named groups for field extraction, e.g. 'took (?P<ms>[0-9]+)ms' (can be repeated; implies --extract regex)"`
 	Dedupe              bool     `help:"Collapse repeated identical messages"`
 	DedupeWindow        string   `help:"Time window for deduplication (e.g., '5s', '1m'). Without this, only consecutive duplicates are collapsed"`
 	Process             []string `help:"Filter by process name (can be repeated)"`
@@ -55,6 +60,14 @@ type WatchCmd struct {
 	MaxParallelTriggers int      `default:"5" help:"Maximum concurrent trigger executions"`
 	TriggerOutput       string   `default:"discard" enum:"inherit,discard,capture" help:"Trigger command output handling"`
 	TriggerNoShell      bool     `help:"Run trigger commands directly without shell (safer). Command is split on spaces; no shell expansions."`
+	OnErrorWebhook      []string `help:"URL to POST a JSON payload to when an error-level log is detected (can be repeated)"`
+	OnFaultWebhook      []string `help:"URL to POST a JSON payload to when a fault-level log is detected (can be repeated)"`
+	OnPatternWebhook    []string `help:"Pattern:URL pairs (e.g., 'crash|fatal:https://hooks.slack.com/services/...') - can be repeated"`
+	WebhookFormat       string   `default:"json" enum:"json,slack,teams" help:"Webhook body: json (entry, trigger id and context lines), slack or teams (chat message)"`
+	WebhookTemplate     string   `help:"Go text/template for the webhook body, or @file (fields: .Trigger .TriggerID .Entry .Context .Summary .ContextText; quote strings with {{json .Entry.Message}})"`
+	WebhookHeader       []string `help:"Header sent with --on-*-webhook requests as key=value (can be repeated; rules files set their own headers)"`
+	WebhookContext      int      `default:"20" help:"Number of preceding log lines included in webhook payloads"`
+	Rules               string   `help:"YAML rules file: named rules with a --where expression, threshold ('5 in 60s'), cooldown and command/webhook/marker actions"`
 	DryRunJSON          bool     `help:"Print resolved stream options and triggers as JSON and exit (no streaming; ndjson output only)"`
 	MaxDuration         string   `help:"Stop after duration (e.g., '5m') emitting cutoff_reached (agent-safe cutoff)"`
 	MaxLogs             int      `help:"Stop after N logs emitting cutoff_reached (agent-safe cutoff)"`
@@ -63,12 +76,16 @@ type WatchCmd struct {
 	SessionPrefix       string   `help:"Prefix for session filename (default: app bundle ID)"`
 	Tmux                bool     `help:"Output to tmux session"`
 	Session             string   `help:"Custom tmux session name (default: xcw-<simulator>)"`
+
+	OTLPFlags
+	AnomalyFlags
 }
 
 // triggerConfig holds parsed trigger configuration
 type triggerConfig struct {
 	pattern *regexp.Regexp
 	command string
+	webhook *webhookSink // set instead of command for --on-pattern-webhook
 }
 
 var (
@@ -149,6 +166,56 @@ func (c *WatchCmd) Run(globals *Globals) error {
 		triggers = append(triggers, triggerConfig{pattern: re, command: parts[1]})
 	}
 
+	// Parse webhook sinks
+	var errorHooks, faultHooks []*webhookSink
+	if len(c.OnErrorWebhook)+len(c.OnFaultWebhook)+len(c.OnPatternWebhook) > 0 {
+		hookOpts, err := newWebhookOptions(c.WebhookFormat, c.WebhookTemplate, c.WebhookHeader)
+		if err != nil {
+			return c.outputError(globals, "INVALID_TRIGGER", err.Error())
+		}
+		parseHooks := func(urls []string) ([]*webhookSink, error) {
+			var hooks []*webhookSink
+			for _, u := range urls {
+				hook, err := newWebhookSink(u, hookOpts)
+				if err != nil {
+					return nil, err
+				}
+				hooks = append(hooks, hook)
+			}
+			return hooks, nil
+		}
+		if errorHooks, err = parseHooks(c.OnErrorWebhook); err != nil {
+			return c.outputError(globals, "INVALID_TRIGGER", err.Error())
+		}
+		if faultHooks, err = parseHooks(c.OnFaultWebhook); err != nil {
+			return c.outputError(globals, "INVALID_TRIGGER", err.Error())
+		}
+		for _, pt := range c.OnPatternWebhook {
+			pattern, url, ok := splitPatternWebhook(pt)
+			if !ok {
+				return c.outputError(globals, "INVALID_TRIGGER", fmt.Sprintf("invalid pattern:url format: %s", pt), "the URL must start with http:// or https://")
+			}
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return c.outputError(globals, "INVALID_TRIGGER_PATTERN", fmt.Sprintf("invalid trigger pattern: %s", err))
+			}
+			hook, err := newWebhookSink(url, hookOpts)
+			if err != nil {
+				return c.outputError(globals, "INVALID_TRIGGER", err.Error())
+			}
+			triggers = append(triggers, triggerConfig{pattern: re, webhook: hook})
+		}
+	}
+
+	// Load rules file
+	var rules *watchRules
+	if c.Rules != "" {
+		rules, err = loadWatchRules(c.Rules, cooldown, c.WebhookFormat, c.WebhookTemplate)
+		if err != nil {
+			return c.outputError(globals, "INVALID_RULES", err.Error(), hintForRules(err))
+		}
+	}
+
 	// Validate mutual exclusivity of flags
 	if globals.FlagProvided("simulator") && globals.FlagProvided("booted") {
 		return c.outputError(globals, "INVALID_FLAGS", "--simulator and --booted are mutually exclusive")
@@ -156,21 +223,41 @@ func (c *WatchCmd) Run(globals *Globals) error {
 	if err := validateFlags(globals, c.DryRunJSON, c.Tmux); err != nil {
 		return err
 	}
-	if err := validateAppPredicateAll(c.App, c.Predicate, c.All, false); err != nil {
+	// Watch has no --subsystem/--category flags; presets may still set them.
+	var subsystems, categories []string
+	if err := applyPresets(globals.Config, c.Preset, presetTarget{
+		Pattern: &c.Pattern, Exclude: &c.Exclude, ExcludeSubsystem: &c.ExcludeSubsystem,
+		Subsystem: &subsystems, Category: &categories, Process: &c.Process,
+		Where: &c.Where, MinLevel: &c.MinLevel, MaxLevel: &c.MaxLevel,
+	}); err != nil {
+		return c.outputError(globals, "INVALID_PRESET", err.Error(), hintForPreset(globals.Config))
+	}
+	if err := validateAppPredicateAll(c.App, c.Predicate, c.All, len(subsystems) > 0 || len(categories) > 0); err != nil {
 		return outputErrorCommon(globals, err.Code, err.Message, err.Hint)
 	}
 
 	// Find the simulator
-	mgr := simulator.NewManager()
+	mgr := newSimulatorManager(globals)
 	device, err := resolveSimulatorDevice(ctx, mgr, c.Simulator, c.Booted)
 	if err != nil {
 		return c.outputError(globals, "DEVICE_NOT_FOUND", err.Error(), hintForStreamOrQuery(err))
 	}
 
+	// Fetch app version/build for metadata (best-effort)
+	appVersion, appBuild := "", ""
+	if c.App != "" {
+		if v, b, err := mgr.GetAppInfo(ctx, device.UDID, c.App); err == nil {
+			appVersion, appBuild = v, b
+			globals.Debug("App info: version=%s build=%s", appVersion, appBuild)
+		} else {
+			globals.Debug("App info unavailable: %v", err)
+		}
+	}
+
 	// Session tracking is only meaningful when watching an app.
 	var sessionTracker tailSessionTracker
 	if c.App != "" {
-		sessionTracker = session.NewTracker(c.App, device.Name, device.UDID, tailID, "", "")
+		sessionTracker = session.NewTracker(c.App, device.Name, device.UDID, tailID, appVersion, appBuild)
 	} else {
 		sessionTracker = &noopSessionTracker{}
 	}
@@ -182,6 +269,14 @@ func (c *WatchCmd) Run(globals *Globals) error {
 	}
 	// Pattern/exclude are applied in the simulator streamer; keep pipeline for where-only filtering.
 	pipeline := filter.NewPipeline(nil, nil, whereFilter)
+	extractor, err := filter.NewFieldExtractor(c.Extract, c.ExtractRegex)
+	if err != nil {
+		return c.outputError(globals, "INVALID_FILTER", err.Error(), hintForExtract(err))
+	}
+	traceExtractor, err := filter.NewTraceExtractor(c.ExtractTrace, c.ExtractTraceRegex)
+	if err != nil {
+		return c.outputError(globals, "INVALID_FILTER", err.Error(), hintForExtractTrace(err))
+	}
 
 	// Setup dedupe filter if enabled
 	var dedupeFilter *filter.DedupeFilter
@@ -196,6 +291,14 @@ func (c *WatchCmd) Run(globals *Globals) error {
 		dedupeFilter = filter.NewDedupeFilter(dedupeWindow)
 	}
 
+	if _, err := c.headers(); err != nil {
+		return c.outputError(globals, "INVALID_FLAGS", err.Error())
+	}
+	anomalies, err := c.newAnomalyDetector()
+	if err != nil {
+		return c.outputError(globals, "INVALID_FLAGS", err.Error())
+	}
+
 	// Determine log level (command-specific overrides global)
 	minLevel, maxLevel := resolveLevels(c.MinLevel, c.MaxLevel, globals.Level)
 
@@ -203,6 +306,8 @@ func (c *WatchCmd) Run(globals *Globals) error {
 	streamer := simulator.NewStreamer(mgr)
 	opts := simulator.StreamOptions{
 		BundleID:          c.App,
+		Subsystems:        subsystems,
+		Categories:        categories,
 		MinLevel:          minLevel,
 		MaxLevel:          maxLevel,
 		Pattern:           pattern,
@@ -232,6 +337,15 @@ func (c *WatchCmd) Run(globals *Globals) error {
 			OnError             string                  `json:"on_error,omitempty"`
 			OnFault             string                  `json:"on_fault,omitempty"`
 			OnPattern           []string                `json:"on_pattern,omitempty"`
+			OnErrorWebhook      []string                `json:"on_error_webhook,omitempty"`
+			OnFaultWebhook      []string                `json:"on_fault_webhook,omitempty"`
+			OnPatternWebhook    []string                `json:"on_pattern_webhook,omitempty"`
+			WebhookFormat       string                  `json:"webhook_format,omitempty"`
+			Rules               []ruleSummary           `json:"rules,omitempty"`
+			Pattern             string                  `json:"pattern,omitempty"`
+			Exclude             []string                `json:"exclude,omitempty"`
+			Where               []string                `json:"where,omitempty"`
+			Presets             []string                `json:"presets,omitempty"`
 		}{
 			Stream:              opts,
 			MaxDuration:         c.MaxDuration,
@@ -244,6 +358,15 @@ func (c *WatchCmd) Run(globals *Globals) error {
 			OnError:             c.OnError,
 			OnFault:             c.OnFault,
 			OnPattern:           c.OnPattern,
+			OnErrorWebhook:      webhookCommands(errorHooks),
+			OnFaultWebhook:      webhookCommands(faultHooks),
+			OnPatternWebhook:    patternWebhookCommands(triggers),
+			WebhookFormat:       webhookFormatForDryRun(c.WebhookFormat, errorHooks, faultHooks, triggers),
+			Rules:               rules.summaries(),
+			Pattern:             c.Pattern,
+			Exclude:             c.Exclude,
+			Where:               c.Where,
+			Presets:             c.Preset,
 		})
 	}
 
@@ -253,6 +376,28 @@ func (c *WatchCmd) Run(globals *Globals) error {
 	var outputFile *os.File
 	var bufferedWriter *bufio.Writer
 
+	exporter, err := c.newExporter(c.App, appVersion, appBuild, device)
+	if err != nil {
+		return c.outputError(globals, "INVALID_FLAGS", err.Error())
+	}
+	// The exporter is flushed explicitly before cutoff_reached; the deferred
+	// call only covers early returns.
+	exporterClosed := false
+	closeOTLP := func() {
+		if exporterClosed {
+			return
+		}
+		exporterClosed = true
+		closeExporter(globals, exporter, func(msg string) {
+			if globals.Format == "ndjson" && outputWriter == globals.Stdout {
+				_ = writeStdout(func(w *output.NDJSONWriter) error { return w.WriteWarning(msg) })
+				return
+			}
+			emitWarning(globals, output.NewEmitter(outputWriter), msg)
+		})
+	}
+	defer closeOTLP()
+
 	// Determine output file path
 	var outputPath string
 	if c.Output != "" {
@@ -375,11 +520,32 @@ func (c *WatchCmd) Run(globals *Globals) error {
 					globals.Debug("failed to write watch info: %v", err)
 				}
 			}
+			for _, hook := range errorHooks {
+				if _, err := fmt.Fprintf(globals.Stderr, "%s\n", watchWarnStyle.Render(fmt.Sprintf("On error: %s", hook.command()))); err != nil {
+					globals.Debug("failed to write watch info: %v", err)
+				}
+			}
+			for _, hook := range faultHooks {
+				if _, err := fmt.Fprintf(globals.Stderr, "%s\n", watchWarnStyle.Render(fmt.Sprintf("On fault: %s", hook.command()))); err != nil {
+					globals.Debug("failed to write watch info: %v", err)
+				}
+			}
 			for _, t := range triggers {
-				if _, err := fmt.Fprintf(globals.Stderr, "On pattern '%s': %s\n", t.pattern.String(), t.command); err != nil {
+				command := t.command
+				if t.webhook != nil {
+					command = t.webhook.command()
+				}
+				if _, err := fmt.Fprintf(globals.Stderr, "On pattern '%s': %s\n", t.pattern.String(), command); err != nil {
 					globals.Debug("failed to write watch info: %v", err)
 				}
 			}
+			if rules != nil {
+				for _, r := range rules.summaries() {
+					if _, err := fmt.Fprintf(globals.Stderr, "Rule '%s' (%s, threshold %s): %s\n", r.Name, r.Where, r.Threshold, strings.Join(r.Actions, ", ")); err != nil {
+						globals.Debug("failed to write watch info: %v", err)
+					}
+				}
+			}
 			if _, err := fmt.Fprintf(globals.Stderr, "Cooldown: %s\n", c.Cooldown); err != nil {
 				globals.Debug("failed to write watch info: %v", err)
 			}
@@ -402,6 +568,41 @@ func (c *WatchCmd) Run(globals *Globals) error {
 	cutoffReason := ""
 	var runErr error
 
+	// Close anomaly windows even when no entries arrive, so silence is noticed
+	var anomalyTicker *clock.Ticker
+	observedAt, tickAnomalies := anomalyClock(globals)
+	if anomalies != nil && tickAnomalies {
+		anomalyTicker = clk.Ticker(anomalies.Window())
+		defer anomalyTicker.Stop()
+	}
+	emitAnomalies := func(found []*domain.Anomaly) error {
+		for _, a := range found {
+			a.TailID = tailID
+			a.Session = sessionTracker.CurrentSession()
+			switch {
+			case globals.Format == "ndjson" && outputWriter == globals.Stdout:
+				if err := writeStdout(func(w *output.NDJSONWriter) error { return w.WriteAnomaly(a) }); err != nil {
+					return err
+				}
+			case globals.Format == "ndjson":
+				if err := output.NewNDJSONWriter(outputWriter).WriteAnomaly(a); err != nil {
+					return err
+				}
+			case !globals.Quiet:
+				if _, err := fmt.Fprintln(globals.Stderr, anomalyText(a)); err != nil {
+					globals.Debug("failed to write anomaly: %v", err)
+				}
+			}
+		}
+		return nil
+	}
+
+	// Recent entries give webhook payloads their context lines
+	var recent *simulator.RingBuffer
+	if c.WebhookContext > 0 && (len(errorHooks)+len(faultHooks) > 0 || len(c.OnPatternWebhook) > 0 || rules.hasWebhooks()) {
+		recent = simulator.NewRingBuffer(c.WebhookContext)
+	}
+
 	// Create output writer
 	var writer interface {
 		Write(entry *domain.LogEntry) error
@@ -429,8 +630,21 @@ loop:
 			cutoffReason = "max_duration"
 			break loop
 
+		case <-func() <-chan time.Time {
+			if anomalyTicker != nil {
+				return anomalyTicker.C
+			}
+			return nil
+		}():
+			if err := emitAnomalies(anomalies.Tick(clk.Now())); err != nil {
+				runErr = err
+				break loop
+			}
+
 		case entry := <-streamer.Logs():
-			// Apply where filtering (post-stream)
+			// Apply field extraction and where filtering (post-stream)
+			extractor.Extract(&entry)
+			traceExtractor.Extract(&entry)
 			if pipeline != nil && !pipeline.Match(&entry) {
 				continue
 			}
@@ -460,21 +674,49 @@ loop:
 					break loop
 				}
 			}
+			if exporter != nil {
+				exporter.Export(&entry)
+			}
 
 			now := clk.Now()
+			if anomalies != nil {
+				if err := emitAnomalies(anomalies.Observe(&entry, observedAt(&entry, now))); err != nil {
+					runErr = err
+					break loop
+				}
+			}
+
+			runHooks := func(triggerType string, hooks ...*webhookSink) {
+				if len(hooks) == 0 {
+					return
+				}
+				var preceding []domain.LogEntry
+				if recent != nil {
+					preceding = recent.GetAll()
+				}
+				for _, hook := range hooks {
+					c.runWebhook(triggerCtx, triggerGroup, globals, writeStdout, triggerType, hook, entry, preceding, device, triggerTimeout, triggerSem, c.TriggerOutput)
+				}
+			}
 
 			// Check error trigger
-			if c.OnError != "" && entry.Level == domain.LogLevelError {
+			if (c.OnError != "" || len(errorHooks) > 0) && entry.Level == domain.LogLevelError {
 				if now.Sub(lastErrorTrigger) >= cooldown {
-					c.runTrigger(triggerCtx, triggerGroup, globals, writeStdout, "error", c.OnError, entry, triggerTimeout, triggerSem, c.TriggerOutput)
+					if c.OnError != "" {
+						c.runTrigger(triggerCtx, triggerGroup, globals, writeStdout, "error", c.OnError, entry, triggerTimeout, triggerSem, c.TriggerOutput)
+					}
+					runHooks("error", errorHooks...)
 					lastErrorTrigger = now
 				}
 			}
 
 			// Check fault trigger
-			if c.OnFault != "" && entry.Level == domain.LogLevelFault {
+			if (c.OnFault != "" || len(faultHooks) > 0) && entry.Level == domain.LogLevelFault {
 				if now.Sub(lastFaultTrigger) >= cooldown {
-					c.runTrigger(triggerCtx, triggerGroup, globals, writeStdout, "fault", c.OnFault, entry, triggerTimeout, triggerSem, c.TriggerOutput)
+					if c.OnFault != "" {
+						c.runTrigger(triggerCtx, triggerGroup, globals, writeStdout, "fault", c.OnFault, entry, triggerTimeout, triggerSem, c.TriggerOutput)
+					}
+					runHooks("fault", faultHooks...)
 					lastFaultTrigger = now
 				}
 			}
@@ -483,11 +725,34 @@ loop:
 			for i, t := range triggers {
 				if t.pattern.MatchString(entry.Message) {
 					if now.Sub(lastPatternTriggers[i]) >= cooldown {
-						c.runTrigger(triggerCtx, triggerGroup, globals, writeStdout, "pattern:"+t.pattern.String(), t.command, entry, triggerTimeout, triggerSem, c.TriggerOutput)
+						if t.webhook != nil {
+							runHooks("pattern:"+t.pattern.String(), t.webhook)
+						} else {
+							c.runTrigger(triggerCtx, triggerGroup, globals, writeStdout, "pattern:"+t.pattern.String(), t.command, entry, triggerTimeout, triggerSem, c.TriggerOutput)
+						}
 						lastPatternTriggers[i] = now
 					}
 				}
 			}
+			// Check rules
+			if rules != nil {
+				for _, f := range rules.evaluator.Observe(&entry, now) {
+					triggerType := "rule:" + f.Rule.Name
+					for _, a := range rules.actions[f.Rule.Name] {
+						switch {
+						case a.marker != "":
+							c.emitMarker(globals, writeStdout, f, a.marker, now)
+						case a.webhook != nil:
+							runHooks(triggerType, a.webhook)
+						default:
+							c.runTrigger(triggerCtx, triggerGroup, globals, writeStdout, triggerType, a.command, entry, triggerTimeout, triggerSem, c.TriggerOutput)
+						}
+					}
+				}
+			}
+			if recent != nil {
+				recent.Push(entry)
+			}
 
 			totalLogs++
 			if maxLogs > 0 && totalLogs >= maxLogs {
@@ -520,6 +785,8 @@ loop:
 	}
 	_ = triggerGroup.Wait()
 
+	closeOTLP()
+
 	if cutoffReason != "" && runErr == nil && globals.Format == "ndjson" {
 		if err := writeStdout(func(w *output.NDJSONWriter) error {
 			return w.WriteCutoff(cutoffReason, tailID, sessionTracker.CurrentSession(), totalLogs)
@@ -539,52 +806,11 @@ loop:
 
 // runTrigger executes a trigger command with safety limits
 func (c *WatchCmd) runTrigger(ctx context.Context, group *errgroup.Group, globals *Globals, writeStdout func(fn func(w *output.NDJSONWriter) error) error, triggerType, command string, entry domain.LogEntry, timeout time.Duration, sem chan struct{}, outputMode string) {
-	// Try to acquire semaphore (non-blocking)
-	select {
-	case sem <- struct{}{}:
-		// Acquired
-	default:
-		// Too many parallel triggers running, skip this one
-		if globals.Format == "ndjson" {
-			if err := writeStdout(func(w *output.NDJSONWriter) error {
-				return w.WriteWarning(fmt.Sprintf("trigger skipped (max parallel %d reached): %s", cap(sem), command))
-			}); err != nil {
-				globals.Debug("failed to write trigger warning: %v", err)
-			}
-		} else if !globals.Quiet {
-			if _, err := fmt.Fprintf(globals.Stderr, "[TRIGGER SKIPPED] Max parallel triggers reached: %s\n", command); err != nil {
-				globals.Debug("failed to write trigger warning: %v", err)
-			}
-		}
+	triggerID, triggerTimestamp, ok := c.beginTrigger(globals, writeStdout, triggerType, command, entry, sem)
+	if !ok {
 		return
 	}
 
-	triggerID := generateTriggerID()
-	triggerTimestamp := time.Now().UTC().Format(time.RFC3339Nano)
-
-	// Output trigger notification
-	if globals.Format == "ndjson" {
-		if err := writeStdout(func(w *output.NDJSONWriter) error {
-			return w.WriteTrigger(&output.TriggerOutput{
-				Type:          "trigger",
-				Timestamp:     triggerTimestamp,
-				TailID:        entry.TailID,
-				Session:       entry.Session,
-				TriggerID:     triggerID,
-				Trigger:       triggerType,
-				Command:       command,
-				Message:       entry.Message,
-				SchemaVersion: 0,
-			})
-		}); err != nil {
-			globals.Debug("failed to write trigger: %v", err)
-		}
-	} else if !globals.Quiet {
-		if _, err := fmt.Fprintf(globals.Stderr, "[TRIGGER:%s] Running: %s\n", triggerType, command); err != nil {
-			globals.Debug("failed to write trigger: %v", err)
-		}
-	}
-
 	// Run command in background (don't block log processing)
 	group.Go(func() error {
 		defer func() { <-sem }() // Release semaphore when done
@@ -600,7 +826,7 @@ func (c *WatchCmd) runTrigger(ctx context.Context, group *errgroup.Group, global
 		if c.TriggerNoShell {
 			argv := strings.Fields(command)
 			if len(argv) == 0 {
-				c.emitTriggerFailure(globals, writeStdout, triggerID, triggerType, command, entry, triggerTimestamp, "empty trigger command", -1, 0, false, "")
+				c.emitTriggerFailure(globals, writeStdout, triggerID, triggerType, command, entry, triggerTimestamp, "empty trigger command", -1, 0, false, "", 0)
 				return nil
 			}
 			cmd = exec.CommandContext(ctx, argv[0], argv[1:]...)
@@ -653,17 +879,104 @@ func (c *WatchCmd) runTrigger(ctx context.Context, group *errgroup.Group, global
 			if timedOut {
 				errMsg = fmt.Sprintf("timeout after %s", timeout)
 			}
-			c.emitTriggerFailure(globals, writeStdout, triggerID, triggerType, command, entry, triggerTimestamp, errMsg, exitCode, durationMs, timedOut, outStr)
+			c.emitTriggerFailure(globals, writeStdout, triggerID, triggerType, command, entry, triggerTimestamp, errMsg, exitCode, durationMs, timedOut, outStr, 0)
 			return nil
 		}
 
 		switch outputMode {
 		case "capture":
 			// Include captured output on success as well.
-			_ = c.emitTriggerResult(globals, writeStdout, triggerID, triggerType, command, entry, triggerTimestamp, exitCode, durationMs, timedOut, outStr, "")
+			_ = c.emitTriggerResult(globals, writeStdout, triggerID, triggerType, command, entry, triggerTimestamp, exitCode, durationMs, timedOut, outStr, "", 0)
 		default:
-			_ = c.emitTriggerResult(globals, writeStdout, triggerID, triggerType, command, entry, triggerTimestamp, exitCode, durationMs, timedOut, "", "")
+			_ = c.emitTriggerResult(globals, writeStdout, triggerID, triggerType, command, entry, triggerTimestamp, exitCode, durationMs, timedOut, "", "", 0)
+		}
+		return nil
+	})
+}
+
+// beginTrigger reserves a slot in the trigger semaphore and announces the
+// trigger. ok is false when the trigger was skipped.
+func (c *WatchCmd) beginTrigger(globals *Globals, writeStdout func(fn func(w *output.NDJSONWriter) error) error, triggerType, command string, entry domain.LogEntry, sem chan struct{}) (triggerID, triggerTimestamp string, ok bool) {
+	// Try to acquire semaphore (non-blocking)
+	select {
+	case sem <- struct{}{}:
+		// Acquired
+	default:
+		// Too many parallel triggers running, skip this one
+		if globals.Format == "ndjson" {
+			if err := writeStdout(func(w *output.NDJSONWriter) error {
+				return w.WriteWarning(fmt.Sprintf("trigger skipped (max parallel %d reached): %s", cap(sem), command))
+			}); err != nil {
+				globals.Debug("failed to write trigger warning: %v", err)
+			}
+		} else if !globals.Quiet {
+			if _, err := fmt.Fprintf(globals.Stderr, "[TRIGGER SKIPPED] Max parallel triggers reached: %s\n", command); err != nil {
+				globals.Debug("failed to write trigger warning: %v", err)
+			}
+		}
+		return "", "", false
+	}
+
+	triggerID = generateTriggerID()
+	triggerTimestamp = time.Now().UTC().Format(time.RFC3339Nano)
+
+	// Output trigger notification
+	if globals.Format == "ndjson" {
+		if err := writeStdout(func(w *output.NDJSONWriter) error {
+			return w.WriteTrigger(&output.TriggerOutput{
+				Type:          "trigger",
+				Timestamp:     triggerTimestamp,
+				TailID:        entry.TailID,
+				Session:       entry.Session,
+				TriggerID:     triggerID,
+				Trigger:       triggerType,
+				Command:       command,
+				Message:       entry.Message,
+				SchemaVersion: 0,
+			})
+		}); err != nil {
+			globals.Debug("failed to write trigger: %v", err)
+		}
+	} else if !globals.Quiet {
+		if _, err := fmt.Fprintf(globals.Stderr, "[TRIGGER:%s] Running: %s\n", triggerType, command); err != nil {
+			globals.Debug("failed to write trigger: %v", err)
+		}
+	}
+	return triggerID, triggerTimestamp, true
+}
+
+// runWebhook POSTs a trigger payload to hook, sharing the trigger semaphore
+// and result events with command triggers
+func (c *WatchCmd) runWebhook(ctx context.Context, group *errgroup.Group, globals *Globals, writeStdout func(fn func(w *output.NDJSONWriter) error) error, triggerType string, hook *webhookSink, entry domain.LogEntry, recent []domain.LogEntry, device *domain.Device, timeout time.Duration, sem chan struct{}, outputMode string) {
+	command := hook.command()
+	triggerID, triggerTimestamp, ok := c.beginTrigger(globals, writeStdout, triggerType, command, entry, sem)
+	if !ok {
+		return
+	}
+	payload := newWebhookPayload(triggerID, triggerType, triggerTimestamp, c.App, device, &entry, recent)
+
+	group.Go(func() error {
+		defer func() { <-sem }() // Release semaphore when done
+
+		start := time.Now()
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		status, body, err := hook.post(ctx, payload)
+		durationMs := time.Since(start).Milliseconds()
+		timedOut := ctx.Err() == context.DeadlineExceeded
+		if outputMode != "capture" {
+			body = ""
+		}
+		if err != nil {
+			errMsg := err.Error()
+			if timedOut {
+				errMsg = fmt.Sprintf("timeout after %s", timeout)
+			}
+			c.emitTriggerFailure(globals, writeStdout, triggerID, triggerType, command, entry, triggerTimestamp, errMsg, -1, durationMs, timedOut, body, status)
+			return nil
 		}
+		_ = c.emitTriggerResult(globals, writeStdout, triggerID, triggerType, command, entry, triggerTimestamp, 0, durationMs, false, body, "", status)
 		return nil
 	})
 }
@@ -679,9 +992,9 @@ func exitCodeFromError(err error) int {
 	return -1
 }
 
-func (c *WatchCmd) emitTriggerFailure(globals *Globals, writeStdout func(fn func(w *output.NDJSONWriter) error) error, triggerID, triggerType, command string, entry domain.LogEntry, timestamp string, errMsg string, exitCode int, durationMs int64, timedOut bool, out string) {
+func (c *WatchCmd) emitTriggerFailure(globals *Globals, writeStdout func(fn func(w *output.NDJSONWriter) error) error, triggerID, triggerType, command string, entry domain.LogEntry, timestamp string, errMsg string, exitCode int, durationMs int64, timedOut bool, out string, statusCode int) {
 	if globals.Format == "ndjson" {
-		_ = c.emitTriggerResult(globals, writeStdout, triggerID, triggerType, command, entry, timestamp, exitCode, durationMs, timedOut, out, errMsg)
+		_ = c.emitTriggerResult(globals, writeStdout, triggerID, triggerType, command, entry, timestamp, exitCode, durationMs, timedOut, out, errMsg, statusCode)
 		_ = writeStdout(func(w *output.NDJSONWriter) error {
 			return w.WriteTriggerError(&output.TriggerErrorOutput{
 				Type:      "trigger_error",
@@ -704,7 +1017,7 @@ func (c *WatchCmd) emitTriggerFailure(globals *Globals, writeStdout func(fn func
 	}
 }
 
-func (c *WatchCmd) emitTriggerResult(globals *Globals, writeStdout func(fn func(w *output.NDJSONWriter) error) error, triggerID, triggerType, command string, entry domain.LogEntry, timestamp string, exitCode int, durationMs int64, timedOut bool, out string, errMsg string) error {
+func (c *WatchCmd) emitTriggerResult(globals *Globals, writeStdout func(fn func(w *output.NDJSONWriter) error) error, triggerID, triggerType, command string, entry domain.LogEntry, timestamp string, exitCode int, durationMs int64, timedOut bool, out string, errMsg string, statusCode int) error {
 	if globals.Format != "ndjson" {
 		return nil
 	}
@@ -718,6 +1031,7 @@ func (c *WatchCmd) emitTriggerResult(globals *Globals, writeStdout func(fn func(
 			Trigger:    triggerType,
 			Command:    command,
 			ExitCode:   exitCode,
+			StatusCode: statusCode,
 			DurationMs: durationMs,
 			TimedOut:   timedOut,
 			Output:     out,
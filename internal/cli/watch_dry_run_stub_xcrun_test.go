@@ -79,3 +79,56 @@ exit 1
 	require.Equal(t, "30s", out["trigger_timeout"])
 	require.Equal(t, "discard", out["trigger_output"])
 }
+
+func TestWatchDryRunJSON_Rules(t *testing.T) {
+	stubWatchXcrun(t)
+	rulesPath := filepath.Join(t.TempDir(), "rules.yaml")
+	require.NoError(t, os.WriteFile(rulesPath, []byte(`
+rules:
+  - name: crash
+    where: message~crash
+    actions:
+      - command: ./notify.sh
+      - webhook: https://hooks.example.com/secret/token
+`), 0o644))
+
+	var stdout bytes.Buffer
+	globals := &Globals{
+		Format: "ndjson",
+		Stdout: &stdout,
+		Stderr: &bytes.Buffer{},
+		Config: config.Default(),
+	}
+	cmd := &WatchCmd{
+		Booted:         true,
+		App:            "com.example.myapp",
+		Rules:          rulesPath,
+		WebhookFormat:  "json",
+		Cooldown:       "5s",
+		TriggerTimeout: "30s",
+		TriggerOutput:  "discard",
+		DryRunJSON:     true,
+	}
+	require.NoError(t, cmd.Run(globals))
+
+	var out struct {
+		Rules []ruleSummary `json:"rules"`
+	}
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &out))
+	require.Equal(t, []ruleSummary{{
+		Name:      "crash",
+		Where:     "message~crash",
+		Threshold: "1",
+		Cooldown:  "5s",
+		Actions:   []string{"command: ./notify.sh", "webhook: POST https://hooks.example.com/…"},
+	}}, out.Rules)
+
+	t.Run("invalid rules fail before streaming", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(rulesPath, []byte("rules:\n  - name: crash\n    where: message~crash\n"), 0o644))
+		stdout.Reset()
+		err := cmd.Run(globals)
+		require.Error(t, err)
+		require.Contains(t, stdout.String(), "INVALID_RULES")
+		require.Contains(t, stdout.String(), "at least one action")
+	})
+}
@@ -3,12 +3,19 @@ package cli
 import (
 	"bytes"
 	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/require"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+
 	"github.com/vburojevic/xcw/internal/config"
 )
 
@@ -181,3 +188,238 @@ exit 1
 	require.Equal(t, "cutoff_reached", v["type"])
 	require.Equal(t, "max_duration", v["reason"])
 }
+
+// stubWatchXcrun installs an xcrun that lists one booted simulator and
+// streams the given log stream JSON lines, then sleeps.
+func stubWatchXcrun(t *testing.T, lines ...string) {
+	t.Helper()
+	stubDir := t.TempDir()
+	var echo strings.Builder
+	for _, l := range lines {
+		echo.WriteString("  echo '" + l + "'\n")
+	}
+	script := `#!/bin/sh
+set -eu
+
+if [ "$#" -ge 4 ] && [ "$1" = "simctl" ] && [ "$2" = "list" ] && [ "$3" = "devices" ] && [ "$4" = "--json" ]; then
+  cat <<'EOF'
+{
+  "devices": {
+    "com.apple.CoreSimulator.SimRuntime.iOS-17-0": [
+      {
+        "udid": "TEST-UDID-123",
+        "name": "iPhone 17 Pro",
+        "state": "Booted",
+        "isAvailable": true,
+        "deviceTypeIdentifier": "com.apple.CoreSimulator.SimDeviceType.iPhone-17-Pro",
+        "dataPath": "/tmp",
+        "logPath": "/tmp"
+      }
+    ]
+  }
+}
+EOF
+  exit 0
+fi
+
+if [ "$#" -ge 2 ] && [ "$1" = "simctl" ] && [ "$2" = "get_app_container" ] && [ -n "${XCW_STUB_APP_CONTAINER:-}" ]; then
+  echo "$XCW_STUB_APP_CONTAINER"
+  exit 0
+fi
+
+if [ "$#" -ge 5 ] && [ "$1" = "simctl" ] && [ "$2" = "spawn" ] && [ "$4" = "log" ] && [ "$5" = "stream" ]; then
+` + echo.String() + `  exec sleep 60
+fi
+
+echo "stub: unsupported xcrun args: $*" >&2
+exit 1
+`
+	require.NoError(t, os.WriteFile(filepath.Join(stubDir, "xcrun"), []byte(script), 0o755))
+	t.Setenv("PATH", stubDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func stubLogLine(ts, level, message string) string {
+	return `{"timestamp":"` + ts + `","messageType":"` + level + `","processImagePath":"/Applications/MyApp.app/MyApp","processID":123,"threadID":1,"subsystem":"com.example.myapp","category":"network","eventMessage":"` + message + `","eventType":"logEvent","processImageUUID":"UUID-123","senderImagePath":""}`
+}
+
+func TestWatchWebhook_WithStubXcrun(t *testing.T) {
+	stubWatchXcrun(t,
+		stubLogLine("2025-12-15 00:00:00.000000+0000", "Info", "Loading feed"),
+		stubLogLine("2025-12-15 00:00:01.000000+0000", "Error", "Feed request failed"),
+	)
+
+	bodies := make(chan []byte, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies <- body
+	}))
+	defer srv.Close()
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	globals := &Globals{
+		Format: "ndjson",
+		Level:  "debug",
+		Quiet:  true,
+		Stdout: &stdout,
+		Stderr: &stderr,
+		Config: config.Default(),
+	}
+	cmd := &WatchCmd{
+		Booted:              true,
+		App:                 "com.example.myapp",
+		OnErrorWebhook:      []string{srv.URL},
+		WebhookFormat:       "json",
+		WebhookContext:      20,
+		Cooldown:            "0s",
+		TriggerTimeout:      "2s",
+		MaxParallelTriggers: 1,
+		TriggerOutput:       "discard",
+		MaxLogs:             2,
+	}
+
+	require.NoError(t, cmd.Run(globals))
+
+	var payload struct {
+		Trigger string           `json:"trigger"`
+		Entry   map[string]any   `json:"entry"`
+		Context []map[string]any `json:"context"`
+	}
+	require.NoError(t, json.Unmarshal(<-bodies, &payload))
+	require.Equal(t, "error", payload.Trigger)
+	require.Equal(t, "Feed request failed", payload.Entry["message"])
+	require.Len(t, payload.Context, 1)
+	require.Equal(t, "Loading feed", payload.Context[0]["message"])
+	require.Contains(t, stdout.String(), `"type":"trigger_result"`)
+}
+
+func TestWatchRules_WithStubXcrun(t *testing.T) {
+	stubWatchXcrun(t,
+		stubLogLine("2025-12-15 00:00:00.000000+0000", "Error", "timeout: feed"),
+		stubLogLine("2025-12-15 00:00:01.000000+0000", "Info", "retrying"),
+		stubLogLine("2025-12-15 00:00:02.000000+0000", "Error", "timeout: feed"),
+	)
+
+	bodies := make(chan []byte, 1)
+	headers := make(chan http.Header, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		headers <- r.Header
+		bodies <- body
+	}))
+	defer srv.Close()
+
+	rulesPath := filepath.Join(t.TempDir(), "rules.yaml")
+	require.NoError(t, os.WriteFile(rulesPath, []byte(`
+rules:
+  - name: feed timeouts
+    where: 'level>=error AND message~"timeout: "'
+    threshold: 2 in 1m
+    cooldown: 0s
+    actions:
+      - marker: feed degraded
+      - webhook: `+srv.URL+`
+        template: '{"rule": {{json .Trigger}}, "message": {{json .Entry.Message}}}'
+        headers:
+          X-Env: ci
+`), 0o644))
+
+	var stdout bytes.Buffer
+	globals := &Globals{
+		Format: "ndjson",
+		Level:  "debug",
+		Quiet:  true,
+		Stdout: &stdout,
+		Stderr: &bytes.Buffer{},
+		Config: config.Default(),
+	}
+	cmd := &WatchCmd{
+		Booted:              true,
+		App:                 "com.example.myapp",
+		Rules:               rulesPath,
+		WebhookFormat:       "json",
+		WebhookHeader:       []string{"Authorization=Bearer secret"},
+		Cooldown:            "5s",
+		TriggerTimeout:      "2s",
+		MaxParallelTriggers: 1,
+		TriggerOutput:       "discard",
+		MaxLogs:             3,
+	}
+
+	require.NoError(t, cmd.Run(globals))
+
+	header := <-headers
+	require.Equal(t, "ci", header.Get("X-Env"))
+	require.Empty(t, header.Get("Authorization"), "--webhook-header must not reach rule webhooks")
+	var body map[string]string
+	require.NoError(t, json.Unmarshal(<-bodies, &body))
+	require.Equal(t, "rule:feed timeouts", body["rule"])
+	require.Equal(t, "timeout: feed", body["message"])
+
+	var markers []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(stdout.String()), "\n") {
+		var v map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &v))
+		if v["type"] == "marker" {
+			markers = append(markers, v)
+		}
+	}
+	require.Len(t, markers, 1)
+	require.Equal(t, "feed timeouts", markers[0]["rule"])
+	require.Equal(t, "feed degraded", markers[0]["message"])
+	require.Equal(t, float64(2), markers[0]["matches"])
+	require.Equal(t, "1m0s", markers[0]["window"])
+}
+
+func TestWatchOTLPExportAppInfo_WithStubXcrun(t *testing.T) {
+	container := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(container, "Info.plist"), []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<plist version="1.0"><dict>
+<key>CFBundleShortVersionString</key><string>2.4.0</string>
+<key>CFBundleVersion</key><string>812</string>
+</dict></plist>`), 0o644))
+	t.Setenv("XCW_STUB_APP_CONTAINER", container)
+	stubWatchXcrun(t, stubLogLine("2025-12-15 00:00:00.000000+0000", "Error", "Feed request failed"))
+
+	var mu sync.Mutex
+	var received []*collogspb.ExportLogsServiceRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		req := &collogspb.ExportLogsServiceRequest{}
+		if err := protojson.Unmarshal(body, req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		received = append(received, req)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	var stdout, stderr bytes.Buffer
+	globals := &Globals{Format: "ndjson", Level: "debug", Quiet: true, Stdout: &stdout, Stderr: &stderr, Config: config.Default()}
+	cmd := &WatchCmd{
+		Booted:              true,
+		App:                 "com.example.myapp",
+		OnError:             "/usr/bin/true",
+		TriggerNoShell:      true,
+		Cooldown:            "0s",
+		TriggerTimeout:      "2s",
+		MaxParallelTriggers: 1,
+		TriggerOutput:       "discard",
+		MaxLogs:             1,
+		OTLPFlags:           OTLPFlags{OTLPEndpoint: srv.URL, OTLPProtocol: "http/json"},
+	}
+	require.NoError(t, cmd.Run(globals))
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, received, 1)
+	res := map[string]string{}
+	for _, kv := range received[0].ResourceLogs[0].Resource.Attributes {
+		res[kv.Key] = kv.Value.GetStringValue()
+	}
+	require.Equal(t, "com.example.myapp", res["service.name"])
+	require.Equal(t, "2.4.0", res["service.version"])
+	require.Equal(t, "812", res["app.build"])
+}
@@ -3,6 +3,9 @@ package cli
 import (
 	"context"
 	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"sync"
 	"testing"
@@ -147,3 +150,160 @@ func TestWatchTriggerOutput_FailureEmitsTriggerError(t *testing.T) {
 	require.Equal(t, trigger["trigger_id"], terr["trigger_id"])
 	require.NotEmpty(t, terr["error"])
 }
+
+func TestWatchWebhook_PostsPayload(t *testing.T) {
+	bodies := make(chan []byte, 2)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.Equal(t, "Bearer t", r.Header.Get("Authorization"))
+		body, _ := io.ReadAll(r.Body)
+		bodies <- body
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	var stdout strings.Builder
+	w := output.NewNDJSONWriter(&stdout)
+	var mu sync.Mutex
+	writeStdout := func(fn func(w *output.NDJSONWriter) error) error {
+		mu.Lock()
+		defer mu.Unlock()
+		return fn(w)
+	}
+	globals := &Globals{
+		Format: "ndjson",
+		Stdout: &stdout,
+		Stderr: &strings.Builder{},
+		Config: config.Default(),
+	}
+	c := &WatchCmd{App: "com.example.app"}
+
+	entry := domain.LogEntry{TailID: "tail-abc", Session: 1, Level: domain.LogLevelError, Process: "MyApp", Message: `parse failed: "key" missing`}
+	preceding := []domain.LogEntry{
+		{Level: domain.LogLevelInfo, Process: "MyApp", Message: "request started"},
+		{Level: domain.LogLevelDebug, Process: "MyApp", Message: "decoding"},
+	}
+	device := &domain.Device{Name: "iPhone 17 Pro", UDID: "UDID-1"}
+
+	t.Run("json", func(t *testing.T) {
+		stdout.Reset()
+		opts, err := newWebhookOptions("json", "", []string{"Authorization=Bearer t"})
+		require.NoError(t, err)
+		hook, err := newWebhookSink(srv.URL+"/hooks/secret", opts)
+		require.NoError(t, err)
+
+		group, ctx := errgroup.WithContext(context.Background())
+		c.runWebhook(ctx, group, globals, writeStdout, "error", hook, entry, preceding, device, 5*time.Second, make(chan struct{}, 1), "capture")
+		require.NoError(t, group.Wait())
+
+		var payload map[string]any
+		require.NoError(t, json.Unmarshal(<-bodies, &payload))
+		require.Equal(t, "xcw_trigger", payload["type"])
+		require.Equal(t, "error", payload["trigger"])
+		require.Equal(t, "com.example.app", payload["app"])
+		require.Equal(t, "UDID-1", payload["udid"])
+		require.Equal(t, entry.Message, payload["entry"].(map[string]any)["message"])
+		require.Len(t, payload["context"], 2)
+
+		lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+		require.Len(t, lines, 2)
+		var trigger, result map[string]any
+		require.NoError(t, json.Unmarshal([]byte(lines[0]), &trigger))
+		require.NoError(t, json.Unmarshal([]byte(lines[1]), &result))
+		require.Equal(t, "POST "+srv.URL+"/…", trigger["command"], "webhook path is redacted")
+		require.Equal(t, trigger["trigger_id"], payload["trigger_id"])
+		require.Equal(t, "trigger_result", result["type"])
+		require.Equal(t, float64(200), result["status_code"])
+		require.Equal(t, "ok", result["output"])
+	})
+
+	t.Run("slack", func(t *testing.T) {
+		stdout.Reset()
+		opts, err := newWebhookOptions("slack", "", []string{"Authorization=Bearer t"})
+		require.NoError(t, err)
+		hook, err := newWebhookSink(srv.URL, opts)
+		require.NoError(t, err)
+
+		group, ctx := errgroup.WithContext(context.Background())
+		c.runWebhook(ctx, group, globals, writeStdout, "error", hook, entry, preceding, device, 5*time.Second, make(chan struct{}, 1), "discard")
+		require.NoError(t, group.Wait())
+
+		var msg map[string]string
+		require.NoError(t, json.Unmarshal(<-bodies, &msg))
+		require.Contains(t, msg["text"], "com.example.app on iPhone 17 Pro")
+		require.Contains(t, msg["text"], entry.Message)
+		require.Contains(t, msg["text"], "```\n")
+		require.Contains(t, msg["text"], "request started")
+	})
+}
+
+func TestWatchWebhook_FailureEmitsTriggerError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	var stdout strings.Builder
+	w := output.NewNDJSONWriter(&stdout)
+	writeStdout := func(fn func(w *output.NDJSONWriter) error) error { return fn(w) }
+	globals := &Globals{
+		Format: "ndjson",
+		Stdout: &stdout,
+		Stderr: &strings.Builder{},
+		Config: config.Default(),
+	}
+	c := &WatchCmd{}
+
+	opts, err := newWebhookOptions("json", "", nil)
+	require.NoError(t, err)
+	hook, err := newWebhookSink(srv.URL, opts)
+	require.NoError(t, err)
+
+	group, ctx := errgroup.WithContext(context.Background())
+	c.runWebhook(ctx, group, globals, writeStdout, "fault", hook, domain.LogEntry{Message: "msg"}, nil, nil, 5*time.Second, make(chan struct{}, 1), "discard")
+	require.NoError(t, group.Wait())
+
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	require.Len(t, lines, 3)
+	var result, terr map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &result))
+	require.NoError(t, json.Unmarshal([]byte(lines[2]), &terr))
+	require.Equal(t, float64(403), result["status_code"])
+	require.Equal(t, float64(-1), result["exit_code"])
+	require.Equal(t, "trigger_error", terr["type"])
+	require.Contains(t, terr["error"], "HTTP 403")
+}
+
+func TestSplitPatternWebhook(t *testing.T) {
+	tests := []struct {
+		in, pattern, url string
+		ok               bool
+	}{
+		{`crash|fatal:https://hooks.example.com/T1`, `crash|fatal`, `https://hooks.example.com/T1`, true},
+		{`code: 5\d\d:https://hooks.example.com/T1`, `code: 5\d\d`, `https://hooks.example.com/T1`, true},
+		{`timeout:http://localhost:8080/hook`, `timeout`, `http://localhost:8080/hook`, true},
+		{`GET https://api.example.com failed:https://hooks.example.com/T1`, `GET https://api.example.com failed`, `https://hooks.example.com/T1`, true},
+		{`crash:ftp://example.com`, "", "", false},
+	}
+	for _, tt := range tests {
+		pattern, url, ok := splitPatternWebhook(tt.in)
+		require.Equal(t, tt.ok, ok, tt.in)
+		require.Equal(t, tt.pattern, pattern, tt.in)
+		require.Equal(t, tt.url, url, tt.in)
+	}
+}
+
+func TestWebhookOptions_Validation(t *testing.T) {
+	_, err := newWebhookOptions("json", "", []string{"novalue"})
+	require.ErrorContains(t, err, "key=value")
+
+	opts, err := newWebhookOptions("json", "{{.Nope", nil)
+	require.NoError(t, err)
+	_, err = newWebhookSink("http://example.com", opts)
+	require.ErrorContains(t, err, "invalid webhook template")
+
+	opts, err = newWebhookOptions("json", "", nil)
+	require.NoError(t, err)
+	_, err = newWebhookSink("ftp://example.com", opts)
+	require.ErrorContains(t, err, "invalid webhook URL")
+}
@@ -0,0 +1,264 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/vburojevic/xcw/internal/domain"
+	"github.com/vburojevic/xcw/internal/output"
+)
+
+// Webhook body formats for --webhook-format
+const (
+	webhookFormatJSON  = "json"
+	webhookFormatSlack = "slack"
+	webhookFormatTeams = "teams"
+)
+
+// webhookChatTemplate renders a chat message with the trigger summary and the
+// context lines in a code block; Slack and Teams incoming webhooks both accept
+// a markdown "text" field.
+const webhookChatTemplate = "{\"text\": {{json (print .Summary (codeBlock .ContextText))}}}"
+
+var webhookPresets = map[string]string{
+	webhookFormatJSON:  "{{json .}}",
+	webhookFormatSlack: webhookChatTemplate,
+	webhookFormatTeams: webhookChatTemplate,
+}
+
+// webhookPayload is the default JSON body, and the data passed to templates
+type webhookPayload struct {
+	Type      string               `json:"type"` // Always "xcw_trigger"
+	TriggerID string               `json:"trigger_id"`
+	Trigger   string               `json:"trigger"`
+	Timestamp string               `json:"timestamp"`
+	TailID    string               `json:"tail_id,omitempty"`
+	Session   int                  `json:"session,omitempty"`
+	App       string               `json:"app,omitempty"`
+	Simulator string               `json:"simulator,omitempty"`
+	UDID      string               `json:"udid,omitempty"`
+	Entry     output.OutputEntry   `json:"entry"`
+	Context   []output.OutputEntry `json:"context,omitempty"` // Entries before the trigger, oldest first
+
+	// Summary and ContextText are plain-text renderings for chat templates
+	Summary     string `json:"-"`
+	ContextText string `json:"-"`
+}
+
+// webhookSink POSTs trigger payloads to a URL
+type webhookSink struct {
+	url      string
+	tmpl     *template.Template
+	headers  map[string]string
+	client   *http.Client
+	redacted string
+}
+
+// webhookOptions are the body and header settings shared by all sinks
+type webhookOptions struct {
+	format   string
+	template string
+	headers  map[string]string
+}
+
+func newWebhookOptions(format, tmpl string, headers []string) (*webhookOptions, error) {
+	opts := &webhookOptions{format: format, headers: map[string]string{}}
+	if opts.format == "" {
+		opts.format = webhookFormatJSON
+	}
+	if _, ok := webhookPresets[opts.format]; !ok {
+		return nil, fmt.Errorf("unknown webhook format %q (use json, slack or teams)", format)
+	}
+	if strings.HasPrefix(tmpl, "@") {
+		b, err := os.ReadFile(tmpl[1:])
+		if err != nil {
+			return nil, fmt.Errorf("cannot read webhook template: %w", err)
+		}
+		tmpl = string(b)
+	}
+	opts.template = tmpl
+	for _, h := range headers {
+		k, v, ok := strings.Cut(h, "=")
+		if !ok || strings.TrimSpace(k) == "" {
+			return nil, fmt.Errorf("invalid --webhook-header %q: expected key=value", h)
+		}
+		opts.headers[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return opts, nil
+}
+
+func newWebhookSink(rawURL string, opts *webhookOptions) (*webhookSink, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid webhook URL %q: expected http(s)://host/path", rawURL)
+	}
+	body := opts.template
+	if body == "" {
+		body = webhookPresets[opts.format]
+	}
+	tmpl, err := template.New("webhook").Funcs(webhookFuncs).Parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhook template: %w", err)
+	}
+	return &webhookSink{
+		url:      rawURL,
+		tmpl:     tmpl,
+		headers:  opts.headers,
+		client:   &http.Client{},
+		redacted: redactURL(u),
+	}, nil
+}
+
+var webhookFuncs = template.FuncMap{
+	// json encodes a value, so messages with quotes stay valid JSON
+	"json": func(v interface{}) (string, error) {
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(v); err != nil {
+			return "", err
+		}
+		return strings.TrimSuffix(buf.String(), "\n"), nil
+	},
+	"codeBlock": func(s string) string {
+		if s == "" {
+			return ""
+		}
+		return "\n```\n" + strings.ReplaceAll(s, "```", "` ` `") + "\n```"
+	},
+	"truncate": truncateRunes,
+}
+
+// redactURL keeps scheme and host; webhook paths and queries often embed secrets
+func redactURL(u *url.URL) string {
+	s := u.Scheme + "://" + u.Host
+	if u.Path != "" && u.Path != "/" || u.RawQuery != "" {
+		s += "/…"
+	}
+	return s
+}
+
+// command describes the sink in trigger events
+func (s *webhookSink) command() string {
+	return "POST " + s.redacted
+}
+
+// webhookCommands lists hooks by their redacted command, for --dry-run-json
+func webhookCommands(hooks []*webhookSink) []string {
+	var out []string
+	for _, h := range hooks {
+		out = append(out, h.command())
+	}
+	return out
+}
+
+// splitPatternWebhook splits a --on-pattern-webhook value at the last
+// ":http://" or ":https://", so the pattern itself may contain colons.
+func splitPatternWebhook(s string) (pattern, url string, ok bool) {
+	i := max(strings.LastIndex(s, ":http://"), strings.LastIndex(s, ":https://"))
+	if i < 0 {
+		return "", "", false
+	}
+	return s[:i], s[i+1:], true
+}
+
+// patternWebhookCommands lists the pattern webhooks among triggers as
+// pattern:command pairs.
+func patternWebhookCommands(triggers []triggerConfig) []string {
+	var out []string
+	for _, t := range triggers {
+		if t.webhook != nil {
+			out = append(out, t.pattern.String()+":"+t.webhook.command())
+		}
+	}
+	return out
+}
+
+// webhookFormatForDryRun reports the body format only when webhooks are set
+func webhookFormatForDryRun(format string, errorHooks, faultHooks []*webhookSink, triggers []triggerConfig) string {
+	if len(errorHooks)+len(faultHooks)+len(patternWebhookCommands(triggers)) == 0 {
+		return ""
+	}
+	return format
+}
+
+// post renders the payload and sends it. It returns the HTTP status and the
+// (truncated) response body.
+func (s *webhookSink) post(ctx context.Context, payload *webhookPayload) (int, string, error) {
+	var body bytes.Buffer
+	if err := s.tmpl.Execute(&body, payload); err != nil {
+		return 0, "", fmt.Errorf("rendering webhook body: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, &body)
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "xcw/"+Version)
+	for k, v := range s.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 16*1024))
+	out := strings.TrimSpace(string(respBody))
+	if resp.StatusCode/100 != 2 {
+		return resp.StatusCode, out, fmt.Errorf("webhook returned HTTP %d", resp.StatusCode)
+	}
+	return resp.StatusCode, out, nil
+}
+
+// newWebhookPayload builds the payload for a trigger on entry with the
+// entries that preceded it.
+func newWebhookPayload(triggerID, triggerType, timestamp, app string, device *domain.Device, entry *domain.LogEntry, context []domain.LogEntry) *webhookPayload {
+	p := &webhookPayload{
+		Type:      "xcw_trigger",
+		TriggerID: triggerID,
+		Trigger:   triggerType,
+		Timestamp: timestamp,
+		TailID:    entry.TailID,
+		Session:   entry.Session,
+		App:       app,
+		Entry:     output.NewOutputEntry(entry),
+	}
+	if device != nil {
+		p.Simulator = device.Name
+		p.UDID = device.UDID
+	}
+	lines := make([]string, 0, len(context))
+	for i := range context {
+		p.Context = append(p.Context, output.NewOutputEntry(&context[i]))
+		lines = append(lines, webhookLine(&context[i]))
+	}
+	p.ContextText = strings.Join(lines, "\n")
+
+	where := p.Simulator
+	if app != "" {
+		where = app + " on " + where
+	}
+	p.Summary = fmt.Sprintf("xcw %s trigger (%s): %s", triggerType, where, webhookLine(entry))
+	return p
+}
+
+func webhookLine(e *domain.LogEntry) string {
+	return fmt.Sprintf("%s [%s] %s: %s", e.Timestamp.Format("15:04:05.000"), e.Level, e.Process, truncateRunes(e.Message, 500))
+}
+
+func truncateRunes(s string, n int) string {
+	r := []rune(s)
+	if n <= 0 || len(r) <= n {
+		return s
+	}
+	return string(r[:n]) + "…"
+}
@@ -4,10 +4,13 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/spf13/viper"
+	"github.com/vburojevic/xcw/internal/filter"
 )
 
 // Config holds application configuration
@@ -23,6 +26,12 @@ type Config struct {
 	Tail     TailConfig     `mapstructure:"tail"`
 	Query    QueryConfig    `mapstructure:"query"`
 	Watch    WatchConfig    `mapstructure:"watch"`
+
+	// Named filter presets applied with --preset
+	Filters map[string]FilterPreset `mapstructure:"filters"`
+
+	// Message templating for patterns and discover
+	Patterns PatternsConfig `mapstructure:"patterns"`
 }
 
 // Source indicates where a config value came from after applying precedence.
@@ -79,6 +88,7 @@ func configKeySpecs() []keySpec {
 		{Key: "tail.session_idle"},
 		{Key: "tail.exclude"},
 		{Key: "tail.where"},
+		{Key: "tail.crash_reports_dir"},
 
 		// query.*
 		{Key: "query.simulator", ExtraEnv: []string{"XCW_SIMULATOR"}},
@@ -128,6 +138,7 @@ type TailConfig struct {
 	SessionIdle     string   `mapstructure:"session_idle"`
 	Exclude         []string `mapstructure:"exclude"`
 	Where           []string `mapstructure:"where"`
+	CrashReportsDir string   `mapstructure:"crash_reports_dir"`
 }
 
 type QueryConfig struct {
@@ -145,6 +156,62 @@ type WatchConfig struct {
 	Cooldown  string `mapstructure:"cooldown"`
 }
 
+// FilterPreset is a named, reusable set of filters defined under "filters:"
+// and applied with --preset on tail, query, watch and ui.
+type FilterPreset struct {
+	Description       string   `mapstructure:"description" json:"description,omitempty"`
+	Pattern           string   `mapstructure:"pattern" json:"pattern,omitempty"`
+	Exclude           []string `mapstructure:"exclude" json:"exclude,omitempty"`
+	ExcludeSubsystems []string `mapstructure:"exclude_subsystems" json:"exclude_subsystems,omitempty"`
+	Subsystems        []string `mapstructure:"subsystems" json:"subsystems,omitempty"`
+	Categories        []string `mapstructure:"categories" json:"categories,omitempty"`
+	Processes         []string `mapstructure:"processes" json:"processes,omitempty"`
+	Where             []string `mapstructure:"where" json:"where,omitempty"`
+	MinLevel          string   `mapstructure:"min_level" json:"min_level,omitempty"`
+	MaxLevel          string   `mapstructure:"max_level" json:"max_level,omitempty"`
+}
+
+// PatternsConfig tunes how messages are grouped into templates by analyze,
+// summary, discover and the pattern store.
+type PatternsConfig struct {
+	// Masks replace variable parts of messages (IDs, tokens) before grouping,
+	// ahead of the built-in masks
+	Masks []PatternMask `mapstructure:"masks" json:"masks,omitempty"`
+	// Similarity is the share of tokens (0-1) a message must share with a
+	// template to join it; 0 uses the default
+	Similarity float64 `mapstructure:"similarity" json:"similarity,omitempty"`
+}
+
+// PatternMask is a named regex whose matches are replaced with Replace
+// (default "<name>").
+type PatternMask struct {
+	Name    string `mapstructure:"name" json:"name"`
+	Regex   string `mapstructure:"regex" json:"regex"`
+	Replace string `mapstructure:"replace" json:"replace,omitempty"`
+}
+
+// Preset looks up a filter preset by name (case-insensitive, as viper lowercases keys).
+func (c *Config) Preset(name string) (FilterPreset, bool) {
+	if c == nil {
+		return FilterPreset{}, false
+	}
+	p, ok := c.Filters[strings.ToLower(name)]
+	return p, ok
+}
+
+// PresetNames returns the configured preset names in sorted order.
+func (c *Config) PresetNames() []string {
+	if c == nil {
+		return nil
+	}
+	names := make([]string, 0, len(c.Filters))
+	for name := range c.Filters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // Default returns a Config with default values
 func Default() *Config {
 	return &Config{
@@ -424,6 +491,49 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("query.limit must be >= 0")
 	}
 
+	for _, name := range c.PresetNames() {
+		if err := c.Filters[name].validate(); err != nil {
+			return fmt.Errorf("filters.%s: %w", name, err)
+		}
+	}
+
+	if c.Patterns.Similarity < 0 || c.Patterns.Similarity > 1 {
+		return fmt.Errorf("patterns.similarity must be between 0 and 1")
+	}
+	for i, m := range c.Patterns.Masks {
+		if m.Name == "" {
+			return fmt.Errorf("patterns.masks[%d]: name is required", i)
+		}
+		if _, err := regexp.Compile(m.Regex); err != nil || m.Regex == "" {
+			return fmt.Errorf("patterns.masks[%d]: invalid regex %q", i, m.Regex)
+		}
+	}
+
+	return nil
+}
+
+// validate checks preset regexes, where expressions and levels.
+func (p FilterPreset) validate() error {
+	if p.Pattern != "" {
+		if _, err := regexp.Compile(p.Pattern); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", p.Pattern, err)
+		}
+	}
+	for _, x := range p.Exclude {
+		if _, err := regexp.Compile(x); err != nil {
+			return fmt.Errorf("invalid exclude pattern %q: %w", x, err)
+		}
+	}
+	if _, err := filter.NewWhereFilter(p.Where); err != nil {
+		return fmt.Errorf("invalid where: %w", err)
+	}
+	for _, lvl := range []string{p.MinLevel, p.MaxLevel} {
+		switch strings.ToLower(lvl) {
+		case "", "debug", "info", "default", "error", "fault":
+		default:
+			return fmt.Errorf("invalid level: %q (expected debug, info, default, error, fault)", lvl)
+		}
+	}
 	return nil
 }
 
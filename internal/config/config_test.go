@@ -181,6 +181,64 @@ watch:
 	})
 }
 
+func TestFilterPresets(t *testing.T) {
+	t.Run("parses presets", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		configContent := `
+filters:
+  Networking:
+    description: HTTP traffic
+    subsystems: [com.example.network]
+    where: ["message~/(GET|POST) /"]
+  no-noise:
+    exclude: ["heartbeat"]
+    exclude_subsystems: ["com.apple.*"]
+    min_level: info
+`
+		configPath := filepath.Join(tmpDir, "xcw.yaml")
+		require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0644))
+
+		cfg, err := LoadFromFile(configPath)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"networking", "no-noise"}, cfg.PresetNames())
+		p, ok := cfg.Preset("NETWORKING")
+		require.True(t, ok)
+		assert.Equal(t, "HTTP traffic", p.Description)
+		assert.Equal(t, []string{"com.example.network"}, p.Subsystems)
+		assert.Equal(t, []string{"message~/(GET|POST) /"}, p.Where)
+		p, ok = cfg.Preset("no-noise")
+		require.True(t, ok)
+		assert.Equal(t, []string{"heartbeat"}, p.Exclude)
+		assert.Equal(t, []string{"com.apple.*"}, p.ExcludeSubsystems)
+		assert.Equal(t, "info", p.MinLevel)
+		_, ok = cfg.Preset("missing")
+		assert.False(t, ok)
+	})
+
+	t.Run("rejects invalid presets", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			preset FilterPreset
+			want   string
+		}{
+			{"pattern", FilterPreset{Pattern: "("}, "filters.bad: invalid pattern"},
+			{"exclude", FilterPreset{Exclude: []string{"["}}, "filters.bad: invalid exclude pattern"},
+			{"where", FilterPreset{Where: []string{"level=="}}, "filters.bad: invalid where"},
+			{"level", FilterPreset{MaxLevel: "loud"}, "filters.bad: invalid level"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				cfg := Default()
+				cfg.Filters = map[string]FilterPreset{"bad": tt.preset}
+				err := cfg.Validate()
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.want)
+			})
+		}
+	})
+}
+
 func TestConfigEnvironmentVariables(t *testing.T) {
 	// Set env variables
 	t.Setenv("XCW_FORMAT", "text")
@@ -381,3 +439,50 @@ func TestEnvOverridesViaViper(t *testing.T) {
 		assert.Equal(t, 42, cfg.Query.Limit)
 	})
 }
+
+func TestPatternsConfig(t *testing.T) {
+	t.Run("parses masks", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		configContent := `
+patterns:
+  similarity: 0.6
+  masks:
+    - name: order
+      regex: 'ORD-[0-9]+'
+    - name: token
+      regex: 'tok_[a-z0-9]+'
+      replace: '<secret>'
+`
+		configPath := filepath.Join(tmpDir, "xcw.yaml")
+		require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0644))
+
+		cfg, err := LoadFromFile(configPath)
+		require.NoError(t, err)
+		assert.Equal(t, 0.6, cfg.Patterns.Similarity)
+		assert.Equal(t, []PatternMask{
+			{Name: "order", Regex: "ORD-[0-9]+"},
+			{Name: "token", Regex: "tok_[a-z0-9]+", Replace: "<secret>"},
+		}, cfg.Patterns.Masks)
+	})
+
+	t.Run("rejects invalid settings", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			patterns PatternsConfig
+			want     string
+		}{
+			{"similarity", PatternsConfig{Similarity: 1.5}, "patterns.similarity"},
+			{"name", PatternsConfig{Masks: []PatternMask{{Regex: "x"}}}, "patterns.masks[0]: name is required"},
+			{"regex", PatternsConfig{Masks: []PatternMask{{Name: "bad", Regex: "("}}}, "patterns.masks[0]: invalid regex"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				cfg := Default()
+				cfg.Patterns = tt.patterns
+				err := cfg.Validate()
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.want)
+			})
+		}
+	})
+}
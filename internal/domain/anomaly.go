@@ -0,0 +1,28 @@
+package domain
+
+// Anomaly kinds reported by the rate-based anomaly detector
+const (
+	AnomalyErrorSpike      = "error_spike"
+	AnomalySubsystemSilent = "subsystem_silent"
+	AnomalyNewPattern      = "new_pattern"
+)
+
+// Anomaly is emitted when the log stream departs from its learned baseline
+type Anomaly struct {
+	Type          string  `json:"type"`                  // "anomaly"
+	SchemaVersion int     `json:"schemaVersion"`         // 1
+	Timestamp     string  `json:"timestamp"`             // ISO8601 time the anomaly was detected
+	TailID        string  `json:"tail_id,omitempty"`     // Tail invocation identifier
+	Session       int     `json:"session,omitempty"`     // Session number (when available)
+	Kind          string  `json:"kind"`                  // error_spike, subsystem_silent or new_pattern
+	Subsystem     string  `json:"subsystem,omitempty"`   // Subsystem concerned (empty for the whole stream)
+	Pattern       string  `json:"pattern,omitempty"`     // Message template (new_pattern)
+	TemplateID    string  `json:"template_id,omitempty"` // Template ID, as in discover templates[].id (new_pattern)
+	Example       string  `json:"example,omitempty"`     // First message seen with the pattern (new_pattern)
+	Level         string  `json:"level,omitempty"`       // Level of the example entry (new_pattern)
+	Window        string  `json:"window"`                // Rate window, e.g. "10s"
+	Observed      int     `json:"observed"`              // Entries in the window that triggered the anomaly
+	Baseline      float64 `json:"baseline"`              // Expected entries per window (EWMA)
+	Score         float64 `json:"score,omitempty"`       // Standard deviations above the baseline (error_spike)
+	Message       string  `json:"message"`               // Human-readable summary
+}
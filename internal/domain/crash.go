@@ -0,0 +1,19 @@
+package domain
+
+// CrashDetected is emitted when the tracked app appears to have crashed
+type CrashDetected struct {
+	Type            string     `json:"type"`                       // "crash_detected"
+	SchemaVersion   int        `json:"schemaVersion"`              // 1
+	Timestamp       string     `json:"timestamp"`                  // ISO8601 timestamp of the triggering log entry
+	TailID          string     `json:"tail_id,omitempty"`          // Tail invocation identifier
+	Session         int        `json:"session,omitempty"`          // Session the crash ended
+	PID             int        `json:"pid"`                        // Process ID that crashed
+	Process         string     `json:"process,omitempty"`          // Process name
+	Trigger         string     `json:"trigger"`                    // "exception_message" or "exit_after_fault"
+	ExceptionType   string     `json:"exception_type,omitempty"`   // e.g. EXC_BAD_ACCESS, NSInvalidArgumentException
+	ExceptionReason string     `json:"exception_reason,omitempty"` // Reason text parsed from the messages
+	Signal          string     `json:"signal,omitempty"`           // e.g. SIGSEGV (from the .ips report when found)
+	BinaryUUID      string     `json:"binary_uuid,omitempty"`      // Mach-O UUID from process image
+	IPSPath         string     `json:"ips_path,omitempty"`         // Matching DiagnosticReports .ips file
+	Lines           []LogEntry `json:"lines,omitempty"`            // Final buffered log lines before the crash
+}
@@ -5,12 +5,16 @@ type Discovery struct {
 	Type          string             `json:"type"`
 	SchemaVersion int                `json:"schemaVersion"`
 	App           string             `json:"app,omitempty"`
+	Files         []string           `json:"files,omitempty"` // Recordings read with --file
 	TimeRange     DiscoveryTimeRange `json:"time_range"`
 	TotalCount    int                `json:"total_count"`
 	Subsystems    []SubsystemInfo    `json:"subsystems"`
 	Categories    []CategoryInfo     `json:"categories"`
 	Processes     []ProcessInfo      `json:"processes"`
 	Levels        map[string]int     `json:"levels"`
+	Fields        []FieldInfo        `json:"fields,omitempty"`
+	Templates     []TemplateInfo     `json:"templates,omitempty"`
+	Suggested     *SuggestedFilters  `json:"suggested_filters,omitempty"`
 }
 
 // DiscoveryTimeRange represents the time range of discovered logs
@@ -21,15 +25,41 @@ type DiscoveryTimeRange struct {
 
 // SubsystemInfo contains aggregated subsystem statistics
 type SubsystemInfo struct {
-	Name   string         `json:"name"`
-	Count  int            `json:"count"`
-	Levels map[string]int `json:"levels"`
+	Name      string          `json:"name"`
+	Count     int             `json:"count"`
+	Levels    map[string]int  `json:"levels"`
+	FirstSeen string          `json:"first_seen,omitempty"`
+	LastSeen  string          `json:"last_seen,omitempty"`
+	Samples   []string        `json:"samples,omitempty"`   // First distinct messages
+	Templates []TemplateCount `json:"templates,omitempty"` // Most frequent templates
 }
 
 // CategoryInfo contains aggregated category statistics
 type CategoryInfo struct {
-	Name  string `json:"name"`
-	Count int    `json:"count"`
+	Name      string          `json:"name"`
+	Count     int             `json:"count"`
+	Levels    map[string]int  `json:"levels,omitempty"`
+	FirstSeen string          `json:"first_seen,omitempty"`
+	LastSeen  string          `json:"last_seen,omitempty"`
+	Samples   []string        `json:"samples,omitempty"`
+	Templates []TemplateCount `json:"templates,omitempty"`
+}
+
+// TemplateCount is a template with its count within one subsystem or category
+type TemplateCount struct {
+	ID       string `json:"id"`
+	Template string `json:"template"`
+	Count    int    `json:"count"`
+}
+
+// SuggestedFilters are ready-made tail/query filters that drop the noisiest
+// Apple subsystems. Subsystems that also logged errors are kept for
+// error/fault entries through Where instead of being excluded.
+type SuggestedFilters struct {
+	ExcludeSubsystems []string `json:"exclude_subsystems,omitempty"`
+	Where             []string `json:"where,omitempty"`
+	Command           string   `json:"command"` // xcw tail command applying the filters
+	Removes           int      `json:"removes"` // Entries in this discovery the filters would drop
 }
 
 // ProcessInfo contains aggregated process statistics
@@ -37,3 +67,31 @@ type ProcessInfo struct {
 	Name  string `json:"name"`
 	Count int    `json:"count"`
 }
+
+// FieldInfo contains aggregated statistics for an extracted message field
+type FieldInfo struct {
+	Name    string   `json:"name"`
+	Count   int      `json:"count"`
+	Samples []string `json:"samples,omitempty"`
+	Min     *float64 `json:"min,omitempty"` // Set when every value is numeric
+	Max     *float64 `json:"max,omitempty"`
+}
+
+// TemplateInfo is a message template mined from logs, with the variable
+// parts replaced by <kind> masks or <*> wildcards
+type TemplateInfo struct {
+	ID        string          `json:"id"` // Hash of the template's token count and leading tokens, stable across runs
+	Template  string          `json:"template"`
+	Count     int             `json:"count"`
+	Level     LogLevel        `json:"level,omitempty"`     // Most severe level seen
+	Subsystem string          `json:"subsystem,omitempty"` // Most common subsystem
+	Example   string          `json:"example,omitempty"`   // First message seen
+	Params    []TemplateParam `json:"params,omitempty"`
+}
+
+// TemplateParam is a variable token of a template
+type TemplateParam struct {
+	Position int      `json:"position"`          // Token index in the template
+	Token    string   `json:"token"`             // Template token, e.g. "<*>" or "id=<n>"
+	Samples  []string `json:"samples,omitempty"` // Distinct values seen (up to 3)
+}
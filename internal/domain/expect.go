@@ -0,0 +1,42 @@
+package domain
+
+// ExpectationFailed is emitted by xcw expect as soon as an expectation fails
+type ExpectationFailed struct {
+	Type          string    `json:"type"`              // "expectation_failed"
+	SchemaVersion int       `json:"schemaVersion"`     // 1
+	Timestamp     string    `json:"timestamp"`         // ISO8601 time the failure was detected
+	Name          string    `json:"name"`              // Expectation name from the rules file
+	Kind          string    `json:"kind"`              // "see", "not_see", "count" or "order"
+	Expression    string    `json:"expression"`        // Where expression(s) the expectation matches on
+	Reason        string    `json:"reason"`            // Human-readable failure reason
+	Count         int       `json:"count"`             // Matching entries seen so far
+	Max           *int      `json:"max,omitempty"`     // Allowed matches (count)
+	Within        string    `json:"within,omitempty"`  // Deadline (see)
+	Entry         *LogEntry `json:"entry,omitempty"`   // Offending log entry, when one caused the failure
+	TailID        string    `json:"tail_id,omitempty"` // Tail invocation identifier (live mode)
+	Session       int       `json:"session,omitempty"` // Session of the offending entry
+}
+
+// ExpectationStatus is the final state of one expectation
+type ExpectationStatus struct {
+	Name   string `json:"name"`
+	Kind   string `json:"kind"`
+	Status string `json:"status"` // "passed" or "failed"
+	Count  int    `json:"count"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// ExpectResult is emitted by xcw expect once all expectations are decided
+type ExpectResult struct {
+	Type          string              `json:"type"`          // "expect_result"
+	SchemaVersion int                 `json:"schemaVersion"` // 1
+	Timestamp     string              `json:"timestamp"`     // ISO8601 timestamp
+	Passed        bool                `json:"passed"`        // True when every expectation passed
+	Total         int                 `json:"total"`         // Number of expectations
+	PassedCount   int                 `json:"passed_count"`
+	FailedCount   int                 `json:"failed_count"`
+	LogsChecked   int                 `json:"logs_checked"`      // Log entries evaluated
+	StopReason    string              `json:"stop_reason"`       // "all_decided", "max_duration", "end_of_file", "fail_fast" or "interrupted"
+	TailID        string              `json:"tail_id,omitempty"` // Tail invocation identifier (live mode)
+	Expectations  []ExpectationStatus `json:"expectations"`      // Per-expectation outcome
+}
@@ -65,6 +65,26 @@ type LogEntry struct {
 	EventType        string    `json:"eventType,omitempty"`
 	TailID           string    `json:"tail_id,omitempty"`
 
+	// Device the entry came from (populated when tailing several simulators)
+	UDID      string `json:"udid,omitempty"`
+	Simulator string `json:"simulator,omitempty"`
+
+	// Activity tracing from the unified logging system (os_activity)
+	ActivityID       uint64 `json:"activity_id,omitempty"`
+	ParentActivityID uint64 `json:"parent_activity_id,omitempty"`
+	OSTraceID        uint64 `json:"os_trace_id,omitempty"`
+
+	// Distributed trace context found in the message (populated when --extract-trace is used)
+	TraceID      string `json:"trace_id,omitempty"`
+	SpanID       string `json:"span_id,omitempty"`
+	ParentSpanID string `json:"parent_span_id,omitempty"`
+
+	// Original messages of a coalesced multi-line group (populated when --group is used)
+	Lines []string `json:"lines,omitempty"`
+
+	// Key/value pairs extracted from the message (populated when --extract is used)
+	Fields map[string]string `json:"fields,omitempty"`
+
 	// Session tracking (populated when session tracking is active)
 	Session int `json:"session,omitempty"` // Session number (1, 2, 3...)
 
@@ -76,20 +96,22 @@ type LogEntry struct {
 
 // RawLogEntry matches the native NDJSON structure from `log stream --style ndjson`
 type RawLogEntry struct {
-	Timestamp        string `json:"timestamp"`
-	MessageType      string `json:"messageType"`
-	EventType        string `json:"eventType"`
-	EventMessage     string `json:"eventMessage"`
-	ProcessID        int    `json:"processID"`
-	ProcessImagePath string `json:"processImagePath"`
-	ProcessImageUUID string `json:"processImageUUID"`
-	Subsystem        string `json:"subsystem"`
-	Category         string `json:"category"`
-	ThreadID         int    `json:"threadID"`
-	FormatString     string `json:"formatString"`
-	UserID           int    `json:"userID"`
-	SenderImagePath  string `json:"senderImagePath"`
-	SenderImageUUID  string `json:"senderImageUUID"`
-	TraceID          int64  `json:"traceID,omitempty"`
-	MachTimestamp    int64  `json:"machTimestamp,omitempty"`
+	Timestamp                string `json:"timestamp"`
+	MessageType              string `json:"messageType"`
+	EventType                string `json:"eventType"`
+	EventMessage             string `json:"eventMessage"`
+	ProcessID                int    `json:"processID"`
+	ProcessImagePath         string `json:"processImagePath"`
+	ProcessImageUUID         string `json:"processImageUUID"`
+	Subsystem                string `json:"subsystem"`
+	Category                 string `json:"category"`
+	ThreadID                 int    `json:"threadID"`
+	FormatString             string `json:"formatString"`
+	UserID                   int    `json:"userID"`
+	SenderImagePath          string `json:"senderImagePath"`
+	SenderImageUUID          string `json:"senderImageUUID"`
+	TraceID                  uint64 `json:"traceID,omitempty"`
+	ActivityIdentifier       uint64 `json:"activityIdentifier,omitempty"`
+	ParentActivityIdentifier uint64 `json:"parentActivityIdentifier,omitempty"`
+	MachTimestamp            int64  `json:"machTimestamp,omitempty"`
 }
@@ -30,6 +30,23 @@ type LogSummary struct {
 
 	// Rate information
 	ErrorRate float64 `json:"errorRate"` // errors per minute
+
+	// Volume over time (--histogram)
+	BucketSize string         `json:"bucketSize,omitempty"` // e.g. "30s"
+	Histogram  []VolumeBucket `json:"histogram,omitempty"`  // Oldest first, empty buckets included
+}
+
+// VolumeBucket counts the entries logged in one histogram bucket
+type VolumeBucket struct {
+	Start             time.Time `json:"start"`
+	TotalCount        int       `json:"totalCount"`
+	DebugCount        int       `json:"debugCount,omitempty"`
+	InfoCount         int       `json:"infoCount,omitempty"`
+	DefaultCount      int       `json:"defaultCount,omitempty"`
+	ErrorCount        int       `json:"errorCount,omitempty"`
+	FaultCount        int       `json:"faultCount,omitempty"`
+	TopSubsystem      string    `json:"topSubsystem,omitempty"`      // Subsystem with the most entries in the bucket
+	TopSubsystemCount int       `json:"topSubsystemCount,omitempty"` // Entries from TopSubsystem
 }
 
 // NewLogSummary creates a new empty summary
@@ -0,0 +1,96 @@
+package filter
+
+import (
+	"strings"
+	"time"
+
+	"github.com/vburojevic/xcw/internal/domain"
+)
+
+// DefaultGroupWindow is the maximum gap between continuation lines of one
+// multi-line message (stack traces, NSException dumps).
+const DefaultGroupWindow = 10 * time.Millisecond
+
+// Coalescer merges consecutive entries from the same process/thread that
+// arrive within a short window into a single entry whose Lines holds each
+// original message. Not safe for concurrent use.
+type Coalescer struct {
+	window  time.Duration
+	pending *domain.LogEntry
+	lastTS  time.Time
+}
+
+// NewCoalescer creates a coalescer; window <= 0 uses DefaultGroupWindow.
+func NewCoalescer(window time.Duration) *Coalescer {
+	if window <= 0 {
+		window = DefaultGroupWindow
+	}
+	return &Coalescer{window: window}
+}
+
+// Window returns the continuation window.
+func (c *Coalescer) Window() time.Duration {
+	return c.window
+}
+
+// Add feeds the next entry. When entry does not continue the pending group,
+// the completed group (or single entry) is returned with ok=true and entry
+// becomes the new pending one.
+func (c *Coalescer) Add(entry domain.LogEntry) (done domain.LogEntry, ok bool) {
+	if c.pending != nil && c.continues(&entry) {
+		p := c.pending
+		if len(p.Lines) == 0 {
+			p.Lines = []string{p.Message}
+		}
+		p.Lines = append(p.Lines, entry.Message)
+		p.Message = strings.Join(p.Lines, "\n")
+		if entry.Level.Priority() > p.Level.Priority() {
+			p.Level = entry.Level
+		}
+		c.lastTS = entry.Timestamp
+		return domain.LogEntry{}, false
+	}
+
+	done, ok = c.Flush()
+	c.pending = &entry
+	c.lastTS = entry.Timestamp
+	return done, ok
+}
+
+// Flush returns the pending group, if any, and resets the coalescer.
+func (c *Coalescer) Flush() (domain.LogEntry, bool) {
+	if c.pending == nil {
+		return domain.LogEntry{}, false
+	}
+	done := *c.pending
+	c.pending = nil
+	return done, true
+}
+
+// continues reports whether entry is a continuation line of the pending group.
+func (c *Coalescer) continues(entry *domain.LogEntry) bool {
+	p := c.pending
+	if p.PID == 0 || entry.PID != p.PID || entry.TID != p.TID || entry.Process != p.Process {
+		return false
+	}
+	if entry.Timestamp.IsZero() || c.lastTS.IsZero() {
+		return false
+	}
+	gap := entry.Timestamp.Sub(c.lastTS)
+	return gap >= 0 && gap <= c.window
+}
+
+// CoalesceEntries groups multi-line messages in an ordered slice of entries.
+func CoalesceEntries(entries []domain.LogEntry, window time.Duration) []domain.LogEntry {
+	c := NewCoalescer(window)
+	out := make([]domain.LogEntry, 0, len(entries))
+	for _, e := range entries {
+		if done, ok := c.Add(e); ok {
+			out = append(out, done)
+		}
+	}
+	if done, ok := c.Flush(); ok {
+		out = append(out, done)
+	}
+	return out
+}
@@ -0,0 +1,77 @@
+package filter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vburojevic/xcw/internal/domain"
+)
+
+func TestCoalesceEntries(t *testing.T) {
+	base := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	at := func(ms int, pid, tid int, level domain.LogLevel, msg string) domain.LogEntry {
+		return domain.LogEntry{
+			Timestamp: base.Add(time.Duration(ms) * time.Millisecond),
+			Level:     level,
+			Process:   "MyApp",
+			PID:       pid,
+			TID:       tid,
+			Message:   msg,
+		}
+	}
+
+	t.Run("merges continuation lines", func(t *testing.T) {
+		out := CoalesceEntries([]domain.LogEntry{
+			at(0, 1, 7, domain.LogLevelDefault, "*** Terminating app"),
+			at(2, 1, 7, domain.LogLevelError, "0 CoreFoundation"),
+			at(4, 1, 7, domain.LogLevelDefault, "1 libobjc.A.dylib"),
+			at(500, 1, 7, domain.LogLevelInfo, "later"),
+		}, 0)
+		require.Len(t, out, 2)
+		assert.Equal(t, []string{"*** Terminating app", "0 CoreFoundation", "1 libobjc.A.dylib"}, out[0].Lines)
+		assert.Equal(t, "*** Terminating app\n0 CoreFoundation\n1 libobjc.A.dylib", out[0].Message)
+		assert.Equal(t, domain.LogLevelError, out[0].Level)
+		assert.Equal(t, base, out[0].Timestamp)
+		assert.Empty(t, out[1].Lines)
+		assert.Equal(t, "later", out[1].Message)
+	})
+
+	t.Run("window is measured between consecutive lines", func(t *testing.T) {
+		out := CoalesceEntries([]domain.LogEntry{
+			at(0, 1, 7, domain.LogLevelError, "a"),
+			at(8, 1, 7, domain.LogLevelError, "b"),
+			at(16, 1, 7, domain.LogLevelError, "c"),
+		}, 10*time.Millisecond)
+		require.Len(t, out, 1)
+		assert.Len(t, out[0].Lines, 3)
+	})
+
+	t.Run("different thread or pid breaks the group", func(t *testing.T) {
+		out := CoalesceEntries([]domain.LogEntry{
+			at(0, 1, 7, domain.LogLevelError, "a"),
+			at(1, 1, 8, domain.LogLevelError, "b"),
+			at(2, 2, 8, domain.LogLevelError, "c"),
+		}, 0)
+		assert.Len(t, out, 3)
+	})
+}
+
+func TestCoalescer_AddFlush(t *testing.T) {
+	c := NewCoalescer(0)
+	assert.Equal(t, DefaultGroupWindow, c.Window())
+
+	ts := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	_, ok := c.Add(domain.LogEntry{Timestamp: ts, PID: 1, Message: "a"})
+	assert.False(t, ok)
+	_, ok = c.Add(domain.LogEntry{Timestamp: ts.Add(time.Millisecond), PID: 1, Message: "b"})
+	assert.False(t, ok)
+
+	done, ok := c.Flush()
+	require.True(t, ok)
+	assert.Equal(t, []string{"a", "b"}, done.Lines)
+
+	_, ok = c.Flush()
+	assert.False(t, ok)
+}
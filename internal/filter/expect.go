@@ -0,0 +1,318 @@
+package filter
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+
+	"github.com/vburojevic/xcw/internal/domain"
+)
+
+// Expectation kinds
+const (
+	ExpectSee    = "see"
+	ExpectNotSee = "not_see"
+	ExpectCount  = "count"
+	ExpectOrder  = "order"
+)
+
+// Expectation outcomes
+const (
+	ExpectPassed = "passed"
+	ExpectFailed = "failed"
+)
+
+// ExpectationFile is the YAML rules file read by xcw expect.
+//
+//	timeout: 60s
+//	expectations:
+//	  - name: login succeeds
+//	    see: message~"Login succeeded"
+//	    within: 20s
+//	  - not_see: level=Fault
+//	  - count: subsystem=com.example.net AND level>=error
+//	    max: 3
+//	  - order: [message~"Launched", message~"Login succeeded"]
+type ExpectationFile struct {
+	Timeout      string            `yaml:"timeout"`
+	Expectations []ExpectationSpec `yaml:"expectations"`
+}
+
+// ExpectationSpec is one rule; exactly one of See, NotSee, Count or Order is set.
+// Each value is a --where expression.
+type ExpectationSpec struct {
+	Name   string   `yaml:"name"`
+	See    string   `yaml:"see"`
+	Within string   `yaml:"within"`
+	NotSee string   `yaml:"not_see"`
+	Count  string   `yaml:"count"`
+	Max    *int     `yaml:"max"`
+	Order  []string `yaml:"order"`
+}
+
+// ParseExpectations decodes a rules file, rejecting unknown keys.
+func ParseExpectations(data []byte) (*ExpectationFile, error) {
+	var file ExpectationFile
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&file); err != nil {
+		return nil, fmt.Errorf("invalid expectations file: %w", err)
+	}
+	if len(file.Expectations) == 0 {
+		return nil, errors.New("expectations file defines no expectations")
+	}
+	return &file, nil
+}
+
+type expectation struct {
+	name       string
+	kind       string
+	expression string
+	within     time.Duration
+	max        *int
+	matchers   []*WhereFilter
+
+	count  int
+	step   int // next order step to match
+	status string
+	reason string
+}
+
+// ExpectEvaluator checks log entries against a set of expectations. Time is
+// supplied by the caller (wall clock when tailing, entry timestamps when
+// replaying) so deadlines work the same in both modes. Not safe for
+// concurrent use.
+type ExpectEvaluator struct {
+	expectations []*expectation
+	start        time.Time
+}
+
+// NewExpectEvaluator compiles the expectations in file.
+func NewExpectEvaluator(file *ExpectationFile) (*ExpectEvaluator, error) {
+	e := &ExpectEvaluator{}
+	for i, spec := range file.Expectations {
+		exp, err := compileExpectation(spec)
+		if err != nil {
+			name := spec.Name
+			if name == "" {
+				name = fmt.Sprintf("#%d", i+1)
+			}
+			return nil, fmt.Errorf("expectation %s: %w", name, err)
+		}
+		if exp.name == "" {
+			exp.name = fmt.Sprintf("%s #%d", exp.kind, i+1)
+		}
+		e.expectations = append(e.expectations, exp)
+	}
+	return e, nil
+}
+
+func compileExpectation(spec ExpectationSpec) (*expectation, error) {
+	exp := &expectation{name: spec.Name}
+	var exprs []string
+	set := 0
+	if spec.See != "" {
+		set++
+		exp.kind, exprs = ExpectSee, []string{spec.See}
+	}
+	if spec.NotSee != "" {
+		set++
+		exp.kind, exprs = ExpectNotSee, []string{spec.NotSee}
+	}
+	if spec.Count != "" {
+		set++
+		exp.kind, exprs = ExpectCount, []string{spec.Count}
+	}
+	if len(spec.Order) > 0 {
+		set++
+		exp.kind, exprs = ExpectOrder, spec.Order
+	}
+	if set != 1 {
+		return nil, errors.New("set exactly one of see, not_see, count or order")
+	}
+
+	switch exp.kind {
+	case ExpectCount:
+		if spec.Max == nil || *spec.Max < 0 {
+			return nil, errors.New("count requires max >= 0")
+		}
+		exp.max = spec.Max
+	case ExpectOrder:
+		if len(exprs) < 2 {
+			return nil, errors.New("order requires at least two expressions")
+		}
+	}
+	if spec.Within != "" {
+		if exp.kind != ExpectSee {
+			return nil, errors.New("within is only valid with see")
+		}
+		d, err := time.ParseDuration(spec.Within)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid within %q", spec.Within)
+		}
+		exp.within = d
+	}
+	if spec.Max != nil && exp.kind != ExpectCount {
+		return nil, errors.New("max is only valid with count")
+	}
+
+	for _, expr := range exprs {
+		m, err := NewWhereFilter([]string{expr})
+		if err != nil {
+			return nil, err
+		}
+		exp.matchers = append(exp.matchers, m)
+	}
+	exp.expression = strings.Join(exprs, " -> ")
+	return exp, nil
+}
+
+// Start sets the reference time for within deadlines. Observe and Tick call
+// it with their first timestamp when it was not set explicitly.
+func (e *ExpectEvaluator) Start(t time.Time) {
+	if e.start.IsZero() {
+		e.start = t
+	}
+}
+
+// MaxWithin returns the longest see deadline, or 0 when none is set.
+func (e *ExpectEvaluator) MaxWithin() time.Duration {
+	var d time.Duration
+	for _, exp := range e.expectations {
+		if exp.within > d {
+			d = exp.within
+		}
+	}
+	return d
+}
+
+// Observe checks entry at time now and returns the expectations it failed.
+func (e *ExpectEvaluator) Observe(entry *domain.LogEntry, now time.Time) []domain.ExpectationFailed {
+	failures := e.Tick(now)
+	for _, exp := range e.expectations {
+		if exp.status != "" {
+			continue
+		}
+		switch exp.kind {
+		case ExpectSee:
+			if exp.matchers[0].Match(entry) {
+				exp.count++
+				exp.status = ExpectPassed
+			}
+		case ExpectNotSee:
+			if exp.matchers[0].Match(entry) {
+				exp.count++
+				failures = append(failures, e.fail(exp, now, entry, "matched a log entry that must not appear"))
+			}
+		case ExpectCount:
+			if exp.matchers[0].Match(entry) {
+				exp.count++
+				if exp.count > *exp.max {
+					failures = append(failures, e.fail(exp, now, entry, fmt.Sprintf("matched %d entries, more than max %d", exp.count, *exp.max)))
+				}
+			}
+		case ExpectOrder:
+			if exp.matchers[exp.step].Match(entry) {
+				exp.count++
+				exp.step++
+				if exp.step == len(exp.matchers) {
+					exp.status = ExpectPassed
+				}
+				continue
+			}
+			for j := exp.step + 1; j < len(exp.matchers); j++ {
+				if exp.matchers[j].Match(entry) {
+					failures = append(failures, e.fail(exp, now, entry, fmt.Sprintf("step %d seen before step %d", j+1, exp.step+1)))
+					break
+				}
+			}
+		}
+	}
+	return failures
+}
+
+// Tick fails see expectations whose within deadline passed before now.
+func (e *ExpectEvaluator) Tick(now time.Time) []domain.ExpectationFailed {
+	e.Start(now)
+	var failures []domain.ExpectationFailed
+	for _, exp := range e.expectations {
+		if exp.status == "" && exp.kind == ExpectSee && exp.within > 0 && now.Sub(e.start) > exp.within {
+			failures = append(failures, e.fail(exp, now, nil, fmt.Sprintf("not seen within %s", exp.within)))
+		}
+	}
+	return failures
+}
+
+// Finish decides the remaining expectations at the end of the run: see and
+// order fail if incomplete, not_see and count pass.
+func (e *ExpectEvaluator) Finish(now time.Time) []domain.ExpectationFailed {
+	var failures []domain.ExpectationFailed
+	for _, exp := range e.expectations {
+		if exp.status != "" {
+			continue
+		}
+		switch exp.kind {
+		case ExpectSee:
+			failures = append(failures, e.fail(exp, now, nil, "never seen"))
+		case ExpectOrder:
+			failures = append(failures, e.fail(exp, now, nil, fmt.Sprintf("step %d never seen", exp.step+1)))
+		default:
+			exp.status = ExpectPassed
+		}
+	}
+	return failures
+}
+
+// Done reports whether every expectation is decided, so further entries
+// cannot change the outcome.
+func (e *ExpectEvaluator) Done() bool {
+	for _, exp := range e.expectations {
+		if exp.status == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// Statuses returns the current outcome of each expectation in file order.
+// Undecided expectations are reported with an empty status.
+func (e *ExpectEvaluator) Statuses() []domain.ExpectationStatus {
+	statuses := make([]domain.ExpectationStatus, 0, len(e.expectations))
+	for _, exp := range e.expectations {
+		statuses = append(statuses, domain.ExpectationStatus{
+			Name:   exp.name,
+			Kind:   exp.kind,
+			Status: exp.status,
+			Count:  exp.count,
+			Reason: exp.reason,
+		})
+	}
+	return statuses
+}
+
+func (e *ExpectEvaluator) fail(exp *expectation, now time.Time, entry *domain.LogEntry, reason string) domain.ExpectationFailed {
+	exp.status = ExpectFailed
+	exp.reason = reason
+	failure := domain.ExpectationFailed{
+		Timestamp:  now.UTC().Format(time.RFC3339Nano),
+		Name:       exp.name,
+		Kind:       exp.kind,
+		Expression: exp.expression,
+		Reason:     reason,
+		Count:      exp.count,
+		Max:        exp.max,
+	}
+	if exp.within > 0 {
+		failure.Within = exp.within.String()
+	}
+	if entry != nil {
+		cp := *entry
+		failure.Entry = &cp
+		failure.Session = entry.Session
+	}
+	return failure
+}
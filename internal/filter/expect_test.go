@@ -0,0 +1,152 @@
+package filter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vburojevic/xcw/internal/domain"
+)
+
+func TestParseExpectations(t *testing.T) {
+	t.Run("parses rules", func(t *testing.T) {
+		file, err := ParseExpectations([]byte(`
+timeout: 30s
+expectations:
+  - name: login
+    see: message~"Login succeeded"
+    within: 20s
+  - not_see: level=Fault
+  - count: subsystem=com.example.net AND level>=error
+    max: 3
+  - order: ['message~"A"', 'message~"B"']
+`))
+		require.NoError(t, err)
+		assert.Equal(t, "30s", file.Timeout)
+		require.Len(t, file.Expectations, 4)
+		assert.Equal(t, 3, *file.Expectations[2].Max)
+		assert.Len(t, file.Expectations[3].Order, 2)
+	})
+
+	t.Run("rejects unknown keys", func(t *testing.T) {
+		_, err := ParseExpectations([]byte("expectations:\n  - must_see: foo\n"))
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects empty file", func(t *testing.T) {
+		_, err := ParseExpectations([]byte("timeout: 5s\n"))
+		assert.Error(t, err)
+	})
+}
+
+func TestNewExpectEvaluator_Validation(t *testing.T) {
+	one := 1
+	tests := []struct {
+		name string
+		spec ExpectationSpec
+	}{
+		{"no kind", ExpectationSpec{Name: "empty"}},
+		{"two kinds", ExpectationSpec{See: "message~a", NotSee: "message~b"}},
+		{"count without max", ExpectationSpec{Count: "level=Error"}},
+		{"max without count", ExpectationSpec{See: "message~a", Max: &one}},
+		{"within without see", ExpectationSpec{NotSee: "message~a", Within: "5s"}},
+		{"single order step", ExpectationSpec{Order: []string{"message~a"}}},
+		{"bad where", ExpectationSpec{See: "message~("}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewExpectEvaluator(&ExpectationFile{Expectations: []ExpectationSpec{tt.spec}})
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestExpectEvaluator(t *testing.T) {
+	base := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	at := func(sec int, level domain.LogLevel, msg string) (*domain.LogEntry, time.Time) {
+		ts := base.Add(time.Duration(sec) * time.Second)
+		return &domain.LogEntry{Timestamp: ts, Level: level, Subsystem: "com.example.net", Message: msg}, ts
+	}
+	newEvaluator := func(t *testing.T, specs ...ExpectationSpec) *ExpectEvaluator {
+		ev, err := NewExpectEvaluator(&ExpectationFile{Expectations: specs})
+		require.NoError(t, err)
+		return ev
+	}
+
+	t.Run("see passes when matched within deadline", func(t *testing.T) {
+		ev := newEvaluator(t, ExpectationSpec{Name: "login", See: `message~"Login succeeded"`, Within: "20s"})
+		ev.Start(base)
+		assert.Empty(t, ev.Observe(at(5, domain.LogLevelInfo, "Login succeeded")))
+		assert.True(t, ev.Done())
+		assert.Empty(t, ev.Finish(base))
+		assert.Equal(t, ExpectPassed, ev.Statuses()[0].Status)
+	})
+
+	t.Run("see fails once deadline passes", func(t *testing.T) {
+		ev := newEvaluator(t, ExpectationSpec{Name: "login", See: `message~"Login succeeded"`, Within: "20s"})
+		ev.Start(base)
+		assert.Empty(t, ev.Tick(base.Add(10*time.Second)))
+		failures := ev.Tick(base.Add(21 * time.Second))
+		require.Len(t, failures, 1)
+		assert.Equal(t, "login", failures[0].Name)
+		assert.Equal(t, "not seen within 20s", failures[0].Reason)
+		assert.Equal(t, "20s", failures[0].Within)
+		assert.Empty(t, ev.Observe(at(22, domain.LogLevelInfo, "Login succeeded")))
+		assert.Equal(t, ExpectFailed, ev.Statuses()[0].Status)
+	})
+
+	t.Run("not_see fails on first match with entry", func(t *testing.T) {
+		ev := newEvaluator(t, ExpectationSpec{NotSee: "level=Fault"})
+		assert.Empty(t, ev.Observe(at(0, domain.LogLevelError, "oops")))
+		assert.False(t, ev.Done())
+		failures := ev.Observe(at(1, domain.LogLevelFault, "boom"))
+		require.Len(t, failures, 1)
+		assert.Equal(t, "not_see #1", failures[0].Name)
+		require.NotNil(t, failures[0].Entry)
+		assert.Equal(t, "boom", failures[0].Entry.Message)
+	})
+
+	t.Run("not_see passes at finish", func(t *testing.T) {
+		ev := newEvaluator(t, ExpectationSpec{NotSee: "level=Fault"})
+		ev.Observe(at(0, domain.LogLevelInfo, "fine"))
+		assert.Empty(t, ev.Finish(base))
+		assert.Equal(t, ExpectPassed, ev.Statuses()[0].Status)
+	})
+
+	t.Run("count fails above max", func(t *testing.T) {
+		ev := newEvaluator(t, ExpectationSpec{Count: "subsystem=com.example.net AND level>=error", Max: intPtr(2)})
+		assert.Empty(t, ev.Observe(at(0, domain.LogLevelError, "e1")))
+		assert.Empty(t, ev.Observe(at(1, domain.LogLevelInfo, "info")))
+		assert.Empty(t, ev.Observe(at(2, domain.LogLevelFault, "e2")))
+		failures := ev.Observe(at(3, domain.LogLevelError, "e3"))
+		require.Len(t, failures, 1)
+		assert.Equal(t, 3, failures[0].Count)
+		assert.Equal(t, 2, *failures[0].Max)
+	})
+
+	t.Run("order passes in sequence", func(t *testing.T) {
+		ev := newEvaluator(t, ExpectationSpec{Order: []string{"message=A", "message=B"}})
+		assert.Empty(t, ev.Observe(at(0, domain.LogLevelInfo, "A")))
+		assert.Empty(t, ev.Observe(at(1, domain.LogLevelInfo, "B")))
+		assert.True(t, ev.Done())
+	})
+
+	t.Run("order fails when later step comes first", func(t *testing.T) {
+		ev := newEvaluator(t, ExpectationSpec{Order: []string{"message=A", "message=B"}})
+		failures := ev.Observe(at(0, domain.LogLevelInfo, "B"))
+		require.Len(t, failures, 1)
+		assert.Equal(t, "step 2 seen before step 1", failures[0].Reason)
+		assert.Equal(t, "message=A -> message=B", failures[0].Expression)
+	})
+
+	t.Run("incomplete order fails at finish", func(t *testing.T) {
+		ev := newEvaluator(t, ExpectationSpec{Order: []string{"message=A", "message=B"}})
+		ev.Observe(at(0, domain.LogLevelInfo, "A"))
+		failures := ev.Finish(base)
+		require.Len(t, failures, 1)
+		assert.Equal(t, "step 2 never seen", failures[0].Reason)
+	})
+}
+
+func intPtr(n int) *int { return &n }
@@ -0,0 +1,260 @@
+package filter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/vburojevic/xcw/internal/domain"
+)
+
+// Field extraction modes accepted by NewFieldExtractor.
+const (
+	ExtractLogfmt = "logfmt"
+	ExtractJSON   = "json"
+	ExtractRegex  = "regex"
+)
+
+// ExtractModes lists the supported extraction modes in help order.
+var ExtractModes = []string{ExtractLogfmt, ExtractJSON, ExtractRegex}
+
+// FieldExtractor parses key/value pairs out of log messages into entry.Fields
+// so they can be used as fields.<key> in where expressions and aggregations.
+type FieldExtractor struct {
+	modes    []string
+	patterns []*regexp.Regexp
+}
+
+// NewFieldExtractor creates an extractor for the given modes (logfmt, json, regex).
+// Regex patterns must use named groups; providing patterns implies regex mode.
+// Returns nil when no extraction is requested.
+func NewFieldExtractor(modes []string, patterns []string) (*FieldExtractor, error) {
+	if len(modes) == 0 && len(patterns) == 0 {
+		return nil, nil
+	}
+
+	e := &FieldExtractor{}
+	seen := make(map[string]bool)
+	add := func(mode string) {
+		if !seen[mode] {
+			seen[mode] = true
+			e.modes = append(e.modes, mode)
+		}
+	}
+	for _, raw := range modes {
+		for _, m := range strings.Split(raw, ",") {
+			mode := strings.ToLower(strings.TrimSpace(m))
+			switch mode {
+			case "":
+				continue
+			case ExtractLogfmt, ExtractJSON, ExtractRegex:
+				add(mode)
+			default:
+				return nil, fmt.Errorf("unknown extract mode %q (use %s)", m, strings.Join(ExtractModes, ", "))
+			}
+		}
+	}
+
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid extract regex '%s': %w", p, err)
+		}
+		named := false
+		for _, name := range re.SubexpNames() {
+			if name != "" {
+				named = true
+				break
+			}
+		}
+		if !named {
+			return nil, fmt.Errorf("extract regex '%s' has no named groups (use (?P<name>...))", p)
+		}
+		e.patterns = append(e.patterns, re)
+	}
+	if len(e.patterns) > 0 {
+		add(ExtractRegex)
+	} else if seen[ExtractRegex] {
+		return nil, fmt.Errorf("regex extraction requires at least one --extract-regex pattern")
+	}
+
+	if len(e.modes) == 0 {
+		return nil, nil
+	}
+	return e, nil
+}
+
+// Modes returns the enabled extraction modes.
+func (e *FieldExtractor) Modes() []string {
+	if e == nil {
+		return nil
+	}
+	return e.modes
+}
+
+// Extract parses the entry message and merges the result into entry.Fields.
+// Modes run in the order given; later modes overwrite keys from earlier ones.
+func (e *FieldExtractor) Extract(entry *domain.LogEntry) {
+	if e == nil || entry == nil || entry.Message == "" {
+		return
+	}
+	for _, mode := range e.modes {
+		switch mode {
+		case ExtractLogfmt:
+			mergeFields(entry, parseLogfmt(entry.Message))
+		case ExtractJSON:
+			mergeFields(entry, parseJSONFields(entry.Message))
+		case ExtractRegex:
+			for _, re := range e.patterns {
+				mergeFields(entry, regexFields(re, entry.Message))
+			}
+		}
+	}
+}
+
+func mergeFields(entry *domain.LogEntry, fields map[string]string) {
+	if len(fields) == 0 {
+		return
+	}
+	if entry.Fields == nil {
+		entry.Fields = make(map[string]string, len(fields))
+	}
+	for k, v := range fields {
+		entry.Fields[k] = v
+	}
+}
+
+// parseLogfmt extracts key=value pairs. Values may be double-quoted; tokens
+// without '=' are ignored so free text around the pairs is tolerated.
+func parseLogfmt(msg string) map[string]string {
+	var fields map[string]string
+	i := 0
+	for i < len(msg) {
+		for i < len(msg) && isWhereSpace(msg[i]) {
+			i++
+		}
+		start := i
+		for i < len(msg) && isLogfmtKeyChar(msg[i]) {
+			i++
+		}
+		key := msg[start:i]
+		if key == "" || i >= len(msg) || msg[i] != '=' {
+			// Not a pair: skip the rest of this token.
+			for i < len(msg) && !isWhereSpace(msg[i]) {
+				i++
+			}
+			continue
+		}
+		i++ // '='
+
+		var value string
+		if i < len(msg) && msg[i] == '"' {
+			end := i + 1
+			for end < len(msg) && msg[end] != '"' {
+				if msg[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(msg) {
+				value = msg[i+1:]
+				i = len(msg)
+			} else {
+				var err error
+				if value, err = unquoteLogfmt(msg[i : end+1]); err != nil {
+					value = msg[i+1 : end]
+				}
+				i = end + 1
+			}
+		} else {
+			vstart := i
+			for i < len(msg) && !isWhereSpace(msg[i]) {
+				i++
+			}
+			value = strings.TrimRight(msg[vstart:i], ",;")
+		}
+
+		if fields == nil {
+			fields = make(map[string]string)
+		}
+		fields[key] = value
+	}
+	return fields
+}
+
+func unquoteLogfmt(s string) (string, error) {
+	var v string
+	err := json.Unmarshal([]byte(s), &v)
+	return v, err
+}
+
+func isLogfmtKeyChar(b byte) bool {
+	return isWhereAlpha(b) || isWhereDigit(b) || b == '_' || b == '-' || b == '.'
+}
+
+// parseJSONFields decodes the first JSON object embedded in msg, flattening
+// nested objects into dotted keys.
+func parseJSONFields(msg string) map[string]string {
+	start := strings.IndexByte(msg, '{')
+	end := strings.LastIndexByte(msg, '}')
+	if start < 0 || end <= start {
+		return nil
+	}
+	dec := json.NewDecoder(strings.NewReader(msg[start : end+1]))
+	dec.UseNumber()
+	var obj map[string]interface{}
+	if err := dec.Decode(&obj); err != nil {
+		return nil
+	}
+	fields := make(map[string]string, len(obj))
+	flattenJSON("", obj, fields)
+	return fields
+}
+
+func flattenJSON(prefix string, obj map[string]interface{}, out map[string]string) {
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		switch v := obj[k].(type) {
+		case map[string]interface{}:
+			flattenJSON(key, v, out)
+		case string:
+			out[key] = v
+		case json.Number:
+			out[key] = v.String()
+		case nil:
+			out[key] = ""
+		default:
+			var buf bytes.Buffer
+			enc := json.NewEncoder(&buf)
+			enc.SetEscapeHTML(false)
+			if err := enc.Encode(v); err == nil {
+				out[key] = strings.TrimSpace(buf.String())
+			}
+		}
+	}
+}
+
+func regexFields(re *regexp.Regexp, msg string) map[string]string {
+	m := re.FindStringSubmatch(msg)
+	if m == nil {
+		return nil
+	}
+	fields := make(map[string]string)
+	for i, name := range re.SubexpNames() {
+		if name != "" && i < len(m) {
+			fields[name] = m[i]
+		}
+	}
+	return fields
+}
@@ -0,0 +1,88 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vburojevic/xcw/internal/domain"
+)
+
+func TestNewFieldExtractor(t *testing.T) {
+	t.Run("nil when nothing requested", func(t *testing.T) {
+		e, err := NewFieldExtractor(nil, nil)
+		require.NoError(t, err)
+		assert.Nil(t, e)
+	})
+
+	t.Run("comma separated and repeated modes", func(t *testing.T) {
+		e, err := NewFieldExtractor([]string{"logfmt,json", "LOGFMT"}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{ExtractLogfmt, ExtractJSON}, e.Modes())
+	})
+
+	t.Run("patterns imply regex mode", func(t *testing.T) {
+		e, err := NewFieldExtractor(nil, []string{`took (?P<ms>\d+)ms`})
+		require.NoError(t, err)
+		assert.Equal(t, []string{ExtractRegex}, e.Modes())
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := NewFieldExtractor([]string{"xml"}, nil)
+		assert.Error(t, err)
+		_, err = NewFieldExtractor([]string{"regex"}, nil)
+		assert.Error(t, err)
+		_, err = NewFieldExtractor(nil, []string{`took (\d+)ms`})
+		assert.Error(t, err)
+		_, err = NewFieldExtractor(nil, []string{`(?P<x>`})
+		assert.Error(t, err)
+	})
+}
+
+func TestFieldExtractorExtract(t *testing.T) {
+	t.Run("logfmt", func(t *testing.T) {
+		e, err := NewFieldExtractor([]string{"logfmt"}, nil)
+		require.NoError(t, err)
+		entry := &domain.LogEntry{Message: `Request done request_id=abc status=500 latency_ms=1234, msg="upstream \"x\" failed" bare = x`}
+		e.Extract(entry)
+		assert.Equal(t, map[string]string{
+			"request_id": "abc",
+			"status":     "500",
+			"latency_ms": "1234",
+			"msg":        `upstream "x" failed`,
+		}, entry.Fields)
+	})
+
+	t.Run("json in message", func(t *testing.T) {
+		e, err := NewFieldExtractor([]string{"json"}, nil)
+		require.NoError(t, err)
+		entry := &domain.LogEntry{Message: `Response: {"status":503,"ok":false,"user":{"id":"42"},"tags":["a"],"note":null}`}
+		e.Extract(entry)
+		assert.Equal(t, map[string]string{
+			"status":  "503",
+			"ok":      "false",
+			"user.id": "42",
+			"tags":    `["a"]`,
+			"note":    "",
+		}, entry.Fields)
+
+		plain := &domain.LogEntry{Message: "no json {here"}
+		e.Extract(plain)
+		assert.Nil(t, plain.Fields)
+	})
+
+	t.Run("regex merges with existing fields", func(t *testing.T) {
+		e, err := NewFieldExtractor([]string{"logfmt"}, []string{`took (?P<ms>\d+)ms`})
+		require.NoError(t, err)
+		entry := &domain.LogEntry{Message: "fetch took 87ms status=200"}
+		e.Extract(entry)
+		assert.Equal(t, map[string]string{"ms": "87", "status": "200"}, entry.Fields)
+	})
+
+	t.Run("nil extractor is a no-op", func(t *testing.T) {
+		var e *FieldExtractor
+		entry := &domain.LogEntry{Message: "status=500"}
+		e.Extract(entry)
+		assert.Nil(t, entry.Fields)
+	})
+}
@@ -1,7 +1,9 @@
 package filter
 
 import (
+	"fmt"
 	"regexp"
+	"strings"
 	"testing"
 	"time"
 
@@ -351,6 +353,24 @@ func TestWhereClause(t *testing.T) {
 		assert.Equal(t, "failed", wc.Value)
 	})
 
+	t.Run("earliest operator wins over list order", func(t *testing.T) {
+		for _, tc := range []struct {
+			clause, field, op, value string
+		}{
+			{"message$=x", "message", "$", "=x"},
+			{"message^a=b", "message", "^", "a=b"},
+			{"message=x~y", "message", "=", "x~y"},
+			{"message~a>=b", "message", "~", "a>=b"},
+			{"pid<=10", "pid", "<=", "10"},
+		} {
+			wc, err := ParseWhereClause(tc.clause)
+			require.NoError(t, err, tc.clause)
+			assert.Equal(t, tc.field, wc.Field, tc.clause)
+			assert.Equal(t, tc.op, wc.Operator, tc.clause)
+			assert.Equal(t, tc.value, wc.Value, tc.clause)
+		}
+	})
+
 	t.Run("invalid clause no operator", func(t *testing.T) {
 		_, err := ParseWhereClause("levelxerror")
 		assert.Error(t, err)
@@ -399,6 +419,16 @@ func TestWhereClauseMatch(t *testing.T) {
 		assert.False(t, wc.Match(entry2))
 	})
 
+	t.Run("trace and activity ids", func(t *testing.T) {
+		wc, _ := ParseWhereClause("trace_id=4bf92f3577b34da6a3ce929d0e0e4736")
+		assert.True(t, wc.Match(&domain.LogEntry{TraceID: "4bf92f3577b34da6a3ce929d0e0e4736"}))
+		assert.False(t, wc.Match(&domain.LogEntry{}))
+
+		wc, _ = ParseWhereClause("activity_id=1234")
+		assert.True(t, wc.Match(&domain.LogEntry{ActivityID: 1234}))
+		assert.False(t, wc.Match(&domain.LogEntry{ParentActivityID: 1234}))
+	})
+
 	t.Run("starts with", func(t *testing.T) {
 		wc, _ := ParseWhereClause("subsystem^com.example")
 		entry := &domain.LogEntry{Subsystem: "com.example.app"}
@@ -510,6 +540,21 @@ func TestWhereFilter(t *testing.T) {
 		assert.False(t, f.Match(entry3))
 	})
 
+	t.Run("extracted fields", func(t *testing.T) {
+		f, err := NewWhereFilter([]string{`fields.status>=500 AND fields.route^"/api"`})
+		require.NoError(t, err)
+
+		assert.True(t, f.Match(&domain.LogEntry{Fields: map[string]string{"status": "503", "route": "/api/users"}}))
+		assert.False(t, f.Match(&domain.LogEntry{Fields: map[string]string{"status": "404", "route": "/api/users"}}))
+		assert.False(t, f.Match(&domain.LogEntry{Fields: map[string]string{"status": "oops", "route": "/api/users"}}))
+		assert.False(t, f.Match(&domain.LogEntry{}), "missing field never compares")
+
+		eq, err := NewWhereFilter([]string{"fields.request_id=abc"})
+		require.NoError(t, err)
+		assert.True(t, eq.Match(&domain.LogEntry{Fields: map[string]string{"request_id": "abc"}}))
+		assert.False(t, eq.Match(&domain.LogEntry{Fields: map[string]string{"request_id": "ABC"}}))
+	})
+
 	t.Run("OR logic inside expression", func(t *testing.T) {
 		f, err := NewWhereFilter([]string{"level=Error OR level=Fault"})
 		require.NoError(t, err)
@@ -570,6 +615,99 @@ func TestWhereFilter(t *testing.T) {
 	})
 }
 
+func TestWhereExprTyped(t *testing.T) {
+	now := time.Now()
+	match := func(t *testing.T, expr string, entry *domain.LogEntry) bool {
+		t.Helper()
+		f, err := NewWhereFilter([]string{expr})
+		require.NoError(t, err, expr)
+		return f.Match(entry)
+	}
+
+	t.Run("strict comparisons", func(t *testing.T) {
+		assert.True(t, match(t, "pid>100", &domain.LogEntry{PID: 101}))
+		assert.False(t, match(t, "pid>100", &domain.LogEntry{PID: 100}))
+		assert.True(t, match(t, "tid<5", &domain.LogEntry{TID: 4}))
+		assert.False(t, match(t, "level>error", &domain.LogEntry{Level: domain.LogLevelError}))
+		assert.True(t, match(t, "level>error", &domain.LogEntry{Level: domain.LogLevelFault}))
+	})
+
+	t.Run("timestamp relative and absolute", func(t *testing.T) {
+		recent := &domain.LogEntry{Timestamp: now.Add(-10 * time.Second)}
+		old := &domain.LogEntry{Timestamp: now.Add(-2 * time.Minute)}
+		for _, expr := range []string{"timestamp > now-30s", "timestamp>now - 30s", `timestamp >= "now-30s"`} {
+			assert.True(t, match(t, expr, recent), expr)
+			assert.False(t, match(t, expr, old), expr)
+		}
+		assert.True(t, match(t, "timestamp < now-1d", &domain.LogEntry{Timestamp: now.Add(-48 * time.Hour)}))
+		assert.True(t, match(t, "timestamp>=2025-12-15T00:00:00Z", &domain.LogEntry{Timestamp: time.Date(2025, 12, 15, 1, 0, 0, 0, time.UTC)}))
+		assert.False(t, match(t, "timestamp>now-30s", &domain.LogEntry{}), "zero timestamp never compares")
+	})
+
+	t.Run("in lists", func(t *testing.T) {
+		assert.True(t, match(t, "level in (error, fault)", &domain.LogEntry{Level: domain.LogLevelFault}))
+		assert.False(t, match(t, "level IN (error,fault)", &domain.LogEntry{Level: domain.LogLevelInfo}))
+		assert.True(t, match(t, `process not in ("MyApp", Other)`, &domain.LogEntry{Process: "Third"}))
+		assert.False(t, match(t, `process not in ("MyApp", Other)`, &domain.LogEntry{Process: "MyApp"}))
+		assert.True(t, match(t, "pid in (1, 2, 3) AND NOT level=debug", &domain.LogEntry{PID: 2, Level: domain.LogLevelError}))
+	})
+
+	t.Run("len function", func(t *testing.T) {
+		long := &domain.LogEntry{Message: strings.Repeat("x", 2001)}
+		assert.True(t, match(t, "len(message) > 2000", long))
+		assert.False(t, match(t, "len(message) > 2000", &domain.LogEntry{Message: "short"}))
+		assert.True(t, match(t, "LEN(message)=3", &domain.LogEntry{Message: "héé"}), "counts characters, not bytes")
+		assert.True(t, match(t, "len(fields.id) in (3, 4)", &domain.LogEntry{Fields: map[string]string{"id": "abcd"}}))
+	})
+
+	t.Run("numeric extracted fields", func(t *testing.T) {
+		entry := &domain.LogEntry{Fields: map[string]string{"latency_ms": "1234.5", "status": "500"}}
+		assert.True(t, match(t, "fields.latency_ms > 1000.25", entry))
+		assert.False(t, match(t, "fields.latency_ms < 1000", entry))
+		assert.True(t, match(t, "fields.status=500.0", entry))
+		assert.True(t, match(t, "fields.status in (500, 503)", entry))
+	})
+
+	t.Run("commas outside lists stay in values", func(t *testing.T) {
+		assert.True(t, match(t, "message=a,b", &domain.LogEntry{Message: "a,b"}))
+	})
+}
+
+func TestWhereExprSyntaxErrors(t *testing.T) {
+	tests := []struct {
+		expr   string
+		column int
+		msg    string
+	}{
+		{"level=", 7, "expected value"},
+		{"level", 6, "expected operator"},
+		{"(level=error", 13, "expected ')'"},
+		{"level in error", 10, "expected '('"},
+		{"level in (error fault)", 17, "expected ',' or ')'"},
+		{"size(message) > 1", 1, "unknown function"},
+		{"timestamp > yesterday", 13, "invalid timestamp"},
+		{"len(message) > many", 16, "compares against a number"},
+		{`message="oops`, 9, "unterminated string"},
+		{"level=error & pid=1", 13, "use && for AND"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := NewWhereFilter([]string{tt.expr})
+			require.Error(t, err)
+			var syn *WhereSyntaxError
+			require.ErrorAs(t, err, &syn)
+			assert.Equal(t, tt.column, syn.Column, err.Error())
+			assert.Contains(t, err.Error(), tt.msg)
+			assert.Contains(t, err.Error(), fmt.Sprintf("at column %d", tt.column))
+		})
+	}
+
+	_, err := NewWhereFilter([]string{"level=error AND"})
+	var syn *WhereSyntaxError
+	require.ErrorAs(t, err, &syn)
+	assert.Equal(t, "level=error AND\n               ^", syn.Pointer())
+}
+
 func TestDedupeFilter(t *testing.T) {
 	t.Run("first occurrence always emits", func(t *testing.T) {
 		f := NewDedupeFilter(0)
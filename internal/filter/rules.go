@@ -0,0 +1,237 @@
+package filter
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+
+	"github.com/vburojevic/xcw/internal/domain"
+)
+
+// RuleFile is the YAML rules file read by xcw watch --rules.
+//
+//	rules:
+//	  - name: network errors
+//	    where: subsystem=com.example.net AND level>=error
+//	    threshold: 5 in 60s
+//	    cooldown: 5m
+//	    actions:
+//	      - command: ./notify.sh
+//	      - webhook: https://hooks.slack.com/services/...
+//	        format: slack
+//	      - marker: network degraded
+type RuleFile struct {
+	Rules []RuleSpec `yaml:"rules"`
+}
+
+// RuleSpec is one rule. Where is a --where expression; Threshold is "N" or
+// "N in DURATION" (default 1) and Cooldown defaults to watch's --cooldown.
+type RuleSpec struct {
+	Name      string           `yaml:"name"`
+	Where     string           `yaml:"where"`
+	Threshold string           `yaml:"threshold"`
+	Cooldown  string           `yaml:"cooldown"`
+	Actions   []RuleActionSpec `yaml:"actions"`
+}
+
+// RuleActionSpec is one action; exactly one of Command, Webhook or Marker is
+// set. Format, Template and Headers only apply to webhooks.
+type RuleActionSpec struct {
+	Command  string            `yaml:"command"`
+	Webhook  string            `yaml:"webhook"`
+	Format   string            `yaml:"format"`
+	Template string            `yaml:"template"`
+	Headers  map[string]string `yaml:"headers"`
+	Marker   string            `yaml:"marker"`
+}
+
+// ParseRules decodes a rules file, rejecting unknown keys.
+func ParseRules(data []byte) (*RuleFile, error) {
+	var file RuleFile
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&file); err != nil {
+		return nil, fmt.Errorf("invalid rules file: %w", err)
+	}
+	if len(file.Rules) == 0 {
+		return nil, errors.New("rules file defines no rules")
+	}
+	return &file, nil
+}
+
+// Rule is a compiled rule with its sliding window of matches.
+type Rule struct {
+	Name     string
+	Where    string
+	Count    int
+	Window   time.Duration
+	Cooldown time.Duration
+	Actions  []RuleActionSpec
+
+	matcher   *WhereFilter
+	hits      []time.Time
+	lastFired time.Time
+}
+
+// Threshold renders the rule's threshold as written in rules files.
+func (r *Rule) Threshold() string {
+	if r.Window == 0 {
+		return strconv.Itoa(r.Count)
+	}
+	return fmt.Sprintf("%d in %s", r.Count, r.Window)
+}
+
+// RuleFiring reports a rule whose threshold was reached by Entry.
+type RuleFiring struct {
+	Rule    *Rule
+	Matches int
+	Entry   *domain.LogEntry
+}
+
+// RuleEvaluator checks log entries against watch rules. Time is supplied by
+// the caller. Not safe for concurrent use.
+type RuleEvaluator struct {
+	rules []*Rule
+}
+
+// NewRuleEvaluator compiles the rules in file. defaultCooldown applies to
+// rules that do not set their own.
+func NewRuleEvaluator(file *RuleFile, defaultCooldown time.Duration) (*RuleEvaluator, error) {
+	e := &RuleEvaluator{}
+	seen := make(map[string]bool)
+	for i, spec := range file.Rules {
+		name := spec.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+		}
+		if spec.Name == "" {
+			return nil, fmt.Errorf("rule %s: name is required", name)
+		}
+		if seen[spec.Name] {
+			return nil, fmt.Errorf("rule %s: duplicate name", name)
+		}
+		seen[spec.Name] = true
+		rule, err := compileRule(spec, defaultCooldown)
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: %w", name, err)
+		}
+		e.rules = append(e.rules, rule)
+	}
+	return e, nil
+}
+
+func compileRule(spec RuleSpec, defaultCooldown time.Duration) (*Rule, error) {
+	if strings.TrimSpace(spec.Where) == "" {
+		return nil, errors.New("where is required")
+	}
+	matcher, err := NewWhereFilter([]string{spec.Where})
+	if err != nil {
+		return nil, err
+	}
+	count, window, err := parseThreshold(spec.Threshold)
+	if err != nil {
+		return nil, err
+	}
+	cooldown := defaultCooldown
+	if spec.Cooldown != "" {
+		cooldown, err = time.ParseDuration(spec.Cooldown)
+		if err != nil || cooldown < 0 {
+			return nil, fmt.Errorf("invalid cooldown %q", spec.Cooldown)
+		}
+	}
+	if len(spec.Actions) == 0 {
+		return nil, errors.New("at least one action is required")
+	}
+	for i, a := range spec.Actions {
+		set := 0
+		for _, v := range []string{a.Command, a.Webhook, a.Marker} {
+			if strings.TrimSpace(v) != "" {
+				set++
+			}
+		}
+		if set != 1 {
+			return nil, fmt.Errorf("action %d: set exactly one of command, webhook or marker", i+1)
+		}
+		if a.Webhook == "" && (a.Format != "" || a.Template != "" || len(a.Headers) > 0) {
+			return nil, fmt.Errorf("action %d: format, template and headers are only valid with webhook", i+1)
+		}
+	}
+	return &Rule{
+		Name:     spec.Name,
+		Where:    spec.Where,
+		Count:    count,
+		Window:   window,
+		Cooldown: cooldown,
+		Actions:  spec.Actions,
+		matcher:  matcher,
+	}, nil
+}
+
+var thresholdPattern = regexp.MustCompile(`^(\d+)(?:\s+(?:matches?\s+)?in\s+(\S+))?$`)
+
+// parseThreshold accepts "N", "N in 60s" or "N matches in 60s".
+func parseThreshold(s string) (int, time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 1, 0, nil
+	}
+	m := thresholdPattern.FindStringSubmatch(s)
+	if m == nil {
+		return 0, 0, fmt.Errorf("invalid threshold %q (use N or 'N in DURATION', e.g. '5 in 60s')", s)
+	}
+	count, err := strconv.Atoi(m[1])
+	if err != nil || count < 1 {
+		return 0, 0, fmt.Errorf("invalid threshold %q: count must be at least 1", s)
+	}
+	var window time.Duration
+	if m[2] != "" {
+		window, err = time.ParseDuration(m[2])
+		if err != nil || window <= 0 {
+			return 0, 0, fmt.Errorf("invalid threshold window %q", m[2])
+		}
+	}
+	return count, window, nil
+}
+
+// Rules returns the compiled rules in file order.
+func (e *RuleEvaluator) Rules() []*Rule {
+	return e.rules
+}
+
+// Observe records entry at time now and returns the rules that fire. A rule
+// fires when Count matches fall within Window and its cooldown has passed;
+// the matches are then consumed, so the next firing needs Count new ones.
+func (e *RuleEvaluator) Observe(entry *domain.LogEntry, now time.Time) []RuleFiring {
+	var firings []RuleFiring
+	for _, r := range e.rules {
+		if !r.matcher.Match(entry) {
+			continue
+		}
+		r.hits = append(r.hits, now)
+		if r.Window > 0 {
+			keep := 0
+			for keep < len(r.hits) && now.Sub(r.hits[keep]) > r.Window {
+				keep++
+			}
+			r.hits = r.hits[keep:]
+		}
+		if len(r.hits) < r.Count {
+			continue
+		}
+		if !r.lastFired.IsZero() && now.Sub(r.lastFired) < r.Cooldown {
+			// Keep only the most recent matches while cooling down
+			r.hits = r.hits[len(r.hits)-r.Count:]
+			continue
+		}
+		firings = append(firings, RuleFiring{Rule: r, Matches: len(r.hits), Entry: entry})
+		r.hits = nil
+		r.lastFired = now
+	}
+	return firings
+}
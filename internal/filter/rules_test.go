@@ -0,0 +1,124 @@
+package filter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vburojevic/xcw/internal/domain"
+)
+
+func TestParseRules(t *testing.T) {
+	t.Run("parses rules", func(t *testing.T) {
+		file, err := ParseRules([]byte(`
+rules:
+  - name: network errors
+    where: 'subsystem=com.example.net AND message~"timeout: 30s"'
+    threshold: 5 in 60s
+    cooldown: 5m
+    actions:
+      - command: ./notify.sh
+      - webhook: https://hooks.slack.com/services/T/B/X
+        format: slack
+        headers:
+          X-Env: ci
+      - marker: network degraded
+`))
+		require.NoError(t, err)
+		require.Len(t, file.Rules, 1)
+		rule := file.Rules[0]
+		assert.Equal(t, "5 in 60s", rule.Threshold)
+		require.Len(t, rule.Actions, 3)
+		assert.Equal(t, "slack", rule.Actions[1].Format)
+		assert.Equal(t, "ci", rule.Actions[1].Headers["X-Env"])
+		assert.Equal(t, "network degraded", rule.Actions[2].Marker)
+	})
+
+	t.Run("rejects unknown keys", func(t *testing.T) {
+		_, err := ParseRules([]byte("rules:\n  - name: a\n    on: level=Error\n"))
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects empty file", func(t *testing.T) {
+		_, err := ParseRules([]byte("rules: []\n"))
+		assert.Error(t, err)
+	})
+}
+
+func TestNewRuleEvaluator_Validation(t *testing.T) {
+	marker := []RuleActionSpec{{Marker: "m"}}
+	tests := []struct {
+		name string
+		spec RuleSpec
+	}{
+		{"no name", RuleSpec{Where: "level=Error", Actions: marker}},
+		{"no where", RuleSpec{Name: "a", Actions: marker}},
+		{"bad where", RuleSpec{Name: "a", Where: "message~(", Actions: marker}},
+		{"bad threshold", RuleSpec{Name: "a", Where: "level=Error", Threshold: "often", Actions: marker}},
+		{"zero threshold", RuleSpec{Name: "a", Where: "level=Error", Threshold: "0 in 1m", Actions: marker}},
+		{"bad window", RuleSpec{Name: "a", Where: "level=Error", Threshold: "5 in soon", Actions: marker}},
+		{"bad cooldown", RuleSpec{Name: "a", Where: "level=Error", Cooldown: "-1s", Actions: marker}},
+		{"no actions", RuleSpec{Name: "a", Where: "level=Error"}},
+		{"two kinds in one action", RuleSpec{Name: "a", Where: "level=Error", Actions: []RuleActionSpec{{Command: "x", Marker: "m"}}}},
+		{"format without webhook", RuleSpec{Name: "a", Where: "level=Error", Actions: []RuleActionSpec{{Command: "x", Format: "slack"}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewRuleEvaluator(&RuleFile{Rules: []RuleSpec{tt.spec}}, 0)
+			assert.Error(t, err)
+		})
+	}
+
+	t.Run("duplicate names", func(t *testing.T) {
+		spec := RuleSpec{Name: "a", Where: "level=Error", Actions: marker}
+		_, err := NewRuleEvaluator(&RuleFile{Rules: []RuleSpec{spec, spec}}, 0)
+		assert.ErrorContains(t, err, "duplicate")
+	})
+}
+
+func TestRuleEvaluator(t *testing.T) {
+	base := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	at := func(sec int, level domain.LogLevel) (*domain.LogEntry, time.Time) {
+		ts := base.Add(time.Duration(sec) * time.Second)
+		return &domain.LogEntry{Timestamp: ts, Level: level, Subsystem: "com.example.net", Message: "request failed"}, ts
+	}
+	newEvaluator := func(t *testing.T, spec RuleSpec) *RuleEvaluator {
+		spec.Name = "net"
+		spec.Actions = []RuleActionSpec{{Marker: "m"}}
+		ev, err := NewRuleEvaluator(&RuleFile{Rules: []RuleSpec{spec}}, 10*time.Second)
+		require.NoError(t, err)
+		return ev
+	}
+
+	t.Run("fires on every match by default, honouring the default cooldown", func(t *testing.T) {
+		ev := newEvaluator(t, RuleSpec{Where: "level>=error"})
+		assert.Equal(t, "1", ev.Rules()[0].Threshold())
+		assert.Empty(t, ev.Observe(at(0, domain.LogLevelInfo)))
+		require.Len(t, ev.Observe(at(1, domain.LogLevelError)), 1)
+		assert.Empty(t, ev.Observe(at(5, domain.LogLevelError)), "cooling down")
+		require.Len(t, ev.Observe(at(11, domain.LogLevelError)), 1)
+	})
+
+	t.Run("threshold counts matches within the window", func(t *testing.T) {
+		ev := newEvaluator(t, RuleSpec{Where: "level>=error", Threshold: "3 matches in 10s", Cooldown: "0s"})
+		assert.Equal(t, "3 in 10s", ev.Rules()[0].Threshold())
+		assert.Empty(t, ev.Observe(at(0, domain.LogLevelError)))
+		assert.Empty(t, ev.Observe(at(5, domain.LogLevelError)))
+		assert.Empty(t, ev.Observe(at(12, domain.LogLevelError)), "first match fell out of the window")
+		firings := ev.Observe(at(14, domain.LogLevelFault))
+		require.Len(t, firings, 1)
+		assert.Equal(t, 3, firings[0].Matches)
+		assert.Equal(t, domain.LogLevelFault, firings[0].Entry.Level)
+		assert.Empty(t, ev.Observe(at(15, domain.LogLevelError)), "matches are consumed by a firing")
+	})
+
+	t.Run("cooldown suppresses repeated firings", func(t *testing.T) {
+		ev := newEvaluator(t, RuleSpec{Where: "level>=error", Threshold: "2 in 1m", Cooldown: "30s"})
+		assert.Empty(t, ev.Observe(at(0, domain.LogLevelError)))
+		require.Len(t, ev.Observe(at(1, domain.LogLevelError)), 1)
+		assert.Empty(t, ev.Observe(at(2, domain.LogLevelError)))
+		assert.Empty(t, ev.Observe(at(3, domain.LogLevelError)))
+		require.Len(t, ev.Observe(at(31, domain.LogLevelError)), 1)
+	})
+}
@@ -0,0 +1,117 @@
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/vburojevic/xcw/internal/domain"
+)
+
+// Trace extraction modes accepted by NewTraceExtractor.
+const (
+	TraceTraceparent = "traceparent"
+	TraceRegex       = "regex"
+)
+
+// TraceModes lists the supported trace extraction modes in help order.
+var TraceModes = []string{TraceTraceparent, TraceRegex}
+
+// traceparentPattern matches a W3C traceparent: version-traceid-parentid-flags
+var traceparentPattern = regexp.MustCompile(`(?i)\b([0-9a-f]{2})-([0-9a-f]{32})-([0-9a-f]{16})-([0-9a-f]{2})\b`)
+
+// TraceExtractor finds distributed trace context in log messages and sets
+// entry.TraceID, entry.SpanID and entry.ParentSpanID.
+type TraceExtractor struct {
+	traceparent bool
+	patterns    []*regexp.Regexp
+}
+
+// NewTraceExtractor creates an extractor for the given modes (traceparent, regex).
+// Regex patterns must have a named group "trace" and may have "span" and
+// "parent"; providing patterns implies regex mode. Returns nil when no
+// extraction is requested.
+func NewTraceExtractor(modes []string, patterns []string) (*TraceExtractor, error) {
+	if len(modes) == 0 && len(patterns) == 0 {
+		return nil, nil
+	}
+
+	e := &TraceExtractor{}
+	regexMode := false
+	for _, raw := range modes {
+		for _, m := range strings.Split(raw, ",") {
+			switch mode := strings.ToLower(strings.TrimSpace(m)); mode {
+			case "":
+				continue
+			case TraceTraceparent:
+				e.traceparent = true
+			case TraceRegex:
+				regexMode = true
+			default:
+				return nil, fmt.Errorf("unknown trace extract mode %q (use %s)", m, strings.Join(TraceModes, ", "))
+			}
+		}
+	}
+
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trace regex '%s': %w", p, err)
+		}
+		if re.SubexpIndex("trace") < 0 {
+			return nil, fmt.Errorf("trace regex '%s' has no (?P<trace>...) group", p)
+		}
+		e.patterns = append(e.patterns, re)
+	}
+	if regexMode && len(e.patterns) == 0 {
+		return nil, fmt.Errorf("regex trace extraction requires at least one --extract-trace-regex pattern")
+	}
+
+	if !e.traceparent && len(e.patterns) == 0 {
+		return nil, nil
+	}
+	return e, nil
+}
+
+// Extract sets the entry's trace context from the first match in its message.
+// traceparent is tried before regex patterns; entries that already carry a
+// trace ID are left alone.
+func (e *TraceExtractor) Extract(entry *domain.LogEntry) {
+	if e == nil || entry == nil || entry.Message == "" || entry.TraceID != "" {
+		return
+	}
+	if e.traceparent {
+		if traceID, spanID, ok := parseTraceparent(entry.Message); ok {
+			entry.TraceID = traceID
+			entry.SpanID = spanID
+			return
+		}
+	}
+	for _, re := range e.patterns {
+		m := re.FindStringSubmatch(entry.Message)
+		if m == nil || m[re.SubexpIndex("trace")] == "" {
+			continue
+		}
+		entry.TraceID = m[re.SubexpIndex("trace")]
+		if i := re.SubexpIndex("span"); i >= 0 {
+			entry.SpanID = m[i]
+		}
+		if i := re.SubexpIndex("parent"); i >= 0 {
+			entry.ParentSpanID = m[i]
+		}
+		return
+	}
+}
+
+// parseTraceparent returns the trace ID and parent (span) ID of the first
+// valid traceparent in msg. All-zero IDs and version ff are invalid.
+func parseTraceparent(msg string) (traceID, spanID string, ok bool) {
+	for _, m := range traceparentPattern.FindAllStringSubmatch(msg, -1) {
+		version, trace, span := strings.ToLower(m[1]), strings.ToLower(m[2]), strings.ToLower(m[3])
+		if version == "ff" || strings.Trim(trace, "0") == "" || strings.Trim(span, "0") == "" {
+			continue
+		}
+		return trace, span, true
+	}
+	return "", "", false
+}
@@ -0,0 +1,50 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vburojevic/xcw/internal/domain"
+)
+
+func TestNewTraceExtractor(t *testing.T) {
+	e, err := NewTraceExtractor(nil, nil)
+	require.NoError(t, err)
+	assert.Nil(t, e)
+
+	_, err = NewTraceExtractor([]string{"b3"}, nil)
+	assert.Error(t, err)
+	_, err = NewTraceExtractor([]string{"regex"}, nil)
+	assert.Error(t, err)
+	_, err = NewTraceExtractor(nil, []string{`req=(?P<id>\w+)`})
+	assert.ErrorContains(t, err, "(?P<trace>...)")
+}
+
+func TestTraceExtractor_Extract(t *testing.T) {
+	e, err := NewTraceExtractor([]string{"traceparent"}, []string{`request_id=(?P<trace>[\w-]+)(?: span=(?P<span>\w+))?`})
+	require.NoError(t, err)
+
+	tests := []struct {
+		msg                 string
+		trace, span, parent string
+	}{
+		{"GET /users traceparent: 00-4BF92F3577B34DA6A3CE929D0E0E4736-00F067AA0BA902B7-01", "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7", ""},
+		{"bad 00-00000000000000000000000000000000-00f067aa0ba902b7-01", "", "", ""},
+		{"bad ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "", "", ""},
+		{"done request_id=abc-123 span=s1", "abc-123", "s1", ""},
+		{"done request_id=abc-123", "abc-123", "", ""},
+		{"nothing here", "", "", ""},
+	}
+	for _, tt := range tests {
+		entry := &domain.LogEntry{Message: tt.msg}
+		e.Extract(entry)
+		assert.Equal(t, tt.trace, entry.TraceID, tt.msg)
+		assert.Equal(t, tt.span, entry.SpanID, tt.msg)
+		assert.Equal(t, tt.parent, entry.ParentSpanID, tt.msg)
+	}
+
+	entry := &domain.LogEntry{Message: "request_id=new", TraceID: "kept"}
+	e.Extract(entry)
+	assert.Equal(t, "kept", entry.TraceID)
+}
@@ -5,6 +5,8 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/vburojevic/xcw/internal/domain"
 )
@@ -14,81 +16,146 @@ type WhereClause struct {
 	Field    string
 	Operator string
 	Value    string
+	Values   []string       // List operands for "in" / "not in"
+	Func     string         // Optional function applied to the field (currently only "len")
 	regex    *regexp.Regexp // Compiled regex for ~ and !~ operators
+
+	// Typed operands resolved by compile()
+	num          float64
+	numOK        bool
+	timeAbs      time.Time
+	timeOffset   time.Duration // Relative to now when timeRelative is set
+	timeRelative bool
+	inClauses    []*WhereClause
 }
 
 // ParseWhereClause parses a where clause like "level=error" or "message~timeout"
-// Supported operators: =, !=, ~, !~, >=, <=, ^, $
+// Supported operators: =, !=, ~, !~, >=, <=, >, <, ^, $
 func ParseWhereClause(clause string) (*WhereClause, error) {
-	// Try operators in order of length (longest first to avoid partial matches)
-	operators := []string{"!~", ">=", "<=", "!=", "~", "=", "^", "$"}
+	// The operator is the earliest one in the clause, so operator characters
+	// in the value (message$=x, message^a=b) stay part of the value. Longer
+	// operators win ties (>= over >).
+	operators := []string{"!~", ">=", "<=", "!=", "~", "=", ">", "<", "^", "$"}
 
-	for _, op := range operators {
-		idx := strings.Index(clause, op)
-		if idx > 0 {
-			field := strings.TrimSpace(clause[:idx])
-			value := strings.TrimSpace(clause[idx+len(op):])
+	op, idx := "", -1
+	for _, cand := range operators {
+		i := strings.Index(clause, cand)
+		if i <= 0 {
+			continue
+		}
+		if idx < 0 || i < idx || (i == idx && len(cand) > len(op)) {
+			op, idx = cand, i
+		}
+	}
 
-			if field == "" || value == "" {
-				return nil, fmt.Errorf("invalid where clause: %s", clause)
-			}
+	if idx < 0 {
+		return nil, fmt.Errorf("no valid operator found in where clause: %s (use =, !=, ~, !~, >=, <=, >, <, ^, $)", clause)
+	}
 
-			// Support quoted values so operators can appear in value.
-			if (strings.HasPrefix(value, "\"") && strings.HasSuffix(value, "\"")) ||
-				(strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'")) {
-				unq, err := strconv.Unquote(value)
-				if err != nil {
-					return nil, fmt.Errorf("invalid quoted value in where clause '%s': %w", clause, err)
-				}
-				value = unq
-			}
+	field := strings.TrimSpace(clause[:idx])
+	value := strings.TrimSpace(clause[idx+len(op):])
 
-			wc := &WhereClause{
-				Field:    field,
-				Operator: op,
-				Value:    value,
-			}
+	if field == "" || value == "" {
+		return nil, fmt.Errorf("invalid where clause: %s", clause)
+	}
 
-			// Pre-compile regex for ~ and !~ operators
-			if op == "~" || op == "!~" {
-				re, err := regexp.Compile(value)
-				if err != nil {
-					return nil, fmt.Errorf("invalid regex in where clause '%s': %w", clause, err)
-				}
-				wc.regex = re
-			}
+	// Support quoted values so operators can appear in value.
+	if (strings.HasPrefix(value, "\"") && strings.HasSuffix(value, "\"")) ||
+		(strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'")) {
+		unq, err := strconv.Unquote(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid quoted value in where clause '%s': %w", clause, err)
+		}
+		value = unq
+	}
 
-			return wc, nil
+	wc := &WhereClause{
+		Field:    field,
+		Operator: op,
+		Value:    value,
+	}
+	if err := wc.compile(); err != nil {
+		return nil, fmt.Errorf("invalid where clause '%s': %w", clause, err)
+	}
+	return wc, nil
+}
+
+// compile validates the clause and pre-resolves regex, numeric and time operands.
+func (wc *WhereClause) compile() error {
+	switch wc.Operator {
+	case "~", "!~":
+		re, err := regexp.Compile(wc.Value)
+		if err != nil {
+			return fmt.Errorf("invalid regex: %w", err)
 		}
+		wc.regex = re
+		return nil
+	case "^", "$":
+		return nil
+	case "in", "not in":
+		wc.inClauses = make([]*WhereClause, 0, len(wc.Values))
+		for _, v := range wc.Values {
+			sub := &WhereClause{Field: wc.Field, Operator: "=", Value: v, Func: wc.Func}
+			if err := sub.compile(); err != nil {
+				return err
+			}
+			wc.inClauses = append(wc.inClauses, sub)
+		}
+		return nil
 	}
 
-	return nil, fmt.Errorf("no valid operator found in where clause: %s (use =, !=, ~, !~, >=, <=, ^, $)", clause)
+	if n, err := strconv.ParseFloat(wc.Value, 64); err == nil {
+		wc.num, wc.numOK = n, true
+	}
+	switch {
+	case wc.Func != "":
+		if !wc.numOK {
+			return fmt.Errorf("%s(%s) compares against a number, got %q", wc.Func, wc.Field, wc.Value)
+		}
+	case strings.EqualFold(wc.Field, "timestamp"):
+		abs, offset, relative, err := parseWhereTime(wc.Value)
+		if err != nil {
+			return err
+		}
+		wc.timeAbs, wc.timeOffset, wc.timeRelative = abs, offset, relative
+	}
+	return nil
 }
 
 // Match checks if a log entry matches this where clause
 func (wc *WhereClause) Match(entry *domain.LogEntry) bool {
-	// Get the field value from the entry
-	fieldValue := wc.getFieldValue(entry)
-
 	switch wc.Operator {
+	case "in":
+		return wc.matchAny(entry)
+	case "not in":
+		return !wc.matchAny(entry)
 	case "=":
-		// Case-insensitive level equality
-		if strings.ToLower(wc.Field) == "level" {
-			return entry.Level == domain.ParseLogLevel(wc.Value)
-		}
-		// Numeric equality for pid/tid
-		if strings.ToLower(wc.Field) == "pid" || strings.ToLower(wc.Field) == "tid" {
-			return wc.compareNumeric(entry, true, true)
-		}
-		return fieldValue == wc.Value
+		eq, ok := wc.equals(entry)
+		return ok && eq
 	case "!=":
-		if strings.ToLower(wc.Field) == "level" {
-			return entry.Level != domain.ParseLogLevel(wc.Value)
+		eq, ok := wc.equals(entry)
+		return ok && !eq
+	case ">=", "<=", ">", "<":
+		c, ok := wc.order(entry)
+		if !ok {
+			return false
 		}
-		if strings.ToLower(wc.Field) == "pid" || strings.ToLower(wc.Field) == "tid" {
-			return wc.compareNumeric(entry, false, true)
+		switch wc.Operator {
+		case ">=":
+			return c >= 0
+		case "<=":
+			return c <= 0
+		case ">":
+			return c > 0
+		default:
+			return c < 0
 		}
-		return fieldValue != wc.Value
+	}
+
+	// Get the field value from the entry
+	fieldValue := wc.getFieldValue(entry)
+
+	switch wc.Operator {
 	case "~": // Contains (regex)
 		if wc.regex != nil {
 			return wc.regex.MatchString(fieldValue)
@@ -103,23 +170,135 @@ func (wc *WhereClause) Match(entry *domain.LogEntry) bool {
 		return strings.HasPrefix(fieldValue, wc.Value)
 	case "$": // Ends with
 		return strings.HasSuffix(fieldValue, wc.Value)
-	case ">=": // Greater or equal (for levels)
-		if strings.ToLower(wc.Field) == "level" {
-			return wc.compareLevel(entry, true)
+	}
+
+	return false
+}
+
+func (wc *WhereClause) matchAny(entry *domain.LogEntry) bool {
+	for _, sub := range wc.inClauses {
+		if sub.Match(entry) {
+			return true
 		}
-		return wc.compareNumeric(entry, true, false)
-	case "<=": // Less or equal (for levels)
-		if strings.ToLower(wc.Field) == "level" {
-			return wc.compareLevel(entry, false)
+	}
+	return false
+}
+
+// equals reports equality for = and !=. ok is false when a typed field cannot
+// be compared (e.g. pid=abc), in which case neither operator matches.
+func (wc *WhereClause) equals(entry *domain.LogEntry) (eq bool, ok bool) {
+	if wc.Func == "" && strings.EqualFold(wc.Field, "level") {
+		return entry.Level == domain.ParseLogLevel(wc.Value), true
+	}
+	if wc.typed() {
+		c, ok := wc.order(entry)
+		return c == 0, ok
+	}
+	if key, ok := wc.extractedKey(); ok && wc.numOK {
+		// Numeric equality when both sides parse (500 = 500.0), else string equality.
+		if n, err := strconv.ParseFloat(entry.Fields[key], 64); err == nil {
+			return n == wc.num, true
 		}
-		return wc.compareNumeric(entry, false, false)
 	}
+	return wc.getFieldValue(entry) == wc.Value, true
+}
 
+// typed reports whether the field only supports typed (not string) equality.
+func (wc *WhereClause) typed() bool {
+	if wc.Func != "" {
+		return true
+	}
+	switch strings.ToLower(wc.Field) {
+	case "pid", "tid", "timestamp":
+		return true
+	}
 	return false
 }
 
-// getFieldValue extracts the field value from a log entry
+// order compares the entry's field against the clause value, returning -1, 0
+// or 1. ok is false when the field has no ordering or a side is not comparable.
+func (wc *WhereClause) order(entry *domain.LogEntry) (int, bool) {
+	if wc.Func == "len" {
+		if !wc.numOK {
+			return 0, false
+		}
+		return compareFloat(float64(utf8.RuneCountInString(wc.rawFieldValue(entry))), wc.num), true
+	}
+
+	if key, ok := wc.extractedKey(); ok {
+		raw, present := entry.Fields[key]
+		if !present || !wc.numOK {
+			return 0, false
+		}
+		n, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return 0, false
+		}
+		return compareFloat(n, wc.num), true
+	}
+
+	switch strings.ToLower(wc.Field) {
+	case "level":
+		target := domain.ParseLogLevel(wc.Value).Priority()
+		return compareFloat(float64(entry.Level.Priority()), float64(target)), true
+	case "timestamp":
+		if entry.Timestamp.IsZero() {
+			return 0, false
+		}
+		target := wc.timeAbs
+		if wc.timeRelative {
+			target = time.Now().Add(wc.timeOffset)
+		}
+		return entry.Timestamp.Compare(target), true
+	case "pid":
+		if !wc.numOK {
+			return 0, false
+		}
+		return compareFloat(float64(entry.PID), wc.num), true
+	case "tid":
+		if !wc.numOK {
+			return 0, false
+		}
+		return compareFloat(float64(entry.TID), wc.num), true
+	}
+	return 0, false
+}
+
+func compareFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// fieldsPrefix selects an extracted key/value field, e.g. fields.status
+const fieldsPrefix = "fields."
+
+// extractedKey returns the key for a fields.<key> reference.
+func (wc *WhereClause) extractedKey() (string, bool) {
+	if len(wc.Field) <= len(fieldsPrefix) || !strings.EqualFold(wc.Field[:len(fieldsPrefix)], fieldsPrefix) {
+		return "", false
+	}
+	return wc.Field[len(fieldsPrefix):], true
+}
+
+// getFieldValue extracts the field value from a log entry, applying Func if set
 func (wc *WhereClause) getFieldValue(entry *domain.LogEntry) string {
+	raw := wc.rawFieldValue(entry)
+	if wc.Func == "len" {
+		return strconv.Itoa(utf8.RuneCountInString(raw))
+	}
+	return raw
+}
+
+func (wc *WhereClause) rawFieldValue(entry *domain.LogEntry) string {
+	if key, ok := wc.extractedKey(); ok {
+		return entry.Fields[key]
+	}
 	switch strings.ToLower(wc.Field) {
 	case "level":
 		return string(entry.Level)
@@ -135,57 +314,81 @@ func (wc *WhereClause) getFieldValue(entry *domain.LogEntry) string {
 		return strconv.Itoa(entry.PID)
 	case "tid":
 		return strconv.Itoa(entry.TID)
+	case "trace_id":
+		return entry.TraceID
+	case "span_id":
+		return entry.SpanID
+	case "parent_span_id":
+		return entry.ParentSpanID
+	case "activity_id":
+		return formatActivityID(entry.ActivityID)
+	case "parent_activity_id":
+		return formatActivityID(entry.ParentActivityID)
+	case "timestamp":
+		if entry.Timestamp.IsZero() {
+			return ""
+		}
+		return entry.Timestamp.Format(time.RFC3339Nano)
 	default:
 		return ""
 	}
 }
 
-// compareLevel handles >= and <= comparisons for log levels
-func (wc *WhereClause) compareLevel(entry *domain.LogEntry, greaterOrEqual bool) bool {
-	if strings.ToLower(wc.Field) != "level" {
-		return false
-	}
-
-	targetLevel := domain.ParseLogLevel(wc.Value)
-	entryPriority := entry.Level.Priority()
-	targetPriority := targetLevel.Priority()
-
-	if greaterOrEqual {
-		return entryPriority >= targetPriority
+func formatActivityID(id uint64) string {
+	if id == 0 {
+		return ""
 	}
-	return entryPriority <= targetPriority
+	return strconv.FormatUint(id, 10)
 }
 
-// compareNumeric handles integer comparisons for pid/tid.
-// If equality is true, greaterOrEqual indicates equality vs inequality for = / !=.
-func (wc *WhereClause) compareNumeric(entry *domain.LogEntry, greaterOrEqual bool, equality bool) bool {
-	field := strings.ToLower(wc.Field)
-	var entryVal int
-	switch field {
-	case "pid":
-		entryVal = entry.PID
-	case "tid":
-		entryVal = entry.TID
-	default:
-		return false
-	}
+// whereTimeLayouts are the absolute timestamp formats accepted by timestamp comparisons.
+var whereTimeLayouts = []string{
+	time.RFC3339Nano,
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
 
-	targetVal, err := strconv.Atoi(wc.Value)
-	if err != nil {
-		return false
+// parseWhereTime parses "now", "now-30s", "now+1h" (relative) or an absolute timestamp.
+func parseWhereTime(value string) (abs time.Time, offset time.Duration, relative bool, err error) {
+	v := strings.TrimSpace(value)
+	if len(v) >= 3 && strings.EqualFold(v[:3], "now") {
+		rest := strings.ReplaceAll(v[3:], " ", "")
+		if rest == "" {
+			return time.Time{}, 0, true, nil
+		}
+		sign := rest[0]
+		if sign != '-' && sign != '+' {
+			return time.Time{}, 0, false, fmt.Errorf("invalid relative time %q (use now-30s or now+1m)", value)
+		}
+		d, derr := parseWhereDuration(rest[1:])
+		if derr != nil {
+			return time.Time{}, 0, false, fmt.Errorf("invalid duration in %q: %w", value, derr)
+		}
+		if sign == '-' {
+			d = -d
+		}
+		return time.Time{}, d, true, nil
 	}
-
-	if equality {
-		if greaterOrEqual {
-			return entryVal == targetVal
+	for _, layout := range whereTimeLayouts {
+		if t, perr := time.Parse(layout, v); perr == nil {
+			return t, 0, false, nil
 		}
-		return entryVal != targetVal
 	}
+	return time.Time{}, 0, false, fmt.Errorf("invalid timestamp %q (use now, now-30s or RFC3339)", value)
+}
 
-	if greaterOrEqual {
-		return entryVal >= targetVal
+// parseWhereDuration extends time.ParseDuration with a "d" (days) unit.
+func parseWhereDuration(s string) (time.Duration, error) {
+	if strings.HasSuffix(s, "d") {
+		days, err := strconv.ParseFloat(strings.TrimSuffix(s, "d"), 64)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(days * float64(24*time.Hour)), nil
 	}
-	return entryVal <= targetVal
+	return time.ParseDuration(s)
 }
 
 // WhereFilter is a filter that applies multiple where clauses (AND logic)
@@ -2,7 +2,6 @@ package filter
 
 import (
 	"fmt"
-	"regexp"
 	"strconv"
 	"strings"
 
@@ -19,6 +18,7 @@ const (
 	whereTokRegex
 	whereTokLParen
 	whereTokRParen
+	whereTokComma
 
 	// Boolean operators (symbol forms only; keyword forms are parsed from identifiers).
 	whereTokAnd // &&
@@ -32,6 +32,8 @@ const (
 	whereTokNotMatch // !~
 	whereTokGte      // >=
 	whereTokLte      // <=
+	whereTokGt       // >
+	whereTokLt       // <
 	whereTokStarts   // ^
 	whereTokEnds     // $
 )
@@ -42,8 +44,43 @@ type whereToken struct {
 	pos int
 }
 
+// WhereSyntaxError reports a --where parse error with its 1-based column.
+type WhereSyntaxError struct {
+	Expr   string // The expression being parsed
+	Column int    // 1-based byte column of the offending token
+	Msg    string
+	Err    error // Underlying error, if any
+}
+
+func (e *WhereSyntaxError) Error() string {
+	msg := e.Msg
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return fmt.Sprintf("%s at column %d", msg, e.Column)
+}
+
+func (e *WhereSyntaxError) Unwrap() error { return e.Err }
+
+// Pointer renders the expression with a caret under the error column.
+func (e *WhereSyntaxError) Pointer() string {
+	col := e.Column
+	if col < 1 {
+		col = 1
+	}
+	if col > len(e.Expr)+1 {
+		col = len(e.Expr) + 1
+	}
+	return e.Expr + "\n" + strings.Repeat(" ", col-1) + "^"
+}
+
+func whereSyntaxErrorf(input string, pos int, format string, args ...interface{}) *WhereSyntaxError {
+	return &WhereSyntaxError{Expr: input, Column: pos + 1, Msg: fmt.Sprintf(format, args...)}
+}
+
 func lexWhereExpr(input string) ([]whereToken, error) {
 	var toks []whereToken
+	depth := 0 // paren depth; commas only separate list items inside parentheses
 	i := 0
 	for i < len(input) {
 		ch := input[i]
@@ -55,26 +92,36 @@ func lexWhereExpr(input string) ([]whereToken, error) {
 		switch ch {
 		case '(':
 			toks = append(toks, whereToken{typ: whereTokLParen, pos: i})
+			depth++
 			i++
 			continue
 		case ')':
 			toks = append(toks, whereToken{typ: whereTokRParen, pos: i})
+			if depth > 0 {
+				depth--
+			}
 			i++
 			continue
+		case ',':
+			if depth > 0 {
+				toks = append(toks, whereToken{typ: whereTokComma, pos: i})
+				i++
+				continue
+			}
 		case '&':
 			if i+1 < len(input) && input[i+1] == '&' {
 				toks = append(toks, whereToken{typ: whereTokAnd, pos: i})
 				i += 2
 				continue
 			}
-			return nil, fmt.Errorf("unexpected character '&' at %d (use && for AND)", i)
+			return nil, whereSyntaxErrorf(input, i, "unexpected character '&' (use && for AND)")
 		case '|':
 			if i+1 < len(input) && input[i+1] == '|' {
 				toks = append(toks, whereToken{typ: whereTokOr, pos: i})
 				i += 2
 				continue
 			}
-			return nil, fmt.Errorf("unexpected character '|' at %d (use || for OR)", i)
+			return nil, whereSyntaxErrorf(input, i, "unexpected character '|' (use || for OR)")
 		case '!':
 			// !=, !~, or unary !
 			if i+1 < len(input) && input[i+1] == '=' {
@@ -96,14 +143,18 @@ func lexWhereExpr(input string) ([]whereToken, error) {
 				i += 2
 				continue
 			}
-			return nil, fmt.Errorf("unexpected character '>' at %d (use >=)", i)
+			toks = append(toks, whereToken{typ: whereTokGt, pos: i})
+			i++
+			continue
 		case '<':
 			if i+1 < len(input) && input[i+1] == '=' {
 				toks = append(toks, whereToken{typ: whereTokLte, pos: i})
 				i += 2
 				continue
 			}
-			return nil, fmt.Errorf("unexpected character '<' at %d (use <=)", i)
+			toks = append(toks, whereToken{typ: whereTokLt, pos: i})
+			i++
+			continue
 		case '=':
 			toks = append(toks, whereToken{typ: whereTokEq, pos: i})
 			i++
@@ -136,27 +187,24 @@ func lexWhereExpr(input string) ([]whereToken, error) {
 			toks = append(toks, whereToken{typ: whereTokRegex, val: pat, pos: i})
 			i = next
 			continue
-		default:
-			if isWhereDigit(ch) {
-				start := i
-				for i < len(input) && isWhereDigit(input[i]) {
-					i++
-				}
-				toks = append(toks, whereToken{typ: whereTokNumber, val: input[start:i], pos: start})
-				continue
-			}
+		}
 
-			start := i
-			for i < len(input) && !isWhereDelimiter(input[i]) {
-				i++
-			}
-			val := strings.TrimSpace(input[start:i])
-			if val == "" {
-				return nil, fmt.Errorf("unexpected character %q at %d", input[start], start)
+		// Bare words: identifiers, numbers (1.5), durations (30s), timestamps (2025-01-02T10:00:00Z).
+		start := i
+		for i < len(input) && !isWhereDelimiter(input[i]) && !(depth > 0 && input[i] == ',') {
+			i++
+		}
+		val := strings.TrimSpace(input[start:i])
+		if val == "" {
+			return nil, whereSyntaxErrorf(input, start, "unexpected character %q", input[start])
+		}
+		typ := whereTokIdent
+		if isWhereDigit(val[0]) {
+			if _, err := strconv.ParseFloat(val, 64); err == nil {
+				typ = whereTokNumber
 			}
-			toks = append(toks, whereToken{typ: whereTokIdent, val: val, pos: start})
-			continue
 		}
+		toks = append(toks, whereToken{typ: typ, val: val, pos: start})
 	}
 	toks = append(toks, whereToken{typ: whereTokEOF, pos: len(input)})
 	return toks, nil
@@ -193,13 +241,13 @@ func lexWhereString(input string, start int) (string, int, error) {
 			lit := input[start : i+1]
 			unq, err := strconv.Unquote(lit)
 			if err != nil {
-				return "", 0, fmt.Errorf("invalid quoted string at %d: %w", start, err)
+				return "", 0, &WhereSyntaxError{Expr: input, Column: start + 1, Msg: "invalid quoted string", Err: err}
 			}
 			return unq, i + 1, nil
 		}
 		i++
 	}
-	return "", 0, fmt.Errorf("unterminated string starting at %d", start)
+	return "", 0, whereSyntaxErrorf(input, start, "unterminated string")
 }
 
 func lexWhereRegex(input string, start int) (string, int, error) {
@@ -223,7 +271,7 @@ func lexWhereRegex(input string, start int) (string, int, error) {
 			if flags != "" {
 				patWithFlags, err := applyRegexFlags(pat, flags)
 				if err != nil {
-					return "", 0, fmt.Errorf("invalid regex flags at %d: %w", start, err)
+					return "", 0, &WhereSyntaxError{Expr: input, Column: i + 2, Msg: "invalid regex flags", Err: err}
 				}
 				pat = patWithFlags
 			}
@@ -232,7 +280,7 @@ func lexWhereRegex(input string, start int) (string, int, error) {
 		}
 		i++
 	}
-	return "", 0, fmt.Errorf("unterminated regex literal starting at %d", start)
+	return "", 0, whereSyntaxErrorf(input, start, "unterminated regex literal")
 }
 
 func isWhereAlpha(b byte) bool {
@@ -332,12 +380,24 @@ func parseWhereExpr(input string) (whereExpr, error) {
 	if err != nil {
 		return nil, err
 	}
-	if p.peek().typ != whereTokEOF {
-		return nil, fmt.Errorf("unexpected token %q at %d", p.peek().val, p.peek().pos)
+	if t := p.peek(); t.typ != whereTokEOF {
+		return nil, p.errorf(t, "unexpected token %s", describeWhereToken(t))
 	}
 	return expr, nil
 }
 
+func (p *whereParser) errorf(t whereToken, format string, args ...interface{}) *WhereSyntaxError {
+	return whereSyntaxErrorf(p.input, t.pos, format, args...)
+}
+
+// peekAt returns the token n positions ahead without consuming it.
+func (p *whereParser) peekAt(n int) whereToken {
+	if p.pos+n >= len(p.toks) {
+		return whereToken{typ: whereTokEOF, pos: len(p.input)}
+	}
+	return p.toks[p.pos+n]
+}
+
 func (p *whereParser) peek() whereToken {
 	if p.pos >= len(p.toks) {
 		return whereToken{typ: whereTokEOF, pos: len(p.input)}
@@ -427,48 +487,145 @@ func (p *whereParser) parsePrimary() (whereExpr, error) {
 			return nil, err
 		}
 		if !p.matchToken(whereTokRParen) {
-			return nil, fmt.Errorf("expected ')' at %d", p.peek().pos)
+			return nil, p.errorf(p.peek(), "expected ')' but found %s", describeWhereToken(p.peek()))
 		}
 		return inner, nil
 	}
 	return p.parseComparison()
 }
 
+// whereFuncs lists the functions callable on a field, e.g. len(message).
+var whereFuncs = map[string]bool{"len": true}
+
 func (p *whereParser) parseComparison() (whereExpr, error) {
 	fieldTok := p.next()
 	if fieldTok.typ != whereTokIdent {
-		return nil, fmt.Errorf("expected field name at %d", fieldTok.pos)
+		return nil, p.errorf(fieldTok, "expected field name but found %s", describeWhereToken(fieldTok))
+	}
+	wc := &WhereClause{Field: fieldTok.val}
+
+	// Function call: len(message)
+	if p.peek().typ == whereTokLParen {
+		fn := strings.ToLower(fieldTok.val)
+		if !whereFuncs[fn] {
+			return nil, p.errorf(fieldTok, "unknown function %q (supported: len)", fieldTok.val)
+		}
+		p.next()
+		argTok := p.next()
+		if argTok.typ != whereTokIdent {
+			return nil, p.errorf(argTok, "expected field name inside %s()", fn)
+		}
+		if !p.matchToken(whereTokRParen) {
+			return nil, p.errorf(p.peek(), "expected ')' after %s(%s", fn, argTok.val)
+		}
+		wc.Func = fn
+		wc.Field = argTok.val
+	}
+
+	// List membership: field in (a, b) / field not in (a, b)
+	if p.matchIdentKeyword("in") {
+		wc.Operator = "in"
+	} else if t := p.peekAt(1); strings.EqualFold(p.peek().val, "not") && p.peek().typ == whereTokIdent &&
+		t.typ == whereTokIdent && strings.EqualFold(t.val, "in") {
+		p.next()
+		p.next()
+		wc.Operator = "not in"
+	}
+	if wc.Operator != "" {
+		values, err := p.parseValueList()
+		if err != nil {
+			return nil, err
+		}
+		wc.Values = values
+		if err := wc.compile(); err != nil {
+			return nil, p.errorf(fieldTok, "invalid %s list for %s: %v", wc.Operator, wc.Field, err)
+		}
+		return &whereClauseExpr{clause: wc}, nil
 	}
 
 	opTok := p.next()
 	op, ok := whereOpString(opTok.typ)
 	if !ok {
-		return nil, fmt.Errorf("expected operator after field %q at %d", fieldTok.val, opTok.pos)
+		return nil, p.errorf(opTok, "expected operator after field %q but found %s", fieldTok.val, describeWhereToken(opTok))
 	}
+	wc.Operator = op
 
 	valTok := p.next()
 	switch valTok.typ {
 	case whereTokIdent, whereTokString, whereTokNumber, whereTokRegex:
 		// ok
 	default:
-		return nil, fmt.Errorf("expected value after %q at %d", op, valTok.pos)
+		return nil, p.errorf(valTok, "expected value after %q but found %s", op, describeWhereToken(valTok))
+	}
+	wc.Value = valTok.val
+
+	// Allow spaces in relative times: now - 30s
+	if valTok.typ == whereTokIdent && strings.EqualFold(valTok.val, "now") {
+		if sign := p.peek(); sign.typ == whereTokIdent && (sign.val == "-" || sign.val == "+") {
+			p.next()
+			amount := p.next()
+			if amount.typ != whereTokIdent && amount.typ != whereTokNumber {
+				return nil, p.errorf(amount, "expected duration after now%s", sign.val)
+			}
+			wc.Value += sign.val + amount.val
+		} else if sign.typ == whereTokIdent && (strings.HasPrefix(sign.val, "-") || strings.HasPrefix(sign.val, "+")) {
+			p.next()
+			wc.Value += sign.val
+		}
 	}
 
-	wc := &WhereClause{
-		Field:    fieldTok.val,
-		Operator: op,
-		Value:    valTok.val,
+	if err := wc.compile(); err != nil {
+		return nil, &WhereSyntaxError{Expr: p.input, Column: valTok.pos + 1, Msg: fmt.Sprintf("invalid value for %s%s", fieldTok.val, op), Err: err}
 	}
+	return &whereClauseExpr{clause: wc}, nil
+}
 
-	if op == "~" || op == "!~" {
-		re, err := regexp.Compile(wc.Value)
-		if err != nil {
-			return nil, fmt.Errorf("invalid regex in where expression: %w", err)
+// parseValueList parses "(a, b, c)" after in / not in.
+func (p *whereParser) parseValueList() ([]string, error) {
+	if !p.matchToken(whereTokLParen) {
+		return nil, p.errorf(p.peek(), "expected '(' to start value list but found %s", describeWhereToken(p.peek()))
+	}
+	var values []string
+	for {
+		t := p.next()
+		switch t.typ {
+		case whereTokIdent, whereTokString, whereTokNumber:
+			values = append(values, t.val)
+		default:
+			return nil, p.errorf(t, "expected list value but found %s", describeWhereToken(t))
+		}
+		if p.matchToken(whereTokComma) {
+			continue
+		}
+		if p.matchToken(whereTokRParen) {
+			return values, nil
 		}
-		wc.regex = re
+		return nil, p.errorf(p.peek(), "expected ',' or ')' in value list but found %s", describeWhereToken(p.peek()))
 	}
+}
 
-	return &whereClauseExpr{clause: wc}, nil
+// describeWhereToken renders a token for error messages.
+func describeWhereToken(t whereToken) string {
+	switch t.typ {
+	case whereTokEOF:
+		return "end of expression"
+	case whereTokLParen:
+		return "'('"
+	case whereTokRParen:
+		return "')'"
+	case whereTokComma:
+		return "','"
+	case whereTokAnd:
+		return "'&&'"
+	case whereTokOr:
+		return "'||'"
+	case whereTokNot:
+		return "'!'"
+	}
+	if op, ok := whereOpString(t.typ); ok {
+		return fmt.Sprintf("operator %q", op)
+	}
+	return fmt.Sprintf("%q", t.val)
 }
 
 func whereOpString(typ whereTokenType) (string, bool) {
@@ -485,6 +642,10 @@ func whereOpString(typ whereTokenType) (string, bool) {
 		return ">=", true
 	case whereTokLte:
 		return "<=", true
+	case whereTokGt:
+		return ">", true
+	case whereTokLt:
+		return "<", true
 	case whereTokStarts:
 		return "^", true
 	case whereTokEnds:
@@ -2,6 +2,7 @@ package filter
 
 import (
 	"testing"
+	"time"
 
 	"github.com/vburojevic/xcw/internal/domain"
 )
@@ -12,6 +13,9 @@ func FuzzNewWhereFilter(f *testing.F) {
 	f.Add(`pid>=123 && process^MyApp`)
 	f.Add(`!message~"hello"`)
 	f.Add(`unterminated"`)
+	f.Add(`timestamp > now-30s && level in (error, fault)`)
+	f.Add(`len(message) > 2000 OR fields.latency_ms>=1.5`)
+	f.Add(`pid not in (1, 2) AND tid<3`)
 
 	entry := &domain.LogEntry{
 		Process:   "MyApp",
@@ -21,6 +25,8 @@ func FuzzNewWhereFilter(f *testing.F) {
 		PID:       123,
 		TID:       1,
 		Level:     domain.LogLevelError,
+		Timestamp: time.Date(2025, 12, 15, 0, 0, 0, 0, time.UTC),
+		Fields:    map[string]string{"latency_ms": "12.5"},
 	}
 
 	f.Fuzz(func(t *testing.T, expr string) {
@@ -0,0 +1,412 @@
+// Package index maintains an on-disk search index over recorded session files.
+//
+// Each recording gets a segment file: per-entry columns (time, level,
+// subsystem, process, tail ID, session), an inverted index from message
+// tokens to entry positions, and the entries themselves, read on demand.
+// A JSON manifest records, per recording, the size/mtime it was indexed at
+// and the time range, levels, subsystems, processes, sessions and tail IDs
+// it contains, so most recordings are ruled out without opening their
+// segment.
+package index
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/vburojevic/xcw/internal/domain"
+)
+
+// DirName is the index directory created inside the session directory
+const DirName = ".index"
+
+// FormatVersion is bumped whenever the segment layout changes; older
+// indexes are rebuilt automatically.
+const FormatVersion = 1
+
+const manifestName = "manifest.json"
+
+// Manifest describes every indexed recording
+type Manifest struct {
+	Version int                  `json:"version"`
+	Files   map[string]*FileMeta `json:"files"` // keyed by recording file name
+}
+
+// FileMeta summarizes one indexed recording
+type FileMeta struct {
+	Name       string                  `json:"name"`
+	Size       int64                   `json:"size"`
+	ModTime    time.Time               `json:"mod_time"`
+	Segment    string                  `json:"segment"`
+	Entries    int                     `json:"entries"`
+	First      time.Time               `json:"first,omitempty"`
+	Last       time.Time               `json:"last,omitempty"`
+	Levels     map[domain.LogLevel]int `json:"levels,omitempty"`
+	Subsystems []string                `json:"subsystems,omitempty"`
+	Processes  []string                `json:"processes,omitempty"`
+	Sessions   []int                   `json:"sessions,omitempty"`
+	TailIDs    []string                `json:"tail_ids,omitempty"`
+}
+
+// segmentHeader is the gob-encoded front of a segment file. Per-entry
+// columns allow filtering without decoding entries; the entries themselves
+// follow the header as JSON lines at Offsets.
+type segmentHeader struct {
+	Timestamps []int64  // UnixNano
+	Levels     []uint8  // domain.LogLevel priority
+	Subsystems []uint32 // index into Dict
+	Processes  []uint32 // index into Dict
+	TailIDs    []uint32 // index into Dict
+	Sessions   []int32
+	Dict       []string
+	Offsets    []int64             // len(entries)+1 byte offsets into the entry data
+	Postings   map[string][]uint32 // token -> ascending entry positions
+}
+
+// Source is a recording to index
+type Source struct {
+	Path    string
+	Size    int64
+	ModTime time.Time
+}
+
+// Loader reads the log entries of a recording
+type Loader func(path string) ([]domain.LogEntry, error)
+
+// UpdateStats reports what an Update changed
+type UpdateStats struct {
+	Files   int // recordings present
+	Indexed int // recordings (re)indexed
+	Skipped int // recordings already up to date
+	Removed int // index entries dropped for deleted recordings
+	Entries int // log entries across all indexed recordings
+	// Failed lists recordings that could not be read (e.g. compressed files
+	// still being written). Their manifest entry is left as it was, so the
+	// next Update retries them.
+	Failed []string
+}
+
+// Store is an index directory. Not safe for concurrent use.
+type Store struct {
+	dir      string
+	manifest *Manifest
+}
+
+// Open loads the index in dir, starting empty when it does not exist or was
+// written by another format version.
+func Open(dir string) (*Store, error) {
+	s := &Store{dir: dir, manifest: &Manifest{Version: FormatVersion, Files: map[string]*FileMeta{}}}
+	data, err := os.ReadFile(filepath.Join(dir, manifestName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, fmt.Errorf("failed to read index manifest: %w", err)
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("corrupt index manifest (rebuild with --rebuild): %w", err)
+	}
+	if m.Version == FormatVersion && m.Files != nil {
+		s.manifest = &m
+	}
+	return s, nil
+}
+
+// Dir returns the index directory
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Files returns the indexed recordings sorted by name
+func (s *Store) Files() []*FileMeta {
+	files := make([]*FileMeta, 0, len(s.manifest.Files))
+	for _, f := range s.manifest.Files {
+		files = append(files, f)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+	return files
+}
+
+// Update indexes new or changed sources and drops recordings that no longer
+// exist. With rebuild, every source is re-indexed. Recordings the loader
+// cannot read are reported in UpdateStats.Failed rather than failing the
+// update.
+func (s *Store) Update(sources []Source, load Loader, rebuild bool) (UpdateStats, error) {
+	stats := UpdateStats{Files: len(sources)}
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return stats, fmt.Errorf("failed to create index directory: %w", err)
+	}
+
+	present := make(map[string]bool, len(sources))
+	for _, src := range sources {
+		name := filepath.Base(src.Path)
+		present[name] = true
+		if meta := s.manifest.Files[name]; !rebuild && meta != nil && meta.Size == src.Size && meta.ModTime.Equal(src.ModTime) {
+			stats.Skipped++
+			continue
+		}
+		entries, err := load(src.Path)
+		if err != nil {
+			stats.Failed = append(stats.Failed, name)
+			continue
+		}
+		meta, err := s.writeSegment(name, entries)
+		if err != nil {
+			return stats, err
+		}
+		meta.Size, meta.ModTime = src.Size, src.ModTime
+		s.manifest.Files[name] = meta
+		stats.Indexed++
+	}
+
+	for name, meta := range s.manifest.Files {
+		if present[name] {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.dir, meta.Segment)); err != nil && !os.IsNotExist(err) {
+			return stats, fmt.Errorf("failed to remove stale segment: %w", err)
+		}
+		delete(s.manifest.Files, name)
+		stats.Removed++
+	}
+
+	for _, meta := range s.manifest.Files {
+		stats.Entries += meta.Entries
+	}
+	return stats, s.saveManifest()
+}
+
+func (s *Store) writeSegment(name string, entries []domain.LogEntry) (*FileMeta, error) {
+	meta := &FileMeta{
+		Name:    name,
+		Segment: name + ".seg",
+		Entries: len(entries),
+		Levels:  map[domain.LogLevel]int{},
+	}
+	n := len(entries)
+	hdr := segmentHeader{
+		Timestamps: make([]int64, n),
+		Levels:     make([]uint8, n),
+		Subsystems: make([]uint32, n),
+		Processes:  make([]uint32, n),
+		TailIDs:    make([]uint32, n),
+		Sessions:   make([]int32, n),
+		Dict:       []string{""},
+		Offsets:    make([]int64, 0, n+1),
+		Postings:   map[string][]uint32{},
+	}
+	dict := map[string]uint32{"": 0}
+	intern := func(v string) uint32 {
+		id, ok := dict[v]
+		if !ok {
+			id = uint32(len(hdr.Dict))
+			dict[v] = id
+			hdr.Dict = append(hdr.Dict, v)
+		}
+		return id
+	}
+
+	var data bytes.Buffer
+	subsystems, processes, tailIDs := set{}, set{}, set{}
+	sessions := map[int]bool{}
+	for i := range entries {
+		e := &entries[i]
+		if meta.First.IsZero() || e.Timestamp.Before(meta.First) {
+			meta.First = e.Timestamp
+		}
+		if e.Timestamp.After(meta.Last) {
+			meta.Last = e.Timestamp
+		}
+		meta.Levels[e.Level]++
+		subsystems.add(e.Subsystem)
+		processes.add(e.Process)
+		tailIDs.add(e.TailID)
+		if e.Session > 0 {
+			sessions[e.Session] = true
+		}
+
+		hdr.Timestamps[i] = e.Timestamp.UnixNano()
+		hdr.Levels[i] = uint8(e.Level.Priority())
+		hdr.Subsystems[i] = intern(e.Subsystem)
+		hdr.Processes[i] = intern(e.Process)
+		hdr.TailIDs[i] = intern(e.TailID)
+		hdr.Sessions[i] = int32(e.Session)
+		for _, tok := range indexTokens(e.Message) {
+			hdr.Postings[tok] = append(hdr.Postings[tok], uint32(i))
+		}
+
+		hdr.Offsets = append(hdr.Offsets, int64(data.Len()))
+		line, err := json.Marshal(e)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode entry: %w", err)
+		}
+		data.Write(line)
+		data.WriteByte('\n')
+	}
+	hdr.Offsets = append(hdr.Offsets, int64(data.Len()))
+	meta.Subsystems, meta.Processes, meta.TailIDs = subsystems.sorted(), processes.sorted(), tailIDs.sorted()
+	for n := range sessions {
+		meta.Sessions = append(meta.Sessions, n)
+	}
+	sort.Ints(meta.Sessions)
+
+	var head bytes.Buffer
+	if err := gob.NewEncoder(&head).Encode(&hdr); err != nil {
+		return nil, fmt.Errorf("failed to encode index segment: %w", err)
+	}
+	path := filepath.Join(s.dir, meta.Segment)
+	err := writeAtomic(path, func(f *os.File) error {
+		var size [8]byte
+		binary.LittleEndian.PutUint64(size[:], uint64(head.Len()))
+		for _, b := range [][]byte{size[:], head.Bytes(), data.Bytes()} {
+			if _, err := f.Write(b); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to write index segment: %w", err)
+	}
+	return meta, nil
+}
+
+// openSegment is a segment file with its header decoded. Entries are read
+// on demand.
+type openSegment struct {
+	meta *FileMeta
+	file *os.File
+	hdr  segmentHeader
+	base int64 // file offset of the entry data
+}
+
+func (s *Store) openSegment(meta *FileMeta) (*openSegment, error) {
+	f, err := os.Open(filepath.Join(s.dir, meta.Segment))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open index segment: %w", err)
+	}
+	seg := &openSegment{meta: meta, file: f}
+	var size [8]byte
+	if _, err := io.ReadFull(f, size[:]); err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("corrupt index segment %s: %w", meta.Segment, err)
+	}
+	n := int64(binary.LittleEndian.Uint64(size[:]))
+	if err := gob.NewDecoder(io.LimitReader(f, n)).Decode(&seg.hdr); err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("corrupt index segment %s: %w", meta.Segment, err)
+	}
+	if len(seg.hdr.Offsets) != len(seg.hdr.Timestamps)+1 {
+		_ = f.Close()
+		return nil, fmt.Errorf("corrupt index segment %s: offset table mismatch", meta.Segment)
+	}
+	seg.base = int64(len(size)) + n
+	return seg, nil
+}
+
+// entry decodes entry i
+func (seg *openSegment) entry(i uint32) (domain.LogEntry, error) {
+	start, end := seg.hdr.Offsets[i], seg.hdr.Offsets[i+1]
+	buf := make([]byte, end-start)
+	var e domain.LogEntry
+	if _, err := seg.file.ReadAt(buf, seg.base+start); err != nil {
+		return e, fmt.Errorf("corrupt index segment %s: %w", seg.meta.Segment, err)
+	}
+	if err := json.Unmarshal(buf, &e); err != nil {
+		return e, fmt.Errorf("corrupt index segment %s: %w", seg.meta.Segment, err)
+	}
+	return e, nil
+}
+
+func (seg *openSegment) Close() error {
+	return seg.file.Close()
+}
+
+func (s *Store) saveManifest() error {
+	data, err := json.MarshalIndent(s.manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeAtomic(filepath.Join(s.dir, manifestName), func(f *os.File) error {
+		_, err := f.Write(data)
+		return err
+	})
+}
+
+// writeAtomic writes path via a temp file and rename so readers never see a
+// partial file.
+func writeAtomic(path string, write func(*os.File) error) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	if err := write(tmp); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// tokenize lowercases s and splits it into runs of letters and digits
+func tokenize(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// indexTokens returns the distinct tokens of s worth indexing. Tokens without
+// a letter (IDs, counters, addresses) are left out to keep the vocabulary
+// small; queries for them fall back to scanning.
+func indexTokens(s string) []string {
+	toks := tokenize(s)
+	seen := make(map[string]bool, len(toks))
+	out := toks[:0]
+	for _, t := range toks {
+		if !seen[t] && hasLetter(t) {
+			seen[t] = true
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+func hasLetter(s string) bool {
+	for _, r := range s {
+		if unicode.IsLetter(r) {
+			return true
+		}
+	}
+	return false
+}
+
+type set map[string]bool
+
+func (s set) add(v string) {
+	if v != "" {
+		s[v] = true
+	}
+}
+
+func (s set) sorted() []string {
+	out := make([]string, 0, len(s))
+	for v := range s {
+		out = append(out, v)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,172 @@
+package index
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vburojevic/xcw/internal/domain"
+)
+
+var base = time.Date(2025, 12, 9, 10, 0, 0, 0, time.UTC)
+
+func entry(offset time.Duration, level domain.LogLevel, subsystem, msg string) domain.LogEntry {
+	return domain.LogEntry{
+		Timestamp: base.Add(offset),
+		Level:     level,
+		Process:   "MyApp",
+		Subsystem: subsystem,
+		Message:   msg,
+		Session:   1,
+		TailID:    "tail-1",
+	}
+}
+
+func testStore(t *testing.T, recordings map[string][]domain.LogEntry) *Store {
+	t.Helper()
+	store, err := Open(filepath.Join(t.TempDir(), DirName))
+	require.NoError(t, err)
+	var sources []Source
+	for name := range recordings {
+		sources = append(sources, Source{Path: "/sessions/" + name, Size: int64(len(recordings[name])), ModTime: base})
+	}
+	_, err = store.Update(sources, func(path string) ([]domain.LogEntry, error) {
+		return recordings[filepath.Base(path)], nil
+	}, false)
+	require.NoError(t, err)
+	return store
+}
+
+func messages(res *Result) []string {
+	var out []string
+	for _, h := range res.Hits {
+		out = append(out, h.Entry.Message)
+	}
+	return out
+}
+
+func TestSearch(t *testing.T) {
+	store := testStore(t, map[string][]domain.LogEntry{
+		"a.ndjson": {
+			entry(0, domain.LogLevelInfo, "com.example.auth", "Reading Keychain item"),
+			entry(time.Minute, domain.LogLevelFault, "com.example.auth", "keychain access denied (-25308)"),
+			entry(2*time.Minute, domain.LogLevelError, "com.example.net", "request timed out"),
+		},
+		"b.ndjson": {
+			entry(48*time.Hour, domain.LogLevelFault, "com.example.auth", "SecKeychainItemCopy failed"),
+			entry(49*time.Hour, domain.LogLevelFault, "com.example.db", "disk full"),
+		},
+	})
+
+	t.Run("finds substrings inside words, newest first", func(t *testing.T) {
+		res, err := store.Search(Query{Terms: []string{"keychain"}}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"SecKeychainItemCopy failed", "keychain access denied (-25308)", "Reading Keychain item"}, messages(res))
+		assert.Equal(t, 3, res.Total)
+	})
+
+	t.Run("combines level and time constraints", func(t *testing.T) {
+		res, err := store.Search(Query{Terms: []string{"keychain"}, MinLevel: domain.LogLevelFault, Since: base.Add(24 * time.Hour)}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"SecKeychainItemCopy failed"}, messages(res))
+		assert.Equal(t, 1, res.FilesSkipped, "a.ndjson ends before --since")
+	})
+
+	t.Run("verifies phrases and letterless terms", func(t *testing.T) {
+		res, err := store.Search(Query{Terms: []string{"access denied"}}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"keychain access denied (-25308)"}, messages(res))
+
+		res, err = store.Search(Query{Terms: []string{"-25308"}}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"keychain access denied (-25308)"}, messages(res))
+	})
+
+	t.Run("filters by subsystem and applies match", func(t *testing.T) {
+		res, err := store.Search(Query{Subsystems: []string{"com.example.auth"}}, func(e *domain.LogEntry) bool {
+			return e.Level == domain.LogLevelFault
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"SecKeychainItemCopy failed", "keychain access denied (-25308)"}, messages(res))
+	})
+
+	t.Run("limit keeps the newest hits", func(t *testing.T) {
+		res, err := store.Search(Query{Limit: 2}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"disk full", "SecKeychainItemCopy failed"}, messages(res))
+		assert.Equal(t, 5, res.Total)
+		assert.True(t, res.Truncated)
+	})
+
+	t.Run("reports the source file", func(t *testing.T) {
+		res, err := store.Search(Query{Terms: []string{"disk"}}, nil)
+		require.NoError(t, err)
+		require.Len(t, res.Hits, 1)
+		assert.Equal(t, "b.ndjson", res.Hits[0].File)
+		assert.Equal(t, "tail-1", res.Hits[0].Entry.TailID)
+	})
+}
+
+func TestUpdate(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), DirName)
+	store, err := Open(dir)
+	require.NoError(t, err)
+
+	loads := 0
+	load := func(path string) ([]domain.LogEntry, error) {
+		loads++
+		return []domain.LogEntry{entry(0, domain.LogLevelError, "", "from "+filepath.Base(path))}, nil
+	}
+	sources := []Source{
+		{Path: "/s/a.ndjson", Size: 10, ModTime: base},
+		{Path: "/s/b.ndjson", Size: 10, ModTime: base},
+	}
+
+	stats, err := store.Update(sources, load, false)
+	require.NoError(t, err)
+	assert.Equal(t, UpdateStats{Files: 2, Indexed: 2, Entries: 2}, stats)
+
+	// Reopen: unchanged files are skipped, changed ones re-indexed, deleted ones dropped
+	store, err = Open(dir)
+	require.NoError(t, err)
+	stats, err = store.Update([]Source{{Path: "/s/a.ndjson", Size: 20, ModTime: base}}, load, false)
+	require.NoError(t, err)
+	assert.Equal(t, UpdateStats{Files: 1, Indexed: 1, Removed: 1, Entries: 1}, stats)
+	assert.Equal(t, 3, loads)
+	_, err = os.Stat(filepath.Join(dir, "b.ndjson.seg"))
+	assert.True(t, os.IsNotExist(err))
+
+	stats, err = store.Update([]Source{{Path: "/s/a.ndjson", Size: 20, ModTime: base}}, load, false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Skipped)
+	assert.Equal(t, 3, loads)
+
+	t.Run("reports unreadable recordings and retries them", func(t *testing.T) {
+		failing := func(path string) ([]domain.LogEntry, error) {
+			if filepath.Base(path) == "a.ndjson" {
+				return nil, errors.New("unexpected EOF")
+			}
+			return load(path)
+		}
+		grown := []Source{
+			{Path: "/s/a.ndjson", Size: 30, ModTime: base},
+			{Path: "/s/c.ndjson", Size: 1, ModTime: base},
+		}
+		stats, err := store.Update(grown, failing, false)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a.ndjson"}, stats.Failed)
+		assert.Equal(t, 1, stats.Indexed)
+		// The last good index of a.ndjson stays searchable
+		assert.Equal(t, int64(20), store.manifest.Files["a.ndjson"].Size)
+
+		stats, err = store.Update(grown, load, false)
+		require.NoError(t, err)
+		assert.Empty(t, stats.Failed)
+		assert.Equal(t, UpdateStats{Files: 2, Indexed: 1, Skipped: 1, Entries: 2}, stats)
+	})
+}
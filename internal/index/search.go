@@ -0,0 +1,339 @@
+package index
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/vburojevic/xcw/internal/domain"
+)
+
+// Query selects indexed log entries. Zero fields do not constrain.
+type Query struct {
+	Terms      []string // case-insensitive substrings that must all appear in the message
+	Since      time.Time
+	Until      time.Time
+	MinLevel   domain.LogLevel
+	MaxLevel   domain.LogLevel
+	Subsystems []string
+	Processes  []string
+	Session    int
+	TailID     string
+	Limit      int // keep only the newest Limit hits
+}
+
+// Hit is a matching entry and the recording it came from
+type Hit struct {
+	File  string
+	Entry domain.LogEntry
+}
+
+// Result holds the hits of a search, newest first
+type Result struct {
+	Hits          []Hit
+	Total         int // matches before Limit
+	FilesSearched int // segments opened
+	FilesSkipped  int // recordings ruled out by the manifest
+	Truncated     bool
+}
+
+// Search runs q over the index. match, when set, is applied to entries that
+// pass q (e.g. a --where filter).
+//
+// Filtering on time, level, subsystem, process, session and tail ID uses
+// the segment columns. Entries are decoded only when a message term needs
+// verifying, match is set, or the entry is returned.
+func (s *Store) Search(q Query, match func(*domain.LogEntry) bool) (*Result, error) {
+	res := &Result{}
+	terms := make([]string, 0, len(q.Terms))
+	exact := true // postings answer every term without reading messages
+	for _, t := range q.Terms {
+		if t = strings.ToLower(strings.TrimSpace(t)); t != "" {
+			terms = append(terms, t)
+			if toks := tokenize(t); len(toks) != 1 || toks[0] != t || !hasLetter(t) {
+				exact = false
+			}
+		}
+	}
+
+	type candidate struct {
+		seg *openSegment
+		i   uint32
+		ts  int64
+	}
+	var cands []candidate
+	var segs []*openSegment
+	defer func() {
+		for _, seg := range segs {
+			_ = seg.Close()
+		}
+	}()
+
+	for _, meta := range s.Files() {
+		if !q.mayMatch(meta) {
+			res.FilesSkipped++
+			continue
+		}
+		seg, err := s.openSegment(meta)
+		if err != nil {
+			return nil, err
+		}
+		segs = append(segs, seg)
+		res.FilesSearched++
+
+		f := q.columnFilter(seg)
+		ids, all := seg.candidates(terms)
+		if all {
+			for i := range seg.hdr.Timestamps {
+				if f(uint32(i)) {
+					cands = append(cands, candidate{seg, uint32(i), seg.hdr.Timestamps[i]})
+				}
+			}
+			continue
+		}
+		for _, i := range ids {
+			if f(i) {
+				cands = append(cands, candidate{seg, i, seg.hdr.Timestamps[i]})
+			}
+		}
+	}
+
+	sort.SliceStable(cands, func(i, j int) bool { return cands[i].ts > cands[j].ts })
+
+	verify := !exact || match != nil
+	if !verify {
+		res.Total = len(cands)
+		if q.Limit > 0 && len(cands) > q.Limit {
+			cands = cands[:q.Limit]
+		}
+	}
+	for _, c := range cands {
+		e, err := c.seg.entry(c.i)
+		if err != nil {
+			return nil, err
+		}
+		if verify && (!matchTerms(e.Message, terms) || (match != nil && !match(&e))) {
+			continue
+		}
+		if verify {
+			res.Total++
+			if q.Limit > 0 && len(res.Hits) >= q.Limit {
+				continue
+			}
+		}
+		res.Hits = append(res.Hits, Hit{File: c.seg.meta.Name, Entry: e})
+	}
+	res.Truncated = res.Total > len(res.Hits)
+	return res, nil
+}
+
+// mayMatch rules out recordings using only the manifest
+func (q *Query) mayMatch(m *FileMeta) bool {
+	if m.Entries == 0 {
+		return false
+	}
+	if !q.Since.IsZero() && m.Last.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && m.First.After(q.Until) {
+		return false
+	}
+	if q.MinLevel != "" || q.MaxLevel != "" {
+		ok := false
+		for level, n := range m.Levels {
+			if n > 0 && q.levelOK(level) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	if len(q.Subsystems) > 0 && !anyIn(q.Subsystems, m.Subsystems) {
+		return false
+	}
+	if len(q.Processes) > 0 && !anyIn(q.Processes, m.Processes) {
+		return false
+	}
+	if q.TailID != "" && !anyIn([]string{q.TailID}, m.TailIDs) {
+		return false
+	}
+	if q.Session > 0 {
+		i := sort.SearchInts(m.Sessions, q.Session)
+		if i == len(m.Sessions) || m.Sessions[i] != q.Session {
+			return false
+		}
+	}
+	return true
+}
+
+func (q *Query) levelOK(level domain.LogLevel) bool {
+	if q.MinLevel != "" && level.Priority() < q.MinLevel.Priority() {
+		return false
+	}
+	if q.MaxLevel != "" && level.Priority() > q.MaxLevel.Priority() {
+		return false
+	}
+	return true
+}
+
+// columnFilter returns a predicate over entry positions of seg that applies
+// every constraint except the message terms.
+func (q *Query) columnFilter(seg *openSegment) func(uint32) bool {
+	h := &seg.hdr
+	var since, until int64
+	if !q.Since.IsZero() {
+		since = q.Since.UnixNano()
+	}
+	if !q.Until.IsZero() {
+		until = q.Until.UnixNano()
+	}
+	minLevel, maxLevel := uint8(0), uint8(255)
+	if q.MinLevel != "" {
+		minLevel = uint8(q.MinLevel.Priority())
+	}
+	if q.MaxLevel != "" {
+		maxLevel = uint8(q.MaxLevel.Priority())
+	}
+	subsystems := dictIDs(h.Dict, q.Subsystems)
+	processes := dictIDs(h.Dict, q.Processes)
+	var tailIDs map[uint32]bool
+	if q.TailID != "" {
+		tailIDs = dictIDs(h.Dict, []string{q.TailID})
+	}
+
+	return func(i uint32) bool {
+		ts := h.Timestamps[i]
+		if (since != 0 && ts < since) || (until != 0 && ts > until) {
+			return false
+		}
+		if l := h.Levels[i]; l < minLevel || l > maxLevel {
+			return false
+		}
+		if subsystems != nil && !subsystems[h.Subsystems[i]] {
+			return false
+		}
+		if processes != nil && !processes[h.Processes[i]] {
+			return false
+		}
+		if tailIDs != nil && !tailIDs[h.TailIDs[i]] {
+			return false
+		}
+		return q.Session <= 0 || int(h.Sessions[i]) == q.Session
+	}
+}
+
+// dictIDs maps values to their dictionary IDs; nil means unconstrained
+func dictIDs(dict, values []string) map[uint32]bool {
+	if len(values) == 0 {
+		return nil
+	}
+	want := make(map[string]bool, len(values))
+	for _, v := range values {
+		want[v] = true
+	}
+	ids := map[uint32]bool{}
+	for id, v := range dict {
+		if want[v] {
+			ids[uint32(id)] = true
+		}
+	}
+	return ids
+}
+
+func matchTerms(message string, terms []string) bool {
+	if len(terms) == 0 {
+		return true
+	}
+	msg := strings.ToLower(message)
+	for _, t := range terms {
+		if !strings.Contains(msg, t) {
+			return false
+		}
+	}
+	return true
+}
+
+// candidates narrows entries using the postings of every indexed token in
+// terms. A query token matches any indexed token containing it, so
+// substrings inside words are found. all is true when no term token is
+// indexed and every entry must be considered.
+func (seg *openSegment) candidates(terms []string) (ids []uint32, all bool) {
+	var result []uint32
+	narrowed := false
+	for _, term := range terms {
+		for _, qt := range tokenize(term) {
+			if !hasLetter(qt) {
+				continue
+			}
+			var union []uint32
+			for tok, postings := range seg.hdr.Postings {
+				if strings.Contains(tok, qt) {
+					union = mergeUnion(union, postings)
+				}
+			}
+			if !narrowed {
+				result, narrowed = union, true
+			} else {
+				result = intersect(result, union)
+			}
+			if len(result) == 0 {
+				return nil, false
+			}
+		}
+	}
+	if !narrowed {
+		return nil, true
+	}
+	return result, false
+}
+
+func mergeUnion(a, b []uint32) []uint32 {
+	out := make([]uint32, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] < b[j]:
+			out = append(out, a[i])
+			i++
+		case a[i] > b[j]:
+			out = append(out, b[j])
+			j++
+		default:
+			out = append(out, a[i])
+			i++
+			j++
+		}
+	}
+	out = append(out, a[i:]...)
+	return append(out, b[j:]...)
+}
+
+func intersect(a, b []uint32) []uint32 {
+	var out []uint32
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] < b[j]:
+			i++
+		case a[i] > b[j]:
+			j++
+		default:
+			out = append(out, a[i])
+			i++
+			j++
+		}
+	}
+	return out
+}
+
+func anyIn(want, have []string) bool {
+	for _, w := range want {
+		i := sort.SearchStrings(have, w)
+		if i < len(have) && have[i] == w {
+			return true
+		}
+	}
+	return false
+}
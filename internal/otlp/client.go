@@ -0,0 +1,147 @@
+package otlp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// httpClient posts OTLP/JSON to <endpoint>/v1/logs
+type httpClient struct {
+	url     string
+	headers map[string]string
+	http    *http.Client
+}
+
+func newHTTPClient(cfg Config) (*httpClient, error) {
+	u, err := url.Parse(cfg.Endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid OTLP endpoint %q: expected http(s)://host:port", cfg.Endpoint)
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = "/v1/logs"
+	}
+	return &httpClient{url: u.String(), headers: cfg.Headers, http: &http.Client{}}, nil
+}
+
+// jsonOptions follows the OTLP/JSON encoding: enums as numbers
+var jsonOptions = protojson.MarshalOptions{UseEnumNumbers: true}
+
+func (c *httpClient) export(ctx context.Context, req *collogspb.ExportLogsServiceRequest) error {
+	body, err := jsonOptions.Marshal(req)
+	if err != nil {
+		return err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	for k, v := range c.headers {
+		httpReq.Header.Set(k, v)
+	}
+	resp, err := c.http.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode/100 != 2 {
+		return &StatusError{Code: resp.StatusCode, Body: strings.TrimSpace(string(msg))}
+	}
+	return nil
+}
+
+func (c *httpClient) close() error {
+	c.http.CloseIdleConnections()
+	return nil
+}
+
+// grpcClient calls the collector's LogsService
+type grpcClient struct {
+	conn    *grpc.ClientConn
+	service collogspb.LogsServiceClient
+	md      metadata.MD
+}
+
+func newGRPCClient(cfg Config) (*grpcClient, error) {
+	target := cfg.Endpoint
+	creds := insecure.NewCredentials()
+	switch {
+	case strings.HasPrefix(target, "https://"):
+		target = strings.TrimPrefix(target, "https://")
+		creds = credentials.NewTLS(nil)
+	case strings.HasPrefix(target, "http://"):
+		target = strings.TrimPrefix(target, "http://")
+	}
+	target = strings.TrimSuffix(target, "/")
+	if target == "" || strings.Contains(target, "/") {
+		return nil, fmt.Errorf("invalid OTLP gRPC endpoint %q: expected [http(s)://]host:port", cfg.Endpoint)
+	}
+	conn, err := grpc.NewClient(target, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, fmt.Errorf("invalid OTLP gRPC endpoint %q: %w", cfg.Endpoint, err)
+	}
+	md := metadata.MD{}
+	for k, v := range cfg.Headers {
+		md.Set(k, v)
+	}
+	return &grpcClient{conn: conn, service: collogspb.NewLogsServiceClient(conn), md: md}, nil
+}
+
+func (c *grpcClient) export(ctx context.Context, req *collogspb.ExportLogsServiceRequest) error {
+	if len(c.md) > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, c.md)
+	}
+	_, err := c.service.Export(ctx, req)
+	return err
+}
+
+func (c *grpcClient) close() error {
+	return c.conn.Close()
+}
+
+// retryable reports whether a failed export may succeed when repeated
+func retryable(err error) bool {
+	var se *StatusError
+	if errors.As(err, &se) {
+		switch se.Code {
+		case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	if s, ok := status.FromError(err); ok && s.Code() != codes.Unknown {
+		switch s.Code() {
+		case codes.Unavailable, codes.ResourceExhausted, codes.DeadlineExceeded, codes.Aborted:
+			return true
+		}
+		return false
+	}
+	// Network errors (connection refused, timeouts)
+	return true
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
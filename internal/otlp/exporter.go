@@ -0,0 +1,376 @@
+// Package otlp forwards log entries to an OpenTelemetry collector as OTLP
+// logs, over HTTP/JSON or gRPC.
+//
+// Entries are queued without blocking the caller, batched, and sent from a
+// single goroutine with bounded retries. When the collector cannot keep up
+// the queue fills and further entries are dropped and counted.
+package otlp
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+
+	"github.com/vburojevic/xcw/internal/domain"
+)
+
+// Supported protocols
+const (
+	ProtocolHTTPJSON = "http/json"
+	ProtocolGRPC     = "grpc"
+)
+
+// Defaults for zero Config fields
+const (
+	DefaultBatchSize     = 512
+	DefaultQueueSize     = 4096
+	DefaultFlushInterval = time.Second
+	DefaultTimeout       = 10 * time.Second
+	DefaultMaxRetries    = 3
+)
+
+// Config configures an Exporter
+type Config struct {
+	Endpoint string // http(s)://host:port[/path] for HTTP; [http(s)://]host:port for gRPC
+	Protocol string // ProtocolHTTPJSON (default) or ProtocolGRPC
+	Headers  map[string]string
+
+	// Resource holds static resource attributes (e.g. service.name,
+	// service.version). xcw.tail_id and xcw.session come from each entry.
+	Resource     map[string]string
+	ScopeVersion string
+
+	BatchSize     int
+	QueueSize     int
+	FlushInterval time.Duration
+	Timeout       time.Duration // per export request
+	MaxRetries    int           // retries of a failed batch on retryable errors
+}
+
+// Stats counts entries by outcome
+type Stats struct {
+	Exported int64
+	Dropped  int64 // queue full or exporter closed
+	Failed   int64 // batches rejected or out of retries
+	LastErr  error
+}
+
+// client sends one export request
+type client interface {
+	export(ctx context.Context, req *collogspb.ExportLogsServiceRequest) error
+	close() error
+}
+
+type record struct {
+	tailID  string
+	session int
+	log     *logspb.LogRecord
+}
+
+// Exporter batches log entries and sends them to a collector
+type Exporter struct {
+	cfg    Config
+	client client
+	queue  chan record
+
+	mu     sync.RWMutex
+	closed bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	exported, dropped, failed atomic.Int64
+	lastErr                   atomic.Value
+}
+
+// New validates cfg and starts the exporter's sender goroutine.
+func New(cfg Config) (*Exporter, error) {
+	if cfg.Endpoint == "" {
+		return nil, errors.New("OTLP endpoint is required")
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = DefaultBatchSize
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = DefaultQueueSize
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = DefaultFlushInterval
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	if cfg.MaxRetries < 0 {
+		cfg.MaxRetries = 0
+	}
+
+	var c client
+	var err error
+	switch cfg.Protocol {
+	case "", ProtocolHTTPJSON:
+		c, err = newHTTPClient(cfg)
+	case ProtocolGRPC:
+		c, err = newGRPCClient(cfg)
+	default:
+		err = fmt.Errorf("unknown OTLP protocol %q (use %s or %s)", cfg.Protocol, ProtocolHTTPJSON, ProtocolGRPC)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	e := &Exporter{
+		cfg:    cfg,
+		client: c,
+		queue:  make(chan record, cfg.QueueSize),
+		ctx:    ctx,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go e.run()
+	return e, nil
+}
+
+// Export queues entry for sending. It never blocks; when the queue is full
+// the entry is dropped.
+func (e *Exporter) Export(entry *domain.LogEntry) {
+	rec := record{tailID: entry.TailID, session: entry.Session, log: logRecord(entry, time.Now())}
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.closed {
+		e.dropped.Add(1)
+		return
+	}
+	select {
+	case e.queue <- rec:
+	default:
+		e.dropped.Add(1)
+	}
+}
+
+// Close sends queued entries and stops the exporter. If ctx ends first,
+// in-flight requests are cancelled and the remaining entries are lost.
+func (e *Exporter) Close(ctx context.Context) error {
+	e.mu.Lock()
+	if !e.closed {
+		e.closed = true
+		close(e.queue)
+	}
+	e.mu.Unlock()
+
+	var err error
+	select {
+	case <-e.done:
+	case <-ctx.Done():
+		e.cancel()
+		<-e.done
+		err = ctx.Err()
+	}
+	e.cancel()
+	if cerr := e.client.close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Stats returns the current counters
+func (e *Exporter) Stats() Stats {
+	s := Stats{Exported: e.exported.Load(), Dropped: e.dropped.Load(), Failed: e.failed.Load()}
+	if err, ok := e.lastErr.Load().(error); ok {
+		s.LastErr = err
+	}
+	return s
+}
+
+func (e *Exporter) run() {
+	defer close(e.done)
+	ticker := time.NewTicker(e.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]record, 0, e.cfg.BatchSize)
+	for {
+		select {
+		case rec, ok := <-e.queue:
+			if !ok {
+				e.send(batch)
+				return
+			}
+			batch = append(batch, rec)
+			if len(batch) >= e.cfg.BatchSize {
+				e.send(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			e.send(batch)
+			batch = batch[:0]
+		}
+	}
+}
+
+// send exports batch, retrying retryable failures with exponential backoff
+func (e *Exporter) send(batch []record) {
+	if len(batch) == 0 {
+		return
+	}
+	req := e.request(batch)
+	backoff := 250 * time.Millisecond
+	for attempt := 0; ; attempt++ {
+		ctx, cancel := context.WithTimeout(e.ctx, e.cfg.Timeout)
+		err := e.client.export(ctx, req)
+		cancel()
+		if err == nil {
+			e.exported.Add(int64(len(batch)))
+			return
+		}
+		e.lastErr.Store(err)
+		if attempt >= e.cfg.MaxRetries || !retryable(err) || e.ctx.Err() != nil {
+			e.failed.Add(int64(len(batch)))
+			return
+		}
+		select {
+		case <-time.After(backoff):
+		case <-e.ctx.Done():
+			e.failed.Add(int64(len(batch)))
+			return
+		}
+		if backoff *= 2; backoff > 5*time.Second {
+			backoff = 5 * time.Second
+		}
+	}
+}
+
+// request groups batch into one ResourceLogs per tail ID and session
+func (e *Exporter) request(batch []record) *collogspb.ExportLogsServiceRequest {
+	type key struct {
+		tailID  string
+		session int
+	}
+	req := &collogspb.ExportLogsServiceRequest{}
+	scopes := map[key]*logspb.ScopeLogs{}
+	for _, rec := range batch {
+		k := key{rec.tailID, rec.session}
+		sl, ok := scopes[k]
+		if !ok {
+			sl = &logspb.ScopeLogs{Scope: &commonpb.InstrumentationScope{Name: "xcw", Version: e.cfg.ScopeVersion}}
+			scopes[k] = sl
+			req.ResourceLogs = append(req.ResourceLogs, &logspb.ResourceLogs{
+				Resource:  &resourcepb.Resource{Attributes: e.resourceAttributes(rec.tailID, rec.session)},
+				ScopeLogs: []*logspb.ScopeLogs{sl},
+			})
+		}
+		sl.LogRecords = append(sl.LogRecords, rec.log)
+	}
+	return req
+}
+
+func (e *Exporter) resourceAttributes(tailID string, session int) []*commonpb.KeyValue {
+	attrs := make([]*commonpb.KeyValue, 0, len(e.cfg.Resource)+2)
+	for _, k := range sortedKeys(e.cfg.Resource) {
+		if v := e.cfg.Resource[k]; v != "" {
+			attrs = append(attrs, stringAttr(k, v))
+		}
+	}
+	if tailID != "" {
+		attrs = append(attrs, stringAttr("xcw.tail_id", tailID))
+	}
+	if session > 0 {
+		attrs = append(attrs, intAttr("xcw.session", int64(session)))
+	}
+	return attrs
+}
+
+// logRecord maps a log entry to an OTLP log record
+func logRecord(entry *domain.LogEntry, observed time.Time) *logspb.LogRecord {
+	lr := &logspb.LogRecord{
+		ObservedTimeUnixNano: uint64(observed.UnixNano()),
+		SeverityNumber:       severity(entry.Level),
+		SeverityText:         string(entry.Level),
+		Body:                 &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: entry.Message}},
+	}
+	if !entry.Timestamp.IsZero() {
+		lr.TimeUnixNano = uint64(entry.Timestamp.UnixNano())
+	}
+	add := func(k, v string) {
+		if v != "" {
+			lr.Attributes = append(lr.Attributes, stringAttr(k, v))
+		}
+	}
+	add("subsystem", entry.Subsystem)
+	add("category", entry.Category)
+	add("process.executable.name", entry.Process)
+	if entry.PID > 0 {
+		lr.Attributes = append(lr.Attributes, intAttr("process.pid", int64(entry.PID)))
+	}
+	if entry.TID > 0 {
+		lr.Attributes = append(lr.Attributes, intAttr("thread.id", int64(entry.TID)))
+	}
+	if entry.ActivityID > 0 {
+		lr.Attributes = append(lr.Attributes, intAttr("xcw.activity_id", int64(entry.ActivityID)))
+	}
+	add("xcw.trace_id", entry.TraceID)
+	// W3C IDs also fill the record's trace context
+	if b, err := hex.DecodeString(entry.TraceID); err == nil && len(b) == 16 {
+		lr.TraceId = b
+		if b, err := hex.DecodeString(entry.SpanID); err == nil && len(b) == 8 {
+			lr.SpanId = b
+		}
+	}
+	add("event_type", entry.EventType)
+	if entry.DedupeCount > 1 {
+		lr.Attributes = append(lr.Attributes, intAttr("xcw.dedupe_count", int64(entry.DedupeCount)))
+	}
+	for _, k := range sortedKeys(entry.Fields) {
+		add("fields."+k, entry.Fields[k])
+	}
+	return lr
+}
+
+// severity maps unified logging levels to OTLP severity numbers
+func severity(level domain.LogLevel) logspb.SeverityNumber {
+	switch level {
+	case domain.LogLevelDebug:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_DEBUG
+	case domain.LogLevelInfo:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_INFO
+	case domain.LogLevelDefault:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_INFO2
+	case domain.LogLevelError:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_ERROR
+	case domain.LogLevelFault:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_FATAL
+	default:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_UNSPECIFIED
+	}
+}
+
+func stringAttr(k, v string) *commonpb.KeyValue {
+	return &commonpb.KeyValue{Key: k, Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: v}}}
+}
+
+func intAttr(k string, v int64) *commonpb.KeyValue {
+	return &commonpb.KeyValue{Key: k, Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: v}}}
+}
+
+// StatusError is a non-success HTTP response from the collector
+type StatusError struct {
+	Code int
+	Body string
+}
+
+func (e *StatusError) Error() string {
+	if e.Body == "" {
+		return "collector returned HTTP " + strconv.Itoa(e.Code)
+	}
+	return fmt.Sprintf("collector returned HTTP %d: %s", e.Code, e.Body)
+}
@@ -0,0 +1,260 @@
+package otlp
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/vburojevic/xcw/internal/domain"
+)
+
+// collector is a stand-in OTLP collector recording what it receives
+type collector struct {
+	mu       sync.Mutex
+	requests []*collogspb.ExportLogsServiceRequest
+	headers  []string
+}
+
+func (c *collector) add(req *collogspb.ExportLogsServiceRequest, header string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requests = append(c.requests, req)
+	c.headers = append(c.headers, header)
+}
+
+func (c *collector) records() []*logspb.LogRecord {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var out []*logspb.LogRecord
+	for _, req := range c.requests {
+		for _, rl := range req.ResourceLogs {
+			for _, sl := range rl.ScopeLogs {
+				out = append(out, sl.LogRecords...)
+			}
+		}
+	}
+	return out
+}
+
+func (c *collector) handler(fail int) http.HandlerFunc {
+	var calls atomic.Int32
+	return func(w http.ResponseWriter, r *http.Request) {
+		if int(calls.Add(1)) <= fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		req := &collogspb.ExportLogsServiceRequest{}
+		if r.URL.Path != "/v1/logs" || r.Header.Get("Content-Type") != "application/json" || protojson.Unmarshal(body, req) != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		c.add(req, r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+type grpcCollector struct {
+	collogspb.UnimplementedLogsServiceServer
+	*collector
+}
+
+func (g *grpcCollector) Export(ctx context.Context, req *collogspb.ExportLogsServiceRequest) (*collogspb.ExportLogsServiceResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	header := ""
+	if v := md.Get("authorization"); len(v) > 0 {
+		header = v[0]
+	}
+	g.add(req, header)
+	return &collogspb.ExportLogsServiceResponse{}, nil
+}
+
+var ts = time.Date(2025, 12, 9, 10, 0, 0, 0, time.UTC)
+
+func entry(session int, level domain.LogLevel, msg string) *domain.LogEntry {
+	return &domain.LogEntry{
+		Timestamp: ts,
+		Level:     level,
+		Process:   "MyApp",
+		PID:       42,
+		TID:       7,
+		Subsystem: "com.example.net",
+		Category:  "http",
+		Message:   msg,
+		Session:   session,
+		TailID:    "tail-1",
+		Fields:    map[string]string{"status": "503"},
+	}
+}
+
+func TestExporter_HTTPJSON(t *testing.T) {
+	c := &collector{}
+	srv := httptest.NewServer(c.handler(1)) // first request fails and is retried
+	defer srv.Close()
+
+	exp, err := New(Config{
+		Endpoint:     srv.URL,
+		Headers:      map[string]string{"Authorization": "Bearer t"},
+		Resource:     map[string]string{"service.name": "com.example.app", "service.version": "1.2"},
+		ScopeVersion: "test",
+		MaxRetries:   2,
+	})
+	require.NoError(t, err)
+	exp.Export(entry(1, domain.LogLevelError, "request failed"))
+	exp.Export(entry(2, domain.LogLevelFault, "crashed"))
+	require.NoError(t, exp.Close(context.Background()))
+
+	stats := exp.Stats()
+	assert.Equal(t, int64(2), stats.Exported)
+	assert.Zero(t, stats.Dropped+stats.Failed)
+	require.Len(t, c.requests, 1)
+	assert.Equal(t, "Bearer t", c.headers[0])
+
+	req := c.requests[0]
+	require.Len(t, req.ResourceLogs, 2, "one resource per session")
+	res := map[string]string{}
+	for _, kv := range req.ResourceLogs[1].Resource.Attributes {
+		res[kv.Key] = kv.Value.String()
+	}
+	assert.Contains(t, res["service.name"], "com.example.app")
+	assert.Contains(t, res["service.version"], "1.2")
+	assert.Contains(t, res["xcw.tail_id"], "tail-1")
+	assert.Contains(t, res["xcw.session"], "2")
+
+	records := c.records()
+	require.Len(t, records, 2)
+	r := records[0]
+	assert.Equal(t, uint64(ts.UnixNano()), r.TimeUnixNano)
+	assert.Equal(t, logspb.SeverityNumber_SEVERITY_NUMBER_ERROR, r.SeverityNumber)
+	assert.Equal(t, "Error", r.SeverityText)
+	assert.Equal(t, "request failed", r.Body.GetStringValue())
+	got := map[string]string{}
+	for _, kv := range r.Attributes {
+		got[kv.Key] = kv.Value.String()
+	}
+	assert.Contains(t, got["subsystem"], "com.example.net")
+	assert.Contains(t, got["category"], "http")
+	assert.Contains(t, got["process.executable.name"], "MyApp")
+	assert.Contains(t, got["process.pid"], "42")
+	assert.Contains(t, got["thread.id"], "7")
+	assert.Contains(t, got["fields.status"], "503")
+	assert.Equal(t, logspb.SeverityNumber_SEVERITY_NUMBER_FATAL, records[1].SeverityNumber)
+}
+
+func TestLogRecord_TraceContext(t *testing.T) {
+	e := entry(1, domain.LogLevelInfo, "x")
+	e.TraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	e.SpanID = "00f067aa0ba902b7"
+	r := logRecord(e, ts)
+	assert.Len(t, r.TraceId, 16)
+	assert.Len(t, r.SpanId, 8)
+
+	e.TraceID, e.SpanID = "req-1", ""
+	r = logRecord(e, ts)
+	assert.Empty(t, r.TraceId)
+}
+
+func TestExporter_GRPC(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	c := &collector{}
+	srv := grpc.NewServer()
+	collogspb.RegisterLogsServiceServer(srv, &grpcCollector{collector: c})
+	go func() { _ = srv.Serve(lis) }()
+	defer srv.Stop()
+
+	exp, err := New(Config{Endpoint: lis.Addr().String(), Protocol: ProtocolGRPC, Headers: map[string]string{"authorization": "Bearer g"}})
+	require.NoError(t, err)
+	exp.Export(entry(1, domain.LogLevelInfo, "hello"))
+	require.NoError(t, exp.Close(context.Background()))
+
+	require.Len(t, c.records(), 1)
+	assert.Equal(t, "hello", c.records()[0].Body.GetStringValue())
+	assert.Equal(t, logspb.SeverityNumber_SEVERITY_NUMBER_INFO, c.records()[0].SeverityNumber)
+	assert.Equal(t, "Bearer g", c.headers[0])
+	assert.Equal(t, int64(1), exp.Stats().Exported)
+}
+
+func TestExporter_Bounded(t *testing.T) {
+	t.Run("drops when the queue is full instead of blocking", func(t *testing.T) {
+		release := make(chan struct{})
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-release
+		}))
+		defer srv.Close()
+
+		exp, err := New(Config{Endpoint: srv.URL, BatchSize: 1, QueueSize: 2})
+		require.NoError(t, err)
+		done := make(chan struct{})
+		go func() {
+			for i := 0; i < 100; i++ {
+				exp.Export(entry(1, domain.LogLevelInfo, "x"))
+			}
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(2 * time.Second):
+			t.Fatal("Export blocked")
+		}
+		close(release)
+		require.NoError(t, exp.Close(context.Background()))
+		s := exp.Stats()
+		assert.Equal(t, int64(100), s.Exported+s.Dropped+s.Failed)
+		assert.Greater(t, s.Dropped, int64(90))
+	})
+
+	t.Run("gives up after the retry budget", func(t *testing.T) {
+		c := &collector{}
+		srv := httptest.NewServer(c.handler(100))
+		defer srv.Close()
+
+		exp, err := New(Config{Endpoint: srv.URL, MaxRetries: 1})
+		require.NoError(t, err)
+		exp.Export(entry(1, domain.LogLevelInfo, "x"))
+		require.NoError(t, exp.Close(context.Background()))
+		s := exp.Stats()
+		assert.Equal(t, int64(1), s.Failed)
+		assert.Contains(t, s.LastErr.Error(), "503")
+	})
+
+	t.Run("close honours its deadline", func(t *testing.T) {
+		hang := make(chan struct{})
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-hang
+		}))
+		defer srv.Close()
+
+		exp, err := New(Config{Endpoint: srv.URL})
+		require.NoError(t, err)
+		exp.Export(entry(1, domain.LogLevelInfo, "x"))
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, exp.Close(ctx), context.DeadlineExceeded)
+		assert.Equal(t, int64(1), exp.Stats().Failed)
+		close(hang)
+	})
+}
+
+func TestNew_Validation(t *testing.T) {
+	_, err := New(Config{})
+	assert.Error(t, err)
+	_, err = New(Config{Endpoint: "localhost:4318"})
+	assert.ErrorContains(t, err, "http(s)://")
+	_, err = New(Config{Endpoint: "http://localhost:4318", Protocol: "thrift"})
+	assert.ErrorContains(t, err, "unknown OTLP protocol")
+}
@@ -1,8 +1,10 @@
 package output
 
 import (
+	"fmt"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -19,11 +21,32 @@ var (
 // Analyzer provides AI-friendly log analysis and summarization
 type Analyzer struct {
 	errorPatterns []*regexp.Regexp
+	masks         []mask
+	similarity    float64
 }
 
-// NewAnalyzer creates a new log analyzer
+// NewAnalyzer creates a new log analyzer with the built-in masks
 func NewAnalyzer() *Analyzer {
+	a, _ := NewAnalyzerWithOptions(TemplateOptions{})
+	return a
+}
+
+// NewAnalyzerWithOptions creates a log analyzer that also applies the given
+// masks and template similarity.
+func NewAnalyzerWithOptions(opts TemplateOptions) (*Analyzer, error) {
+	masks, err := compileMasks(opts.Masks)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Similarity < 0 || opts.Similarity > 1 {
+		return nil, fmt.Errorf("invalid template similarity %v (use 0 to 1)", opts.Similarity)
+	}
+	if opts.Similarity == 0 {
+		opts.Similarity = DefaultTemplateSimilarity
+	}
 	return &Analyzer{
+		masks:      masks,
+		similarity: opts.Similarity,
 		errorPatterns: []*regexp.Regexp{
 			regexp.MustCompile(`(?i)error[:\s]`),
 			regexp.MustCompile(`(?i)fail(?:ed|ure)?[:\s]`),
@@ -34,7 +57,12 @@ func NewAnalyzer() *Analyzer {
 			regexp.MustCompile(`(?i)invalid[:\s]`),
 			regexp.MustCompile(`(?i)nil|null pointer`),
 		},
-	}
+	}, nil
+}
+
+// newMiner starts a template miner using the analyzer's masks
+func (a *Analyzer) newMiner() *templateMiner {
+	return newTemplateMiner(a.masks, a.similarity)
 }
 
 // Summarize generates an AI-friendly summary from log entries
@@ -94,21 +122,114 @@ func (a *Analyzer) Summarize(entries []domain.LogEntry) *domain.LogSummary {
 	return summary
 }
 
-// normalizeMessage removes variable parts to group similar messages
-func (a *Analyzer) normalizeMessage(msg string) string {
-	// Remove UUIDs first (before numbers, since UUIDs contain numbers)
-	msg = uuidRegex.ReplaceAllString(msg, "<uuid>")
-	// Remove hex addresses
-	msg = hexAddrRegex.ReplaceAllString(msg, "<addr>")
-	// Remove numbers
-	msg = numberRegex.ReplaceAllString(msg, "<n>")
+// MaxHistogramBuckets bounds the buckets a summary histogram may have
+const MaxHistogramBuckets = 1000
+
+// histogramTargetBuckets is the bucket count an automatic size aims for
+const histogramTargetBuckets = 30
+
+// histogramSteps are the round bucket sizes picked automatically
+var histogramSteps = []time.Duration{
+	time.Second, 2 * time.Second, 5 * time.Second, 10 * time.Second, 15 * time.Second, 30 * time.Second,
+	time.Minute, 2 * time.Minute, 5 * time.Minute, 10 * time.Minute, 15 * time.Minute, 30 * time.Minute,
+	time.Hour, 2 * time.Hour, 3 * time.Hour, 6 * time.Hour, 12 * time.Hour, 24 * time.Hour,
+}
+
+// AddHistogram buckets entries by timestamp into summary.Histogram, with
+// per-bucket level counts and the busiest subsystem. A zero bucket picks a
+// round size giving about 30 buckets. Entries without a timestamp are skipped.
+func (a *Analyzer) AddHistogram(summary *domain.LogSummary, entries []domain.LogEntry, bucket time.Duration) error {
+	var first, last time.Time
+	for _, e := range entries {
+		if e.Timestamp.IsZero() {
+			continue
+		}
+		if first.IsZero() || e.Timestamp.Before(first) {
+			first = e.Timestamp
+		}
+		if e.Timestamp.After(last) {
+			last = e.Timestamp
+		}
+	}
+	if first.IsZero() {
+		return nil
+	}
+	if bucket <= 0 {
+		bucket = histogramBucketSize(last.Sub(first))
+	}
+	start := first.Truncate(bucket)
+	n := int(last.Sub(start)/bucket) + 1
+	if n > MaxHistogramBuckets {
+		return fmt.Errorf("bucket size %s gives %d buckets over %s (max %d)", bucket, n, last.Sub(first).Round(time.Second), MaxHistogramBuckets)
+	}
 
-	// Truncate long messages
-	if len(msg) > 100 {
-		msg = msg[:100] + "..."
+	buckets := make([]domain.VolumeBucket, n)
+	subsystems := make([]map[string]int, n)
+	for i := range buckets {
+		buckets[i].Start = start.Add(time.Duration(i) * bucket)
+	}
+	for _, e := range entries {
+		if e.Timestamp.IsZero() {
+			continue
+		}
+		i := int(e.Timestamp.Sub(start) / bucket)
+		b := &buckets[i]
+		b.TotalCount++
+		switch e.Level {
+		case domain.LogLevelDebug:
+			b.DebugCount++
+		case domain.LogLevelInfo:
+			b.InfoCount++
+		case domain.LogLevelDefault:
+			b.DefaultCount++
+		case domain.LogLevelError:
+			b.ErrorCount++
+		case domain.LogLevelFault:
+			b.FaultCount++
+		}
+		if e.Subsystem != "" {
+			if subsystems[i] == nil {
+				subsystems[i] = make(map[string]int)
+			}
+			subsystems[i][e.Subsystem]++
+		}
+	}
+	for i, counts := range subsystems {
+		for sub, count := range counts {
+			b := &buckets[i]
+			if count > b.TopSubsystemCount || count == b.TopSubsystemCount && sub < b.TopSubsystem {
+				b.TopSubsystem, b.TopSubsystemCount = sub, count
+			}
+		}
 	}
 
-	return strings.TrimSpace(msg)
+	summary.BucketSize = bucket.String()
+	summary.Histogram = buckets
+	return nil
+}
+
+// histogramBucketSize picks the smallest round size covering span in about
+// histogramTargetBuckets buckets.
+func histogramBucketSize(span time.Duration) time.Duration {
+	for _, step := range histogramSteps {
+		if int(span/step)+1 <= histogramTargetBuckets {
+			return step
+		}
+	}
+	day := 24 * time.Hour
+	return (span/histogramTargetBuckets/day + 1) * day
+}
+
+// normalizeMessage masks variable parts (user masks, then URLs, e-mails,
+// UUIDs, object descriptions, quoted strings, paths, addresses and numbers)
+// to group similar messages
+func (a *Analyzer) normalizeMessage(msg string) string {
+	return truncatePattern(maskText(a.masks, msg))
+}
+
+// truncatePattern trims and shortens a pattern for display
+func truncatePattern(msg string) string {
+	return strings.TrimSpace(truncateRunes(msg, 100))
 }
 
 // getTopMessages returns the top N messages by frequency
@@ -134,26 +255,24 @@ func (a *Analyzer) getTopMessages(counts map[string]int, limit int) []string {
 	return top
 }
 
-// DetectPatterns finds recurring error patterns
+// DetectPatterns finds recurring error patterns. Error and fault messages are
+// grouped into templates, so messages differing only in their parameters
+// share a pattern.
 func (a *Analyzer) DetectPatterns(entries []domain.LogEntry) []PatternMatch {
-	// Filter to only error/fault entries
-	errorEntries := make([]domain.LogEntry, 0, len(entries))
+	miner := a.newMiner()
+	errorGroups := make(map[*templateCluster][]domain.LogEntry)
 	for _, e := range entries {
-		if e.Level == domain.LogLevelError || e.Level == domain.LogLevelFault {
-			errorEntries = append(errorEntries, e)
+		if e.Level != domain.LogLevelError && e.Level != domain.LogLevelFault {
+			continue
 		}
-	}
-
-	// Group similar error messages by normalized pattern
-	errorGroups := make(map[string][]domain.LogEntry)
-	for _, e := range errorEntries {
-		key := a.normalizeMessage(e.Message)
-		errorGroups[key] = append(errorGroups[key], e)
+		c, _ := miner.add(e.Message)
+		errorGroups[c] = append(errorGroups[c], e)
 	}
 
 	// Convert to PatternMatch slice, filtering those with >= 2 occurrences
 	patterns := make([]PatternMatch, 0)
-	for key, group := range errorGroups {
+	for _, c := range miner.clusters {
+		group := errorGroups[c]
 		if len(group) < 2 {
 			continue
 		}
@@ -162,15 +281,26 @@ func (a *Analyzer) DetectPatterns(entries []domain.LogEntry) []PatternMatch {
 		for i := 0; i < len(group) && i < 3; i++ {
 			samples = append(samples, group[i].Message)
 		}
+		level := group[0].Level
+		for _, e := range group[1:] {
+			if e.Level.Priority() > level.Priority() {
+				level = e.Level
+			}
+		}
 		patterns = append(patterns, PatternMatch{
-			Pattern: key,
-			Count:   len(group),
-			Samples: samples,
+			Pattern:    truncatePattern(c.text()),
+			TemplateID: c.id(),
+			Params:     c.slots(),
+			Count:      len(group),
+			Samples:    samples,
+			Level:      level,
+			Subsystem:  mostCommon(group, func(e domain.LogEntry) string { return e.Subsystem }),
+			Category:   mostCommon(group, func(e domain.LogEntry) string { return e.Category }),
 		})
 	}
 
 	// Sort by frequency (descending)
-	sort.Slice(patterns, func(i, j int) bool {
+	sort.SliceStable(patterns, func(i, j int) bool {
 		return patterns[i].Count > patterns[j].Count
 	})
 
@@ -181,11 +311,98 @@ func (a *Analyzer) DetectPatterns(entries []domain.LogEntry) []PatternMatch {
 	return patterns
 }
 
+// mostCommon returns the most frequent non-empty key in entries (first seen wins ties)
+func mostCommon(entries []domain.LogEntry, keyFn func(domain.LogEntry) string) string {
+	counts := make(map[string]int)
+	best := ""
+	for _, e := range entries {
+		key := keyFn(e)
+		if key == "" {
+			continue
+		}
+		counts[key]++
+		if counts[key] > counts[best] {
+			best = key
+		}
+	}
+	return best
+}
+
+// maxFieldSamples caps the distinct sample values kept per extracted field
+const maxFieldSamples = 3
+
+// SummarizeFields aggregates extracted message fields (see --extract), most
+// frequent first, keeping up to limit fields (0 = all).
+func (a *Analyzer) SummarizeFields(entries []domain.LogEntry, limit int) []domain.FieldInfo {
+	type fieldAgg struct {
+		info    domain.FieldInfo
+		seen    map[string]bool
+		numeric bool
+		min     float64
+		max     float64
+	}
+	aggs := make(map[string]*fieldAgg)
+	for _, e := range entries {
+		for k, v := range e.Fields {
+			agg := aggs[k]
+			if agg == nil {
+				agg = &fieldAgg{info: domain.FieldInfo{Name: k}, seen: make(map[string]bool), numeric: true}
+				aggs[k] = agg
+			}
+			agg.info.Count++
+			if !agg.seen[v] && len(agg.info.Samples) < maxFieldSamples {
+				agg.seen[v] = true
+				agg.info.Samples = append(agg.info.Samples, v)
+			}
+			if !agg.numeric {
+				continue
+			}
+			n, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				agg.numeric = false
+				continue
+			}
+			if agg.info.Count == 1 || n < agg.min {
+				agg.min = n
+			}
+			if agg.info.Count == 1 || n > agg.max {
+				agg.max = n
+			}
+		}
+	}
+
+	fields := make([]domain.FieldInfo, 0, len(aggs))
+	for _, agg := range aggs {
+		info := agg.info
+		if agg.numeric {
+			minV, maxV := agg.min, agg.max
+			info.Min = &minV
+			info.Max = &maxV
+		}
+		fields = append(fields, info)
+	}
+	sort.Slice(fields, func(i, j int) bool {
+		if fields[i].Count != fields[j].Count {
+			return fields[i].Count > fields[j].Count
+		}
+		return fields[i].Name < fields[j].Name
+	})
+	if limit > 0 && len(fields) > limit {
+		fields = fields[:limit]
+	}
+	return fields
+}
+
 // PatternMatch represents a detected error pattern
 type PatternMatch struct {
-	Pattern string   `json:"pattern"`
-	Count   int      `json:"count"`
-	Samples []string `json:"samples"`
+	Pattern    string                 `json:"pattern"`
+	TemplateID string                 `json:"template_id,omitempty"` // Template ID, as in discover templates[].id
+	Params     []domain.TemplateParam `json:"params,omitempty"`      // Variable tokens of the template
+	Count      int                    `json:"count"`
+	Samples    []string               `json:"samples"`
+	Level      domain.LogLevel        `json:"level,omitempty"`     // Most severe level in the group
+	Subsystem  string                 `json:"subsystem,omitempty"` // Most common subsystem in the group
+	Category   string                 `json:"category,omitempty"`  // Most common category in the group
 }
 
 // SummaryOutput wraps a summary for NDJSON output with timing
@@ -195,6 +412,7 @@ type SummaryOutput struct {
 	Timestamp     string             `json:"timestamp"`
 	Summary       *domain.LogSummary `json:"summary"`
 	Patterns      []PatternMatch     `json:"patterns,omitempty"`
+	Fields        []domain.FieldInfo `json:"fields,omitempty"`
 }
 
 // NewSummaryOutput creates a summary output wrapper
@@ -217,27 +435,39 @@ type EnhancedSummaryOutput struct {
 	Patterns          []EnhancedPatternMatch `json:"patterns,omitempty"`
 	NewPatternCount   int                    `json:"new_pattern_count"`
 	KnownPatternCount int                    `json:"known_pattern_count"`
+	App               string                 `json:"app,omitempty"`
+	AppVersion        string                 `json:"app_version,omitempty"`
+	AppBuild          string                 `json:"app_build,omitempty"`
+	NewInBuildCount   int                    `json:"new_in_build_count,omitempty"`
+	ReturnedCount     int                    `json:"returned_count,omitempty"`
+	RateIncreaseCount int                    `json:"rate_increase_count,omitempty"`
+	Fields            []domain.FieldInfo     `json:"fields,omitempty"`
 }
 
 // NewEnhancedSummaryOutput creates an enhanced summary output wrapper
 func NewEnhancedSummaryOutput(summary *domain.LogSummary, patterns []EnhancedPatternMatch) *EnhancedSummaryOutput {
-	newCount := 0
-	knownCount := 0
+	out := &EnhancedSummaryOutput{
+		Type:          "analysis",
+		SchemaVersion: SchemaVersion,
+		Timestamp:     time.Now().UTC().Format(time.RFC3339Nano),
+		Summary:       summary,
+		Patterns:      patterns,
+	}
 	for _, p := range patterns {
 		if p.IsNew {
-			newCount++
+			out.NewPatternCount++
 		} else {
-			knownCount++
+			out.KnownPatternCount++
+		}
+		if p.NewInBuild {
+			out.NewInBuildCount++
+		}
+		if p.Returned {
+			out.ReturnedCount++
+		}
+		if p.RateIncreased {
+			out.RateIncreaseCount++
 		}
 	}
-
-	return &EnhancedSummaryOutput{
-		Type:              "analysis",
-		SchemaVersion:     SchemaVersion,
-		Timestamp:         time.Now().UTC().Format(time.RFC3339Nano),
-		Summary:           summary,
-		Patterns:          patterns,
-		NewPatternCount:   newCount,
-		KnownPatternCount: knownCount,
-	}
+	return out
 }
@@ -184,3 +184,79 @@ func TestPrecompiledRegexes(t *testing.T) {
 		assert.False(t, uuidRegex.MatchString("not-a-uuid"))
 	})
 }
+
+func TestAnalyzer_SummarizeFields(t *testing.T) {
+	a := NewAnalyzer()
+	entries := []domain.LogEntry{
+		{Fields: map[string]string{"status": "500", "route": "/a"}},
+		{Fields: map[string]string{"status": "200", "route": "/a"}},
+		{Fields: map[string]string{"status": "503", "route": "/b"}},
+		{Fields: map[string]string{"status": "404", "route": "/c"}},
+		{Fields: map[string]string{"latency_ms": "12"}},
+		{},
+	}
+
+	fields := a.SummarizeFields(entries, 0)
+	if assert.Len(t, fields, 3) {
+		assert.Equal(t, "route", fields[0].Name)
+		assert.Equal(t, 4, fields[0].Count)
+		assert.Equal(t, []string{"/a", "/b", "/c"}, fields[0].Samples)
+		assert.Nil(t, fields[0].Min, "non-numeric fields have no range")
+
+		assert.Equal(t, "status", fields[1].Name)
+		if assert.NotNil(t, fields[1].Min) && assert.NotNil(t, fields[1].Max) {
+			assert.Equal(t, 200.0, *fields[1].Min)
+			assert.Equal(t, 503.0, *fields[1].Max)
+		}
+		assert.Len(t, fields[1].Samples, maxFieldSamples)
+	}
+
+	assert.Len(t, a.SummarizeFields(entries, 1), 1)
+	assert.Empty(t, a.SummarizeFields(nil, 0))
+}
+
+func TestAnalyzer_AddHistogram(t *testing.T) {
+	a := NewAnalyzer()
+	base := time.Date(2025, 12, 9, 10, 0, 5, 0, time.UTC)
+	entries := []domain.LogEntry{
+		{Timestamp: base, Level: domain.LogLevelInfo, Subsystem: "com.example.ui"},
+		{Timestamp: base.Add(70 * time.Second), Level: domain.LogLevelError, Subsystem: "com.example.net"},
+		{Timestamp: base.Add(75 * time.Second), Level: domain.LogLevelFault, Subsystem: "com.example.net"},
+		{Timestamp: base.Add(80 * time.Second), Level: domain.LogLevelInfo, Subsystem: "com.example.ui"},
+		{Level: domain.LogLevelError}, // no timestamp: skipped
+		{Timestamp: base.Add(3 * time.Minute), Level: domain.LogLevelDebug},
+	}
+
+	summary := a.Summarize(entries[:4])
+	assert.NoError(t, a.AddHistogram(summary, entries, time.Minute))
+	assert.Equal(t, "1m0s", summary.BucketSize)
+	if assert.Len(t, summary.Histogram, 4) {
+		assert.Equal(t, time.Date(2025, 12, 9, 10, 0, 0, 0, time.UTC), summary.Histogram[0].Start, "buckets are aligned")
+		second := summary.Histogram[1]
+		assert.Equal(t, 3, second.TotalCount)
+		assert.Equal(t, 1, second.ErrorCount)
+		assert.Equal(t, 1, second.FaultCount)
+		assert.Equal(t, "com.example.net", second.TopSubsystem)
+		assert.Equal(t, 2, second.TopSubsystemCount)
+		assert.Zero(t, summary.Histogram[2].TotalCount, "empty buckets are kept")
+		assert.Equal(t, 1, summary.Histogram[3].DebugCount)
+		assert.Empty(t, summary.Histogram[3].TopSubsystem)
+	}
+
+	t.Run("automatic bucket size", func(t *testing.T) {
+		s := a.Summarize(entries)
+		assert.NoError(t, a.AddHistogram(s, entries, 0))
+		assert.Equal(t, "10s", s.BucketSize)
+		assert.LessOrEqual(t, len(s.Histogram), histogramTargetBuckets)
+	})
+
+	t.Run("too many buckets", func(t *testing.T) {
+		assert.ErrorContains(t, a.AddHistogram(a.Summarize(entries), entries, time.Millisecond), "max")
+	})
+
+	t.Run("no timestamps", func(t *testing.T) {
+		s := a.Summarize(nil)
+		assert.NoError(t, a.AddHistogram(s, []domain.LogEntry{{Message: "x"}}, 0))
+		assert.Empty(t, s.Histogram)
+	})
+}
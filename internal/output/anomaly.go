@@ -0,0 +1,339 @@
+package output
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+	"unicode/utf8"
+
+	"github.com/vburojevic/xcw/internal/domain"
+)
+
+// Anomaly detector defaults
+const (
+	DefaultAnomalyWindow = 10 * time.Second
+	DefaultAnomalyWarmup = time.Minute
+
+	// anomalySpan is the EWMA span in windows: the baseline follows roughly
+	// the last 30 windows (5 minutes at the default window).
+	anomalySpan = 30
+	// anomalyMaxCatchUp bounds how many empty windows are replayed after a
+	// long gap (e.g. the machine slept); later windows are skipped.
+	anomalyMaxCatchUp = 360
+	// anomalyMaxPatterns bounds the set of known message templates
+	anomalyMaxPatterns = 10000
+)
+
+// AnomalySensitivities lists the accepted sensitivity levels
+var AnomalySensitivities = []string{"low", "medium", "high"}
+
+// anomalyThresholds are the per-sensitivity detection limits
+type anomalyThresholds struct {
+	zscore         float64         // error_spike: standard deviations above baseline
+	minErrors      int             // error_spike: minimum errors in the window
+	silentWindows  int             // subsystem_silent: consecutive empty windows
+	newPatternFrom domain.LogLevel // new_pattern: lowest level reported
+}
+
+var anomalySensitivity = map[string]anomalyThresholds{
+	"low":    {zscore: 5, minErrors: 10, silentWindows: 12, newPatternFrom: domain.LogLevelFault},
+	"medium": {zscore: 3.5, minErrors: 5, silentWindows: 6, newPatternFrom: domain.LogLevelError},
+	"high":   {zscore: 2.5, minErrors: 3, silentWindows: 3, newPatternFrom: domain.LogLevelDefault},
+}
+
+// AnomalyConfig configures an AnomalyDetector
+type AnomalyConfig struct {
+	Window      time.Duration // Rate window (default 10s)
+	Warmup      time.Duration // Learning period before anything is reported (default 1m)
+	Sensitivity string        // low, medium (default) or high
+}
+
+// rateSeries is an EWMA baseline of per-window counts
+type rateSeries struct {
+	mean, variance float64
+	samples        int
+	count          int     // entries in the open window
+	active         float64 // EWMA of windows with at least one entry
+	alerting       bool    // error_spike reported and not yet back to normal
+
+	zeroRun      int
+	activeBefore float64 // activity and mean when the current empty run began
+	meanBefore   float64
+	silent       bool
+}
+
+// update folds the closed window's count x into the baseline. The first
+// windows are averaged evenly so a young series is not biased towards zero.
+func (s *rateSeries) update(x, alpha float64) {
+	alpha = math.Max(alpha, 1/float64(s.samples+1))
+	diff := x - s.mean
+	s.mean += alpha * diff
+	s.variance = (1 - alpha) * (s.variance + alpha*diff*diff)
+	indicator := 0.0
+	if x > 0 {
+		indicator = 1
+	}
+	s.active += alpha * (indicator - s.active)
+	s.samples++
+	s.count = 0
+}
+
+// patternSeries is the error baseline of one message template
+type patternSeries struct {
+	rateSeries
+	template  *templateCluster
+	subsystem string // Subsystem of the first error with the template
+}
+
+// AnomalyDetector learns per-window baselines of error counts (overall, per
+// subsystem and per message template) and of each subsystem's volume, and
+// reports error spikes, subsystems that stop logging, and message templates
+// never seen before. Time is supplied by the caller. Not safe for concurrent
+// use.
+type AnomalyDetector struct {
+	window     time.Duration
+	warmup     int // windows
+	thresholds anomalyThresholds
+	alpha      float64
+	miner      *templateMiner
+
+	start, windowStart time.Time
+	closed             int
+	errors             map[string]*rateSeries // "" is the whole stream
+	volume             map[string]*rateSeries
+	patternErrors      map[*templateCluster]*patternSeries
+	patternOrder       []*patternSeries // In creation order, at most anomalyMaxPatterns
+}
+
+// NewAnomalyDetector creates a detector; zero config values take defaults.
+func NewAnomalyDetector(cfg AnomalyConfig) (*AnomalyDetector, error) {
+	if cfg.Window <= 0 {
+		cfg.Window = DefaultAnomalyWindow
+	}
+	if cfg.Warmup < 0 {
+		return nil, fmt.Errorf("invalid anomaly warmup %s", cfg.Warmup)
+	}
+	if cfg.Warmup == 0 {
+		cfg.Warmup = DefaultAnomalyWarmup
+	}
+	if cfg.Sensitivity == "" {
+		cfg.Sensitivity = "medium"
+	}
+	thresholds, ok := anomalySensitivity[cfg.Sensitivity]
+	if !ok {
+		return nil, fmt.Errorf("unknown anomaly sensitivity %q (use low, medium or high)", cfg.Sensitivity)
+	}
+	return &AnomalyDetector{
+		window:     cfg.Window,
+		warmup:     int(math.Ceil(float64(cfg.Warmup) / float64(cfg.Window))),
+		thresholds: thresholds,
+		alpha:      2.0 / (anomalySpan + 1),
+		miner:      NewAnalyzer().newMiner(),
+		errors:     map[string]*rateSeries{"": {}},
+		volume:     make(map[string]*rateSeries),
+
+		patternErrors: make(map[*templateCluster]*patternSeries),
+	}, nil
+}
+
+// Window returns the rate window, for callers that tick the detector
+func (d *AnomalyDetector) Window() time.Duration {
+	return d.window
+}
+
+// Observe counts entry at time now. It returns anomalies from windows that
+// closed before now, plus a new_pattern anomaly for entry itself.
+func (d *AnomalyDetector) Observe(entry *domain.LogEntry, now time.Time) []*domain.Anomaly {
+	anomalies := d.Tick(now)
+
+	failed := entry.Level == domain.LogLevelError || entry.Level == domain.LogLevelFault
+	if failed {
+		d.errors[""].count++
+	}
+	if sub := entry.Subsystem; sub != "" {
+		if failed {
+			d.series(d.errors, sub).count++
+		}
+		d.series(d.volume, sub).count++
+	}
+
+	var c *templateCluster
+	isNew := false
+	if len(d.miner.clusters) < anomalyMaxPatterns {
+		c, isNew = d.miner.add(entry.Message)
+	} else {
+		c = d.miner.addExisting(entry.Message)
+	}
+	if c == nil || len(c.tokens) == 0 {
+		return anomalies
+	}
+	if failed {
+		p := d.patternErrors[c]
+		if p == nil {
+			p = &patternSeries{template: c, subsystem: entry.Subsystem}
+			d.patternErrors[c] = p
+			d.patternOrder = append(d.patternOrder, p)
+		}
+		p.count++
+	}
+	if isNew {
+		if d.closed >= d.warmup && entry.Level.Priority() >= d.thresholds.newPatternFrom.Priority() {
+			pattern := truncatePattern(c.text())
+			anomalies = append(anomalies, d.newAnomaly(now, domain.AnomalyNewPattern, entry.Subsystem, func(a *domain.Anomaly) {
+				a.Pattern = pattern
+				a.TemplateID = c.id()
+				a.Example = truncateRunes(entry.Message, 300)
+				a.Level = string(entry.Level)
+				a.Observed = 1
+				a.Message = fmt.Sprintf("new %s pattern: %s", entry.Level, pattern)
+			}))
+		}
+	}
+	return anomalies
+}
+
+// Tick closes every window that ended before now and returns the anomalies
+// they revealed. Call it periodically so silence is noticed without entries.
+func (d *AnomalyDetector) Tick(now time.Time) []*domain.Anomaly {
+	if d.start.IsZero() {
+		d.start, d.windowStart = now, now
+		return nil
+	}
+	var anomalies []*domain.Anomaly
+	for replayed := 0; now.Sub(d.windowStart) >= d.window; replayed++ {
+		if replayed == anomalyMaxCatchUp {
+			skipped := now.Sub(d.windowStart) / d.window
+			d.windowStart = d.windowStart.Add(skipped * d.window)
+			break
+		}
+		d.windowStart = d.windowStart.Add(d.window)
+		anomalies = append(anomalies, d.closeWindow(d.windowStart)...)
+	}
+	return anomalies
+}
+
+// series returns the series for key, creating it on first use
+func (d *AnomalyDetector) series(m map[string]*rateSeries, key string) *rateSeries {
+	s, ok := m[key]
+	if !ok {
+		s = &rateSeries{}
+		m[key] = s
+	}
+	return s
+}
+
+func (d *AnomalyDetector) closeWindow(end time.Time) []*domain.Anomaly {
+	var anomalies []*domain.Anomaly
+	warm := d.closed >= d.warmup
+
+	for _, key := range sortedSeriesKeys(d.errors) {
+		where := "stream"
+		if key != "" {
+			where = key
+		}
+		if a := d.closeErrorWindow(d.errors[key], end, warm, key, where, nil); a != nil {
+			anomalies = append(anomalies, a)
+		}
+	}
+	for _, p := range d.patternOrder {
+		pattern, id := truncatePattern(p.template.text()), p.template.id()
+		if a := d.closeErrorWindow(&p.rateSeries, end, warm, p.subsystem, fmt.Sprintf("pattern %q", pattern), func(a *domain.Anomaly) {
+			a.Pattern = pattern
+			a.TemplateID = id
+		}); a != nil {
+			anomalies = append(anomalies, a)
+		}
+	}
+
+	for _, key := range sortedSeriesKeys(d.volume) {
+		s := d.volume[key]
+		if s.count > 0 {
+			s.zeroRun = 0
+			s.silent = false
+		} else {
+			if s.zeroRun == 0 {
+				s.activeBefore, s.meanBefore = s.active, s.mean
+			}
+			s.zeroRun++
+			if warm && s.samples >= d.warmup && !s.silent && s.zeroRun >= d.thresholds.silentWindows && s.activeBefore >= 0.9 && s.meanBefore >= 1 {
+				s.silent = true
+				baseline := s.meanBefore
+				silentFor := time.Duration(s.zeroRun) * d.window
+				anomalies = append(anomalies, d.newAnomaly(end, domain.AnomalySubsystemSilent, key, func(a *domain.Anomaly) {
+					a.Baseline = round2(baseline)
+					a.Message = fmt.Sprintf("%s has not logged for %s (usually %.1f per %s)", key, silentFor, baseline, d.window)
+				}))
+			}
+		}
+		s.update(float64(s.count), d.alpha)
+	}
+
+	d.closed++
+	return anomalies
+}
+
+// closeErrorWindow folds the closed window into an error series and returns
+// an error_spike when the window was far above the baseline and the series
+// was not already alerting.
+func (d *AnomalyDetector) closeErrorWindow(s *rateSeries, end time.Time, warm bool, subsystem, where string, fill func(a *domain.Anomaly)) *domain.Anomaly {
+	x := float64(s.count)
+	baseline := s.mean
+	var anomaly *domain.Anomaly
+	spike := false
+	if warm && s.count >= d.thresholds.minErrors && x >= 2*s.mean {
+		sd := math.Max(math.Sqrt(s.variance), math.Sqrt(math.Max(s.mean, 1)))
+		if z := (x - s.mean) / sd; z >= d.thresholds.zscore {
+			spike = true
+			if !s.alerting {
+				anomaly = d.newAnomaly(end, domain.AnomalyErrorSpike, subsystem, func(a *domain.Anomaly) {
+					a.Observed = int(x)
+					a.Baseline = round2(baseline)
+					a.Score = round2(z)
+					a.Message = fmt.Sprintf("%d errors in %s from %s (baseline %.1f)", int(x), d.window, where, baseline)
+					if fill != nil {
+						fill(a)
+					}
+				})
+			}
+		}
+	}
+	s.alerting = spike
+	s.update(x, d.alpha)
+	return anomaly
+}
+
+func (d *AnomalyDetector) newAnomaly(now time.Time, kind, subsystem string, fill func(a *domain.Anomaly)) *domain.Anomaly {
+	a := &domain.Anomaly{
+		Type:          "anomaly",
+		SchemaVersion: SchemaVersion,
+		Timestamp:     now.UTC().Format(time.RFC3339Nano),
+		Kind:          kind,
+		Subsystem:     subsystem,
+		Window:        d.window.String(),
+	}
+	fill(a)
+	return a
+}
+
+func sortedSeriesKeys(m map[string]*rateSeries) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func round2(f float64) float64 {
+	return math.Round(f*100) / 100
+}
+
+// truncateRunes shortens s to n runes, so multi-byte characters are never
+// split
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n]) + "..."
+}
@@ -0,0 +1,155 @@
+package output
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vburojevic/xcw/internal/domain"
+)
+
+func TestAnomalyDetector(t *testing.T) {
+	base := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	newDetector := func(t *testing.T, sensitivity string) *AnomalyDetector {
+		d, err := NewAnomalyDetector(AnomalyConfig{Window: 10 * time.Second, Warmup: time.Minute, Sensitivity: sensitivity})
+		require.NoError(t, err)
+		return d
+	}
+	entry := func(level domain.LogLevel, subsystem, msg string) *domain.LogEntry {
+		return &domain.LogEntry{Level: level, Subsystem: subsystem, Message: msg}
+	}
+	// feed logs n entries per window for windows [from, to), one second apart
+	feed := func(d *AnomalyDetector, from, to int, perWindow func(w int) []*domain.LogEntry) []*domain.Anomaly {
+		var out []*domain.Anomaly
+		for w := from; w < to; w++ {
+			for i, e := range perWindow(w) {
+				out = append(out, d.Observe(e, base.Add(time.Duration(w)*10*time.Second+time.Duration(i)*100*time.Millisecond))...)
+			}
+		}
+		return out
+	}
+	steady := func(w int) []*domain.LogEntry {
+		return []*domain.LogEntry{
+			entry(domain.LogLevelInfo, "com.example.net", "request 1 ok"),
+			entry(domain.LogLevelInfo, "com.example.db", "query took 3ms"),
+			entry(domain.LogLevelError, "com.example.net", "request 2 failed"),
+		}
+	}
+	kinds := func(as []*domain.Anomaly) []string {
+		var out []string
+		for _, a := range as {
+			out = append(out, a.Kind+":"+a.Subsystem)
+		}
+		return out
+	}
+
+	t.Run("steady stream is quiet", func(t *testing.T) {
+		d := newDetector(t, "medium")
+		assert.Empty(t, feed(d, 0, 60, steady))
+	})
+
+	t.Run("error spike is reported once", func(t *testing.T) {
+		d := newDetector(t, "medium")
+		require.Empty(t, feed(d, 0, 30, steady))
+		burst := func(w int) []*domain.LogEntry {
+			out := steady(w)
+			for i := 0; i < 12; i++ {
+				out = append(out, entry(domain.LogLevelError, "com.example.net", "request 2 failed"))
+			}
+			return out
+		}
+		got := feed(d, 30, 33, burst)
+		got = append(got, d.Tick(base.Add(34*10*time.Second))...)
+		assert.Equal(t, []string{"error_spike:", "error_spike:com.example.net", "error_spike:com.example.net"}, kinds(got))
+		assert.Empty(t, got[1].Pattern)
+		assert.Equal(t, "request <n> failed", got[2].Pattern)
+		spike := got[0]
+		assert.Equal(t, 13, spike.Observed)
+		assert.Equal(t, float64(1), spike.Baseline)
+		assert.Greater(t, spike.Score, 3.5)
+		assert.Equal(t, "10s", spike.Window)
+		assert.Equal(t, "anomaly", spike.Type)
+	})
+
+	t.Run("pattern spike within a steady subsystem", func(t *testing.T) {
+		d := newDetector(t, "medium")
+		mixed := func(timeouts, refused int) func(w int) []*domain.LogEntry {
+			return func(w int) []*domain.LogEntry {
+				var out []*domain.LogEntry
+				for i := 0; i < timeouts; i++ {
+					out = append(out, entry(domain.LogLevelError, "com.example.net", "request 7 timed out"))
+				}
+				for i := 0; i < refused; i++ {
+					out = append(out, entry(domain.LogLevelError, "com.example.net", "connection refused by upstream"))
+				}
+				return out
+			}
+		}
+		require.Empty(t, feed(d, 0, 30, mixed(10, 1)))
+		got := feed(d, 30, 32, mixed(5, 6))
+		require.Equal(t, []string{"error_spike:com.example.net"}, kinds(got), "stream and subsystem totals are unchanged")
+		assert.Equal(t, "connection refused by upstream", got[0].Pattern)
+		assert.Len(t, got[0].TemplateID, 12)
+		assert.Equal(t, 6, got[0].Observed)
+		assert.Contains(t, got[0].Message, `from pattern "connection refused by upstream"`)
+	})
+
+	t.Run("busy subsystem going silent", func(t *testing.T) {
+		d := newDetector(t, "medium")
+		require.Empty(t, feed(d, 0, 30, steady))
+		onlyDB := func(w int) []*domain.LogEntry {
+			return []*domain.LogEntry{entry(domain.LogLevelInfo, "com.example.db", "query took 3ms")}
+		}
+		got := feed(d, 30, 45, onlyDB)
+		require.Equal(t, []string{"subsystem_silent:com.example.net"}, kinds(got))
+		assert.Contains(t, got[0].Message, "com.example.net has not logged for 1m0s")
+		assert.Empty(t, feed(d, 45, 50, onlyDB), "reported once per silence")
+	})
+
+	t.Run("silence is noticed by ticks alone", func(t *testing.T) {
+		d := newDetector(t, "high")
+		require.Empty(t, feed(d, 0, 30, steady))
+		got := d.Tick(base.Add(40 * 10 * time.Second))
+		assert.ElementsMatch(t, []string{"subsystem_silent:com.example.net", "subsystem_silent:com.example.db"}, kinds(got))
+	})
+
+	t.Run("new pattern after warmup", func(t *testing.T) {
+		d := newDetector(t, "medium")
+		require.Empty(t, feed(d, 0, 10, steady))
+		got := d.Observe(entry(domain.LogLevelInfo, "com.example.ui", "screen 4 shown"), base.Add(101*time.Second))
+		assert.Empty(t, got, "info is below the medium new_pattern level")
+		got = d.Observe(entry(domain.LogLevelError, "com.example.net", "TLS handshake 42 failed"), base.Add(102*time.Second))
+		require.Len(t, got, 1)
+		assert.Equal(t, domain.AnomalyNewPattern, got[0].Kind)
+		assert.Equal(t, "TLS handshake <n> failed", got[0].Pattern)
+		assert.Len(t, got[0].TemplateID, 12)
+		assert.Equal(t, "TLS handshake 42 failed", got[0].Example)
+		assert.Empty(t, d.Observe(entry(domain.LogLevelError, "com.example.net", "TLS handshake 43 failed"), base.Add(103*time.Second)))
+	})
+
+	t.Run("patterns during warmup are learned silently", func(t *testing.T) {
+		d := newDetector(t, "high")
+		assert.Empty(t, d.Observe(entry(domain.LogLevelFault, "x", "boom"), base))
+	})
+
+	t.Run("examples are truncated by rune", func(t *testing.T) {
+		d := newDetector(t, "high")
+		require.Empty(t, feed(d, 0, 10, steady))
+		msg := strings.Repeat("é", 400)
+		got := d.Observe(entry(domain.LogLevelError, "x", msg), base.Add(101*time.Second))
+		require.Len(t, got, 1)
+		assert.True(t, utf8.ValidString(got[0].Example))
+		assert.Equal(t, strings.Repeat("é", 300)+"...", got[0].Example)
+		assert.True(t, utf8.ValidString(got[0].Pattern))
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		_, err := NewAnomalyDetector(AnomalyConfig{Sensitivity: "extreme"})
+		assert.Error(t, err)
+		_, err = NewAnomalyDetector(AnomalyConfig{Warmup: -time.Second})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,206 @@
+package output
+
+import (
+	"sort"
+	"time"
+
+	"github.com/vburojevic/xcw/internal/domain"
+)
+
+// DefaultDiffLimit caps the number of patterns and deltas listed per section
+const DefaultDiffLimit = 20
+
+// DiffSide describes one side (A or B) of a diff
+type DiffSide struct {
+	Label           string  `json:"label"`
+	Session         int     `json:"session,omitempty"`
+	Total           int     `json:"total"`
+	Patterns        int     `json:"patterns"`
+	DurationSeconds float64 `json:"duration_seconds"`
+}
+
+// DiffPattern is a normalized message pattern present on only one side
+type DiffPattern struct {
+	Pattern string          `json:"pattern"`
+	Count   int             `json:"count"`
+	Level   domain.LogLevel `json:"level"`
+	Sample  string          `json:"sample"`
+}
+
+// DiffDelta compares counts and per-minute rates for one key (subsystem, category or level)
+type DiffDelta struct {
+	Key          string  `json:"key"`
+	CountA       int     `json:"count_a"`
+	CountB       int     `json:"count_b"`
+	Delta        int     `json:"delta"`
+	RateA        float64 `json:"rate_a"`
+	RateB        float64 `json:"rate_b"`
+	RateDeltaPct float64 `json:"rate_delta_pct,omitempty"`
+}
+
+// DiffResult is the output of comparing two sets of log entries
+type DiffResult struct {
+	Type          string        `json:"type"`
+	SchemaVersion int           `json:"schemaVersion"`
+	Timestamp     string        `json:"timestamp"`
+	A             DiffSide      `json:"a"`
+	B             DiffSide      `json:"b"`
+	OnlyInA       []DiffPattern `json:"only_in_a"`
+	OnlyInB       []DiffPattern `json:"only_in_b"`
+	OnlyInACount  int           `json:"only_in_a_count"`
+	OnlyInBCount  int           `json:"only_in_b_count"`
+	Subsystems    []DiffDelta   `json:"subsystems"`
+	Categories    []DiffDelta   `json:"categories"`
+	Levels        []DiffDelta   `json:"levels"`
+}
+
+// diffNoneKey labels entries without a subsystem or category
+const diffNoneKey = "(none)"
+
+// Diff compares entries from two recordings or sessions. Messages are
+// normalized so that patterns differing only in numbers, addresses or UUIDs
+// match; limit caps each list (0 uses DefaultDiffLimit).
+func (a *Analyzer) Diff(sideA DiffSide, entriesA []domain.LogEntry, sideB DiffSide, entriesB []domain.LogEntry, limit int) *DiffResult {
+	if limit <= 0 {
+		limit = DefaultDiffLimit
+	}
+
+	patternsA := a.diffPatterns(entriesA)
+	patternsB := a.diffPatterns(entriesB)
+	sideA.Total, sideA.Patterns, sideA.DurationSeconds = len(entriesA), len(patternsA), entriesDuration(entriesA).Seconds()
+	sideB.Total, sideB.Patterns, sideB.DurationSeconds = len(entriesB), len(patternsB), entriesDuration(entriesB).Seconds()
+
+	result := &DiffResult{
+		Type:          "diff_result",
+		SchemaVersion: SchemaVersion,
+		Timestamp:     time.Now().UTC().Format(time.RFC3339Nano),
+		A:             sideA,
+		B:             sideB,
+		OnlyInA:       onlyIn(patternsA, patternsB),
+		OnlyInB:       onlyIn(patternsB, patternsA),
+	}
+	result.OnlyInACount = len(result.OnlyInA)
+	result.OnlyInBCount = len(result.OnlyInB)
+	if len(result.OnlyInA) > limit {
+		result.OnlyInA = result.OnlyInA[:limit]
+	}
+	if len(result.OnlyInB) > limit {
+		result.OnlyInB = result.OnlyInB[:limit]
+	}
+
+	minutesA := entriesDuration(entriesA).Minutes()
+	minutesB := entriesDuration(entriesB).Minutes()
+	result.Subsystems = diffDeltas(entriesA, entriesB, minutesA, minutesB, limit, func(e domain.LogEntry) string { return e.Subsystem })
+	result.Categories = diffDeltas(entriesA, entriesB, minutesA, minutesB, limit, func(e domain.LogEntry) string { return e.Category })
+	result.Levels = diffDeltas(entriesA, entriesB, minutesA, minutesB, limit, func(e domain.LogEntry) string { return string(e.Level) })
+
+	return result
+}
+
+// diffPatterns groups entries by normalized message
+func (a *Analyzer) diffPatterns(entries []domain.LogEntry) map[string]*DiffPattern {
+	patterns := make(map[string]*DiffPattern)
+	for _, entry := range entries {
+		normalized := a.normalizeMessage(entry.Message)
+		p, ok := patterns[normalized]
+		if !ok {
+			p = &DiffPattern{Pattern: normalized, Level: entry.Level, Sample: entry.Message}
+			patterns[normalized] = p
+		}
+		p.Count++
+		if entry.Level.Priority() > p.Level.Priority() {
+			p.Level = entry.Level
+		}
+	}
+	return patterns
+}
+
+// onlyIn returns patterns present in from but absent from other, most frequent first
+func onlyIn(from, other map[string]*DiffPattern) []DiffPattern {
+	result := make([]DiffPattern, 0)
+	for key, p := range from {
+		if _, ok := other[key]; !ok {
+			result = append(result, *p)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Pattern < result[j].Pattern
+	})
+	return result
+}
+
+// diffDeltas counts entries per key on both sides, largest absolute change first
+func diffDeltas(entriesA, entriesB []domain.LogEntry, minutesA, minutesB float64, limit int, keyFn func(domain.LogEntry) string) []DiffDelta {
+	counts := make(map[string]*DiffDelta)
+	get := func(e domain.LogEntry) *DiffDelta {
+		key := keyFn(e)
+		if key == "" {
+			key = diffNoneKey
+		}
+		d, ok := counts[key]
+		if !ok {
+			d = &DiffDelta{Key: key}
+			counts[key] = d
+		}
+		return d
+	}
+	for _, e := range entriesA {
+		get(e).CountA++
+	}
+	for _, e := range entriesB {
+		get(e).CountB++
+	}
+
+	deltas := make([]DiffDelta, 0, len(counts))
+	for _, d := range counts {
+		d.Delta = d.CountB - d.CountA
+		if minutesA > 0 {
+			d.RateA = float64(d.CountA) / minutesA
+		}
+		if minutesB > 0 {
+			d.RateB = float64(d.CountB) / minutesB
+		}
+		if d.RateA > 0 {
+			d.RateDeltaPct = (d.RateB - d.RateA) / d.RateA * 100
+		}
+		deltas = append(deltas, *d)
+	}
+	sort.Slice(deltas, func(i, j int) bool {
+		di, dj := abs(deltas[i].Delta), abs(deltas[j].Delta)
+		if di != dj {
+			return di > dj
+		}
+		return deltas[i].Key < deltas[j].Key
+	})
+	if len(deltas) > limit {
+		deltas = deltas[:limit]
+	}
+	return deltas
+}
+
+// entriesDuration returns the span between the earliest and latest entry
+func entriesDuration(entries []domain.LogEntry) time.Duration {
+	if len(entries) == 0 {
+		return 0
+	}
+	start, end := entries[0].Timestamp, entries[0].Timestamp
+	for _, e := range entries[1:] {
+		if e.Timestamp.Before(start) {
+			start = e.Timestamp
+		}
+		if e.Timestamp.After(end) {
+			end = e.Timestamp
+		}
+	}
+	return end.Sub(start)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
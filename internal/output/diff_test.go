@@ -0,0 +1,76 @@
+package output
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vburojevic/xcw/internal/domain"
+)
+
+func TestAnalyzer_Diff(t *testing.T) {
+	a := NewAnalyzer()
+	base := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	entry := func(offset time.Duration, level domain.LogLevel, subsystem, msg string) domain.LogEntry {
+		return domain.LogEntry{Timestamp: base.Add(offset), Level: level, Subsystem: subsystem, Message: msg}
+	}
+
+	entriesA := []domain.LogEntry{
+		entry(0, domain.LogLevelInfo, "com.example.net", "Request 1 started"),
+		entry(30*time.Second, domain.LogLevelInfo, "com.example.net", "Request 2 started"),
+		entry(time.Minute, domain.LogLevelDebug, "", "Cache warmed in 12ms"),
+	}
+	entriesB := []domain.LogEntry{
+		entry(0, domain.LogLevelInfo, "com.example.net", "Request 7 started"),
+		entry(20*time.Second, domain.LogLevelError, "com.example.net", "Request 7 timed out"),
+		entry(40*time.Second, domain.LogLevelError, "com.example.net", "Request 8 timed out"),
+		entry(time.Minute, domain.LogLevelFault, "com.example.net", "Request 9 timed out"),
+	}
+
+	result := a.Diff(DiffSide{Label: "a.ndjson"}, entriesA, DiffSide{Label: "b.ndjson"}, entriesB, 0)
+
+	assert.Equal(t, "diff_result", result.Type)
+	assert.Equal(t, 3, result.A.Total)
+	assert.Equal(t, 2, result.A.Patterns)
+	assert.Equal(t, 60.0, result.B.DurationSeconds)
+
+	t.Run("reports patterns only on one side", func(t *testing.T) {
+		require.Len(t, result.OnlyInA, 1)
+		assert.Equal(t, "Cache warmed in <n>ms", result.OnlyInA[0].Pattern)
+		require.Len(t, result.OnlyInB, 1)
+		assert.Equal(t, "Request <n> timed out", result.OnlyInB[0].Pattern)
+		assert.Equal(t, 3, result.OnlyInB[0].Count)
+		assert.Equal(t, domain.LogLevelFault, result.OnlyInB[0].Level)
+		assert.Equal(t, "Request 7 timed out", result.OnlyInB[0].Sample)
+	})
+
+	t.Run("computes count and rate deltas", func(t *testing.T) {
+		require.NotEmpty(t, result.Levels)
+		assert.Equal(t, "Error", result.Levels[0].Key)
+		assert.Equal(t, 2, result.Levels[0].Delta)
+
+		var net, none *DiffDelta
+		for i := range result.Subsystems {
+			switch result.Subsystems[i].Key {
+			case "com.example.net":
+				net = &result.Subsystems[i]
+			case "(none)":
+				none = &result.Subsystems[i]
+			}
+		}
+		require.NotNil(t, net)
+		require.NotNil(t, none)
+		assert.Equal(t, 2, net.CountA)
+		assert.Equal(t, 4, net.CountB)
+		assert.InDelta(t, 100.0, net.RateDeltaPct, 0.001)
+		assert.Equal(t, -1, none.Delta)
+	})
+
+	t.Run("limits lists", func(t *testing.T) {
+		limited := a.Diff(DiffSide{}, entriesA, DiffSide{}, entriesB, 1)
+		assert.Len(t, limited.Levels, 1)
+		assert.Equal(t, 1, limited.OnlyInBCount)
+	})
+}
@@ -47,3 +47,7 @@ func (e *Emitter) WriteReconnect(msg, tailID, severity string) error {
 func (e *Emitter) SessionDebug(sd *SessionDebugOutput) error { return e.w.WriteSessionDebug(sd) }
 func (e *Emitter) GapDetected(g *GapDetectedOutput) error    { return e.w.WriteGapDetected(g) }
 func (e *Emitter) GapFilled(g *GapFilledOutput) error        { return e.w.WriteGapFilled(g) }
+func (e *Emitter) CrashDetected(c *domain.CrashDetected) error {
+	return e.w.WriteCrashDetected(c)
+}
+func (e *Emitter) Anomaly(a *domain.Anomaly) error { return e.w.WriteAnomaly(a) }
@@ -26,17 +26,31 @@ func NewNDJSONWriter(w io.Writer) *NDJSONWriter {
 
 // OutputEntry is the simplified NDJSON output format
 type OutputEntry struct {
-	Type          string `json:"type"`          // Always "log"
+	Type          string `json:"type"`          // "log", or "log_group" for coalesced multi-line messages
 	SchemaVersion int    `json:"schemaVersion"` // Schema version for compatibility
 	Timestamp     string `json:"timestamp"`
 	Level         string `json:"level"`
 	Process       string `json:"process"`
 	PID           int    `json:"pid"`
+	TID           int    `json:"tid,omitempty"`
 	Subsystem     string `json:"subsystem,omitempty"`
 	Category      string `json:"category,omitempty"`
 	Message       string `json:"message"`
-	Session       int    `json:"session,omitempty"` // Session number (1, 2, 3...)
-	TailID        string `json:"tail_id,omitempty"` // Tail invocation ID
+
+	ActivityID       uint64 `json:"activity_id,omitempty"`        // os_activity identifier
+	ParentActivityID uint64 `json:"parent_activity_id,omitempty"` // Parent os_activity identifier
+	OSTraceID        uint64 `json:"os_trace_id,omitempty"`        // Unified logging trace identifier
+	TraceID          string `json:"trace_id,omitempty"`           // Distributed trace ID (--extract-trace)
+	SpanID           string `json:"span_id,omitempty"`            // Span ID (--extract-trace)
+	ParentSpanID     string `json:"parent_span_id,omitempty"`     // Parent span ID (--extract-trace)
+
+	Lines   []string          `json:"lines,omitempty"`   // Original messages of a log_group (--group)
+	Fields  map[string]string `json:"fields,omitempty"`  // Extracted key/value pairs (--extract)
+	Session int               `json:"session,omitempty"` // Session number (1, 2, 3...)
+	TailID  string            `json:"tail_id,omitempty"` // Tail invocation ID
+
+	UDID      string `json:"udid,omitempty"`      // Source simulator UDID (multi-device tail)
+	Simulator string `json:"simulator,omitempty"` // Source simulator name (multi-device tail)
 }
 
 // Heartbeat is a keepalive message for AI agents
@@ -112,6 +126,7 @@ type RotationOutput struct {
 	Path          string `json:"path"`
 	TailID        string `json:"tail_id,omitempty"`
 	Session       int    `json:"session,omitempty"`
+	Part          int    `json:"part,omitempty"` // Part number when a session is split by size/age (2, 3, ...)
 }
 
 // ReconnectNotice signals a stream reconnect
@@ -209,12 +224,27 @@ type TriggerResultOutput struct {
 	Trigger       string `json:"trigger,omitempty"`
 	Command       string `json:"command"`
 	ExitCode      int    `json:"exit_code"`
+	StatusCode    int    `json:"status_code,omitempty"` // HTTP status for webhook triggers
 	DurationMs    int64  `json:"duration_ms"`
 	TimedOut      bool   `json:"timed_out,omitempty"`
 	Output        string `json:"output,omitempty"`
 	Error         string `json:"error,omitempty"`
 }
 
+// MarkerOutput is emitted by the marker action of a watch rule
+type MarkerOutput struct {
+	Type          string      `json:"type"` // Always "marker"
+	SchemaVersion int         `json:"schemaVersion"`
+	Timestamp     string      `json:"timestamp"`
+	TailID        string      `json:"tail_id,omitempty"`
+	Session       int         `json:"session,omitempty"`
+	Rule          string      `json:"rule"`
+	Message       string      `json:"message"`
+	Matches       int         `json:"matches"`
+	Window        string      `json:"window,omitempty"`
+	Entry         OutputEntry `json:"entry"` // Entry that fired the rule
+}
+
 // ClearBufferOutput instructs consumers to discard cached state at session boundaries
 type ClearBufferOutput struct {
 	Type          string   `json:"type"` // Always "clear_buffer"
@@ -251,20 +281,39 @@ type AgentHintsOutput struct {
 
 // Write outputs a single log entry as NDJSON
 func (w *NDJSONWriter) Write(entry *domain.LogEntry) error {
+	return w.encoder.Encode(NewOutputEntry(entry))
+}
+
+// NewOutputEntry converts a log entry to its NDJSON form
+func NewOutputEntry(entry *domain.LogEntry) OutputEntry {
 	out := OutputEntry{
-		Type:          "log",
-		SchemaVersion: SchemaVersion,
-		Timestamp:     entry.Timestamp.Format(time.RFC3339Nano),
-		Level:         string(entry.Level),
-		Process:       entry.Process,
-		PID:           entry.PID,
-		Subsystem:     entry.Subsystem,
-		Category:      entry.Category,
-		Message:       entry.Message,
-		Session:       entry.Session,
-		TailID:        entry.TailID,
+		Type:             "log",
+		SchemaVersion:    SchemaVersion,
+		Timestamp:        entry.Timestamp.Format(time.RFC3339Nano),
+		Level:            string(entry.Level),
+		Process:          entry.Process,
+		PID:              entry.PID,
+		TID:              entry.TID,
+		Subsystem:        entry.Subsystem,
+		Category:         entry.Category,
+		Message:          entry.Message,
+		ActivityID:       entry.ActivityID,
+		ParentActivityID: entry.ParentActivityID,
+		OSTraceID:        entry.OSTraceID,
+		TraceID:          entry.TraceID,
+		SpanID:           entry.SpanID,
+		ParentSpanID:     entry.ParentSpanID,
+		Lines:            entry.Lines,
+		Fields:           entry.Fields,
+		Session:          entry.Session,
+		TailID:           entry.TailID,
+		UDID:             entry.UDID,
+		Simulator:        entry.Simulator,
 	}
-	return w.encoder.Encode(out)
+	if len(entry.Lines) > 0 {
+		out.Type = "log_group"
+	}
+	return out
 }
 
 // WriteSessionStart outputs a session start event
@@ -289,6 +338,50 @@ func (w *NDJSONWriter) WriteSessionEnd(session *domain.SessionEnd) error {
 	return w.encoder.Encode(session)
 }
 
+// WriteCrashDetected outputs a crash_detected event
+func (w *NDJSONWriter) WriteCrashDetected(crash *domain.CrashDetected) error {
+	if crash.Type == "" {
+		crash.Type = "crash_detected"
+	}
+	if crash.SchemaVersion == 0 {
+		crash.SchemaVersion = SchemaVersion
+	}
+	return w.encoder.Encode(crash)
+}
+
+// WriteAnomaly outputs an anomaly event
+func (w *NDJSONWriter) WriteAnomaly(a *domain.Anomaly) error {
+	if a.Type == "" {
+		a.Type = "anomaly"
+	}
+	if a.SchemaVersion == 0 {
+		a.SchemaVersion = SchemaVersion
+	}
+	return w.encoder.Encode(a)
+}
+
+// WriteExpectationFailed outputs an expectation_failed event from xcw expect
+func (w *NDJSONWriter) WriteExpectationFailed(f *domain.ExpectationFailed) error {
+	if f.Type == "" {
+		f.Type = "expectation_failed"
+	}
+	if f.SchemaVersion == 0 {
+		f.SchemaVersion = SchemaVersion
+	}
+	return w.encoder.Encode(f)
+}
+
+// WriteExpectResult outputs the final expect_result event from xcw expect
+func (w *NDJSONWriter) WriteExpectResult(r *domain.ExpectResult) error {
+	if r.Type == "" {
+		r.Type = "expect_result"
+	}
+	if r.SchemaVersion == 0 {
+		r.SchemaVersion = SchemaVersion
+	}
+	return w.encoder.Encode(r)
+}
+
 // WriteSummary outputs a summary marker
 func (w *NDJSONWriter) WriteSummary(summary *domain.LogSummary) error {
 	summary.SchemaVersion = SchemaVersion
@@ -391,6 +484,18 @@ func (w *NDJSONWriter) WriteRotation(path, tailID string, session int) error {
 	})
 }
 
+// WriteRotationPart outputs a rotation notice for a new part of a session file
+func (w *NDJSONWriter) WriteRotationPart(path, tailID string, session, part int) error {
+	return w.encoder.Encode(&RotationOutput{
+		Type:          "rotation",
+		SchemaVersion: SchemaVersion,
+		Path:          path,
+		TailID:        tailID,
+		Session:       session,
+		Part:          part,
+	})
+}
+
 // WriteReconnect outputs a reconnect notice
 func (w *NDJSONWriter) WriteReconnect(message, tailID, severity string) error {
 	return w.encoder.Encode(&ReconnectNotice{
@@ -473,6 +578,17 @@ func (w *NDJSONWriter) WriteTriggerResult(t *TriggerResultOutput) error {
 	return w.encoder.Encode(t)
 }
 
+// WriteMarker outputs a rule marker event.
+func (w *NDJSONWriter) WriteMarker(m *MarkerOutput) error {
+	if m.Type == "" {
+		m.Type = "marker"
+	}
+	if m.SchemaVersion == 0 {
+		m.SchemaVersion = SchemaVersion
+	}
+	return w.encoder.Encode(m)
+}
+
 // WriteReady outputs a ready signal indicating log capture is active
 func (w *NDJSONWriter) WriteReady(timestamp, simulator, udid, app, tailID string, session int) error {
 	return w.encoder.Encode(&ReadyOutput{
@@ -532,6 +648,9 @@ func (w *TextWriter) Write(entry *domain.LogEntry) error {
 	process := Styles.Process.Render("[" + entry.Process + "]")
 
 	line := timestamp + " " + levelIndicator + " " + process + " "
+	if entry.Simulator != "" {
+		line = "[" + entry.Simulator + "] " + line
+	}
 	if entry.Subsystem != "" {
 		subsystem := Styles.Subsystem.Render(entry.Subsystem)
 		if entry.Category != "" {
@@ -540,9 +659,16 @@ func (w *TextWriter) Write(entry *domain.LogEntry) error {
 		line += subsystem + ": "
 	}
 
-	// Style message based on level
+	// Style message based on level; indent continuation lines of a group
 	msgStyle := LevelStyle(levelStr)
-	line += msgStyle.Render(entry.Message) + "\n"
+	if len(entry.Lines) > 0 {
+		line += msgStyle.Render(entry.Lines[0]) + "\n"
+		for _, l := range entry.Lines[1:] {
+			line += "    " + msgStyle.Render(l) + "\n"
+		}
+	} else {
+		line += msgStyle.Render(entry.Message) + "\n"
+	}
 
 	_, err := io.WriteString(w.w, line)
 	return err
@@ -3,9 +3,12 @@ package output
 import (
 	"bytes"
 	"encoding/json"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
+	"github.com/vburojevic/xcw/internal/domain"
 )
 
 func decodeLine(t *testing.T, buf *bytes.Buffer) map[string]interface{} {
@@ -79,3 +82,47 @@ func TestHeartbeatContractFields(t *testing.T) {
 	require.EqualValues(t, 4, m["latest_session"])
 	require.Equal(t, "tail-1", m["tail_id"])
 }
+
+func TestWriteDeviceTags(t *testing.T) {
+	entry := &domain.LogEntry{
+		Timestamp: time.Date(2025, 12, 11, 10, 0, 0, 0, time.UTC),
+		Level:     domain.LogLevelInfo,
+		Process:   "MyApp",
+		Message:   "hello",
+		UDID:      "UDID-A",
+		Simulator: "iPhone A",
+	}
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, NewNDJSONWriter(buf).Write(entry))
+	m := decodeLine(t, buf)
+	require.Equal(t, "UDID-A", m["udid"])
+	require.Equal(t, "iPhone A", m["simulator"])
+
+	buf.Reset()
+	require.NoError(t, NewTextWriter(buf).Write(entry))
+	require.True(t, strings.HasPrefix(buf.String(), "[iPhone A] "), buf.String())
+}
+
+func TestWriteLogGroup(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := NewNDJSONWriter(buf)
+
+	err := w.Write(&domain.LogEntry{
+		Timestamp: time.Date(2025, 12, 11, 10, 0, 0, 0, time.UTC),
+		Level:     domain.LogLevelError,
+		Process:   "MyApp",
+		PID:       42,
+		TID:       7,
+		Message:   "*** Terminating app\n0 CoreFoundation",
+		Lines:     []string{"*** Terminating app", "0 CoreFoundation"},
+	})
+	require.NoError(t, err)
+
+	m := decodeLine(t, buf)
+	require.Equal(t, "log_group", m["type"])
+	require.EqualValues(t, 7, m["tid"])
+	lines, ok := m["lines"].([]interface{})
+	require.True(t, ok)
+	require.Len(t, lines, 2)
+}
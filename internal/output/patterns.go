@@ -4,29 +4,100 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 )
 
+// maxPatternRuns caps the per-run history kept in the pattern file
+const maxPatternRuns = 100
+
 // PatternStore handles persistence of known error patterns
 type PatternStore struct {
 	mu       sync.RWMutex
 	path     string
 	patterns map[string]*StoredPattern
+	runs     []*PatternRun
 }
 
 // StoredPattern represents a persisted error pattern
 type StoredPattern struct {
-	Pattern    string    `json:"pattern"`
-	FirstSeen  time.Time `json:"first_seen"`
-	LastSeen   time.Time `json:"last_seen"`
-	TotalCount int       `json:"total_count"`
+	Pattern      string    `json:"pattern"`
+	FirstSeen    time.Time `json:"first_seen"`
+	LastSeen     time.Time `json:"last_seen"`
+	TotalCount   int       `json:"total_count"`
+	FirstVersion string    `json:"first_version,omitempty"`
+	FirstBuild   string    `json:"first_build,omitempty"`
+	TemplateID   string    `json:"template_id,omitempty"`
+	// Apps holds the version/build each app first logged the pattern in;
+	// FirstVersion/FirstBuild are the origin across all apps
+	Apps map[string]PatternOrigin `json:"apps,omitempty"`
+}
+
+// PatternOrigin is the app version/build a pattern was first seen in
+type PatternOrigin struct {
+	Version string `json:"version,omitempty"`
+	Build   string `json:"build,omitempty"`
+}
+
+// origin returns where app first logged the pattern, and whether it has
+func (p *StoredPattern) origin(app string) (PatternOrigin, bool) {
+	if app == "" {
+		// Patterns from stores without app keys carry only the legacy origin
+		if o, ok := p.Apps[""]; ok || len(p.Apps) > 0 {
+			return o, ok
+		}
+		return PatternOrigin{Version: p.FirstVersion, Build: p.FirstBuild}, true
+	}
+	o, ok := p.Apps[app]
+	return o, ok
+}
+
+// PatternRun records the patterns seen in one analysis run
+type PatternRun struct {
+	At       time.Time      `json:"at"`
+	App      string         `json:"app,omitempty"`
+	Version  string         `json:"version,omitempty"`
+	Build    string         `json:"build,omitempty"`
+	Entries  int            `json:"entries"`
+	Patterns map[string]int `json:"patterns,omitempty"`
+}
+
+// RunMeta identifies the app build an analysis run belongs to. Runs are
+// only compared with earlier runs of the same App.
+type RunMeta struct {
+	App     string
+	Version string
+	Build   string
+}
+
+// TrendOptions controls regression detection in RecordRun
+type TrendOptions struct {
+	CleanRuns       int     // Runs without a pattern before its return is flagged (default 3)
+	RateIncreasePct float64 // Rate increase over baseline that is flagged, in percent (default 50)
+	Lookback        int     // Previous runs used for the baseline rate (default 5)
+}
+
+func (o TrendOptions) withDefaults() TrendOptions {
+	if o.CleanRuns <= 0 {
+		o.CleanRuns = 3
+	}
+	if o.RateIncreasePct <= 0 {
+		o.RateIncreasePct = 50
+	}
+	if o.Lookback <= 0 {
+		o.Lookback = 5
+	}
+	return o
 }
 
 // patternsFile is the structure stored on disk
 type patternsFile struct {
 	Version  int                       `json:"version"`
 	Patterns map[string]*StoredPattern `json:"patterns"`
+	Runs     []*PatternRun             `json:"runs,omitempty"`
 }
 
 // NewPatternStore creates a new pattern store
@@ -75,6 +146,7 @@ func (s *PatternStore) Load() error {
 	if s.patterns == nil {
 		s.patterns = make(map[string]*StoredPattern)
 	}
+	s.runs = file.Runs
 
 	return nil
 }
@@ -93,6 +165,7 @@ func (s *PatternStore) Save() error {
 	file := patternsFile{
 		Version:  1,
 		Patterns: s.patterns,
+		Runs:     s.runs,
 	}
 
 	data, err := json.MarshalIndent(file, "", "  ")
@@ -159,11 +232,19 @@ func (s *PatternStore) Count() int {
 	return len(s.patterns)
 }
 
-// Clear removes all stored patterns
+// Runs returns the recorded run history (oldest first)
+func (s *PatternStore) Runs() []*PatternRun {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]*PatternRun(nil), s.runs...)
+}
+
+// Clear removes all stored patterns and run history
 func (s *PatternStore) Clear() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.patterns = make(map[string]*StoredPattern)
+	s.runs = nil
 }
 
 // EnhancedPatternMatch extends PatternMatch with knowledge status
@@ -172,6 +253,20 @@ type EnhancedPatternMatch struct {
 	IsNew      bool       `json:"is_new"`
 	FirstSeen  *time.Time `json:"first_seen,omitempty"`
 	TotalCount int        `json:"total_count,omitempty"`
+
+	// Trend fields (populated by RecordRun)
+	NewInBuild    bool    `json:"new_in_build,omitempty"`    // First seen in the current app version/build (needs runs of another build)
+	Returned      bool    `json:"returned,omitempty"`        // Came back after CleanRuns runs without it
+	CleanRuns     int     `json:"clean_runs,omitempty"`      // Consecutive previous runs without the pattern
+	Rate          float64 `json:"rate_per_1k,omitempty"`     // Occurrences per 1000 analyzed entries
+	BaselineRate  float64 `json:"baseline_per_1k,omitempty"` // Mean rate over recent runs that had the pattern
+	RateChangePct float64 `json:"rate_change_pct,omitempty"` // Change of Rate vs BaselineRate, in percent
+	RateIncreased bool    `json:"rate_increased,omitempty"`  // RateChangePct exceeded the threshold
+}
+
+// IsRegression reports whether the pattern should fail a CI gate
+func (p EnhancedPatternMatch) IsRegression() bool {
+	return p.NewInBuild || p.Returned || p.RateIncreased
 }
 
 // AnnotatePatterns adds known/new status to detected patterns
@@ -179,6 +274,7 @@ func (s *PatternStore) AnnotatePatterns(patterns []PatternMatch) []EnhancedPatte
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
+	keys := s.sortedKeys()
 	result := make([]EnhancedPatternMatch, len(patterns))
 	for i, p := range patterns {
 		enhanced := EnhancedPatternMatch{
@@ -186,7 +282,7 @@ func (s *PatternStore) AnnotatePatterns(patterns []PatternMatch) []EnhancedPatte
 			IsNew:        true,
 		}
 
-		if stored, ok := s.patterns[p.Pattern]; ok {
+		if stored := s.find(p.Pattern, keys); stored != nil {
 			enhanced.IsNew = false
 			enhanced.FirstSeen = &stored.FirstSeen
 			enhanced.TotalCount = stored.TotalCount
@@ -216,3 +312,196 @@ func (s *PatternStore) RecordPatterns(patterns []PatternMatch) []EnhancedPattern
 	}
 	return result
 }
+
+// RecordRun records patterns from one analysis run of entries log entries,
+// appends the run to the history and returns the patterns annotated with
+// new-in-build, returned-after-clean-runs and rate-increase status.
+func (s *PatternStore) RecordRun(meta RunMeta, entries int, patterns []PatternMatch, opts TrendOptions) []EnhancedPatternMatch {
+	opts = opts.withDefaults()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	history := s.appRuns(meta.App)
+	// "New in build" needs history from some other build to compare against
+	hasBaseline := false
+	if meta.Version != "" || meta.Build != "" {
+		for _, r := range history {
+			if r.Version != meta.Version || r.Build != meta.Build {
+				hasBaseline = true
+				break
+			}
+		}
+	}
+	run := &PatternRun{At: now, App: meta.App, Version: meta.Version, Build: meta.Build, Entries: entries, Patterns: make(map[string]int, len(patterns))}
+
+	keys := s.sortedKeys()
+	result := make([]EnhancedPatternMatch, len(patterns))
+	for i, p := range patterns {
+		stored := s.find(p.Pattern, keys)
+		known := stored != nil
+		var origin PatternOrigin
+		knownForApp := false
+		if known {
+			// Keep the more general template as the key
+			if strings.Count(p.Pattern, templateWildcard) > strings.Count(stored.Pattern, templateWildcard) {
+				keys = removeSorted(keys, stored.Pattern)
+				keys = insertSorted(keys, p.Pattern)
+				s.rename(stored.Pattern, p.Pattern)
+			}
+			stored.LastSeen = now
+			stored.TotalCount += p.Count
+			stored.TemplateID = p.TemplateID
+			origin, knownForApp = stored.origin(meta.App)
+		} else {
+			stored = &StoredPattern{
+				Pattern:      p.Pattern,
+				FirstSeen:    now,
+				LastSeen:     now,
+				TotalCount:   p.Count,
+				FirstVersion: meta.Version,
+				FirstBuild:   meta.Build,
+				TemplateID:   p.TemplateID,
+			}
+			s.patterns[p.Pattern] = stored
+			keys = insertSorted(keys, p.Pattern)
+		}
+		if !knownForApp {
+			origin = PatternOrigin{Version: meta.Version, Build: meta.Build}
+			if meta.App != "" || len(stored.Apps) > 0 {
+				if stored.Apps == nil {
+					stored.Apps = make(map[string]PatternOrigin)
+					if known {
+						// Keep the app-less origin from before apps were recorded
+						stored.Apps[""] = PatternOrigin{Version: stored.FirstVersion, Build: stored.FirstBuild}
+					}
+				}
+				stored.Apps[meta.App] = origin
+			}
+		}
+		key := stored.Pattern
+		firstSeen := stored.FirstSeen
+
+		enhanced := EnhancedPatternMatch{
+			PatternMatch: p,
+			IsNew:        !known,
+			FirstSeen:    &firstSeen,
+			TotalCount:   stored.TotalCount,
+			NewInBuild:   hasBaseline && origin.Version == meta.Version && origin.Build == meta.Build,
+		}
+
+		// Consecutive most recent runs of this app without this pattern
+		for j := len(history) - 1; j >= 0 && history[j].Patterns[key] == 0; j-- {
+			enhanced.CleanRuns++
+		}
+		enhanced.Returned = knownForApp && enhanced.CleanRuns >= opts.CleanRuns
+
+		if entries > 0 {
+			enhanced.Rate = patternRate(p.Count, entries)
+			enhanced.BaselineRate = baselineRate(history, key, opts.Lookback)
+			if enhanced.BaselineRate > 0 {
+				enhanced.RateChangePct = (enhanced.Rate - enhanced.BaselineRate) / enhanced.BaselineRate * 100
+				enhanced.RateIncreased = enhanced.RateChangePct > opts.RateIncreasePct
+			}
+		}
+
+		run.Patterns[key] += p.Count
+		result[i] = enhanced
+	}
+
+	s.runs = append(s.runs, run)
+	if len(s.runs) > maxPatternRuns {
+		s.runs = s.runs[len(s.runs)-maxPatternRuns:]
+	}
+	return result
+}
+
+// sortedKeys returns the stored patterns in order, for find. Caller must
+// hold s.mu.
+func (s *PatternStore) sortedKeys() []string {
+	keys := make([]string, 0, len(s.patterns))
+	for k := range s.patterns {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func insertSorted(keys []string, k string) []string {
+	if i, found := slices.BinarySearch(keys, k); !found {
+		keys = slices.Insert(keys, i, k)
+	}
+	return keys
+}
+
+func removeSorted(keys []string, k string) []string {
+	if i, found := slices.BinarySearch(keys, k); found {
+		keys = slices.Delete(keys, i, i+1)
+	}
+	return keys
+}
+
+// find returns the stored pattern for pattern: the exact one, or else a
+// known template that pattern generalizes or refines (a token of one became
+// <*> in the other). keys are the stored patterns from sortedKeys, so the
+// first compatible one wins deterministically. Caller must hold s.mu.
+func (s *PatternStore) find(pattern string, keys []string) *StoredPattern {
+	if stored, ok := s.patterns[pattern]; ok {
+		return stored
+	}
+	for _, k := range keys {
+		if templatesCompatible(k, pattern) {
+			return s.patterns[k]
+		}
+	}
+	return nil
+}
+
+// rename re-keys a stored pattern and its run history after its template
+// changed. Caller must hold s.mu for writing.
+func (s *PatternStore) rename(from, to string) {
+	stored := s.patterns[from]
+	delete(s.patterns, from)
+	stored.Pattern = to
+	s.patterns[to] = stored
+	for _, r := range s.runs {
+		if c, ok := r.Patterns[from]; ok {
+			delete(r.Patterns, from)
+			r.Patterns[to] += c
+		}
+	}
+}
+
+// appRuns returns the run history recorded for app, oldest first. Caller
+// must hold s.mu.
+func (s *PatternStore) appRuns(app string) []*PatternRun {
+	var runs []*PatternRun
+	for _, r := range s.runs {
+		if r.App == app {
+			runs = append(runs, r)
+		}
+	}
+	return runs
+}
+
+// baselineRate averages the pattern rate over the last lookback runs that had it.
+func baselineRate(runs []*PatternRun, pattern string, lookback int) float64 {
+	var sum float64
+	n := 0
+	for j := len(runs) - 1; j >= 0 && n < lookback; j-- {
+		r := runs[j]
+		if c := r.Patterns[pattern]; c > 0 && r.Entries > 0 {
+			sum += patternRate(c, r.Entries)
+			n++
+		}
+	}
+	if n == 0 {
+		return 0
+	}
+	return sum / float64(n)
+}
+
+func patternRate(count, entries int) float64 {
+	return float64(count) * 1000 / float64(entries)
+}
@@ -200,6 +200,104 @@ func TestPatternStore_RecordPatterns(t *testing.T) {
 	assert.True(t, store.IsKnown("new error"))
 }
 
+func TestPatternStore_RecordRun(t *testing.T) {
+	store := NewPatternStore(filepath.Join(t.TempDir(), "patterns.json"))
+	opts := TrendOptions{CleanRuns: 2, RateIncreasePct: 50}
+	v1 := RunMeta{Version: "1.0", Build: "100"}
+	v2 := RunMeta{Version: "1.1", Build: "101"}
+
+	t.Run("first build has no baseline", func(t *testing.T) {
+		enhanced := store.RecordRun(v1, 1000, []PatternMatch{{Pattern: "timeout", Count: 10}}, opts)
+		require.Len(t, enhanced, 1)
+		assert.True(t, enhanced[0].IsNew)
+		assert.False(t, enhanced[0].NewInBuild)
+		assert.InDelta(t, 10.0, enhanced[0].Rate, 0.001)
+		assert.False(t, enhanced[0].Returned)
+	})
+
+	t.Run("clean runs then return", func(t *testing.T) {
+		store.RecordRun(v1, 1000, nil, opts)
+		store.RecordRun(v1, 1000, nil, opts)
+		enhanced := store.RecordRun(v2, 1000, []PatternMatch{{Pattern: "timeout", Count: 10}, {Pattern: "crash", Count: 2}}, opts)
+		require.Len(t, enhanced, 2)
+
+		assert.False(t, enhanced[0].NewInBuild)
+		assert.True(t, enhanced[0].Returned)
+		assert.Equal(t, 2, enhanced[0].CleanRuns)
+		assert.False(t, enhanced[0].RateIncreased)
+
+		assert.True(t, enhanced[1].NewInBuild)
+		assert.False(t, enhanced[1].Returned)
+	})
+
+	t.Run("rate increase over baseline", func(t *testing.T) {
+		enhanced := store.RecordRun(v2, 1000, []PatternMatch{{Pattern: "timeout", Count: 25}}, opts)
+		require.Len(t, enhanced, 1)
+		assert.False(t, enhanced[0].Returned)
+		assert.InDelta(t, 10.0, enhanced[0].BaselineRate, 0.001)
+		assert.InDelta(t, 150.0, enhanced[0].RateChangePct, 0.001)
+		assert.True(t, enhanced[0].RateIncreased)
+		assert.True(t, enhanced[0].IsRegression())
+	})
+
+	t.Run("history persists", func(t *testing.T) {
+		require.NoError(t, store.Save())
+		reloaded := NewPatternStore(store.path)
+		runs := reloaded.Runs()
+		require.Len(t, runs, 5)
+		assert.Equal(t, "101", runs[4].Build)
+		assert.Equal(t, 25, runs[4].Patterns["timeout"])
+		assert.Equal(t, "100", reloaded.GetPattern("timeout").FirstBuild)
+	})
+}
+
+func TestPatternStore_RecordRunPerApp(t *testing.T) {
+	store := NewPatternStore(filepath.Join(t.TempDir(), "patterns.json"))
+	opts := TrendOptions{CleanRuns: 2, RateIncreasePct: 50}
+	a1 := RunMeta{App: "com.example.a", Version: "1.0", Build: "100"}
+	a2 := RunMeta{App: "com.example.a", Version: "1.1", Build: "101"}
+	b1 := RunMeta{App: "com.example.b", Version: "5.0", Build: "500"}
+
+	store.RecordRun(a1, 1000, []PatternMatch{{Pattern: "timeout", Count: 10}}, opts)
+	store.RecordRun(b1, 1000, nil, opts)
+	store.RecordRun(b1, 1000, nil, opts)
+
+	t.Run("other app's clean runs do not make a pattern return", func(t *testing.T) {
+		enhanced := store.RecordRun(a2, 1000, []PatternMatch{{Pattern: "timeout", Count: 10}}, opts)
+		require.Len(t, enhanced, 1)
+		assert.False(t, enhanced[0].Returned)
+		assert.Equal(t, 0, enhanced[0].CleanRuns)
+		assert.False(t, enhanced[0].NewInBuild)
+	})
+
+	t.Run("pattern known only from another app has no history here", func(t *testing.T) {
+		enhanced := store.RecordRun(b1, 1000, []PatternMatch{{Pattern: "timeout", Count: 50}}, opts)
+		require.Len(t, enhanced, 1)
+		assert.False(t, enhanced[0].IsNew)
+		assert.False(t, enhanced[0].Returned)
+		assert.False(t, enhanced[0].NewInBuild, "app b has no other build to compare with")
+		assert.Zero(t, enhanced[0].BaselineRate)
+		assert.False(t, enhanced[0].RateIncreased)
+	})
+
+	t.Run("baseline rate uses this app's runs only", func(t *testing.T) {
+		enhanced := store.RecordRun(a2, 1000, []PatternMatch{{Pattern: "timeout", Count: 12}}, opts)
+		require.Len(t, enhanced, 1)
+		assert.InDelta(t, 10.0, enhanced[0].BaselineRate, 0.001)
+		assert.False(t, enhanced[0].RateIncreased)
+	})
+
+	t.Run("origins persist per app", func(t *testing.T) {
+		require.NoError(t, store.Save())
+		reloaded := NewPatternStore(store.path)
+		stored := reloaded.GetPattern("timeout")
+		require.NotNil(t, stored)
+		assert.Equal(t, PatternOrigin{Version: "1.0", Build: "100"}, stored.Apps["com.example.a"])
+		assert.Equal(t, PatternOrigin{Version: "5.0", Build: "500"}, stored.Apps["com.example.b"])
+		assert.Equal(t, "com.example.b", reloaded.Runs()[1].App)
+	})
+}
+
 func TestPatternStore_Concurrency(t *testing.T) {
 	store := NewPatternStore("")
 	store.Clear()
@@ -256,3 +354,21 @@ func TestEnhancedPatternMatch(t *testing.T) {
 	assert.False(t, decoded.IsNew)
 	assert.Equal(t, 15, decoded.TotalCount)
 }
+
+func TestPatternStore_GeneralizedTemplates(t *testing.T) {
+	store := NewPatternStore(filepath.Join(t.TempDir(), "patterns.json"))
+	opts := TrendOptions{CleanRuns: 2, RateIncreasePct: 50}
+
+	store.RecordRun(RunMeta{}, 100, []PatternMatch{{Pattern: "sync failed for inbox", Count: 4, TemplateID: "a"}}, opts)
+	enhanced := store.RecordRun(RunMeta{}, 100, []PatternMatch{{Pattern: "sync failed for <*>", Count: 6, TemplateID: "b"}}, opts)
+	require.Len(t, enhanced, 1)
+	assert.False(t, enhanced[0].IsNew)
+	assert.Equal(t, 10, enhanced[0].TotalCount)
+	assert.InDelta(t, 40.0, enhanced[0].BaselineRate, 0.001)
+
+	assert.Nil(t, store.GetPattern("sync failed for inbox"))
+	stored := store.GetPattern("sync failed for <*>")
+	require.NotNil(t, stored)
+	assert.Equal(t, "b", stored.TemplateID)
+	assert.Equal(t, 4, store.Runs()[0].Patterns["sync failed for <*>"])
+}
@@ -0,0 +1,316 @@
+package output
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/vburojevic/xcw/internal/domain"
+)
+
+// Report formats for --report
+const (
+	ReportJUnit = "junit"
+	ReportSARIF = "sarif"
+)
+
+// ReportFormats lists the supported --report values
+var ReportFormats = []string{ReportJUnit, ReportSARIF}
+
+// EnhancePatterns wraps plain patterns so reports can be built the same way
+// with and without --persist-patterns.
+func EnhancePatterns(patterns []PatternMatch) []EnhancedPatternMatch {
+	result := make([]EnhancedPatternMatch, len(patterns))
+	for i, p := range patterns {
+		result[i] = EnhancedPatternMatch{PatternMatch: p}
+	}
+	return result
+}
+
+// WriteReport writes an analysis as a CI report in the given format.
+// suite names the JUnit test suite; toolVersion is recorded in SARIF.
+func WriteReport(w io.Writer, format string, analysis *EnhancedSummaryOutput, suite, toolVersion string) error {
+	switch format {
+	case ReportJUnit:
+		return WriteJUnit(w, analysis, suite)
+	case ReportSARIF:
+		return WriteSARIF(w, analysis, toolVersion)
+	default:
+		return fmt.Errorf("unknown report format %q (use %s)", format, strings.Join(ReportFormats, " or "))
+	}
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Errors     int             `xml:"errors,attr"`
+	Skipped    int             `xml:"skipped,attr"`
+	Timestamp  string          `xml:"timestamp,attr,omitempty"`
+	Time       string          `xml:"time,attr"`
+	Properties []junitProperty `xml:"properties>property,omitempty"`
+	Cases      []junitTestCase `xml:"testcase"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Body    string `xml:",chardata"`
+}
+
+// WriteJUnit writes the analysis as JUnit XML: every error/fault pattern is a
+// failing test case. A passing placeholder case keeps clean runs non-empty.
+func WriteJUnit(w io.Writer, analysis *EnhancedSummaryOutput, suite string) error {
+	ts := junitTestSuite{
+		Name:      suite,
+		Timestamp: analysis.Timestamp,
+		Time:      "0",
+	}
+	if s := analysis.Summary; s != nil {
+		if !s.WindowStart.IsZero() && !s.WindowEnd.IsZero() {
+			ts.Time = fmt.Sprintf("%.3f", s.WindowEnd.Sub(s.WindowStart).Seconds())
+		}
+		ts.Properties = append(ts.Properties,
+			junitProperty{Name: "total_logs", Value: fmt.Sprint(s.TotalCount)},
+			junitProperty{Name: "errors", Value: fmt.Sprint(s.ErrorCount)},
+			junitProperty{Name: "faults", Value: fmt.Sprint(s.FaultCount)},
+		)
+	}
+	if analysis.App != "" {
+		ts.Properties = append(ts.Properties, junitProperty{Name: "app", Value: analysis.App})
+	}
+	if analysis.AppVersion != "" {
+		ts.Properties = append(ts.Properties, junitProperty{Name: "app_version", Value: analysis.AppVersion})
+	}
+	if analysis.AppBuild != "" {
+		ts.Properties = append(ts.Properties, junitProperty{Name: "app_build", Value: analysis.AppBuild})
+	}
+
+	for _, p := range analysis.Patterns {
+		ts.Cases = append(ts.Cases, junitTestCase{
+			ClassName: reportClassName(p.PatternMatch),
+			Name:      p.Pattern,
+			Time:      "0",
+			Failure: &junitFailure{
+				Message: fmt.Sprintf("%s (%dx)", p.Pattern, p.Count),
+				Type:    string(p.Level),
+				Body:    junitFailureBody(p),
+			},
+		})
+	}
+	ts.Failures = len(ts.Cases)
+	if len(ts.Cases) == 0 {
+		ts.Cases = append(ts.Cases, junitTestCase{ClassName: "xcw", Name: "no error or fault patterns", Time: "0"})
+	}
+	ts.Tests = len(ts.Cases)
+
+	doc := junitTestSuites{Name: "xcw", Tests: ts.Tests, Failures: ts.Failures, Suites: []junitTestSuite{ts}}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func junitFailureBody(p EnhancedPatternMatch) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "count: %d\n", p.Count)
+	if p.Subsystem != "" {
+		fmt.Fprintf(&b, "subsystem: %s\n", p.Subsystem)
+	}
+	if p.Category != "" {
+		fmt.Fprintf(&b, "category: %s\n", p.Category)
+	}
+	for _, flag := range reportTrendFlags(p) {
+		fmt.Fprintf(&b, "%s: true\n", flag)
+	}
+	for _, s := range p.Samples {
+		fmt.Fprintf(&b, "sample: %s\n", s)
+	}
+	return b.String()
+}
+
+// reportClassName groups test cases by subsystem and category
+func reportClassName(p PatternMatch) string {
+	switch {
+	case p.Subsystem != "" && p.Category != "":
+		return p.Subsystem + "." + p.Category
+	case p.Subsystem != "":
+		return p.Subsystem
+	case p.Category != "":
+		return p.Category
+	default:
+		return "xcw"
+	}
+}
+
+func reportTrendFlags(p EnhancedPatternMatch) []string {
+	var flags []string
+	if p.NewInBuild {
+		flags = append(flags, "new_in_build")
+	}
+	if p.Returned {
+		flags = append(flags, "returned")
+	}
+	if p.RateIncreased {
+		flags = append(flags, "rate_increased")
+	}
+	return flags
+}
+
+// sarifSchemaURI is the SARIF 2.1.0 JSON schema location
+const sarifSchemaURI = "https://json.schemastore.org/sarif-2.1.0.json"
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+	DefaultConfig    sarifConfig  `json:"defaultConfiguration"`
+}
+
+type sarifConfig struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID              string                 `json:"ruleId"`
+	RuleIndex           int                    `json:"ruleIndex"`
+	Level               string                 `json:"level"`
+	Message             sarifMessage           `json:"message"`
+	Locations           []sarifLocation        `json:"locations"`
+	PartialFingerprints map[string]string      `json:"partialFingerprints"`
+	Properties          map[string]interface{} `json:"properties,omitempty"`
+}
+
+type sarifLocation struct {
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifLogicalLocation struct {
+	Name               string `json:"name"`
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+// WriteSARIF writes fault patterns as SARIF 2.1.0 results. Log lines have no
+// source position, so subsystem/category become a logical location.
+func WriteSARIF(w io.Writer, analysis *EnhancedSummaryOutput, toolVersion string) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "xcw",
+			Version:        toolVersion,
+			InformationURI: "https://github.com/vburojevic/xcw",
+			Rules:          []sarifRule{},
+		}},
+		Results: []sarifResult{},
+	}
+
+	for _, p := range analysis.Patterns {
+		if p.Level != domain.LogLevelFault {
+			continue
+		}
+		fingerprint := patternFingerprint(p.Pattern)
+		ruleID := "xcw/fault/" + fingerprint[:12]
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+			ID:               ruleID,
+			ShortDescription: sarifMessage{Text: p.Pattern},
+			DefaultConfig:    sarifConfig{Level: "error"},
+		})
+
+		props := map[string]interface{}{"count": p.Count}
+		if len(p.Samples) > 0 {
+			props["samples"] = p.Samples
+		}
+		for _, flag := range reportTrendFlags(p) {
+			props[flag] = true
+		}
+		run.Results = append(run.Results, sarifResult{
+			RuleID:              ruleID,
+			RuleIndex:           len(run.Tool.Driver.Rules) - 1,
+			Level:               "error",
+			Message:             sarifMessage{Text: fmt.Sprintf("%s (%dx)", p.Pattern, p.Count)},
+			Locations:           []sarifLocation{{LogicalLocations: []sarifLogicalLocation{sarifLogicalLocationFor(p.PatternMatch)}}},
+			PartialFingerprints: map[string]string{"xcwPattern/v1": fingerprint},
+			Properties:          props,
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{Schema: sarifSchemaURI, Version: "2.1.0", Runs: []sarifRun{run}})
+}
+
+func sarifLogicalLocationFor(p PatternMatch) sarifLogicalLocation {
+	subsystem, category := p.Subsystem, p.Category
+	if subsystem == "" {
+		subsystem = "(none)"
+	}
+	if category == "" {
+		category = "(none)"
+	}
+	return sarifLogicalLocation{
+		Name:               category,
+		FullyQualifiedName: subsystem + "/" + category,
+		Kind:               "module",
+	}
+}
+
+// patternFingerprint returns a stable hex digest of a normalized pattern
+func patternFingerprint(pattern string) string {
+	sum := sha256.Sum256([]byte(pattern))
+	return hex.EncodeToString(sum[:])
+}
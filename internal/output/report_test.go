@@ -0,0 +1,125 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vburojevic/xcw/internal/domain"
+)
+
+func reportFixture() *EnhancedSummaryOutput {
+	base := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	entries := []domain.LogEntry{
+		{Timestamp: base, Level: domain.LogLevelError, Subsystem: "com.example.net", Category: "http", Message: "Request 1 failed"},
+		{Timestamp: base.Add(time.Second), Level: domain.LogLevelError, Subsystem: "com.example.net", Category: "http", Message: "Request 2 failed"},
+		{Timestamp: base.Add(2 * time.Second), Level: domain.LogLevelFault, Subsystem: "com.example.db", Category: "store", Message: "Store 0x1 corrupted"},
+		{Timestamp: base.Add(3 * time.Second), Level: domain.LogLevelFault, Subsystem: "com.example.db", Category: "store", Message: "Store 0x2 corrupted"},
+		{Timestamp: base.Add(4 * time.Second), Level: domain.LogLevelInfo, Message: "ok"},
+	}
+	a := NewAnalyzer()
+	patterns := EnhancePatterns(a.DetectPatterns(entries))
+	for i := range patterns {
+		if patterns[i].Level == domain.LogLevelFault {
+			patterns[i].NewInBuild = true
+		}
+	}
+	out := NewEnhancedSummaryOutput(a.Summarize(entries), patterns)
+	out.AppBuild = "42"
+	return out
+}
+
+func TestAnalyzer_DetectPatternsLocation(t *testing.T) {
+	patterns := reportFixture().Patterns
+	require.Len(t, patterns, 2)
+	for _, p := range patterns {
+		switch p.Level {
+		case domain.LogLevelError:
+			assert.Equal(t, "com.example.net", p.Subsystem)
+			assert.Equal(t, "http", p.Category)
+		case domain.LogLevelFault:
+			assert.Equal(t, "com.example.db", p.Subsystem)
+			assert.Equal(t, "store", p.Category)
+		default:
+			t.Fatalf("unexpected level %q", p.Level)
+		}
+	}
+}
+
+func TestWriteJUnit(t *testing.T) {
+	t.Run("patterns become failing test cases", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, WriteJUnit(&buf, reportFixture(), "xcw analyze run.ndjson"))
+		assert.True(t, strings.HasPrefix(buf.String(), "<?xml"))
+
+		var doc junitTestSuites
+		require.NoError(t, xml.Unmarshal(buf.Bytes(), &doc))
+		assert.Equal(t, 2, doc.Tests)
+		assert.Equal(t, 2, doc.Failures)
+		require.Len(t, doc.Suites, 1)
+		suite := doc.Suites[0]
+		assert.Equal(t, "xcw analyze run.ndjson", suite.Name)
+		assert.Equal(t, "4.000", suite.Time)
+		assert.Contains(t, suite.Properties, junitProperty{Name: "app_build", Value: "42"})
+
+		byClass := map[string]junitTestCase{}
+		for _, tc := range suite.Cases {
+			byClass[tc.ClassName] = tc
+		}
+		fault := byClass["com.example.db.store"]
+		require.NotNil(t, fault.Failure)
+		assert.Equal(t, "Store <addr> corrupted", fault.Name)
+		assert.Equal(t, "Fault", fault.Failure.Type)
+		assert.Contains(t, fault.Failure.Body, "new_in_build: true")
+		assert.Contains(t, fault.Failure.Body, "sample: Store 0x1 corrupted")
+		require.NotNil(t, byClass["com.example.net.http"].Failure)
+	})
+
+	t.Run("clean run has a passing case", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, WriteJUnit(&buf, NewEnhancedSummaryOutput(domain.NewLogSummary(), nil), "xcw"))
+
+		var doc junitTestSuites
+		require.NoError(t, xml.Unmarshal(buf.Bytes(), &doc))
+		assert.Equal(t, 1, doc.Tests)
+		assert.Equal(t, 0, doc.Failures)
+		assert.Nil(t, doc.Suites[0].Cases[0].Failure)
+	})
+}
+
+func TestWriteSARIF(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteSARIF(&buf, reportFixture(), "1.2.3"))
+
+	var doc sarifLog
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &doc))
+	assert.Equal(t, "2.1.0", doc.Version)
+	require.Len(t, doc.Runs, 1)
+	run := doc.Runs[0]
+	assert.Equal(t, "xcw", run.Tool.Driver.Name)
+	assert.Equal(t, "1.2.3", run.Tool.Driver.Version)
+
+	// Only fault patterns become results
+	require.Len(t, run.Results, 1)
+	require.Len(t, run.Tool.Driver.Rules, 1)
+	res := run.Results[0]
+	assert.Equal(t, run.Tool.Driver.Rules[0].ID, res.RuleID)
+	assert.Equal(t, "error", res.Level)
+	assert.Equal(t, "Store <addr> corrupted (2x)", res.Message.Text)
+	loc := res.Locations[0].LogicalLocations[0]
+	assert.Equal(t, "store", loc.Name)
+	assert.Equal(t, "com.example.db/store", loc.FullyQualifiedName)
+	assert.Equal(t, patternFingerprint("Store <addr> corrupted"), res.PartialFingerprints["xcwPattern/v1"])
+	assert.Equal(t, true, res.Properties["new_in_build"])
+}
+
+func TestWriteReport_UnknownFormat(t *testing.T) {
+	err := WriteReport(&bytes.Buffer{}, "html", reportFixture(), "xcw", "dev")
+	assert.Error(t, err)
+}
@@ -0,0 +1,405 @@
+package output
+
+import (
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/vburojevic/xcw/internal/domain"
+)
+
+// Template mining defaults
+const (
+	// DefaultTemplateSimilarity is the share of tokens a message must have in
+	// common with a template to join it.
+	DefaultTemplateSimilarity = 0.7
+
+	// templatePrefixTokens are the leading tokens that pick a template group
+	// (the inner levels of the Drain parse tree).
+	templatePrefixTokens = 2
+	// maxTemplateTokens caps the tokens compared per message
+	maxTemplateTokens = 64
+	// maxMaskedValues caps the values masked per message
+	maxMaskedValues = 256
+	// maxParamSamples caps the distinct sample values kept per parameter slot
+	maxParamSamples = 3
+	// templateWildcard marks a token that varies between messages
+	templateWildcard = "<*>"
+)
+
+// MaskRule replaces variable parts of messages before they are grouped into
+// templates. Replace defaults to "<Name>".
+type MaskRule struct {
+	Name    string
+	Regex   string
+	Replace string
+}
+
+// TemplateOptions configures message templating
+type TemplateOptions struct {
+	Masks      []MaskRule // Applied before the built-in masks, in order
+	Similarity float64    // 0 takes DefaultTemplateSimilarity
+}
+
+// mask is a compiled MaskRule
+type mask struct {
+	pattern *regexp.Regexp
+	replace string
+}
+
+// builtinMasks run after user masks; earlier masks win, so URLs and e-mails
+// are masked whole before their numbers and paths.
+var builtinMasks = []mask{
+	{regexp.MustCompile(`\b[a-zA-Z][a-zA-Z0-9+.-]*://[^\s"'<>]+`), "<url>"},
+	{regexp.MustCompile(`\b[\w.+-]+@[\w-]+(?:\.[\w-]+)+\b`), "<email>"},
+	{uuidRegex, "<uuid>"},
+	{regexp.MustCompile(`<[A-Za-z_][\w.]*(?:<[^<>]*>)?: 0x[0-9a-fA-F]+[^<>]*>`), "<object>"},
+	{regexp.MustCompile(`"[^"\n]*"`), "<str>"},
+	{regexp.MustCompile(`(?:~|\.\.?)?(?:/[\w.@+-]+){2,}/?`), "<path>"},
+	{hexAddrRegex, "<addr>"},
+	{numberRegex, "<n>"},
+}
+
+func compileMasks(rules []MaskRule) ([]mask, error) {
+	masks := make([]mask, 0, len(rules)+len(builtinMasks))
+	for _, r := range rules {
+		if r.Name == "" {
+			return nil, fmt.Errorf("mask %q: name is required", r.Regex)
+		}
+		re, err := regexp.Compile(r.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("mask %s: invalid regex: %w", r.Name, err)
+		}
+		replace := r.Replace
+		if replace == "" {
+			replace = "<" + r.Name + ">"
+		}
+		masks = append(masks, mask{pattern: re, replace: replace})
+	}
+	return append(masks, builtinMasks...), nil
+}
+
+// maskedMessage is a message split into tokens, before and after masking
+type maskedMessage struct {
+	tokens []string // Masked tokens, e.g. "id=<n>"
+	raw    []string // The same tokens as logged
+	masked []bool   // Whether masking changed the token
+}
+
+// placeholder stands in for a masked value while later masks run. Private
+// use runes match none of the masks, so values are never masked twice.
+func placeholder(i int) string {
+	return string(rune(0xE000 + i))
+}
+
+func placeholderIndex(r rune) (int, bool) {
+	if r >= 0xE000 && r < 0xE000+maxMaskedValues {
+		return int(r - 0xE000), true
+	}
+	return 0, false
+}
+
+// applyMasks returns msg with every mask applied, plus the masked values
+// behind each placeholder. A value may itself contain earlier placeholders.
+func applyMasks(masks []mask, msg string) (string, []string, []string) {
+	var values, replaces []string
+	for _, m := range masks {
+		msg = m.pattern.ReplaceAllStringFunc(msg, func(match string) string {
+			if len(values) >= maxMaskedValues {
+				return match
+			}
+			values = append(values, match)
+			replaces = append(replaces, m.replace)
+			return placeholder(len(values) - 1)
+		})
+	}
+	return msg, values, replaces
+}
+
+// expandPlaceholders replaces placeholders in s using values (raw) or
+// replaces (masked).
+func expandPlaceholders(s string, subst []string) string {
+	if !strings.ContainsFunc(s, func(r rune) bool { _, ok := placeholderIndex(r); return ok }) {
+		return s
+	}
+	var b strings.Builder
+	for _, r := range s {
+		if i, ok := placeholderIndex(r); ok && i < len(subst) {
+			b.WriteString(expandPlaceholders(subst[i], subst))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func maskText(masks []mask, msg string) string {
+	text, _, replaces := applyMasks(masks, msg)
+	return expandPlaceholders(text, replaces)
+}
+
+func maskMessage(masks []mask, msg string) maskedMessage {
+	text, values, replaces := applyMasks(masks, msg)
+	fields := strings.Fields(text)
+	if len(fields) > maxTemplateTokens {
+		fields = append(fields[:maxTemplateTokens-1], strings.Join(fields[maxTemplateTokens-1:], " "))
+	}
+	m := maskedMessage{
+		tokens: make([]string, len(fields)),
+		raw:    make([]string, len(fields)),
+		masked: make([]bool, len(fields)),
+	}
+	for i, f := range fields {
+		m.tokens[i] = expandPlaceholders(f, replaces)
+		m.raw[i] = expandPlaceholders(f, values)
+		m.masked[i] = m.tokens[i] != m.raw[i]
+	}
+	return m
+}
+
+// templateCluster is one template and the messages grouped under it
+type templateCluster struct {
+	tokens  []string
+	params  []bool     // Token varies (wildcard or masked)
+	samples [][]string // Distinct raw values per parameter position
+	count   int
+	key     string // Group key plus the masked tokens of the first message
+}
+
+// text renders the template
+func (c *templateCluster) text() string {
+	return strings.Join(c.tokens, " ")
+}
+
+// id hashes the cluster's group and the masked tokens it was created from,
+// not the current template text, so the ID does not change as tokens become
+// <*>. It does not depend on the other templates mined alongside, so the same
+// log statement gets the same ID in every run, subset or message order, as
+// long as the values that vary between its messages are masked.
+func (c *templateCluster) id() string {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(c.key))
+	return fmt.Sprintf("%016x", h.Sum64())[:12]
+}
+
+// slots lists the parameter positions with their sample values
+func (c *templateCluster) slots() []domain.TemplateParam {
+	var out []domain.TemplateParam
+	for i, isParam := range c.params {
+		if isParam {
+			out = append(out, domain.TemplateParam{Position: i, Token: c.tokens[i], Samples: c.samples[i]})
+		}
+	}
+	return out
+}
+
+func (c *templateCluster) add(m maskedMessage) {
+	c.count++
+	for i, tok := range m.tokens {
+		if c.tokens[i] != tok && c.tokens[i] != templateWildcard {
+			if !c.params[i] {
+				// Until now every message had this literal token
+				c.samples[i] = []string{c.tokens[i]}
+			}
+			c.tokens[i] = templateWildcard
+		}
+		if c.tokens[i] == templateWildcard || m.masked[i] {
+			c.params[i] = true
+		}
+	}
+	for i, isParam := range c.params {
+		if !isParam || len(c.samples[i]) >= maxParamSamples {
+			continue
+		}
+		if !slices.Contains(c.samples[i], m.raw[i]) {
+			c.samples[i] = append(c.samples[i], m.raw[i])
+		}
+	}
+}
+
+// similarity is the share of template tokens m matches exactly; wildcards
+// count as misses, so a new message prefers the most specific template.
+func (c *templateCluster) similarity(m maskedMessage) float64 {
+	if len(c.tokens) == 0 {
+		return 1
+	}
+	same := 0
+	for i, tok := range m.tokens {
+		if c.tokens[i] == tok && tok != templateWildcard {
+			same++
+		}
+	}
+	return float64(same) / float64(len(c.tokens))
+}
+
+// templateMiner groups messages into templates with the Drain algorithm:
+// messages are bucketed by token count and leading tokens, then join the most
+// similar template in their bucket, which turns differing tokens into
+// wildcards. Not safe for concurrent use.
+type templateMiner struct {
+	masks      []mask
+	similarity float64
+	groups     map[string][]*templateCluster
+	clusters   []*templateCluster // In creation order
+}
+
+func newTemplateMiner(masks []mask, similarity float64) *templateMiner {
+	return &templateMiner{
+		masks:      masks,
+		similarity: similarity,
+		groups:     make(map[string][]*templateCluster),
+	}
+}
+
+// add groups msg and returns its template, and whether the template is new
+func (t *templateMiner) add(msg string) (*templateCluster, bool) {
+	m := maskMessage(t.masks, msg)
+	key := groupKey(m.tokens)
+	if c := t.join(m, key); c != nil {
+		return c, false
+	}
+
+	c := &templateCluster{
+		tokens:  append([]string(nil), m.tokens...),
+		params:  make([]bool, len(m.tokens)),
+		samples: make([][]string, len(m.tokens)),
+		key:     key + "\x00" + strings.Join(m.tokens, "\x00"),
+	}
+	for _, other := range t.groups[key] {
+		if other.key == c.key {
+			// The same message started an earlier, since generalized template
+			c.key = fmt.Sprintf("%s\x00%d", c.key, len(t.groups[key]))
+			break
+		}
+	}
+	c.add(m)
+	t.groups[key] = append(t.groups[key], c)
+	t.clusters = append(t.clusters, c)
+	return c, true
+}
+
+// addExisting groups msg like add but never starts a template; it returns
+// nil when no template is similar enough.
+func (t *templateMiner) addExisting(msg string) *templateCluster {
+	m := maskMessage(t.masks, msg)
+	return t.join(m, groupKey(m.tokens))
+}
+
+// join adds m to the most similar template in its group, if any is similar
+// enough
+func (t *templateMiner) join(m maskedMessage, key string) *templateCluster {
+	var best *templateCluster
+	bestSim := -1.0
+	for _, c := range t.groups[key] {
+		sim := c.similarity(m)
+		if sim > bestSim {
+			best, bestSim = c, sim
+		}
+	}
+	if best == nil || bestSim < t.similarity {
+		return nil
+	}
+	best.add(m)
+	return best
+}
+
+func groupKey(tokens []string) string {
+	n := min(len(tokens), templatePrefixTokens)
+	return fmt.Sprintf("%d\x00%s", len(tokens), strings.Join(tokens[:n], "\x00"))
+}
+
+// templatesCompatible reports whether two rendered templates describe the
+// same messages, one being a generalization of the other: they have the same
+// tokens except where either has a wildcard, and at least half of the tokens
+// agree.
+func templatesCompatible(a, b string) bool {
+	ta, tb := strings.Fields(a), strings.Fields(b)
+	if len(ta) != len(tb) || len(ta) == 0 {
+		return false
+	}
+	same := 0
+	for i := range ta {
+		switch {
+		case ta[i] == tb[i]:
+			same++
+		case ta[i] == templateWildcard || tb[i] == templateWildcard:
+		default:
+			return false
+		}
+	}
+	return float64(same) >= float64(len(ta))/2
+}
+
+// EntryTemplate is the template an entry was grouped under
+type EntryTemplate struct {
+	ID       string
+	Template string
+}
+
+// EntryTemplates mines templates from entries and returns the final template
+// of each entry, in entry order.
+func (a *Analyzer) EntryTemplates(entries []domain.LogEntry) []EntryTemplate {
+	miner := a.newMiner()
+	clusters := make([]*templateCluster, len(entries))
+	for i := range entries {
+		clusters[i], _ = miner.add(entries[i].Message)
+	}
+	out := make([]EntryTemplate, len(entries))
+	for i, c := range clusters {
+		out[i] = EntryTemplate{ID: c.id(), Template: truncatePattern(c.text())}
+	}
+	return out
+}
+
+// Templates mines message templates from entries, most frequent first,
+// keeping up to limit templates (0 = all).
+func (a *Analyzer) Templates(entries []domain.LogEntry, limit int) []domain.TemplateInfo {
+	miner := a.newMiner()
+	type agg struct {
+		info       domain.TemplateInfo
+		cluster    *templateCluster
+		subsystems map[string]int
+	}
+	byCluster := make(map[*templateCluster]*agg)
+	var order []*agg
+	for _, e := range entries {
+		c, _ := miner.add(e.Message)
+		g := byCluster[c]
+		if g == nil {
+			g = &agg{cluster: c, info: domain.TemplateInfo{Example: e.Message, Level: e.Level}, subsystems: make(map[string]int)}
+			byCluster[c] = g
+			order = append(order, g)
+		}
+		if e.Level.Priority() > g.info.Level.Priority() {
+			g.info.Level = e.Level
+		}
+		if e.Subsystem != "" {
+			g.subsystems[e.Subsystem]++
+		}
+	}
+
+	out := make([]domain.TemplateInfo, 0, len(order))
+	for _, g := range order {
+		info := g.info
+		info.ID = g.cluster.id()
+		info.Template = g.cluster.text()
+		info.Count = g.cluster.count
+		info.Params = g.cluster.slots()
+		for sub, n := range g.subsystems {
+			if n > g.subsystems[info.Subsystem] || n == g.subsystems[info.Subsystem] && sub < info.Subsystem {
+				info.Subsystem = sub
+			}
+		}
+		out = append(out, info)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Count > out[j].Count
+	})
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
@@ -0,0 +1,159 @@
+package output
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vburojevic/xcw/internal/domain"
+)
+
+func TestMaskText(t *testing.T) {
+	masks, err := compileMasks([]MaskRule{
+		{Name: "order", Regex: `ORD-[0-9]+`},
+		{Name: "token", Regex: `tok_[a-z0-9]+`, Replace: "<secret>"},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"GET https://api.example.com/v1/items?id=5 failed", "GET <url> failed"},
+		{"mail to jane@example.com bounced", "mail to <email> bounced"},
+		{"reading /var/mobile/Containers/Data/app.db", "reading <path>"},
+		{`key "user.name" missing`, "key <str> missing"},
+		{"released <UIView: 0x7fa1b2c3d4e5; frame = (0 0; 320 480)>", "released <object>"},
+		{"order ORD-123 charged with tok_ab12cd", "order <order> charged with <secret>"},
+		{"retry 3 of 5", "retry <n> of <n>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			assert.Equal(t, tt.expected, maskText(masks, tt.input))
+		})
+	}
+
+	t.Run("rejects invalid rules", func(t *testing.T) {
+		_, err := compileMasks([]MaskRule{{Regex: "x"}})
+		assert.Error(t, err)
+		_, err = compileMasks([]MaskRule{{Name: "bad", Regex: "("}})
+		assert.Error(t, err)
+	})
+}
+
+func TestTemplateMiner(t *testing.T) {
+	miner := newTemplateMiner(builtinMasks, DefaultTemplateSimilarity)
+
+	t.Run("generalizes differing tokens", func(t *testing.T) {
+		first, isNew := miner.add("Connection to api-eu closed by peer after 3 retries")
+		assert.True(t, isNew)
+		second, isNew := miner.add("Connection to api-us closed by peer after 7 retries")
+		assert.False(t, isNew)
+		assert.Same(t, first, second)
+		assert.Equal(t, "Connection to <*> closed by peer after <n> retries", second.text())
+
+		slots := second.slots()
+		require.Len(t, slots, 2)
+		assert.Equal(t, domain.TemplateParam{Position: 2, Token: "<*>", Samples: []string{"api-eu", "api-us"}}, slots[0])
+		assert.Equal(t, domain.TemplateParam{Position: 7, Token: "<n>", Samples: []string{"3", "7"}}, slots[1])
+	})
+
+	t.Run("keeps dissimilar messages apart", func(t *testing.T) {
+		a, _ := miner.add("Cache hit for key alpha")
+		b, isNew := miner.add("Cache miss while loading beta")
+		assert.True(t, isNew)
+		assert.NotSame(t, a, b)
+	})
+
+	t.Run("IDs survive generalization and match across runs", func(t *testing.T) {
+		other := newTemplateMiner(builtinMasks, DefaultTemplateSimilarity)
+		c, _ := other.add("Connection to api-eu closed by peer after 1 retries")
+		id := c.id()
+		assert.Len(t, id, 12)
+		assert.Equal(t, miner.clusters[0].id(), id)
+
+		other.add("Connection to api-sa closed by peer after 2 retries")
+		assert.Equal(t, "Connection to <*> closed by peer after <n> retries", c.text())
+		assert.Equal(t, id, c.id())
+
+		sibling, isNew := other.add("Connection to server lost and will not be retried")
+		require.True(t, isNew)
+		assert.NotEqual(t, id, sibling.id(), "templates sharing a group get distinct IDs")
+	})
+}
+
+func TestTemplatesCompatible(t *testing.T) {
+	assert.True(t, templatesCompatible("open <*> failed with <n>", "open file failed with <n>"))
+	assert.False(t, templatesCompatible("open file failed", "close file failed"))
+	assert.False(t, templatesCompatible("open file failed", "open file"))
+	assert.False(t, templatesCompatible("<*> <*> failed", "a b failed"))
+}
+
+func TestAnalyzer_Templates(t *testing.T) {
+	a, err := NewAnalyzerWithOptions(TemplateOptions{Masks: []MaskRule{{Name: "order", Regex: `ORD-[0-9]+`}}})
+	require.NoError(t, err)
+
+	now := time.Now()
+	entries := []domain.LogEntry{
+		{Timestamp: now, Level: domain.LogLevelInfo, Subsystem: "com.example.shop", Message: "checkout ORD-1 started"},
+		{Timestamp: now, Level: domain.LogLevelError, Subsystem: "com.example.shop", Message: "checkout ORD-2 started"},
+		{Timestamp: now, Level: domain.LogLevelInfo, Subsystem: "com.example.ui", Message: "checkout ORD-3 started"},
+		{Timestamp: now, Level: domain.LogLevelDebug, Message: "view appeared"},
+	}
+
+	templates := a.Templates(entries, 0)
+	require.Len(t, templates, 2)
+	assert.Equal(t, "checkout <order> started", templates[0].Template)
+	assert.Equal(t, 3, templates[0].Count)
+	assert.Equal(t, domain.LogLevelError, templates[0].Level)
+	assert.Equal(t, "com.example.shop", templates[0].Subsystem)
+	assert.Equal(t, "checkout ORD-1 started", templates[0].Example)
+	require.Len(t, templates[0].Params, 1)
+	assert.Equal(t, []string{"ORD-1", "ORD-2", "ORD-3"}, templates[0].Params[0].Samples)
+	assert.Equal(t, "view appeared", templates[1].Template)
+
+	assert.Len(t, a.Templates(entries, 1), 1)
+
+	_, err = NewAnalyzerWithOptions(TemplateOptions{Similarity: 2})
+	assert.Error(t, err)
+}
+
+func TestAnalyzer_TemplateIDsIgnoreOrderAndSubset(t *testing.T) {
+	a := NewAnalyzer()
+	now := time.Now()
+	// Both templates share a group (5 tokens starting "request <n>")
+	timeouts := []domain.LogEntry{
+		{Timestamp: now, Level: domain.LogLevelError, Subsystem: "com.example.net", Message: "request 7 failed: timeout after 30ms"},
+		{Timestamp: now, Level: domain.LogLevelError, Subsystem: "com.example.net", Message: "request 9 failed: timeout after 45ms"},
+	}
+	servers := []domain.LogEntry{
+		{Timestamp: now, Level: domain.LogLevelError, Subsystem: "com.example.api", Message: "request 8 failed: server returned 500"},
+		{Timestamp: now, Level: domain.LogLevelError, Subsystem: "com.example.api", Message: "request 3 failed: server returned 503"},
+	}
+
+	ids := func(entries []domain.LogEntry) map[string]string {
+		out := make(map[string]string)
+		for _, tpl := range a.Templates(entries, 0) {
+			out[tpl.Template] = tpl.ID
+		}
+		return out
+	}
+	forward := ids(append(append([]domain.LogEntry{}, timeouts...), servers...))
+	backward := ids(append(append([]domain.LogEntry{}, servers...), timeouts...))
+	require.Len(t, forward, 2)
+	assert.Equal(t, forward, backward)
+	assert.NotEqual(t, forward["request <n> failed: timeout after <n>ms"], forward["request <n> failed: server returned <n>"])
+
+	// Per-subsystem mining (as discover does) sees only one of the templates
+	assert.Equal(t, forward["request <n> failed: server returned <n>"], ids(servers)["request <n> failed: server returned <n>"])
+
+	// Patterns and per-entry templates use the same IDs
+	all := append(append([]domain.LogEntry{}, servers...), timeouts...)
+	for _, p := range a.DetectPatterns(all) {
+		assert.Equal(t, forward[p.Pattern], p.TemplateID)
+	}
+	for i, et := range a.EntryTemplates(all) {
+		assert.Equal(t, forward[et.Template], et.ID, all[i].Message)
+	}
+}
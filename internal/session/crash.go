@@ -0,0 +1,209 @@
+package session
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/vburojevic/xcw/internal/domain"
+)
+
+// DefaultFaultExitWindow is how long after a fault the process may go quiet
+// and still be reported as crashed when its PID disappears.
+const DefaultFaultExitWindow = 5 * time.Second
+
+// DefaultReportWait is how long a crash is held back waiting for its .ips
+// report, which the system writes only after the process has died.
+const DefaultReportWait = 10 * time.Second
+
+var (
+	uncaughtExceptionRe = regexp.MustCompile(`Terminating app due to uncaught exception '([^']+)'(?:, reason: '(.*)')?`)
+	// Mach exceptions only as reported by the crash reporter or debugger, not
+	// any message that happens to mention EXC_BAD_ACCESS
+	machExceptionRe = regexp.MustCompile(`(?:^|\s)(?:Exception Type:|Thread \d+:)\s*(EXC_[A-Z_]+)\b(?:\s*\(([^)]*)\))?`)
+	// Swift runtime traps: "<file>:<line>: Fatal error: <msg>", or the older
+	// "Fatal error: <msg>: file <file>, line <line>"
+	swiftFatalErrorRe    = regexp.MustCompile(`(?:^|\s)\S+:\d+: Fatal error: (.+)`)
+	swiftFatalErrorOldRe = regexp.MustCompile(`^Fatal error: (.+): file \S+, line \d+\s*$`)
+)
+
+// ParseCrashMessage extracts the exception type and reason from a log message
+// that signals a crash. ok is false when the message is not a crash signature.
+func ParseCrashMessage(msg string) (excType, reason string, ok bool) {
+	if m := uncaughtExceptionRe.FindStringSubmatch(msg); m != nil {
+		return m[1], m[2], true
+	}
+	if m := machExceptionRe.FindStringSubmatch(msg); m != nil {
+		return m[1], strings.TrimSpace(m[2]), true
+	}
+	if m := swiftFatalErrorRe.FindStringSubmatch(msg); m != nil {
+		return "SwiftFatalError", strings.TrimSpace(m[1]), true
+	}
+	if m := swiftFatalErrorOldRe.FindStringSubmatch(msg); m != nil {
+		return "SwiftFatalError", strings.TrimSpace(m[1]), true
+	}
+	return "", "", false
+}
+
+// CrashDetector watches tracked log entries for crash signatures and for a
+// process disappearing right after a fault. Not safe for concurrent use.
+//
+// A crash whose .ips report is not written yet is held back and returned by
+// Pending once the report appears or the report wait runs out, or by Flush
+// when the stream ends.
+type CrashDetector struct {
+	reportsDir  string
+	faultWindow time.Duration
+	reportWait  time.Duration
+	now         func() time.Time
+	lastFault   *domain.LogEntry
+	lastSeen    map[int]time.Time
+	reported    map[int]bool
+	pending     []pendingCrash
+}
+
+type pendingCrash struct {
+	crash    *domain.CrashDetected
+	deadline time.Time
+}
+
+// NewCrashDetector creates a detector that looks for .ips reports in
+// reportsDir (empty disables the lookup).
+func NewCrashDetector(reportsDir string) *CrashDetector {
+	return &CrashDetector{
+		reportsDir:  reportsDir,
+		faultWindow: DefaultFaultExitWindow,
+		reportWait:  DefaultReportWait,
+		now:         time.Now,
+		lastSeen:    make(map[int]time.Time),
+		reported:    make(map[int]bool),
+	}
+}
+
+// Observe processes a log entry of session and returns a crash event when the
+// message carries a crash signature (reported once per PID). It returns nil
+// while the crash waits for its .ips report; see Pending.
+func (d *CrashDetector) Observe(entry *domain.LogEntry, session int) *domain.CrashDetected {
+	if entry.PID == 0 {
+		return nil
+	}
+	d.lastSeen[entry.PID] = entry.Timestamp
+	if entry.Level == domain.LogLevelFault {
+		e := *entry
+		d.lastFault = &e
+	}
+	if d.reported[entry.PID] {
+		return nil
+	}
+	excType, reason, ok := ParseCrashMessage(entry.Message)
+	if !ok {
+		return nil
+	}
+	d.reported[entry.PID] = true
+	crash := d.newCrash(entry, session, "exception_message")
+	crash.ExceptionType = excType
+	crash.ExceptionReason = reason
+	return d.resolve(crash)
+}
+
+// ProcessExited reports that pid stopped logging (relaunch or idle rollover),
+// ending session. It returns a crash event when the last thing the process
+// logged was a fault, or nil while that crash waits for its .ips report; see
+// Pending.
+func (d *CrashDetector) ProcessExited(pid, session int) *domain.CrashDetected {
+	defer delete(d.lastSeen, pid)
+	f := d.lastFault
+	if f == nil || f.PID != pid || d.reported[pid] {
+		return nil
+	}
+	d.lastFault = nil
+	if last, ok := d.lastSeen[pid]; ok && last.Sub(f.Timestamp) > d.faultWindow {
+		return nil
+	}
+	d.reported[pid] = true
+	crash := d.newCrash(f, session, "exit_after_fault")
+	if excType, reason, ok := ParseCrashMessage(f.Message); ok {
+		crash.ExceptionType = excType
+		crash.ExceptionReason = reason
+	} else {
+		crash.ExceptionReason = f.Message
+	}
+	return d.resolve(crash)
+}
+
+// Pending retries the .ips lookup for held-back crashes and returns those
+// whose report was found or whose report wait ended by now.
+func (d *CrashDetector) Pending(now time.Time) []*domain.CrashDetected {
+	var ready []*domain.CrashDetected
+	kept := d.pending[:0]
+	for _, p := range d.pending {
+		if d.attachReport(p.crash) || !now.Before(p.deadline) {
+			ready = append(ready, p.crash)
+			continue
+		}
+		kept = append(kept, p)
+	}
+	d.pending = kept
+	return ready
+}
+
+// Flush returns every held-back crash after one last .ips lookup, for when
+// the stream ends.
+func (d *CrashDetector) Flush() []*domain.CrashDetected {
+	ready := make([]*domain.CrashDetected, 0, len(d.pending))
+	for _, p := range d.pending {
+		d.attachReport(p.crash)
+		ready = append(ready, p.crash)
+	}
+	d.pending = nil
+	return ready
+}
+
+// resolve returns crash when its .ips report is already there (or lookups are
+// disabled) and otherwise holds it back until the report wait ends.
+func (d *CrashDetector) resolve(crash *domain.CrashDetected) *domain.CrashDetected {
+	if d.reportsDir == "" || d.attachReport(crash) {
+		return crash
+	}
+	d.pending = append(d.pending, pendingCrash{crash: crash, deadline: d.now().Add(d.reportWait)})
+	return nil
+}
+
+func (d *CrashDetector) newCrash(entry *domain.LogEntry, session int, trigger string) *domain.CrashDetected {
+	return &domain.CrashDetected{
+		Type:          "crash_detected",
+		SchemaVersion: 1,
+		Timestamp:     entry.Timestamp.UTC().Format(time.RFC3339Nano),
+		Session:       session,
+		PID:           entry.PID,
+		Process:       entry.Process,
+		Trigger:       trigger,
+		BinaryUUID:    entry.ProcessImageUUID,
+	}
+}
+
+// attachReport fills ips_path (and missing exception details) from a matching
+// crash report written since shortly before the crash, and reports whether
+// one was found.
+func (d *CrashDetector) attachReport(crash *domain.CrashDetected) bool {
+	if d.reportsDir == "" {
+		return false
+	}
+	notBefore := d.now().Add(-time.Minute)
+	if ts, err := time.Parse(time.RFC3339Nano, crash.Timestamp); err == nil && !ts.IsZero() {
+		notBefore = ts.Add(-time.Minute)
+	}
+	report := FindIPSReport(d.reportsDir, crash.PID, crash.BinaryUUID, notBefore)
+	if report == nil {
+		return false
+	}
+	crash.IPSPath = report.Path
+	crash.Signal = report.Signal
+	if crash.ExceptionType == "" {
+		crash.ExceptionType = report.ExceptionType
+	}
+	if crash.BinaryUUID == "" {
+		crash.BinaryUUID = report.SliceUUID
+	}
+	return true
+}
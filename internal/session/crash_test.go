@@ -0,0 +1,193 @@
+package session
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/vburojevic/xcw/internal/domain"
+)
+
+func loadFixtureEntries(t *testing.T, name string) []domain.LogEntry {
+	t.Helper()
+	f, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("open fixture: %v", err)
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			t.Fatalf("close fixture: %v", err)
+		}
+	}()
+	var entries []domain.LogEntry
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var e domain.LogEntry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			t.Fatalf("decode fixture line: %v", err)
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+func TestParseCrashMessage(t *testing.T) {
+	tests := []struct {
+		msg, excType, reason string
+		ok                   bool
+	}{
+		{"*** Terminating app due to uncaught exception 'NSRangeException', reason: 'index 3 beyond bounds [0 .. 2]'", "NSRangeException", "index 3 beyond bounds [0 .. 2]", true},
+		{"Thread 1: EXC_BAD_ACCESS (code=1, address=0x0)", "EXC_BAD_ACCESS", "code=1, address=0x0", true},
+		{"MyApp/Cart.swift:42: Fatal error: Unexpectedly found nil while unwrapping an Optional value", "SwiftFatalError", "Unexpectedly found nil while unwrapping an Optional value", true},
+		{"Exception Type:  EXC_BAD_ACCESS (SIGSEGV)", "EXC_BAD_ACCESS", "SIGSEGV", true},
+		{"Fatal error: Index out of range: file MyApp/List.swift, line 12", "SwiftFatalError", "Index out of range", true},
+		{"Request finished in 120ms", "", "", false},
+		{"Installed handler for EXC_BAD_ACCESS (guard pages)", "", "", false},
+		{"Fatal error: retrying upload later", "", "", false},
+		{"Server said: Fatal error: quota exceeded", "", "", false},
+	}
+	for _, tt := range tests {
+		excType, reason, ok := ParseCrashMessage(tt.msg)
+		if ok != tt.ok || excType != tt.excType || reason != tt.reason {
+			t.Errorf("ParseCrashMessage(%q) = (%q, %q, %v), want (%q, %q, %v)", tt.msg, excType, reason, ok, tt.excType, tt.reason, tt.ok)
+		}
+	}
+}
+
+func TestParseIPS(t *testing.T) {
+	f, err := os.Open(filepath.Join("testdata", "MyApp-2024-01-15-103046.ips"))
+	if err != nil {
+		t.Fatalf("open fixture: %v", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	report, err := ParseIPS(f)
+	if err != nil {
+		t.Fatalf("ParseIPS: %v", err)
+	}
+	if report.PID != 4242 || report.ProcessName != "MyApp" || report.BundleID != "com.example.myapp" {
+		t.Fatalf("unexpected identity: %+v", report)
+	}
+	if report.SliceUUID != "4C4C44F5-5555-3144-A1B2-C3D4E5F60718" {
+		t.Fatalf("slice uuid = %q", report.SliceUUID)
+	}
+	if report.ExceptionType != "EXC_CRASH" || report.Signal != "SIGABRT" {
+		t.Fatalf("exception = %q/%q", report.ExceptionType, report.Signal)
+	}
+	if report.Reason == "" {
+		t.Fatalf("expected reason from asi")
+	}
+}
+
+func TestCrashDetectorExceptionMessage(t *testing.T) {
+	d := NewCrashDetector("testdata")
+
+	var crashes []*domain.CrashDetected
+	for _, e := range loadFixtureEntries(t, "nsexception_stream.ndjson") {
+		if crash := d.Observe(&e, 1); crash != nil {
+			crashes = append(crashes, crash)
+		}
+	}
+	if len(crashes) != 1 {
+		t.Fatalf("expected one crash, got %d", len(crashes))
+	}
+	crash := crashes[0]
+	if crash.Trigger != "exception_message" || crash.ExceptionType != "NSInvalidArgumentException" {
+		t.Fatalf("unexpected crash: %+v", crash)
+	}
+	if crash.BinaryUUID != "4C4C44F5-5555-3144-A1B2-C3D4E5F60718" || crash.PID != 4242 {
+		t.Fatalf("unexpected identity: %+v", crash)
+	}
+	if filepath.Base(crash.IPSPath) != "MyApp-2024-01-15-103046.ips" || crash.Signal != "SIGABRT" {
+		t.Fatalf("expected matching ips report, got %q (%q)", crash.IPSPath, crash.Signal)
+	}
+
+	// Already reported: the relaunch must not produce a second event.
+	if crash := d.ProcessExited(4242, 1); crash != nil {
+		t.Fatalf("unexpected duplicate crash: %+v", crash)
+	}
+}
+
+func TestCrashDetectorWaitsForReport(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Date(2024, 1, 15, 10, 30, 46, 0, time.UTC)
+	d := NewCrashDetector(dir)
+	d.now = func() time.Time { return start }
+
+	for _, e := range loadFixtureEntries(t, "nsexception_stream.ndjson") {
+		if crash := d.Observe(&e, 1); crash != nil {
+			t.Fatalf("crash emitted before its report was written: %+v", crash)
+		}
+	}
+	if ready := d.Pending(start.Add(time.Second)); len(ready) != 0 {
+		t.Fatalf("expected crash to stay pending, got %d", len(ready))
+	}
+
+	// The system writes the report after the process died.
+	data, err := os.ReadFile(filepath.Join("testdata", "MyApp-2024-01-15-103046.ips"))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "MyApp-2024-01-15-103046.ips"), data, 0o644); err != nil {
+		t.Fatalf("write report: %v", err)
+	}
+	ready := d.Pending(start.Add(2 * time.Second))
+	if len(ready) != 1 {
+		t.Fatalf("expected pending crash once its report appeared, got %d", len(ready))
+	}
+	if ready[0].Signal != "SIGABRT" || filepath.Base(ready[0].IPSPath) != "MyApp-2024-01-15-103046.ips" {
+		t.Fatalf("expected report details, got %+v", ready[0])
+	}
+	if crash := d.ProcessExited(4242, 1); crash != nil || len(d.Flush()) != 0 {
+		t.Fatalf("crash reported twice")
+	}
+}
+
+func TestCrashDetectorReportWaitExpires(t *testing.T) {
+	start := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	d := NewCrashDetector(t.TempDir())
+	d.now = func() time.Time { return start }
+
+	msg := "MyApp/Cart.swift:42: Fatal error: Unexpectedly found nil while unwrapping an Optional value"
+	if crash := d.Observe(&domain.LogEntry{Timestamp: start, PID: 7, Level: domain.LogLevelFault, Message: msg}, 1); crash != nil {
+		t.Fatalf("expected crash to wait for its report")
+	}
+	if ready := d.Pending(start.Add(DefaultReportWait - time.Second)); len(ready) != 0 {
+		t.Fatalf("crash released before the report wait ended")
+	}
+	ready := d.Pending(start.Add(DefaultReportWait))
+	if len(ready) != 1 || ready[0].IPSPath != "" || ready[0].ExceptionType != "SwiftFatalError" {
+		t.Fatalf("expected crash without report after the wait, got %+v", ready)
+	}
+
+	d.Observe(&domain.LogEntry{Timestamp: start, PID: 8, Level: domain.LogLevelFault, Message: "*** Terminating app due to uncaught exception 'NSGenericException'"}, 1)
+	if flushed := d.Flush(); len(flushed) != 1 || flushed[0].PID != 8 {
+		t.Fatalf("expected Flush to return the pending crash, got %+v", flushed)
+	}
+}
+
+func TestCrashDetectorExitAfterFault(t *testing.T) {
+	ts := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	d := NewCrashDetector("")
+
+	d.Observe(&domain.LogEntry{Timestamp: ts, PID: 10, Level: domain.LogLevelInfo, Message: "loading"}, 1)
+	d.Observe(&domain.LogEntry{Timestamp: ts.Add(time.Second), PID: 10, Level: domain.LogLevelFault, Message: "database corrupted"}, 1)
+
+	crash := d.ProcessExited(10, 1)
+	if crash == nil {
+		t.Fatalf("expected crash after fault + exit")
+	}
+	if crash.Trigger != "exit_after_fault" || crash.ExceptionReason != "database corrupted" {
+		t.Fatalf("unexpected crash: %+v", crash)
+	}
+
+	// Process kept logging long after the fault: a later exit is not a crash.
+	d.Observe(&domain.LogEntry{Timestamp: ts, PID: 11, Level: domain.LogLevelFault, Message: "recoverable"}, 1)
+	d.Observe(&domain.LogEntry{Timestamp: ts.Add(time.Minute), PID: 11, Level: domain.LogLevelInfo, Message: "still alive"}, 1)
+	if crash := d.ProcessExited(11, 1); crash != nil {
+		t.Fatalf("unexpected crash: %+v", crash)
+	}
+}
@@ -0,0 +1,141 @@
+package session
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultDiagnosticReportsDir is where macOS writes crash reports for
+// simulator processes (relative to the user's home directory).
+const DefaultDiagnosticReportsDir = "Library/Logs/DiagnosticReports"
+
+// IPSReport holds the fields of an .ips crash report needed to correlate it
+// with a crash seen in the log stream.
+type IPSReport struct {
+	Path          string
+	AppName       string
+	BundleID      string
+	ProcessName   string
+	PID           int
+	SliceUUID     string
+	ExceptionType string
+	Signal        string
+	Reason        string
+}
+
+// ipsHeader is the single-line JSON header of an .ips file.
+type ipsHeader struct {
+	AppName   string `json:"app_name"`
+	BundleID  string `json:"bundleID"`
+	SliceUUID string `json:"slice_uuid"`
+	BugType   string `json:"bug_type"`
+}
+
+// ipsBody is the subset of the JSON payload following the header.
+type ipsBody struct {
+	PID       int    `json:"pid"`
+	ProcName  string `json:"procName"`
+	Exception struct {
+		Type   string `json:"type"`
+		Signal string `json:"signal"`
+	} `json:"exception"`
+	ASI map[string][]string `json:"asi"`
+}
+
+// ParseIPS parses an .ips crash report (JSON header line followed by a JSON body).
+func ParseIPS(r io.Reader) (*IPSReport, error) {
+	br := bufio.NewReader(r)
+	headerLine, err := br.ReadBytes('\n')
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	var header ipsHeader
+	if err := json.Unmarshal(bytes.TrimSpace(headerLine), &header); err != nil {
+		return nil, fmt.Errorf("invalid ips header: %w", err)
+	}
+
+	report := &IPSReport{
+		AppName:   header.AppName,
+		BundleID:  header.BundleID,
+		SliceUUID: strings.ToUpper(header.SliceUUID),
+	}
+
+	rest, err := io.ReadAll(br)
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(rest)) == 0 {
+		return report, nil
+	}
+	var body ipsBody
+	if err := json.Unmarshal(rest, &body); err != nil {
+		return nil, fmt.Errorf("invalid ips body: %w", err)
+	}
+	report.PID = body.PID
+	report.ProcessName = body.ProcName
+	report.ExceptionType = body.Exception.Type
+	report.Signal = body.Exception.Signal
+	for _, msgs := range body.ASI {
+		for _, msg := range msgs {
+			if _, reason, ok := ParseCrashMessage(msg); ok && reason != "" {
+				report.Reason = reason
+			}
+		}
+	}
+	return report, nil
+}
+
+// FindIPSReport returns the newest .ips report in dir modified at or after
+// notBefore whose PID or slice UUID matches. Unreadable files are skipped.
+func FindIPSReport(dir string, pid int, binaryUUID string, notBefore time.Time) *IPSReport {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	binaryUUID = strings.ToUpper(binaryUUID)
+
+	var best *IPSReport
+	var bestMod time.Time
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".ips" {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil || info.ModTime().Before(notBefore) {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		report, err := parseIPSFile(path)
+		if err != nil {
+			continue
+		}
+		if !(pid > 0 && report.PID == pid) && !(binaryUUID != "" && report.SliceUUID == binaryUUID) {
+			continue
+		}
+		if best == nil || info.ModTime().After(bestMod) {
+			best, bestMod = report, info.ModTime()
+		}
+	}
+	return best
+}
+
+func parseIPSFile(path string) (*IPSReport, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	report, err := ParseIPS(f)
+	if err != nil {
+		return nil, err
+	}
+	report.Path = path
+	return report, nil
+}
@@ -24,6 +24,16 @@ type Tracker struct {
 	appVersion        string
 	appBuild          string
 	initialized       bool
+	ended             []SessionRecord
+}
+
+// SessionRecord describes one session observed by a Tracker
+type SessionRecord struct {
+	Session   int                   `json:"session"`
+	PID       int                   `json:"pid"`
+	Active    bool                  `json:"active"`
+	StartedAt string                `json:"started_at"`
+	Summary   domain.SessionSummary `json:"summary"`
 }
 
 // SessionChange contains events emitted when a session changes
@@ -94,6 +104,8 @@ func (t *Tracker) CheckEntry(entry *domain.LogEntry) *SessionChange {
 			DurationSeconds: int(time.Since(t.sessionStart).Seconds()),
 		}
 
+		t.recordEnded(previousSession, previousPID, summary)
+
 		// Start new session
 		t.currentSession++
 		t.currentPID = pid
@@ -196,6 +208,8 @@ func (t *Tracker) ForceRollover(alert string) *SessionChange {
 		DurationSeconds: int(time.Since(t.sessionStart).Seconds()),
 	}
 
+	t.recordEnded(previousSession, previousPID, summary)
+
 	// Start new session with same PID; counters reset
 	t.currentSession++
 	t.sessionStart = time.Now()
@@ -221,6 +235,42 @@ func (t *Tracker) ForceRollover(alert string) *SessionChange {
 	}
 }
 
+// Sessions returns every session seen so far, oldest first. The last record is
+// the active session (if any) with its running totals.
+func (t *Tracker) Sessions() []SessionRecord {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	out := make([]SessionRecord, 0, len(t.ended)+1)
+	out = append(out, t.ended...)
+	if t.initialized {
+		out = append(out, SessionRecord{
+			Session:   t.currentSession,
+			PID:       t.currentPID,
+			Active:    true,
+			StartedAt: t.sessionStart.UTC().Format(time.RFC3339Nano),
+			Summary: domain.SessionSummary{
+				TotalLogs:       t.logCount,
+				Errors:          t.errorCount,
+				Faults:          t.faultCount,
+				DurationSeconds: int(time.Since(t.sessionStart).Seconds()),
+			},
+		})
+	}
+	return out
+}
+
+// recordEnded appends the session that is being closed to the history.
+// Caller must hold t.mu.
+func (t *Tracker) recordEnded(session, pid int, summary domain.SessionSummary) {
+	t.ended = append(t.ended, SessionRecord{
+		Session:   session,
+		PID:       pid,
+		StartedAt: t.sessionStart.UTC().Format(time.RFC3339Nano),
+		Summary:   summary,
+	})
+}
+
 // Stats returns current session statistics
 func (t *Tracker) Stats() (session, pid, logs, errors, faults int) {
 	t.mu.Lock()
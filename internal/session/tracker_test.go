@@ -32,3 +32,29 @@ func TestTrackerDetectsBinaryUUIDChange(t *testing.T) {
 		t.Fatalf("expected previous session to close")
 	}
 }
+
+func TestTrackerSessionsHistory(t *testing.T) {
+	tr := NewTracker("com.example.app", "Sim", "UDID", "tail-1", "", "")
+	if got := tr.Sessions(); len(got) != 0 {
+		t.Fatalf("expected no sessions before first entry, got %d", len(got))
+	}
+
+	tr.CheckEntry(&domain.LogEntry{PID: 1, Level: domain.LogLevelError})
+	tr.CheckEntry(&domain.LogEntry{PID: 1, Level: domain.LogLevelInfo})
+	tr.CheckEntry(&domain.LogEntry{PID: 2, Level: domain.LogLevelInfo})
+	tr.ForceRollover("IDLE")
+
+	got := tr.Sessions()
+	if len(got) != 3 {
+		t.Fatalf("expected 3 sessions, got %d", len(got))
+	}
+	if got[0].Session != 1 || got[0].PID != 1 || got[0].Active || got[0].Summary.TotalLogs != 2 || got[0].Summary.Errors != 1 {
+		t.Fatalf("unexpected first session: %+v", got[0])
+	}
+	if got[1].Session != 2 || got[1].PID != 2 || got[1].Active {
+		t.Fatalf("unexpected second session: %+v", got[1])
+	}
+	if got[2].Session != 3 || !got[2].Active || got[2].Summary.TotalLogs != 0 {
+		t.Fatalf("unexpected active session: %+v", got[2])
+	}
+}
@@ -2,7 +2,6 @@ package simulator
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
@@ -19,6 +18,7 @@ import (
 // Manager handles simulator discovery and lifecycle operations
 type Manager struct {
 	xcrunPath    string
+	source       LogSource
 	pollInterval time.Duration
 	cacheTTL     time.Duration
 
@@ -36,13 +36,25 @@ const (
 
 // NewManager creates a new simulator manager
 func NewManager() *Manager {
+	return NewManagerWithSource(NewXcrunSource())
+}
+
+// NewManagerWithSource creates a simulator manager that lists devices and
+// reads logs through the given source
+func NewManagerWithSource(source LogSource) *Manager {
 	return &Manager{
 		xcrunPath:    "xcrun",
+		source:       source,
 		pollInterval: 2 * time.Second,
 		cacheTTL:     2 * time.Second,
 	}
 }
 
+// Source returns the log source backing this manager
+func (m *Manager) Source() LogSource {
+	return m.source
+}
+
 // ListDevices returns all available simulators
 func (m *Manager) ListDevices(ctx context.Context) ([]domain.Device, error) {
 	// Serve from short-lived cache to avoid repeated simctl calls
@@ -55,49 +67,9 @@ func (m *Manager) ListDevices(ctx context.Context) ([]domain.Device, error) {
 	}
 	m.cacheMu.Unlock()
 
-	cmdCtx, cancel := context.WithTimeout(ctx, simctlListDevicesTimeout)
-	defer cancel()
-	cmd := exec.CommandContext(cmdCtx, m.xcrunPath, "simctl", "list", "devices", "--json")
-	output, err := cmd.Output()
+	devices, err := m.source.ListDevices(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("simctl list failed: %w", err)
-	}
-
-	var resp domain.SimctlDevicesResponse
-	if err := json.Unmarshal(output, &resp); err != nil {
-		return nil, fmt.Errorf("failed to parse simctl output: %w", err)
-	}
-
-	var devices []domain.Device
-	for runtime, devs := range resp.Devices {
-		for _, d := range devs {
-			if !d.IsAvailable {
-				continue
-			}
-
-			var lastBooted *time.Time
-			if d.LastBootedAt != nil {
-				if t, err := time.Parse(time.RFC3339, *d.LastBootedAt); err == nil {
-					lastBooted = &t
-				}
-			}
-
-			// Extract iOS version from runtime identifier
-			// e.g., "com.apple.CoreSimulator.SimRuntime.iOS-17-0" -> "iOS 17.0"
-			runtimeName := parseRuntimeName(runtime)
-
-			devices = append(devices, domain.Device{
-				UDID:                 d.UDID,
-				Name:                 d.Name,
-				State:                domain.DeviceState(d.State),
-				IsAvailable:          d.IsAvailable,
-				DeviceTypeIdentifier: d.DeviceTypeIdentifier,
-				RuntimeIdentifier:    runtimeName,
-				DataPath:             d.DataPath,
-				LogPath:              d.LogPath,
-				LastBootedAt:         lastBooted,
-			})
-		}
+		return nil, err
 	}
 
 	// Update cache
@@ -76,6 +76,9 @@ func (p *Parser) Parse(line []byte) (*domain.LogEntry, error) {
 		ProcessImageUUID: gjson.GetBytes(line, "processImageUUID").String(),
 		SenderPath:       gjson.GetBytes(line, "senderImagePath").String(),
 		EventType:        eventType,
+		ActivityID:       gjson.GetBytes(line, "activityIdentifier").Uint(),
+		ParentActivityID: gjson.GetBytes(line, "parentActivityIdentifier").Uint(),
+		OSTraceID:        gjson.GetBytes(line, "traceID").Uint(),
 	}, nil
 }
 
@@ -22,6 +22,9 @@ func TestParserFixtures(t *testing.T) {
 		category  string
 		message   string
 		ts        string
+		activity  uint64
+		parent    uint64
+		traceID   uint64
 		nilEntry  bool
 	}
 
@@ -34,6 +37,9 @@ func TestParserFixtures(t *testing.T) {
 			category:  "ui",
 			message:   "Hello (no-colon offset, fractional)",
 			ts:        "2025-12-08T22:11:55.808033+01:00",
+			activity:  5407,
+			parent:    5406,
+			traceID:   18442195553230209540,
 		},
 		{
 			level:     "Error",
@@ -79,6 +85,9 @@ func TestParserFixtures(t *testing.T) {
 		require.Equal(t, w.category, entry.Category)
 		require.Equal(t, w.message, entry.Message)
 		require.Equal(t, w.ts, entry.Timestamp.Format(time.RFC3339Nano))
+		require.Equal(t, w.activity, entry.ActivityID)
+		require.Equal(t, w.parent, entry.ParentActivityID)
+		require.Equal(t, w.traceID, entry.OSTraceID)
 	}
 	require.NoError(t, sc.Err())
 	require.Equal(t, len(wants), i, "fixture count mismatch")
@@ -5,8 +5,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"os"
-	"os/exec"
 	"regexp"
 	"strings"
 	"time"
@@ -37,20 +35,25 @@ type QueryOptions struct {
 
 // QueryReader reads historical logs from a simulator
 type QueryReader struct {
+	source LogSource
 	parser *Parser
 }
 
 // NewQueryReader creates a new query reader
 func NewQueryReader() *QueryReader {
+	return NewQueryReaderWithSource(NewXcrunSource())
+}
+
+// NewQueryReaderWithSource creates a query reader that reads from the given source
+func NewQueryReaderWithSource(source LogSource) *QueryReader {
 	return &QueryReader{
+		source: source,
 		parser: NewParser(),
 	}
 }
 
 // Query reads historical logs matching the criteria
 func (r *QueryReader) Query(ctx context.Context, udid string, opts QueryOptions) ([]domain.LogEntry, error) {
-	args := r.buildArgs(udid, opts)
-
 	cmdCtx := ctx
 	cancel := func() {}
 	if opts.CommandTimeout > 0 {
@@ -61,40 +64,13 @@ func (r *QueryReader) Query(ctx context.Context, udid string, opts QueryOptions)
 	}
 	defer cancel()
 
-	cmd := exec.CommandContext(cmdCtx, "xcrun", args...)
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		return nil, fmt.Errorf("failed to create pipe: %w", err)
-	}
-	stderr, err := cmd.StderrPipe()
+	stream, err := r.source.Show(cmdCtx, udid, opts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create stderr pipe: %w", err)
+		return nil, err
 	}
 
-	if err := cmd.Start(); err != nil {
-		return nil, fmt.Errorf("failed to start log show: %w", err)
-	}
-
-	// Drain stderr to avoid deadlocks and optionally surface diagnostics.
-	stderrErrCh := make(chan error, 1)
-	onStderr := opts.OnStderrLine
-	go func() {
-		sc := bufio.NewScanner(stderr)
-		sc.Buffer(make([]byte, 0, 64*1024), 256*1024)
-		for sc.Scan() {
-			line := strings.TrimSpace(sc.Text())
-			if line == "" {
-				continue
-			}
-			if onStderr != nil {
-				onStderr(line)
-			}
-		}
-		stderrErrCh <- sc.Err()
-	}()
-
 	var entries []domain.LogEntry
-	scanner := bufio.NewScanner(stdout)
+	scanner := bufio.NewScanner(stream)
 	const maxLineBytes = 1024 * 1024
 	scanner.Buffer(make([]byte, 0, 64*1024), maxLineBytes)
 
@@ -149,54 +125,21 @@ func (r *QueryReader) Query(ctx context.Context, udid string, opts QueryOptions)
 	if stdoutErr != nil && errors.Is(stdoutErr, bufio.ErrTooLong) {
 		stdoutErr = fmt.Errorf("log show output line too long (>%d bytes): %w", maxLineBytes, stdoutErr)
 	}
-	if stdoutErr != nil && cmd.Process != nil {
-		_ = cmd.Process.Kill()
-	}
 
-	waitErr := cmd.Wait()
-	stderrErr := <-stderrErrCh
+	closeErr := stream.Close()
 
 	if stdoutErr != nil {
 		return nil, stdoutErr
 	}
-	if stderrErr != nil && cmdCtx.Err() == nil && !errors.Is(stderrErr, os.ErrClosed) {
-		return nil, fmt.Errorf("log show stderr read error: %w", stderrErr)
-	}
 	if cmdCtx.Err() != nil {
 		return nil, cmdCtx.Err()
 	}
-	if waitErr != nil {
-		return nil, fmt.Errorf("log show failed: %w", waitErr)
+	if closeErr != nil {
+		return nil, fmt.Errorf("log show failed: %w", closeErr)
 	}
 	return entries, nil
 }
 
-func (r *QueryReader) buildArgs(udid string, opts QueryOptions) []string {
-	args := []string{"simctl", "spawn", udid, "log", "show", "--style", "ndjson"}
-
-	// Time range: use --start/--end when Until is set, otherwise --last
-	if !opts.Until.IsZero() {
-		// Absolute time range with --start and --end
-		start := time.Now().Add(-opts.Since)
-		args = append(args, "--start", start.Format(time.RFC3339))
-		args = append(args, "--end", opts.Until.Format(time.RFC3339))
-	} else if opts.Since > 0 {
-		// Relative duration with --last
-		args = append(args, "--last", formatDuration(opts.Since))
-	}
-
-	// Include all log levels to allow filtering
-	args = append(args, "--info", "--debug")
-
-	// Build predicate
-	predicate := r.buildPredicate(opts)
-	if predicate != "" {
-		args = append(args, "--predicate", predicate)
-	}
-
-	return args
-}
-
 // buildPredicate constructs an NSPredicate string for log filtering
 // Uses AND between groups (subsystem, category) for narrowing results
 // Uses OR within groups for matching any of multiple values
@@ -0,0 +1,113 @@
+package simulator
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/vburojevic/xcw/internal/domain"
+)
+
+// LogSource abstracts where devices and raw unified-log NDJSON come from.
+// The xcrun backend shells out to `simctl`; FileSource replays a recorded
+// `log stream --style ndjson` capture so commands can run without simulators.
+type LogSource interface {
+	// ListDevices returns all available devices known to the backend.
+	ListDevices(ctx context.Context) ([]domain.Device, error)
+
+	// Stream opens a live log stream for a device. The reader yields NDJSON
+	// lines until the stream ends or ctx is cancelled. Close releases the
+	// stream and reports how it terminated.
+	Stream(ctx context.Context, udid string, opts StreamOptions) (io.ReadCloser, error)
+
+	// Show opens a historical log query for a device, yielding NDJSON lines.
+	Show(ctx context.Context, udid string, opts QueryOptions) (io.ReadCloser, error)
+}
+
+// commandStream adapts a running command's stdout to an io.ReadCloser.
+// Stderr is drained in the background so the child never blocks on it.
+type commandStream struct {
+	ctx      context.Context
+	label    string
+	cmd      *exec.Cmd
+	stdout   io.ReadCloser
+	stderrCh chan error
+	eof      bool
+}
+
+// startCommandStream starts cmd and returns its stdout as a stream. Each
+// non-empty stderr line is passed to onStderr (when non-nil).
+func startCommandStream(ctx context.Context, label string, cmd *exec.Cmd, onStderr func(line string)) (*commandStream, error) {
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stdout pipe: %w", err)
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stderr pipe: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", label, err)
+	}
+
+	s := &commandStream{
+		ctx:      ctx,
+		label:    label,
+		cmd:      cmd,
+		stdout:   stdout,
+		stderrCh: make(chan error, 1),
+	}
+
+	// Drain stderr to avoid deadlocks and optionally surface diagnostics.
+	go func() {
+		sc := bufio.NewScanner(stderr)
+		sc.Buffer(make([]byte, 0, 64*1024), 256*1024)
+		for sc.Scan() {
+			line := strings.TrimSpace(sc.Text())
+			if line == "" {
+				continue
+			}
+			if onStderr != nil {
+				onStderr(line)
+			}
+		}
+		s.stderrCh <- sc.Err()
+	}()
+
+	return s, nil
+}
+
+func (s *commandStream) Read(p []byte) (int, error) {
+	n, err := s.stdout.Read(p)
+	if errors.Is(err, io.EOF) {
+		s.eof = true
+	}
+	return n, err
+}
+
+// Close waits for the command to exit. If the consumer stopped reading before
+// EOF the process is killed first, and the resulting exit error is ignored.
+func (s *commandStream) Close() error {
+	killed := false
+	if !s.eof && s.cmd.Process != nil {
+		_ = s.cmd.Process.Kill()
+		killed = true
+	}
+
+	waitErr := s.cmd.Wait()
+	stderrErr := <-s.stderrCh
+
+	if stderrErr != nil && s.ctx.Err() == nil && !errors.Is(stderrErr, os.ErrClosed) {
+		return fmt.Errorf("%s stderr read error: %w", s.label, stderrErr)
+	}
+	if killed {
+		return nil
+	}
+	return waitErr
+}
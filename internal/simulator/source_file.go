@@ -0,0 +1,250 @@
+package simulator
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+	"github.com/vburojevic/xcw/internal/domain"
+)
+
+// FileSource replays a recorded `log stream --style ndjson` (or `log show`)
+// capture. It exposes a single booted device so device resolution works
+// unchanged, and emulates the structured predicate (app, subsystem, category)
+// that simctl would normally evaluate. Raw NSPredicate strings are not
+// evaluated; every line is passed through in that case.
+type FileSource struct {
+	path   string
+	device domain.Device
+}
+
+// NewFileSource creates a LogSource that reads from a capture file.
+func NewFileSource(path string) *FileSource {
+	name := filepath.Base(path)
+	return &FileSource{
+		path: path,
+		device: domain.Device{
+			UDID:              "FILE-" + sanitizeDeviceID(name),
+			Name:              name,
+			State:             domain.DeviceStateBooted,
+			IsAvailable:       true,
+			RuntimeIdentifier: "recorded",
+			DataPath:          filepath.Dir(path),
+		},
+	}
+}
+
+// ListDevices returns the synthetic device representing the capture file
+func (f *FileSource) ListDevices(ctx context.Context) ([]domain.Device, error) {
+	if _, err := os.Stat(f.path); err != nil {
+		return nil, fmt.Errorf("log source file: %w", err)
+	}
+	return []domain.Device{f.device}, nil
+}
+
+// Stream replays the capture, then holds the stream open until ctx is
+// cancelled so consumers behave as if the device simply went quiet.
+func (f *FileSource) Stream(ctx context.Context, udid string, opts StreamOptions) (io.ReadCloser, error) {
+	return f.open(udid, sourceLineFilter(opts.RawPredicate, opts.BundleID, opts.Subsystems, opts.Categories), ctx.Done())
+}
+
+// Show returns the capture lines inside the query window. Since counts back
+// from the last recorded entry rather than from now, so a recording queried
+// long after it was made still yields its final minutes; Until is an absolute
+// cutoff.
+func (f *FileSource) Show(ctx context.Context, udid string, opts QueryOptions) (io.ReadCloser, error) {
+	keep := sourceLineFilter(opts.RawPredicate, opts.BundleID, opts.Subsystems, opts.Categories)
+	if opts.Since > 0 || !opts.Until.IsZero() {
+		var from time.Time
+		if opts.Since > 0 {
+			last, err := f.lastTimestamp()
+			if err != nil {
+				return nil, err
+			}
+			if !last.IsZero() {
+				from = last.Add(-opts.Since)
+			}
+		}
+		keep = andLineFilter(keep, sourceTimeFilter(from, opts.Until))
+	}
+	r, err := f.open(udid, keep, nil)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// lastTimestamp returns the latest timestamp in the capture, or zero when no
+// line has one.
+func (f *FileSource) lastTimestamp() (time.Time, error) {
+	file, err := os.Open(f.path)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to open log source file: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	var last time.Time
+	sc := bufio.NewScanner(file)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for sc.Scan() {
+		if ts, ok := lineTimestamp(sc.Bytes()); ok && ts.After(last) {
+			last = ts
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return time.Time{}, fmt.Errorf("failed to read log source file: %w", err)
+	}
+	return last, nil
+}
+
+func (f *FileSource) open(udid string, keep func([]byte) bool, hold <-chan struct{}) (*lineFilterReader, error) {
+	if udid != f.device.UDID {
+		return nil, fmt.Errorf("device not found: %s", udid)
+	}
+	file, err := os.Open(f.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log source file: %w", err)
+	}
+	sc := bufio.NewScanner(file)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	return &lineFilterReader{sc: sc, file: file, keep: keep, hold: hold}, nil
+}
+
+// lineFilterReader yields the newline-terminated lines of a file that pass
+// keep. When hold is set, EOF is deferred until hold is done.
+type lineFilterReader struct {
+	sc   *bufio.Scanner
+	file *os.File
+	keep func([]byte) bool
+	hold <-chan struct{}
+	buf  []byte
+}
+
+func (r *lineFilterReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		if !r.sc.Scan() {
+			if err := r.sc.Err(); err != nil {
+				return 0, err
+			}
+			if r.hold != nil {
+				<-r.hold
+			}
+			return 0, io.EOF
+		}
+		line := r.sc.Bytes()
+		if r.keep != nil && !r.keep(line) {
+			continue
+		}
+		r.buf = append(append(r.buf[:0], line...), '\n')
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+func (r *lineFilterReader) Close() error {
+	return r.file.Close()
+}
+
+// sourceLineFilter mirrors buildPredicate for backends that cannot evaluate
+// NSPredicate: AND between the subsystem and category groups, OR within each.
+func sourceLineFilter(rawPredicate, bundleID string, subsystems, categories []string) func([]byte) bool {
+	if rawPredicate != "" {
+		return nil
+	}
+	bundleID = strings.TrimSpace(bundleID)
+	subsystems = nonBlank(subsystems)
+	categories = nonBlank(categories)
+	if bundleID == "" && len(subsystems) == 0 && len(categories) == 0 {
+		return nil
+	}
+	return func(line []byte) bool {
+		if len(subsystems) > 0 || bundleID != "" {
+			sub := gjson.GetBytes(line, "subsystem").String()
+			ok := bundleID != "" && strings.HasPrefix(sub, bundleID)
+			for _, s := range subsystems {
+				if sub == s {
+					ok = true
+					break
+				}
+			}
+			if !ok {
+				return false
+			}
+		}
+		if len(categories) > 0 {
+			cat := gjson.GetBytes(line, "category").String()
+			ok := false
+			for _, c := range categories {
+				if cat == c {
+					ok = true
+					break
+				}
+			}
+			if !ok {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// sourceTimeFilter keeps lines stamped within [from, until]; a zero bound is
+// open. Lines without a parseable timestamp are passed to the parser as-is.
+func sourceTimeFilter(from, until time.Time) func([]byte) bool {
+	return func(line []byte) bool {
+		ts, ok := lineTimestamp(line)
+		if !ok {
+			return true
+		}
+		if !from.IsZero() && ts.Before(from) {
+			return false
+		}
+		return until.IsZero() || !ts.After(until)
+	}
+}
+
+func andLineFilter(a, b func([]byte) bool) func([]byte) bool {
+	if a == nil {
+		return b
+	}
+	return func(line []byte) bool { return a(line) && b(line) }
+}
+
+// nonBlank drops empty values the way buildPredicate skips them
+func nonBlank(values []string) []string {
+	var out []string
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func lineTimestamp(line []byte) (time.Time, bool) {
+	raw := gjson.GetBytes(line, "timestamp").String()
+	if raw == "" {
+		return time.Time{}, false
+	}
+	ts, err := parseTimestamp(raw)
+	return ts, err == nil
+}
+
+func sanitizeDeviceID(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(name) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('-')
+		}
+	}
+	return b.String()
+}
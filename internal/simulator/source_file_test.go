@@ -0,0 +1,120 @@
+package simulator
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vburojevic/xcw/internal/domain"
+)
+
+func TestFileSourceListDevices(t *testing.T) {
+	src := NewFileSource(filepath.Join("testdata", "parser_fixtures.ndjson"))
+	devices, err := src.ListDevices(context.Background())
+	require.NoError(t, err)
+	require.Len(t, devices, 1)
+	assert.Equal(t, "FILE-PARSER-FIXTURES-NDJSON", devices[0].UDID)
+	assert.True(t, devices[0].IsBooted())
+
+	_, err = NewFileSource(filepath.Join("testdata", "missing.ndjson")).ListDevices(context.Background())
+	assert.Error(t, err)
+}
+
+func TestFileSourceQueryFiltersByApp(t *testing.T) {
+	src := NewFileSource(filepath.Join("testdata", "parser_fixtures.ndjson"))
+	reader := NewQueryReaderWithSource(src)
+
+	entries, err := reader.Query(context.Background(), src.device.UDID, QueryOptions{BundleID: "com.example.app", MinLevel: domain.LogLevelDebug})
+	require.NoError(t, err)
+	require.NotEmpty(t, entries)
+	for _, e := range entries {
+		assert.Equal(t, "com.example.app", e.Subsystem)
+	}
+
+	all, err := reader.Query(context.Background(), src.device.UDID, QueryOptions{MinLevel: domain.LogLevelDebug})
+	require.NoError(t, err)
+	assert.Greater(t, len(all), len(entries))
+}
+
+func TestFileSourceQueryUnknownDevice(t *testing.T) {
+	reader := NewQueryReaderWithSource(NewFileSource(filepath.Join("testdata", "parser_fixtures.ndjson")))
+	_, err := reader.Query(context.Background(), "NOPE", QueryOptions{})
+	assert.Error(t, err)
+}
+
+func TestFileSourceStreamHoldsUntilCancel(t *testing.T) {
+	src := NewFileSource(filepath.Join("testdata", "parser_fixtures.ndjson"))
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := src.Stream(ctx, src.device.UDID, StreamOptions{Categories: []string{"net"}})
+	require.NoError(t, err)
+	defer func() { _ = stream.Close() }()
+
+	done := make(chan []byte, 1)
+	go func() {
+		data, _ := io.ReadAll(stream)
+		done <- data
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("stream returned EOF before context cancellation")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	cancel()
+	select {
+	case data := <-done:
+		assert.Contains(t, string(data), `"category":"net"`)
+		assert.NotContains(t, string(data), `"category":"ui"`)
+	case <-time.After(time.Second):
+		t.Fatal("stream did not end after cancellation")
+	}
+}
+
+func TestSourceLineFilter(t *testing.T) {
+	line := []byte(`{"subsystem":"com.example.app.net","category":"http"}`)
+
+	assert.Nil(t, sourceLineFilter(`subsystem == "x"`, "com.other", nil, nil))
+	assert.Nil(t, sourceLineFilter("", "", nil, nil))
+	assert.True(t, sourceLineFilter("", "com.example.app", nil, nil)(line))
+	assert.False(t, sourceLineFilter("", "com.other", nil, nil)(line))
+	assert.True(t, sourceLineFilter("", "com.other", []string{"com.example.app.net"}, nil)(line))
+	assert.True(t, sourceLineFilter("", "", nil, []string{"db", "http"})(line))
+	assert.False(t, sourceLineFilter("", "com.example.app", nil, []string{"db"})(line))
+	assert.Nil(t, sourceLineFilter("", "", []string{" "}, []string{""}))
+	assert.True(t, sourceLineFilter("", "com.example.app", []string{""}, nil)(line))
+}
+
+func TestFileSourceShowWindowFromLastEntry(t *testing.T) {
+	capture := filepath.Join(t.TempDir(), "capture.ndjson")
+	lines := []string{
+		`{"timestamp":"2025-12-15 00:00:00.000000+0000","messageType":"Info","eventType":"logEvent","eventMessage":"first","processImagePath":"/a/MyApp","processID":1}`,
+		`{"timestamp":"2025-12-15 00:09:00.000000+0000","messageType":"Info","eventType":"logEvent","eventMessage":"middle","processImagePath":"/a/MyApp","processID":1}`,
+		`{"timestamp":"2025-12-15 00:10:00.000000+0000","messageType":"Info","eventType":"logEvent","eventMessage":"last","processImagePath":"/a/MyApp","processID":1}`,
+	}
+	require.NoError(t, os.WriteFile(capture, []byte(strings.Join(lines, "\n")+"\n"), 0o644))
+	src := NewFileSource(capture)
+	reader := NewQueryReaderWithSource(src)
+
+	messages := func(opts QueryOptions) []string {
+		opts.MinLevel = domain.LogLevelDebug
+		entries, err := reader.Query(context.Background(), src.device.UDID, opts)
+		require.NoError(t, err)
+		var out []string
+		for _, e := range entries {
+			out = append(out, e.Message)
+		}
+		return out
+	}
+
+	assert.Equal(t, []string{"first", "middle", "last"}, messages(QueryOptions{}))
+	assert.Equal(t, []string{"middle", "last"}, messages(QueryOptions{Since: 5 * time.Minute}))
+	assert.Equal(t, []string{"middle"}, messages(QueryOptions{Since: 5 * time.Minute, Until: time.Date(2025, 12, 15, 0, 9, 30, 0, time.UTC)}))
+	assert.Equal(t, []string{"first"}, messages(QueryOptions{Until: time.Date(2025, 12, 15, 0, 1, 0, 0, time.UTC)}))
+}
@@ -0,0 +1,132 @@
+package simulator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/vburojevic/xcw/internal/domain"
+)
+
+// XcrunSource is the default LogSource backed by `xcrun simctl`.
+type XcrunSource struct {
+	xcrunPath string
+}
+
+// NewXcrunSource creates a LogSource that shells out to xcrun.
+func NewXcrunSource() *XcrunSource {
+	return &XcrunSource{xcrunPath: "xcrun"}
+}
+
+// ListDevices returns all available simulators reported by simctl
+func (x *XcrunSource) ListDevices(ctx context.Context) ([]domain.Device, error) {
+	cmdCtx, cancel := context.WithTimeout(ctx, simctlListDevicesTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(cmdCtx, x.xcrunPath, "simctl", "list", "devices", "--json")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("simctl list failed: %w", err)
+	}
+
+	var resp domain.SimctlDevicesResponse
+	if err := json.Unmarshal(output, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse simctl output: %w", err)
+	}
+
+	var devices []domain.Device
+	for runtime, devs := range resp.Devices {
+		for _, d := range devs {
+			if !d.IsAvailable {
+				continue
+			}
+
+			var lastBooted *time.Time
+			if d.LastBootedAt != nil {
+				if t, err := time.Parse(time.RFC3339, *d.LastBootedAt); err == nil {
+					lastBooted = &t
+				}
+			}
+
+			// Extract iOS version from runtime identifier
+			// e.g., "com.apple.CoreSimulator.SimRuntime.iOS-17-0" -> "iOS 17.0"
+			runtimeName := parseRuntimeName(runtime)
+
+			devices = append(devices, domain.Device{
+				UDID:                 d.UDID,
+				Name:                 d.Name,
+				State:                domain.DeviceState(d.State),
+				IsAvailable:          d.IsAvailable,
+				DeviceTypeIdentifier: d.DeviceTypeIdentifier,
+				RuntimeIdentifier:    runtimeName,
+				DataPath:             d.DataPath,
+				LogPath:              d.LogPath,
+				LastBootedAt:         lastBooted,
+			})
+		}
+	}
+
+	return devices, nil
+}
+
+// Stream runs `log stream --style ndjson` inside the simulator
+func (x *XcrunSource) Stream(ctx context.Context, udid string, opts StreamOptions) (io.ReadCloser, error) {
+	cmd := exec.CommandContext(ctx, x.xcrunPath, streamArgs(udid, opts)...)
+	return startCommandStream(ctx, "log stream", cmd, opts.OnStderrLine)
+}
+
+// Show runs `log show --style ndjson` inside the simulator
+func (x *XcrunSource) Show(ctx context.Context, udid string, opts QueryOptions) (io.ReadCloser, error) {
+	cmd := exec.CommandContext(ctx, x.xcrunPath, showArgs(udid, opts)...)
+	return startCommandStream(ctx, "log show", cmd, opts.OnStderrLine)
+}
+
+// streamArgs builds the xcrun arguments for a live log stream
+func streamArgs(udid string, opts StreamOptions) []string {
+	args := []string{"simctl", "spawn", udid, "log", "stream", "--style", "ndjson"}
+
+	// Add log level
+	level := strings.ToLower(string(opts.MinLevel))
+	if level == "" || level == "default" {
+		level = "default"
+	}
+	args = append(args, "--level", level)
+
+	// Build predicate for filtering
+	predicate := buildPredicate(opts.RawPredicate, opts.BundleID, opts.Subsystems, opts.Categories)
+	if predicate != "" {
+		args = append(args, "--predicate", predicate)
+	}
+
+	return args
+}
+
+// showArgs builds the xcrun arguments for a historical log query
+func showArgs(udid string, opts QueryOptions) []string {
+	args := []string{"simctl", "spawn", udid, "log", "show", "--style", "ndjson"}
+
+	// Time range: use --start/--end when Until is set, otherwise --last
+	if !opts.Until.IsZero() {
+		// Absolute time range with --start and --end
+		start := time.Now().Add(-opts.Since)
+		args = append(args, "--start", start.Format(time.RFC3339))
+		args = append(args, "--end", opts.Until.Format(time.RFC3339))
+	} else if opts.Since > 0 {
+		// Relative duration with --last
+		args = append(args, "--last", formatDuration(opts.Since))
+	}
+
+	// Include all log levels to allow filtering
+	args = append(args, "--info", "--debug")
+
+	// Build predicate
+	predicate := buildPredicate(opts.RawPredicate, opts.BundleID, opts.Subsystems, opts.Categories)
+	if predicate != "" {
+		args = append(args, "--predicate", predicate)
+	}
+
+	return args
+}
@@ -6,8 +6,6 @@ import (
 	"errors"
 	"fmt"
 	"math/rand"
-	"os"
-	"os/exec"
 	"regexp"
 	"strings"
 	"sync"
@@ -30,18 +28,20 @@ type StreamOptions struct {
 	BufferSize        int              // Ring buffer size
 	RawPredicate      string           // Raw NSPredicate string (overrides other filters)
 	Verbose           bool             // Enable verbose diagnostics
+
+	OnStderrLine func(line string) `json:"-"` // Optional callback for source stderr output (trimmed)
 }
 
 // Streamer handles real-time log streaming from a simulator
 type Streamer struct {
 	manager *Manager
+	source  LogSource
 	parser  *Parser
 	rng     *rand.Rand
 
 	mu         sync.RWMutex
 	udid       string
 	opts       StreamOptions
-	cmd        *exec.Cmd
 	logs       chan domain.LogEntry
 	errors     chan error
 	running    bool
@@ -65,6 +65,7 @@ type Streamer struct {
 func NewStreamer(manager *Manager) *Streamer {
 	return &Streamer{
 		manager: manager,
+		source:  manager.Source(),
 		parser:  NewParser(),
 		rng:     rand.New(rand.NewSource(time.Now().UnixNano())),
 		logs:    make(chan domain.LogEntry, 1000),
@@ -211,63 +212,24 @@ func (s *Streamer) streamLoop(ctx context.Context) {
 	}
 }
 
-// runLogStream executes the log stream command and processes output
+// runLogStream opens the log source stream and processes output
 func (s *Streamer) runLogStream(ctx context.Context) error {
-	// Build command arguments
-	args := []string{"simctl", "spawn", s.udid, "log", "stream", "--style", "ndjson"}
-
-	// Add log level
-	level := strings.ToLower(string(s.opts.MinLevel))
-	if level == "" || level == "default" {
-		level = "default"
-	}
-	args = append(args, "--level", level)
-
-	// Build predicate for filtering
-	predicate := s.buildPredicate()
-	if predicate != "" {
-		args = append(args, "--predicate", predicate)
+	opts := s.opts
+	verbose := s.opts.Verbose
+	// Surface source diagnostics (eg. xcrun stderr) in verbose mode.
+	opts.OnStderrLine = func(line string) {
+		if verbose {
+			s.sendError(fmt.Errorf("xcrun_stderr: %s", line))
+		}
 	}
 
-	cmd := exec.CommandContext(ctx, "xcrun", args...)
-
-	stdout, err := cmd.StdoutPipe()
+	stream, err := s.source.Stream(ctx, s.udid, opts)
 	if err != nil {
-		return fmt.Errorf("failed to create stdout pipe: %w", err)
+		return err
 	}
-	stderr, err := cmd.StderrPipe()
-	if err != nil {
-		return fmt.Errorf("failed to create stderr pipe: %w", err)
-	}
-
-	if err := cmd.Start(); err != nil {
-		return fmt.Errorf("failed to start log stream: %w", err)
-	}
-
-	s.mu.Lock()
-	s.cmd = cmd
-	s.mu.Unlock()
-
-	// Drain stderr to avoid deadlocks and surface diagnostics in verbose mode.
-	stderrErrCh := make(chan error, 1)
-	verbose := s.opts.Verbose
-	go func() {
-		sc := bufio.NewScanner(stderr)
-		sc.Buffer(make([]byte, 0, 64*1024), 256*1024)
-		for sc.Scan() {
-			line := strings.TrimSpace(sc.Text())
-			if line == "" {
-				continue
-			}
-			if verbose {
-				s.sendError(fmt.Errorf("xcrun_stderr: %s", line))
-			}
-		}
-		stderrErrCh <- sc.Err()
-	}()
 
 	// Read and parse log lines
-	scanner := bufio.NewScanner(stdout)
+	scanner := bufio.NewScanner(stream)
 	// Increase buffer size for long log lines
 	const maxLineBytes = 1024 * 1024
 	scanner.Buffer(make([]byte, 0, 64*1024), maxLineBytes)
@@ -353,23 +315,16 @@ Loop:
 	if stdoutErr != nil && errors.Is(stdoutErr, bufio.ErrTooLong) {
 		stdoutErr = fmt.Errorf("log stream output line too long (>%d bytes): %w", maxLineBytes, stdoutErr)
 	}
-	if stdoutErr != nil && cmd.Process != nil {
-		_ = cmd.Process.Kill()
-	}
 
-	waitErr := cmd.Wait()
-	stderrErr := <-stderrErrCh
+	closeErr := stream.Close()
 
 	if stdoutErr != nil {
 		return stdoutErr
 	}
-	if stderrErr != nil && ctx.Err() == nil && !errors.Is(stderrErr, os.ErrClosed) {
-		return fmt.Errorf("log stream stderr read error: %w", stderrErr)
-	}
 	if ctx.Err() != nil {
 		return ctx.Err()
 	}
-	return waitErr
+	return closeErr
 }
 
 // buildPredicate constructs an NSPredicate string for log filtering
@@ -422,7 +377,6 @@ func (s *Streamer) jitter(d time.Duration) time.Duration {
 func (s *Streamer) Stop() error {
 	s.mu.Lock()
 	cancel := s.cancelFunc
-	cmd := s.cmd
 	done := s.done
 	s.running = false
 	s.mu.Unlock()
@@ -431,10 +385,6 @@ func (s *Streamer) Stop() error {
 		cancel()
 	}
 
-	if cmd != nil && cmd.Process != nil {
-		_ = cmd.Process.Kill()
-	}
-
 	// Wait for streamLoop/runLogStream to exit
 	s.wg.Wait()
 	if done != nil {